@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"golang/internal/config"
+	"golang/internal/database"
+	"golang/internal/store"
+	"golang/internal/store/backup"
+	"golang/internal/store/storecopy"
+)
+
+const usage = `Usage: backup [-config path] [-o file | -dir dir [-keep n]]
+
+Writes every tenant, contact, group, outbox message and audit entry of the
+configured store to a compressed, checksummed archive, which restore loads
+into a store of any type. The store is read from a snapshot, so servers can
+keep using it meanwhile.
+
+With -dir, the archive is named after the time it is taken and only the
+newest -keep archives of the directory are kept. Without -o or -dir, the
+directory of the backup settings is used.
+
+Flags:
+`
+
+func main() {
+	configPath := flag.String("config", "./config.json", "path to the configuration file")
+	output := flag.String("o", "", "file to write the archive to")
+	dir := flag.String("dir", "", "directory to write the archive to, rotating older ones")
+	keep := flag.Int("keep", 0, "archives to keep in -dir (default: the keep backup setting)")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() > 0 || (*output != "" && *dir != "") || *keep < 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	if *output == "" && *dir == "" {
+		if cfg.Backup.Dir == "" {
+			log.Fatalf("No backup destination: pass -o or -dir, or set backup.dir in the config")
+		}
+		*dir = cfg.Backup.Dir
+	}
+	if *keep == 0 {
+		*keep = cfg.Backup.Keep
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	db, err := database.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create database: %v", err)
+	}
+	if err := db.Connect(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := run(ctx, cfg, db, *output, *dir, *keep); err != nil {
+		db.Close()
+		log.Fatalf("Backup failed: %v", err)
+	}
+}
+
+func run(ctx context.Context, cfg *config.Config, db database.Database, output, dir string, keep int) error {
+	storage, err := store.New(cfg, db.GetDB())
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	version, err := database.SchemaVersion(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to read the schema version: %w", err)
+	}
+
+	header := backup.Header{CreatedAt: time.Now().UTC(), StoreType: string(cfg.Store.Type), SchemaVersion: version}
+	path := output
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create backup directory: %w", err)
+		}
+		path = filepath.Join(dir, backup.FileName(header.CreatedAt))
+	}
+	trailer, err := backup.WriteFile(ctx, path, storage, header)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-9s %8s  %s\n", "KIND", "RECORDS", "CHECKSUM")
+	for _, kind := range storecopy.Kinds {
+		sum := trailer.Records[kind]
+		fmt.Printf("%-9s %8d  %s\n", kind, sum.Count, sum.Checksum[:12])
+	}
+	fmt.Printf("\nWrote %s\n", path)
+
+	if dir != "" {
+		removed, err := backup.Rotate(dir, keep)
+		for _, old := range removed {
+			fmt.Printf("Removed %s\n", old)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to remove old backups: %w", err)
+		}
+	}
+	return nil
+}
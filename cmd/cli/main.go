@@ -1,10 +1,16 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"os"
 
 	"golang/internal/config"
 	"golang/internal/database"
+	"golang/internal/models"
 	cliserver "golang/internal/server/cli"
 	"golang/internal/service"
 	"golang/internal/store"
@@ -12,40 +18,73 @@ import (
 )
 
 func main() {
+	configPath := flag.String("config", "./config.json", "path to the configuration file")
+	tenant := flag.String("tenant", models.DefaultTenant, "tenant whose contacts the commands work on")
+	verbose := flag.Bool("v", false, "log diagnostics to stderr")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, cliserver.Usage) }
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(cliserver.ExitUsage)
+	}
+	// keep stdout and stderr clean for scripts
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	if err := models.ValidateTenantID(*tenant); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cliserver.ExitUsage)
+	}
+
+	os.Exit(run(*configPath, *tenant, flag.Args()))
+}
+
+// run wires the layers and executes the command, returning the exit code
+func run(configPath, tenant string, args []string) int {
 	// Load configuration
-	cfg, err := config.Load("./config.json")
+	cfg, err := config.Load(configPath)
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fmt.Fprintf(os.Stderr, "Error: failed to load config: %v\n", err)
+		return cliserver.ExitUsage
 	}
 
 	// Create database instance
 	db, err := database.New(cfg)
 	if err != nil {
-		log.Fatalf("Failed to create database: %v", err)
+		fmt.Fprintf(os.Stderr, "Error: failed to create database: %v\n", err)
+		return cliserver.ExitFailure
 	}
 
-	err = db.Connect()
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	if err := db.Connect(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to connect to database: %v\n", err)
+		return cliserver.ExitUnavailable
 	}
 	defer db.Close()
 
+	if err := db.Migrate(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to migrate database: %v\n", err)
+		return cliserver.ExitUnavailable
+	}
+
 	storage, err := store.New(cfg, db.GetDB())
 	if err != nil {
-		log.Fatalf("Failed to create the store: %v", err)
+		fmt.Fprintf(os.Stderr, "Error: failed to create the store: %v\n", err)
+		return cliserver.ExitUnavailable
 	}
+	defer storage.Close()
 
-	// Integration Layer
-	emailClient := messaging.NewEmailClient(cfg.Email.Token)
-	emailClient.Connect()
+	// Integration Layer: notifications are only delivered while the
+	// interactive shell runs; other commands leave them queued for the API
+	emailClient := messaging.NewEmailClient(cfg.Email)
 
 	// Service Layer (SAME as HTTP server!)
-	svc := service.NewService(storage, emailClient)
+	svc := service.NewService(storage, emailClient, cfg.Outbox)
 
 	// Presentation Layer (CLI)
-	cli := cliserver.NewCLI(svc)
+	cli := cliserver.NewCLI(svc, cfg.Auth.CLIRole, tenant)
 
-	// Start interactive CLI
-	log.Printf("Starting CLI with store type: %s", cfg.Store.Type)
-	cli.Start()
+	log.Printf("Running CLI with store type: %s", cfg.Store.Type)
+	return cli.Run(context.Background(), args)
 }
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"golang/internal/auth"
 	"golang/internal/config"
 	"golang/internal/database"
 	httpserver "golang/internal/server/http"
 	"golang/internal/service"
 	"golang/internal/store"
+	"golang/internal/store/backup"
 	"golang/internal/utils/messaging"
 )
 
@@ -19,6 +27,14 @@ func main() {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
+	// Load the credentials accepted by the API
+	var authn *auth.Authenticator
+	if cfg.Auth.Disabled {
+		log.Printf("Warning: authentication is disabled, anyone reaching the server can change contacts")
+	} else if authn, err = auth.New(cfg.Auth); err != nil {
+		log.Fatalf("Failed to set up authentication: %v", err)
+	}
+
 	// Create database instance
 	db, err := database.New(cfg)
 	if err != nil {
@@ -29,32 +45,121 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
-	defer db.Close()
+
+	if err := db.Migrate(context.Background()); err != nil {
+		db.Close()
+		log.Fatalf("Failed to migrate database: %v", err)
+	}
 
 	// Create Store Layer
 	storage, err := store.New(cfg, db.GetDB())
 	if err != nil {
+		db.Close()
 		log.Fatalf("Failed to create the store: %v", err)
 	}
 
 	// Integration Layer
-	emailClient := messaging.NewEmailClient(cfg.Email.Token)
-	emailClient.Connect()
+	emailClient := messaging.NewEmailClient(cfg.Email)
+	if err := emailClient.Connect(); err != nil {
+		// not fatal: queued notifications are retried until the server is back
+		log.Printf("Warning: email server unavailable: %v", err)
+	}
 
 	// Service Layer
-	svc := service.NewService(storage, emailClient)
+	svc := service.NewService(storage, emailClient, cfg.Outbox)
+
+	// Stop on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Background delivery of queued notifications
+	dispatcherDone := make(chan struct{})
+	go func() {
+		defer close(dispatcherDone)
+		svc.OutboxService.Run(ctx)
+	}()
+
+	// Scheduled backups of the store
+	backupsDone := make(chan struct{})
+	if cfg.Backup.Interval > 0 {
+		version, err := database.SchemaVersion(ctx, db)
+		if err != nil {
+			storage.Close()
+			db.Close()
+			log.Fatalf("Failed to read the schema version: %v", err)
+		}
+		header := backup.Header{StoreType: string(cfg.Store.Type), SchemaVersion: version}
+		scheduler := backup.NewScheduler(storage, header, cfg.Backup)
+		log.Printf("Backing up to %s every %s, keeping %d", cfg.Backup.Dir, time.Duration(cfg.Backup.Interval), cfg.Backup.Keep)
+		go func() {
+			defer close(backupsDone)
+			scheduler.Run(ctx)
+		}()
+	} else {
+		close(backupsDone)
+	}
 
 	// Presentation Layer (HTTP)
-	server := httpserver.NewServer(svc)
+	server := httpserver.NewServer(svc, authn)
+
+	httpServer := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           server,
+		ReadTimeout:       time.Duration(cfg.Server.ReadTimeout),
+		ReadHeaderTimeout: time.Duration(cfg.Server.ReadHeaderTimeout),
+		WriteTimeout:      time.Duration(cfg.Server.WriteTimeout),
+		IdleTimeout:       time.Duration(cfg.Server.IdleTimeout),
+	}
 
 	log.Printf("HTTP Server listening on :%s", cfg.Server.Port)
 	log.Printf("  Store type: %s", cfg.Store.Type)
-	log.Printf("  GET    /contacts")
-	log.Printf("  GET    /contacts/{id}")
-	log.Printf("  POST   /contacts")
-	log.Printf("  PUT    /contacts/{id}")
-	log.Printf("  DELETE /contacts/{id}")
-
-	// TODO: handle graceful shutdown
-	http.ListenAndServe(":"+cfg.Server.Port, server)
+	server.Walk(func(method, route string) {
+		log.Printf("  %-6s %s", method, route)
+	})
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("HTTP server failed: %v", err)
+		}
+		stop()
+	case <-ctx.Done():
+		log.Printf("Shutdown signal received, draining (up to %s)", time.Duration(cfg.Server.ShutdownTimeout))
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout))
+	defer cancel()
+
+	// Stop accepting connections and wait for in-flight requests
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Warning: HTTP server did not drain in time: %v", err)
+	}
+
+	// Wait for the notification being delivered, if any
+	select {
+	case <-dispatcherDone:
+	case <-shutdownCtx.Done():
+		log.Printf("Warning: notification dispatcher did not stop in time, pending messages stay queued")
+	}
+
+	// Wait for the backup being written, if any, to be abandoned
+	select {
+	case <-backupsDone:
+	case <-shutdownCtx.Done():
+		log.Printf("Warning: backup scheduler did not stop in time")
+	}
+
+	// Release the store, then the connection it used
+	if err := storage.Close(); err != nil {
+		log.Printf("Warning: failed to close the store: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		log.Printf("Warning: failed to close database: %v", err)
+	}
+	log.Printf("Server stopped")
 }
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"golang/internal/utils/messaging/smtpsink"
+)
+
+// mailsink runs the in-process SMTP sink on its own for local development.
+// Point the "email" config at it with "security": "none".
+func main() {
+	addr := flag.String("addr", "127.0.0.1:2525", "address to listen on")
+	flag.Parse()
+
+	sink, err := smtpsink.Start(*addr, func(msg smtpsink.Message) {
+		log.Printf("Received email from %s to %s: %s", msg.From, strings.Join(msg.To, ", "), msg.Header.Get("Subject"))
+		if msg.Text != "" {
+			log.Printf("  text: %s", strings.TrimSpace(msg.Text))
+		}
+		if msg.HTML != "" {
+			log.Printf("  html: %s", strings.TrimSpace(msg.HTML))
+		}
+	})
+	if err != nil {
+		log.Fatalf("Failed to start SMTP sink: %v", err)
+	}
+	log.Printf("SMTP sink listening on %s", sink.Addr())
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	<-stop
+
+	sink.Close()
+}
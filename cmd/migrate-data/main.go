@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang/internal/config"
+	"golang/internal/database"
+	"golang/internal/store"
+	"golang/internal/store/interfaces"
+	"golang/internal/store/storecopy"
+)
+
+const usage = `Usage: migrate-data -from <config> -to <config> [-renumber]
+
+Copies every tenant, contact, group, outbox message and audit entry of the
+store configured in -from to the store configured in -to, keeping their IDs,
+then checks that both hold the same records. Records the destination already
+holds are skipped: an interrupted run is resumed by running it again.
+Stop the servers using the source first, so that it does not change.
+
+Flags:
+`
+
+func main() {
+	fromPath := flag.String("from", "", "configuration file of the store to copy")
+	toPath := flag.String("to", "", "configuration file of the store to copy to")
+	renumber := flag.Bool("renumber", false,
+		"give new IDs to the contacts and groups whose ID several tenants use, as the file store allows")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *fromPath == "" || *toPath == "" || flag.NArg() > 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	from, closeFrom, err := open(ctx, *fromPath, false)
+	if err != nil {
+		log.Fatalf("Failed to open the source: %v", err)
+	}
+	defer closeFrom()
+	to, closeTo, err := open(ctx, *toPath, true)
+	if err != nil {
+		closeFrom()
+		log.Fatalf("Failed to open the destination: %v", err)
+	}
+	defer closeTo()
+
+	if err := run(ctx, from, to, storecopy.Options{Renumber: *renumber}); err != nil {
+		closeFrom()
+		closeTo()
+		log.Fatalf("Data migration failed: %v", err)
+	}
+}
+
+// open connects to the store configured in path. The schema of the
+// destination is migrated.
+func open(ctx context.Context, path string, destination bool) (*interfaces.Store, func(), error) {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := database.New(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := db.Connect(); err != nil {
+		return nil, nil, err
+	}
+	closeDB := func() { db.Close() }
+
+	if destination {
+		if err := db.Migrate(ctx); err != nil {
+			closeDB()
+			return nil, nil, err
+		}
+	}
+	storage, err := store.New(cfg, db.GetDB())
+	if err != nil {
+		closeDB()
+		return nil, nil, err
+	}
+	return storage, func() { storage.Close(); closeDB() }, nil
+}
+
+func run(ctx context.Context, from, to *interfaces.Store, opts storecopy.Options) error {
+	opts.Progress = func(c storecopy.Count) {
+		log.Printf("%s: %d copied, %d already there", c.Kind, c.Copied, c.Skipped)
+	}
+	counts, err := storecopy.Copy(ctx, storecopy.FromStore(from), to, opts)
+	if errors.Is(err, storecopy.ErrIDCollision) {
+		return fmt.Errorf("%w\nthe destination cannot keep these IDs: run again with -renumber to give them new ones", err)
+	}
+	if err != nil {
+		return fmt.Errorf("%w (run again to resume)", err)
+	}
+	for _, c := range counts {
+		fmt.Printf("%-9s %8d copied %8d already there\n", c.Kind, c.Copied, c.Skipped)
+	}
+
+	checks, err := storecopy.Verify(ctx, storecopy.FromStore(from), to, opts)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	fmt.Printf("\n%-9s %8s %8s  %s\n", "KIND", "SOURCE", "DEST", "CHECKSUM")
+	for _, c := range checks {
+		state := "ok " + c.Source.Checksum[:12]
+		if !c.OK() {
+			state = fmt.Sprintf("MISMATCH %s != %s", c.Source.Checksum[:12], c.Destination.Checksum[:12])
+			failed++
+		}
+		fmt.Printf("%-9s %8d %8d  %s\n", c.Kind, c.Source.Count, c.Destination.Count, state)
+	}
+	if failed > 0 {
+		return fmt.Errorf("verification found %d kind(s) of records that differ between the stores", failed)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"golang/internal/config"
+	"golang/internal/database"
+	"golang/internal/database/migrate"
+)
+
+const usage = `Usage: migrate [-config path] <command>
+
+Commands:
+  up              Apply all pending migrations
+  down [steps]    Roll back the last migration (or the last <steps>)
+  status          List migrations and whether they are applied
+  create <name>   Create empty up/down files for every dialect
+  seed [path]     Load a seed file of sample data, by default the
+                  seed_path of the configuration
+`
+
+func main() {
+	configPath := flag.String("config", "./config.json", "path to the configuration file")
+	migrationsDir := flag.String("dir", "./db/migrations", "migrations directory (used by create)")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// create only touches files, no database required
+	if args[0] == "create" {
+		if len(args) != 2 {
+			log.Fatalf("create expects a migration name")
+		}
+		paths, err := migrate.Create(*migrationsDir, args[1])
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		for _, path := range paths {
+			fmt.Println(path)
+		}
+		return
+	}
+
+	// Load configuration
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	// Create database instance
+	db, err := database.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create database: %v", err)
+	}
+
+	migratable, ok := db.(database.Migratable)
+	if !ok {
+		log.Fatalf("Store type %s has no schema to migrate", cfg.Store.Type)
+	}
+
+	if err := db.Connect(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := run(context.Background(), migratable, args); err != nil {
+		log.Fatalf("%s failed: %v", args[0], err)
+	}
+}
+
+func run(ctx context.Context, db database.Migratable, args []string) error {
+	migrator := db.Migrator()
+	switch args[0] {
+	case "up":
+		n, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d migration(s)\n", n)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid steps: %s", args[1])
+			}
+		}
+		n, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rolled back %d migration(s)\n", n)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d  %-40s %s\n", s.Version, s.Name, state)
+		}
+	case "seed":
+		path := db.SeedPath()
+		if len(args) > 1 {
+			path = args[1]
+		}
+		if path == "" {
+			return fmt.Errorf("no seed file: pass its path or set seed_path in the configuration")
+		}
+		// seed rows need the tables, so pending migrations run first
+		if _, err := migrator.Up(ctx); err != nil {
+			return err
+		}
+		if err := migrator.Seed(ctx, path); err != nil {
+			return err
+		}
+		fmt.Printf("Seed loaded from %s\n", path)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	return nil
+}
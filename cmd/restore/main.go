@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang/internal/config"
+	"golang/internal/database"
+	"golang/internal/store"
+	"golang/internal/store/backup"
+	"golang/internal/store/interfaces"
+	"golang/internal/store/storecopy"
+)
+
+const usage = `Usage: restore [-config path] [-check] [-resume] [-renumber] <archive>
+       restore [-config path] [-check] [-resume] [-renumber] -dir <dir> [-at time]
+
+Loads an archive written by backup into the configured store, which may be
+of another type than the one backed up. With -dir, the newest archive of the
+directory taken at or before -at (default: now) is restored, which brings
+the data back to that point in time.
+
+The archive is checked in full, and its schema version against the store's,
+before anything is loaded. The store must be empty, unless -resume is given
+to finish a restore that was interrupted. Stop the servers using the store
+first.
+
+Flags:
+`
+
+func main() {
+	configPath := flag.String("config", "./config.json", "path to the configuration file")
+	dir := flag.String("dir", "", "directory of archives to pick from")
+	at := flag.String("at", "", "restore the newest archive of -dir taken at or before this RFC 3339 time")
+	check := flag.Bool("check", false, "only check the archive, without loading it")
+	resume := flag.Bool("resume", false, "load into a store that already holds records of an interrupted restore")
+	renumber := flag.Bool("renumber", false,
+		"give new IDs to the contacts and groups whose ID several tenants use, as the file store allows")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if (*dir == "") == (flag.NArg() == 0) || flag.NArg() > 1 || (*at != "" && *dir == "") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	path := flag.Arg(0)
+	if *dir != "" {
+		pointInTime := time.Now()
+		if *at != "" {
+			var err error
+			if pointInTime, err = time.Parse(time.RFC3339, *at); err != nil {
+				log.Fatalf("Invalid -at time: %v", err)
+			}
+		}
+		file, err := backup.Latest(*dir, pointInTime)
+		if err != nil {
+			log.Fatalf("Failed to pick an archive: %v", err)
+		}
+		path = file.Path
+	}
+
+	archive, err := validate(ctx, path)
+	if err != nil {
+		log.Fatalf("Failed to check the archive: %v", err)
+	}
+	if *check {
+		return
+	}
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	db, err := database.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create database: %v", err)
+	}
+	if err := db.Connect(); err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	if err := run(ctx, cfg, db, archive, *resume, storecopy.Options{Renumber: *renumber}); err != nil {
+		db.Close()
+		log.Fatalf("Restore failed: %v", err)
+	}
+}
+
+// validate opens the archive at path and checks all of it
+func validate(ctx context.Context, path string) (*backup.Archive, error) {
+	archive, err := backup.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	trailer, err := archive.Validate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	h := archive.Header
+	fmt.Printf("Archive %s\n", path)
+	fmt.Printf("  taken %s of a %s store at schema version %d\n",
+		h.CreatedAt.UTC().Format(time.RFC3339), h.StoreType, h.SchemaVersion)
+	for _, kind := range storecopy.Kinds {
+		fmt.Printf("  %-9s %8d\n", kind, trailer.Records[kind].Count)
+	}
+	fmt.Println()
+	return archive, nil
+}
+
+func run(ctx context.Context, cfg *config.Config, db database.Database, archive *backup.Archive, resume bool, opts storecopy.Options) error {
+	if err := db.Migrate(ctx); err != nil {
+		return err
+	}
+	version, err := database.SchemaVersion(ctx, db)
+	if err != nil {
+		return fmt.Errorf("failed to read the schema version: %w", err)
+	}
+	if _, ok := db.(database.Migratable); ok && archive.Header.SchemaVersion > version {
+		return fmt.Errorf("the archive was taken at schema version %d, newer than the store's %d: upgrade first",
+			archive.Header.SchemaVersion, version)
+	}
+
+	storage, err := store.New(cfg, db.GetDB())
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+	if !resume {
+		if err := checkEmpty(ctx, storage); err != nil {
+			return err
+		}
+	}
+
+	opts.Progress = func(c storecopy.Count) {
+		log.Printf("%s: %d restored, %d already there", c.Kind, c.Copied, c.Skipped)
+	}
+	counts, err := storecopy.Copy(ctx, archive, storage, opts)
+	if errors.Is(err, storecopy.ErrIDCollision) {
+		return fmt.Errorf("%w\nthe store cannot keep these IDs: run again with -renumber to give them new ones", err)
+	}
+	if err != nil {
+		return fmt.Errorf("%w (run again with -resume to finish)", err)
+	}
+	for _, c := range counts {
+		fmt.Printf("%-9s %8d restored %8d already there\n", c.Kind, c.Copied, c.Skipped)
+	}
+
+	checks, err := storecopy.Verify(ctx, archive, storage, opts)
+	if err != nil {
+		return err
+	}
+	failed := 0
+	fmt.Printf("\n%-9s %8s %8s  %s\n", "KIND", "ARCHIVE", "STORE", "CHECKSUM")
+	for _, c := range checks {
+		state := "ok " + c.Source.Checksum[:12]
+		if !c.OK() {
+			state = fmt.Sprintf("MISMATCH %s != %s", c.Source.Checksum[:12], c.Destination.Checksum[:12])
+			failed++
+		}
+		fmt.Printf("%-9s %8d %8d  %s\n", c.Kind, c.Source.Count, c.Destination.Count, state)
+	}
+	if failed > 0 {
+		return fmt.Errorf("verification found %d kind(s) of records that differ between the archive and the store", failed)
+	}
+	return nil
+}
+
+// checkEmpty fails unless the store holds no records besides the default
+// tenant, which every store has
+func checkEmpty(ctx context.Context, storage *interfaces.Store) error {
+	sums, err := storecopy.Summarize(ctx, storecopy.FromStore(storage))
+	if err != nil {
+		return fmt.Errorf("failed to read the store: %w", err)
+	}
+	for _, kind := range storecopy.Kinds {
+		held := sums[kind].Count
+		if kind == storecopy.Tenants {
+			held--
+		}
+		if held > 0 {
+			return fmt.Errorf("the store already holds %s: restore into an empty store, or pass -resume to finish an interrupted restore", kind)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_contacts_email;
+DROP INDEX IF EXISTS idx_contacts_last_name;
+DROP INDEX IF EXISTS idx_contacts_first_name;
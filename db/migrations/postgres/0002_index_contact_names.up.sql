@@ -0,0 +1,4 @@
+-- listings order text columns with the "C" collation, so the indexes must too
+CREATE INDEX IF NOT EXISTS idx_contacts_first_name ON contacts (first_name COLLATE "C", id);
+CREATE INDEX IF NOT EXISTS idx_contacts_last_name ON contacts (last_name COLLATE "C", id);
+CREATE INDEX IF NOT EXISTS idx_contacts_email ON contacts (email COLLATE "C", id);
@@ -0,0 +1,22 @@
+-- append-only history of contact mutations; no foreign key so that history
+-- outlives deleted contacts
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    contact_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL,
+    before JSONB,
+    after JSONB
+);
+
+CREATE INDEX idx_audit_log_contact ON audit_log (contact_id, id);
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
@@ -0,0 +1,2 @@
+-- optimistic concurrency: every update increments the version
+ALTER TABLE contacts ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,19 @@
+-- contacts of other tenants than the default one are lost
+DROP INDEX idx_audit_log_contact;
+ALTER TABLE audit_log DROP COLUMN tenant_id;
+CREATE INDEX idx_audit_log_contact ON audit_log (contact_id, id);
+
+DELETE FROM contacts WHERE tenant_id <> 'default';
+
+DROP INDEX idx_contacts_first_name;
+DROP INDEX idx_contacts_last_name;
+DROP INDEX idx_contacts_email;
+CREATE INDEX idx_contacts_first_name ON contacts (first_name COLLATE "C", id);
+CREATE INDEX idx_contacts_last_name ON contacts (last_name COLLATE "C", id);
+CREATE INDEX idx_contacts_email ON contacts (email COLLATE "C", id);
+
+ALTER TABLE contacts DROP CONSTRAINT contacts_tenant_email_key;
+ALTER TABLE contacts ADD CONSTRAINT contacts_email_key UNIQUE (email);
+ALTER TABLE contacts DROP COLUMN tenant_id;
+
+DROP TABLE tenants;
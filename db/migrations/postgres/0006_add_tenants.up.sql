@@ -0,0 +1,23 @@
+-- contact books isolated per tenant; emails are only unique within a tenant
+CREATE TABLE tenants (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+INSERT INTO tenants (id, name, created_at) VALUES ('default', 'Default', now());
+
+ALTER TABLE contacts ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE contacts DROP CONSTRAINT contacts_email_key;
+ALTER TABLE contacts ADD CONSTRAINT contacts_tenant_email_key UNIQUE (tenant_id, email);
+
+DROP INDEX IF EXISTS idx_contacts_first_name;
+DROP INDEX IF EXISTS idx_contacts_last_name;
+DROP INDEX IF EXISTS idx_contacts_email;
+CREATE INDEX idx_contacts_first_name ON contacts (tenant_id, first_name COLLATE "C", id);
+CREATE INDEX idx_contacts_last_name ON contacts (tenant_id, last_name COLLATE "C", id);
+CREATE INDEX idx_contacts_email ON contacts (tenant_id, email COLLATE "C", id);
+
+ALTER TABLE audit_log ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+DROP INDEX idx_audit_log_contact;
+CREATE INDEX idx_audit_log_contact ON audit_log (tenant_id, contact_id, id);
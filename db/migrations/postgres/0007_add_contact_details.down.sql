@@ -0,0 +1,8 @@
+ALTER TABLE contacts
+    DROP COLUMN custom_fields,
+    DROP COLUMN addresses,
+    DROP COLUMN phones,
+    DROP COLUMN notes,
+    DROP COLUMN birthday,
+    DROP COLUMN title,
+    DROP COLUMN company;
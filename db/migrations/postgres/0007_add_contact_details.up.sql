@@ -0,0 +1,9 @@
+-- richer contacts: phones, addresses and custom fields are JSONB documents
+ALTER TABLE contacts
+    ADD COLUMN company TEXT NOT NULL DEFAULT '',
+    ADD COLUMN title TEXT NOT NULL DEFAULT '',
+    ADD COLUMN birthday TEXT NOT NULL DEFAULT '',
+    ADD COLUMN notes TEXT NOT NULL DEFAULT '',
+    ADD COLUMN phones JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN addresses JSONB NOT NULL DEFAULT '[]',
+    ADD COLUMN custom_fields JSONB NOT NULL DEFAULT '{}';
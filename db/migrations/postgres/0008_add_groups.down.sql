@@ -0,0 +1,4 @@
+DROP TABLE group_members;
+DROP TABLE contact_groups;
+DROP INDEX idx_contacts_tags;
+ALTER TABLE contacts DROP COLUMN tags;
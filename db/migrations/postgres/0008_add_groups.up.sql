@@ -0,0 +1,23 @@
+-- free-form labels, kept with the other contact details
+ALTER TABLE contacts ADD COLUMN tags JSONB NOT NULL DEFAULT '[]';
+
+CREATE INDEX idx_contacts_tags ON contacts USING GIN (tags);
+
+-- named sets of contacts; "groups" is an SQL keyword
+CREATE TABLE contact_groups (
+    id SERIAL PRIMARY KEY,
+    tenant_id TEXT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL,
+    UNIQUE (tenant_id, name)
+);
+
+-- memberships go with their contact or group
+CREATE TABLE group_members (
+    group_id INTEGER NOT NULL REFERENCES contact_groups (id) ON DELETE CASCADE,
+    contact_id INTEGER NOT NULL REFERENCES contacts (id) ON DELETE CASCADE,
+    PRIMARY KEY (group_id, contact_id)
+);
+
+CREATE INDEX idx_group_members_contact ON group_members (contact_id);
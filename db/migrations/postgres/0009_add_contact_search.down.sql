@@ -0,0 +1,4 @@
+DROP INDEX idx_contacts_search_text;
+DROP INDEX idx_contacts_search_vector;
+
+ALTER TABLE contacts DROP COLUMN search_vector, DROP COLUMN search_text;
@@ -0,0 +1,15 @@
+-- full-text search of contact names and emails: a tsvector for words and
+-- prefixes, and trigrams for words with typos. Emails are split into words
+-- like the names.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE contacts
+    ADD COLUMN search_text TEXT GENERATED ALWAYS AS (
+        lower(first_name || ' ' || last_name || ' ' || regexp_replace(email, '[^[:alnum:]]+', ' ', 'g'))
+    ) STORED,
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('simple', first_name || ' ' || last_name || ' ' || regexp_replace(email, '[^[:alnum:]]+', ' ', 'g'))
+    ) STORED;
+
+CREATE INDEX idx_contacts_search_vector ON contacts USING GIN (search_vector);
+CREATE INDEX idx_contacts_search_text ON contacts USING GIN (search_text gin_trgm_ops);
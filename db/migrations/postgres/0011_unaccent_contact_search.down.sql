@@ -0,0 +1,14 @@
+ALTER TABLE contacts DROP COLUMN search_vector, DROP COLUMN search_text;
+
+ALTER TABLE contacts
+    ADD COLUMN search_text TEXT GENERATED ALWAYS AS (
+        lower(first_name || ' ' || last_name || ' ' || regexp_replace(email, '[^[:alnum:]]+', ' ', 'g'))
+    ) STORED,
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('simple', first_name || ' ' || last_name || ' ' || regexp_replace(email, '[^[:alnum:]]+', ' ', 'g'))
+    ) STORED;
+
+CREATE INDEX idx_contacts_search_vector ON contacts USING GIN (search_vector);
+CREATE INDEX idx_contacts_search_text ON contacts USING GIN (search_text gin_trgm_ops);
+
+DROP FUNCTION contact_search_fold(TEXT);
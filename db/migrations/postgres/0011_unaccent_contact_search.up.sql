@@ -0,0 +1,23 @@
+-- search words lose their diacritics, as on SQLite and in the file store,
+-- so that "jose" finds José. unaccent() is only stable, since its dictionary
+-- may change, so the generated columns call it through an immutable wrapper
+-- naming the dictionary.
+CREATE EXTENSION IF NOT EXISTS unaccent;
+
+CREATE FUNCTION contact_search_fold(TEXT) RETURNS TEXT
+    LANGUAGE sql IMMUTABLE STRICT PARALLEL SAFE
+    AS $$ SELECT public.unaccent('public.unaccent'::regdictionary, $1) $$;
+
+-- dropping the columns drops their indexes
+ALTER TABLE contacts DROP COLUMN search_vector, DROP COLUMN search_text;
+
+ALTER TABLE contacts
+    ADD COLUMN search_text TEXT GENERATED ALWAYS AS (
+        lower(contact_search_fold(first_name || ' ' || last_name || ' ' || regexp_replace(email, '[^[:alnum:]]+', ' ', 'g')))
+    ) STORED,
+    ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+        to_tsvector('simple', contact_search_fold(first_name || ' ' || last_name || ' ' || regexp_replace(email, '[^[:alnum:]]+', ' ', 'g')))
+    ) STORED;
+
+CREATE INDEX idx_contacts_search_vector ON contacts USING GIN (search_vector);
+CREATE INDEX idx_contacts_search_text ON contacts USING GIN (search_text gin_trgm_ops);
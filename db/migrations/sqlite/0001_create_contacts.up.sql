@@ -0,0 +1,7 @@
+-- IF NOT EXISTS lets databases created before versioned migrations adopt this history
+CREATE TABLE IF NOT EXISTS contacts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    email TEXT NOT NULL UNIQUE
+);
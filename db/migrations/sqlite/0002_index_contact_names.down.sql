@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_contacts_last_name;
+DROP INDEX IF EXISTS idx_contacts_first_name;
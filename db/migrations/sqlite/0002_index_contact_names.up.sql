@@ -0,0 +1,3 @@
+-- supports sorted and prefix-filtered listings
+CREATE INDEX IF NOT EXISTS idx_contacts_first_name ON contacts (first_name, id);
+CREATE INDEX IF NOT EXISTS idx_contacts_last_name ON contacts (last_name, id);
@@ -0,0 +1,23 @@
+-- append-only history of contact mutations; no foreign key so that history
+-- outlives deleted contacts
+CREATE TABLE audit_log (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    contact_id INTEGER NOT NULL,
+    action TEXT NOT NULL,
+    actor TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    before TEXT,
+    after TEXT
+);
+
+CREATE INDEX idx_audit_log_contact ON audit_log (contact_id, id);
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
+
+CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is append-only');
+END;
@@ -0,0 +1,26 @@
+-- contacts of other tenants than the default one are lost
+DROP INDEX idx_audit_log_contact;
+ALTER TABLE audit_log DROP COLUMN tenant_id;
+CREATE INDEX idx_audit_log_contact ON audit_log (contact_id, id);
+
+CREATE TABLE contacts_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    email TEXT NOT NULL UNIQUE,
+    version INTEGER NOT NULL DEFAULT 1
+);
+
+INSERT INTO contacts_old (id, first_name, last_name, email, version)
+    SELECT id, first_name, last_name, email, version FROM contacts WHERE tenant_id = 'default';
+
+DELETE FROM sqlite_sequence WHERE name = 'contacts_old';
+UPDATE sqlite_sequence SET name = 'contacts_old' WHERE name = 'contacts';
+
+DROP TABLE contacts;
+ALTER TABLE contacts_old RENAME TO contacts;
+
+CREATE INDEX idx_contacts_first_name ON contacts (first_name, id);
+CREATE INDEX idx_contacts_last_name ON contacts (last_name, id);
+
+DROP TABLE tenants;
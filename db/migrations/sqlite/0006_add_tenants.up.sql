@@ -0,0 +1,36 @@
+-- contact books isolated per tenant; emails are only unique within a tenant
+CREATE TABLE tenants (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+INSERT INTO tenants (id, name, created_at) VALUES ('default', 'Default', CURRENT_TIMESTAMP);
+
+-- SQLite cannot drop the UNIQUE constraint on email, so the table is rebuilt
+CREATE TABLE contacts_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id TEXT NOT NULL DEFAULT 'default',
+    first_name TEXT NOT NULL,
+    last_name TEXT NOT NULL,
+    email TEXT NOT NULL,
+    version INTEGER NOT NULL DEFAULT 1,
+    UNIQUE (tenant_id, email)
+);
+
+INSERT INTO contacts_new (id, tenant_id, first_name, last_name, email, version)
+    SELECT id, 'default', first_name, last_name, email, version FROM contacts;
+
+-- carry the AUTOINCREMENT counter over so that IDs of deleted contacts are not reused
+DELETE FROM sqlite_sequence WHERE name = 'contacts_new';
+UPDATE sqlite_sequence SET name = 'contacts_new' WHERE name = 'contacts';
+
+DROP TABLE contacts;
+ALTER TABLE contacts_new RENAME TO contacts;
+
+CREATE INDEX idx_contacts_first_name ON contacts (tenant_id, first_name, id);
+CREATE INDEX idx_contacts_last_name ON contacts (tenant_id, last_name, id);
+
+ALTER TABLE audit_log ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+DROP INDEX idx_audit_log_contact;
+CREATE INDEX idx_audit_log_contact ON audit_log (tenant_id, contact_id, id);
@@ -0,0 +1,7 @@
+ALTER TABLE contacts DROP COLUMN custom_fields;
+ALTER TABLE contacts DROP COLUMN addresses;
+ALTER TABLE contacts DROP COLUMN phones;
+ALTER TABLE contacts DROP COLUMN notes;
+ALTER TABLE contacts DROP COLUMN birthday;
+ALTER TABLE contacts DROP COLUMN title;
+ALTER TABLE contacts DROP COLUMN company;
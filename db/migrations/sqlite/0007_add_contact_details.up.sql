@@ -0,0 +1,8 @@
+-- richer contacts: phones, addresses and custom fields are JSON documents
+ALTER TABLE contacts ADD COLUMN company TEXT NOT NULL DEFAULT '';
+ALTER TABLE contacts ADD COLUMN title TEXT NOT NULL DEFAULT '';
+ALTER TABLE contacts ADD COLUMN birthday TEXT NOT NULL DEFAULT '';
+ALTER TABLE contacts ADD COLUMN notes TEXT NOT NULL DEFAULT '';
+ALTER TABLE contacts ADD COLUMN phones TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE contacts ADD COLUMN addresses TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE contacts ADD COLUMN custom_fields TEXT NOT NULL DEFAULT '{}';
@@ -0,0 +1,3 @@
+DROP TABLE group_members;
+DROP TABLE contact_groups;
+ALTER TABLE contacts DROP COLUMN tags;
@@ -0,0 +1,5 @@
+DROP TRIGGER contacts_fts_delete;
+DROP TRIGGER contacts_fts_update;
+DROP TRIGGER contacts_fts_insert;
+DROP TABLE contacts_fts_terms;
+DROP TABLE contacts_fts;
@@ -0,0 +1,24 @@
+-- full-text index of contact names and emails, on FTS5, which go-sqlite3
+-- only compiles in with the sqlite_fts5 build tag. unicode61 splits emails
+-- into words and folds case and diacritics.
+CREATE VIRTUAL TABLE contacts_fts USING fts5(first_name, last_name, email, tokenize = 'unicode61 remove_diacritics 2');
+
+-- the indexed words, matched against search terms with typos
+CREATE VIRTUAL TABLE contacts_fts_terms USING fts5vocab(contacts_fts, 'row');
+
+INSERT INTO contacts_fts (rowid, first_name, last_name, email)
+    SELECT id, first_name, last_name, email FROM contacts;
+
+CREATE TRIGGER contacts_fts_insert AFTER INSERT ON contacts BEGIN
+    INSERT INTO contacts_fts (rowid, first_name, last_name, email)
+        VALUES (new.id, new.first_name, new.last_name, new.email);
+END;
+
+CREATE TRIGGER contacts_fts_update AFTER UPDATE OF first_name, last_name, email ON contacts BEGIN
+    UPDATE contacts_fts SET first_name = new.first_name, last_name = new.last_name, email = new.email
+        WHERE rowid = old.id;
+END;
+
+CREATE TRIGGER contacts_fts_delete AFTER DELETE ON contacts BEGIN
+    DELETE FROM contacts_fts WHERE rowid = old.id;
+END;
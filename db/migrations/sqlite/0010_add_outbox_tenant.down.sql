@@ -0,0 +1,3 @@
+-- notifications of other tenants than the default one end up in it when migrated up again
+DROP INDEX idx_outbox_tenant;
+ALTER TABLE outbox DROP COLUMN tenant_id;
@@ -0,0 +1,5 @@
+-- notifications belong to the tenant of the change that caused them;
+-- those queued before tenants existed belong to the default one
+ALTER TABLE outbox ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX idx_outbox_tenant ON outbox (tenant_id, status, id);
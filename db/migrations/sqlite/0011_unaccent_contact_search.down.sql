@@ -0,0 +1 @@
+-- nothing to undo, see the up migration
@@ -0,0 +1,2 @@
+-- nothing to do: the unicode61 tokenizer of contacts_fts (0009) already
+-- folds diacritics
@@ -0,0 +1,9 @@
+-- sample data
+INSERT INTO contacts (id, first_name, last_name, email) VALUES
+    (1, 'John', 'Doe', 'john.doe@example.com'),
+    (2, 'Jane', 'Smith', 'jane.smith@example.com'),
+    (3, 'Bob', 'Johnson', 'bob.johnson@example.com')
+ON CONFLICT DO NOTHING;
+
+-- explicit ids do not advance the serial sequence
+SELECT setval(pg_get_serial_sequence('contacts', 'id'), GREATEST((SELECT MAX(id) FROM contacts), 1));
@@ -1,12 +1,3 @@
--- database Schema
-
-CREATE TABLE IF NOT EXISTS contacts (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    first_name TEXT NOT NULL,
-    last_name TEXT NOT NULL,
-    email TEXT NOT NULL UNIQUE
-);
-
 -- sample data
 INSERT OR IGNORE INTO contacts (id, first_name, last_name, email) VALUES
     (1, 'John', 'Doe', 'john.doe@example.com'),
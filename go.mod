@@ -7,3 +7,5 @@ require github.com/go-chi/chi/v5 v5.0.12
 require github.com/mattn/go-sqlite3 v1.14.32
 
 require github.com/lib/pq v1.10.9
+
+require golang.org/x/text v0.34.0
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang/internal/config"
+	"golang/internal/models"
+)
+
+// apiKeyHashPrefix starts the hash of every configured API key
+const apiKeyHashPrefix = "sha256:"
+
+// apiKey is a configured API key; only its digest is kept
+type apiKey struct {
+	name   string
+	role   models.Role
+	tenant string
+	digest []byte
+}
+
+func parseAPIKey(cfg config.APIKeyConfig) (apiKey, error) {
+	if cfg.Name == "" {
+		return apiKey{}, errors.New("name is required")
+	}
+	encoded, ok := strings.CutPrefix(cfg.Hash, apiKeyHashPrefix)
+	if !ok {
+		return apiKey{}, fmt.Errorf("hash of %q must start with %q", cfg.Name, apiKeyHashPrefix)
+	}
+	digest, err := hex.DecodeString(encoded)
+	if err != nil || len(digest) != sha256.Size {
+		return apiKey{}, fmt.Errorf("hash of %q is not a hex SHA-256 digest", cfg.Name)
+	}
+	return apiKey{name: cfg.Name, role: cfg.Role, tenant: cfg.Tenant, digest: digest}, nil
+}
+
+// AuthenticateAPIKey returns the principal named after the matching API key
+func (a *Authenticator) AuthenticateAPIKey(key string) (models.Principal, error) {
+	digest := sha256.Sum256([]byte(key))
+	for _, k := range a.apiKeys {
+		if subtle.ConstantTimeCompare(digest[:], k.digest) == 1 {
+			return models.Principal{Subject: k.name, Method: MethodAPIKey, Role: k.role, Tenant: k.tenant}, nil
+		}
+	}
+	return models.Principal{}, fmt.Errorf("%w: unknown API key", models.ErrUnauthenticated)
+}
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"golang/internal/config"
+	"golang/internal/models"
+)
+
+func apiKeyHash(key string) string {
+	digest := sha256.Sum256([]byte(key))
+	return apiKeyHashPrefix + hex.EncodeToString(digest[:])
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	a, err := New(config.AuthConfig{APIKeys: []config.APIKeyConfig{
+		{Name: "ci", Hash: apiKeyHash("ci-key"), Role: models.RoleEditor, Tenant: "sales"},
+		{Name: "ops", Hash: apiKeyHashPrefix + strings.ToUpper(apiKeyHash("ops-key")[len(apiKeyHashPrefix):]), Role: models.RoleAdmin},
+	}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		key  string
+		want models.Principal // zero when the key is refused
+	}{
+		{"FirstKey", "ci-key", models.Principal{Subject: "ci", Method: MethodAPIKey, Role: models.RoleEditor, Tenant: "sales"}},
+		{"UppercaseHexHash", "ops-key", models.Principal{Subject: "ops", Method: MethodAPIKey, Role: models.RoleAdmin}},
+		{"Unknown", "other-key", models.Principal{}},
+		{"Empty", "", models.Principal{}},
+		{"DifferentCase", "CI-KEY", models.Principal{}},
+		{"TrailingSpace", "ci-key ", models.Principal{}},
+		{"Prefix", "ci-ke", models.Principal{}},
+		{"TheHashItself", apiKeyHash("ci-key"), models.Principal{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := a.AuthenticateAPIKey(tt.key)
+			if tt.want == (models.Principal{}) {
+				if !errors.Is(err, models.ErrUnauthenticated) {
+					t.Errorf("AuthenticateAPIKey = %+v, %v, want ErrUnauthenticated", got, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("AuthenticateAPIKey = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAPIKeyRejects(t *testing.T) {
+	digest := apiKeyHash("key")[len(apiKeyHashPrefix):]
+	tests := []struct {
+		name string
+		cfg  config.APIKeyConfig
+	}{
+		{"NoName", config.APIKeyConfig{Hash: apiKeyHash("key")}},
+		{"NoPrefix", config.APIKeyConfig{Name: "ci", Hash: digest}},
+		{"OtherAlgorithm", config.APIKeyConfig{Name: "ci", Hash: "md5:" + digest}},
+		{"PlainKey", config.APIKeyConfig{Name: "ci", Hash: "sha256:key"}},
+		{"ShortDigest", config.APIKeyConfig{Name: "ci", Hash: apiKeyHashPrefix + digest[:62]}},
+		{"LongDigest", config.APIKeyConfig{Name: "ci", Hash: apiKeyHashPrefix + digest + "00"}},
+		{"NotHex", config.APIKeyConfig{Name: "ci", Hash: apiKeyHashPrefix + strings.Repeat("g", 64)}},
+	}
+	for _, tt := range tests {
+		if _, err := parseAPIKey(tt.cfg); err == nil {
+			t.Errorf("parseAPIKey %s succeeded", tt.name)
+		}
+	}
+}
+
+func TestNewRequiresAKey(t *testing.T) {
+	if _, err := New(config.AuthConfig{}); err == nil {
+		t.Error("New without any key succeeded")
+	}
+}
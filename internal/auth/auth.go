@@ -0,0 +1,78 @@
+// Package auth checks the credentials of API callers: static API keys and
+// HS256 or RS256 JWT bearer tokens.
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang/internal/config"
+	"golang/internal/models"
+)
+
+// Authentication methods recorded in models.Principal
+const (
+	MethodAPIKey = "api-key"
+	MethodJWT    = "jwt"
+)
+
+// Authenticator validates credentials against the configured API keys and
+// JWT keys
+type Authenticator struct {
+	apiKeys []apiKey
+	jwt     *jwtVerifier // nil when no JWT key is configured
+}
+
+// New loads the keys listed in cfg. At least one API key or JWT key is required.
+func New(cfg config.AuthConfig) (*Authenticator, error) {
+	a := &Authenticator{}
+	for i, kc := range cfg.APIKeys {
+		key, err := parseAPIKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("api_keys[%d]: %w", i, err)
+		}
+		a.apiKeys = append(a.apiKeys, key)
+	}
+
+	if cfg.JWT.Enabled() {
+		keys, err := loadJWTKeys(cfg.JWT)
+		if err != nil {
+			return nil, fmt.Errorf("jwt: %w", err)
+		}
+		a.jwt = &jwtVerifier{
+			keys:          keys,
+			issuer:        cfg.JWT.Issuer,
+			audience:      cfg.JWT.Audience,
+			leeway:        time.Duration(cfg.JWT.Leeway),
+			roleClaim:     cfg.JWT.RoleClaim,
+			defaultRole:   cfg.JWT.DefaultRole,
+			tenantClaim:   cfg.JWT.TenantClaim,
+			defaultTenant: cfg.JWT.DefaultTenant,
+			now:           time.Now,
+		}
+	}
+
+	if len(a.apiKeys) == 0 && a.jwt == nil {
+		return nil, errors.New("no API key or JWT key configured")
+	}
+	return a, nil
+}
+
+// AuthenticateToken validates a JWT bearer token and returns its subject
+// with the role and tenant it claims
+func (a *Authenticator) AuthenticateToken(token string) (models.Principal, error) {
+	if a.jwt == nil {
+		return models.Principal{}, fmt.Errorf("%w: bearer tokens are not accepted", models.ErrUnauthenticated)
+	}
+	claims, err := a.jwt.verify(token)
+	if err != nil {
+		return models.Principal{}, fmt.Errorf("%w: %v", models.ErrUnauthenticated, err)
+	}
+	return models.Principal{
+		Subject: claims.Subject,
+		Method:  MethodJWT,
+		Role:    claims.role,
+		Tenant:  claims.tenant,
+	}, nil
+}
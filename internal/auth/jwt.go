@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"golang/internal/models"
+)
+
+// Signature algorithms accepted in the "alg" header of a token
+const (
+	algHS256 = "HS256"
+	algRS256 = "RS256"
+)
+
+// jwtKey is a key tokens may be signed with. Each key is bound to one
+// algorithm so that a public RSA key can never be used as an HMAC secret.
+type jwtKey struct {
+	id     string // matched against the "kid" header when both are set
+	alg    string
+	secret []byte         // HS256
+	public *rsa.PublicKey // RS256
+}
+
+func (k jwtKey) verify(signingInput string, signature []byte) bool {
+	switch k.alg {
+	case algHS256:
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write([]byte(signingInput))
+		return hmac.Equal(mac.Sum(nil), signature)
+	case algRS256:
+		digest := sha256.Sum256([]byte(signingInput))
+		return rsa.VerifyPKCS1v15(k.public, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Subject   string     `json:"sub"`
+	Issuer    string     `json:"iss"`
+	Audience  stringList `json:"aud"`
+	ExpiresAt *float64   `json:"exp"`
+	NotBefore *float64   `json:"nbf"`
+
+	role   models.Role // read from the configured role claim
+	tenant string      // read from the configured tenant claim
+}
+
+// stringList is a claim holding a single string or an array of strings
+type stringList []string
+
+func (l *stringList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = stringList{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(l))
+}
+
+type jwtVerifier struct {
+	keys          []jwtKey
+	issuer        string // required "iss" when set
+	audience      string // required among "aud" when set
+	leeway        time.Duration
+	roleClaim     string
+	defaultRole   models.Role
+	tenantClaim   string
+	defaultTenant string
+	now           func() time.Time
+}
+
+// verify checks the signature and claims of a compact JWS token and returns
+// its claims. Tokens must expire.
+func (v *jwtVerifier) verify(token string) (jwtClaims, error) {
+	var claims jwtClaims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, errors.New("malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return claims, errors.New("malformed token header")
+	}
+	if header.Alg != algHS256 && header.Alg != algRS256 {
+		return claims, fmt.Errorf("unsupported algorithm %q", header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, errors.New("malformed token signature")
+	}
+	if !v.verifySignature(header, parts[0]+"."+parts[1], signature) {
+		return claims, errors.New("invalid token signature")
+	}
+
+	var all map[string]json.RawMessage
+	if decodeSegment(parts[1], &claims) != nil || decodeSegment(parts[1], &all) != nil {
+		return claims, errors.New("malformed token claims")
+	}
+	claims.role = v.roleOf(all[v.roleClaim])
+	if claims.tenant, err = v.tenantOf(all[v.tenantClaim]); err != nil {
+		return claims, err
+	}
+	return claims, v.checkClaims(claims)
+}
+
+// tenantOf returns the tenant named by a tenant claim, or the default tenant.
+// Unlike roles, a malformed tenant rejects the token: guessing one could
+// expose another tenant's contacts.
+func (v *jwtVerifier) tenantOf(claim json.RawMessage) (string, error) {
+	if claim == nil {
+		return v.defaultTenant, nil
+	}
+	var tenant string
+	if json.Unmarshal(claim, &tenant) != nil || models.ValidateTenantID(tenant) != nil {
+		return "", fmt.Errorf("invalid %q claim", v.tenantClaim)
+	}
+	return tenant, nil
+}
+
+// roleOf returns the highest known role listed in a role claim, or the
+// default role
+func (v *jwtVerifier) roleOf(claim json.RawMessage) models.Role {
+	var names stringList
+	if claim == nil || json.Unmarshal(claim, &names) != nil {
+		return v.defaultRole
+	}
+	role := models.Role("")
+	for _, name := range names {
+		if r := models.Role(name); r.Valid() && roleRank[r] > roleRank[role] {
+			role = r
+		}
+	}
+	if role == "" {
+		return v.defaultRole
+	}
+	return role
+}
+
+// roleRank orders the roles from the least to the most privileged
+var roleRank = map[models.Role]int{
+	models.RoleViewer: 1,
+	models.RoleEditor: 2,
+	models.RoleAdmin:  3,
+}
+
+func (v *jwtVerifier) verifySignature(header jwtHeader, signingInput string, signature []byte) bool {
+	for _, key := range v.keys {
+		if key.alg != header.Alg {
+			continue
+		}
+		if header.Kid != "" && key.id != "" && key.id != header.Kid {
+			continue
+		}
+		if key.verify(signingInput, signature) {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *jwtVerifier) checkClaims(claims jwtClaims) error {
+	now := v.now()
+	if claims.ExpiresAt == nil {
+		return errors.New("token has no expiry")
+	}
+	if now.After(numericDate(*claims.ExpiresAt).Add(v.leeway)) {
+		return errors.New("token expired")
+	}
+	if claims.NotBefore != nil && now.Add(v.leeway).Before(numericDate(*claims.NotBefore)) {
+		return errors.New("token not valid yet")
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return errors.New("token issued by another issuer")
+	}
+	if v.audience != "" && !slices.Contains(claims.Audience, v.audience) {
+		return errors.New("token intended for another audience")
+	}
+	if claims.Subject == "" {
+		return errors.New("token has no subject")
+	}
+	return nil
+}
+
+// numericDate converts a JWT date, in seconds since the epoch
+func numericDate(seconds float64) time.Time {
+	return time.Unix(int64(seconds), 0)
+}
+
+func decodeSegment(segment string, v any) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
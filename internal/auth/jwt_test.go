@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"golang/internal/models"
+)
+
+// testNow is the clock of the verifiers under test
+var testNow = time.Date(2026, 3, 14, 12, 0, 0, 0, time.UTC)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// signToken builds a compact JWS token. key is an HMAC secret ([]byte) for
+// HS* algorithms, an *rsa.PrivateKey for RS256, or nil for an unsigned token.
+func signToken(t *testing.T, header map[string]any, claims map[string]any, key any) string {
+	t.Helper()
+	segment := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	input := segment(header) + "." + segment(claims)
+
+	var signature []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(input))
+		signature = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		digest := sha256.Sum256([]byte(input))
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func testRSAKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func testVerifier(keys ...jwtKey) *jwtVerifier {
+	return &jwtVerifier{
+		keys:          keys,
+		issuer:        "https://id.example.com",
+		audience:      "contacts",
+		leeway:        30 * time.Second,
+		roleClaim:     "role",
+		defaultRole:   models.RoleViewer,
+		tenantClaim:   "tenant",
+		defaultTenant: "default",
+		now:           func() time.Time { return testNow },
+	}
+}
+
+// unix returns a JWT date offset from testNow
+func unix(offset time.Duration) int64 {
+	return testNow.Add(offset).Unix()
+}
+
+func TestJWTVerify(t *testing.T) {
+	rsaKey := testRSAKey(t)
+	otherRSAKey := testRSAKey(t)
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey)})
+
+	hsKey := jwtKey{alg: algHS256, secret: []byte(testSecret)}
+	rsKey := jwtKey{alg: algRS256, public: &rsaKey.PublicKey}
+
+	tests := []struct {
+		name    string
+		keys    []jwtKey
+		header  map[string]any
+		edit    func(claims map[string]any) // changes the valid claims
+		signKey any
+		wantErr string // "" when the token is valid
+	}{
+		{"HS256", []jwtKey{hsKey}, map[string]any{"alg": "HS256"}, nil, []byte(testSecret), ""},
+		{"RS256", []jwtKey{rsKey}, map[string]any{"alg": "RS256"}, nil, rsaKey, ""},
+		{"WrongSecret", []jwtKey{hsKey}, map[string]any{"alg": "HS256"}, nil, []byte(strings.Repeat("x", 32)), "invalid token signature"},
+		{"WrongRSAKey", []jwtKey{rsKey}, map[string]any{"alg": "RS256"}, nil, otherRSAKey, "invalid token signature"},
+
+		// algorithm confusion
+		{"HSTokenSignedWithRSAPublicKey", []jwtKey{rsKey}, map[string]any{"alg": "HS256"}, nil, publicPEM, "invalid token signature"},
+		{"HSTokenSignedWithRSAModulus", []jwtKey{rsKey}, map[string]any{"alg": "HS256"}, nil, rsaKey.PublicKey.N.Bytes(), "invalid token signature"},
+		{"RSTokenForHSKey", []jwtKey{hsKey}, map[string]any{"alg": "RS256"}, nil, rsaKey, "invalid token signature"},
+		{"AlgNone", []jwtKey{hsKey, rsKey}, map[string]any{"alg": "none"}, nil, nil, `unsupported algorithm "none"`},
+		{"AlgNoneUppercase", []jwtKey{hsKey}, map[string]any{"alg": "NONE"}, nil, nil, `unsupported algorithm "NONE"`},
+		{"AlgMissing", []jwtKey{hsKey}, map[string]any{}, nil, []byte(testSecret), `unsupported algorithm ""`},
+		{"AlgHS512", []jwtKey{hsKey}, map[string]any{"alg": "HS512"}, nil, []byte(testSecret), `unsupported algorithm "HS512"`},
+
+		// key IDs
+		{"MatchingKid", []jwtKey{{id: "k1", alg: algRS256, public: &otherRSAKey.PublicKey}, {id: "k2", alg: algRS256, public: &rsaKey.PublicKey}},
+			map[string]any{"alg": "RS256", "kid": "k2"}, nil, rsaKey, ""},
+		{"UnknownKid", []jwtKey{{id: "k1", alg: algHS256, secret: []byte(testSecret)}},
+			map[string]any{"alg": "HS256", "kid": "k2"}, nil, []byte(testSecret), "invalid token signature"},
+		{"KidOfAnotherKey", []jwtKey{{id: "k1", alg: algRS256, public: &rsaKey.PublicKey}, {id: "k2", alg: algRS256, public: &otherRSAKey.PublicKey}},
+			map[string]any{"alg": "RS256", "kid": "k2"}, nil, rsaKey, "invalid token signature"},
+		{"KidWithUnnamedKey", []jwtKey{hsKey}, map[string]any{"alg": "HS256", "kid": "k1"}, nil, []byte(testSecret), ""},
+
+		// exp and nbf
+		{"ExpMissing", []jwtKey{hsKey}, map[string]any{"alg": "HS256"},
+			func(c map[string]any) { delete(c, "exp") }, []byte(testSecret), "token has no expiry"},
+		{"Expired", []jwtKey{hsKey}, map[string]any{"alg": "HS256"},
+			func(c map[string]any) { c["exp"] = unix(-time.Minute) }, []byte(testSecret), "token expired"},
+		{"ExpiredWithinLeeway", []jwtKey{hsKey}, map[string]any{"alg": "HS256"},
+			func(c map[string]any) { c["exp"] = unix(-10 * time.Second) }, []byte(testSecret), ""},
+		{"ExpNotANumber", []jwtKey{hsKey}, map[string]any{"alg": "HS256"},
+			func(c map[string]any) { c["exp"] = "tomorrow" }, []byte(testSecret), "malformed token claims"},
+		{"NotYetValid", []jwtKey{hsKey}, map[string]any{"alg": "HS256"},
+			func(c map[string]any) { c["nbf"] = unix(time.Minute) }, []byte(testSecret), "token not valid yet"},
+		{"NotBeforeWithinLeeway", []jwtKey{hsKey}, map[string]any{"alg": "HS256"},
+			func(c map[string]any) { c["nbf"] = unix(10 * time.Second) }, []byte(testSecret), ""},
+		{"NotBeforePast", []jwtKey{hsKey}, map[string]any{"alg": "HS256"},
+			func(c map[string]any) { c["nbf"] = unix(-time.Hour) }, []byte(testSecret), ""},
+
+		// iss, aud and sub
+		{"WrongIssuer", []jwtKey{hsKey}, map[string]any{"alg": "HS256"},
+			func(c map[string]any) { c["iss"] = "https://evil.example.com" }, []byte(testSecret), "token issued by another issuer"},
+		{"IssuerMissing", []jwtKey{hsKey}, map[string]any{"alg": "HS256"},
+			func(c map[string]any) { delete(c, "iss") }, []byte(testSecret), "token issued by another issuer"},
+		{"WrongAudience", []jwtKey{hsKey}, map[string]any{"alg": "HS256"},
+			func(c map[string]any) { c["aud"] = "billing" }, []byte(testSecret), "token intended for another audience"},
+		{"AudienceArray", []jwtKey{hsKey}, map[string]any{"alg": "HS256"},
+			func(c map[string]any) { c["aud"] = []string{"billing", "contacts"} }, []byte(testSecret), ""},
+		{"AudienceArrayWithoutOurs", []jwtKey{hsKey}, map[string]any{"alg": "HS256"},
+			func(c map[string]any) { c["aud"] = []string{"billing", "crm"} }, []byte(testSecret), "token intended for another audience"},
+		{"SubjectMissing", []jwtKey{hsKey}, map[string]any{"alg": "HS256"},
+			func(c map[string]any) { delete(c, "sub") }, []byte(testSecret), "token has no subject"},
+		{"InvalidTenant", []jwtKey{hsKey}, map[string]any{"alg": "HS256"},
+			func(c map[string]any) { c["tenant"] = "../sales" }, []byte(testSecret), `invalid "tenant" claim`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := map[string]any{
+				"sub": "alice",
+				"iss": "https://id.example.com",
+				"aud": "contacts",
+				"exp": unix(time.Hour),
+			}
+			if tt.edit != nil {
+				tt.edit(claims)
+			}
+			token := signToken(t, tt.header, claims, tt.signKey)
+
+			got, err := testVerifier(tt.keys...).verify(token)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("verify: %v", err)
+				}
+				if got.Subject != "alice" {
+					t.Errorf("verify subject = %q, want %q", got.Subject, "alice")
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("verify = %v, want error %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestJWTVerifyRejectsTampering(t *testing.T) {
+	token := signToken(t, map[string]any{"alg": "HS256"},
+		map[string]any{"sub": "alice", "iss": "https://id.example.com", "aud": "contacts", "exp": unix(time.Hour)},
+		[]byte(testSecret))
+	parts := strings.Split(token, ".")
+	forged, _ := json.Marshal(map[string]any{"sub": "alice", "iss": "https://id.example.com", "aud": "contacts",
+		"exp": unix(time.Hour), "role": "admin"})
+
+	for name, token := range map[string]string{
+		"Claims":      parts[0] + "." + base64.RawURLEncoding.EncodeToString(forged) + "." + parts[2],
+		"NoSignature": parts[0] + "." + parts[1] + ".",
+		"TwoParts":    parts[0] + "." + parts[1],
+		"BadHeader":   "e30x." + parts[1] + "." + parts[2],
+		"BadEncoding": parts[0] + "." + parts[1] + ".***",
+	} {
+		if _, err := testVerifier(jwtKey{alg: algHS256, secret: []byte(testSecret)}).verify(token); err == nil {
+			t.Errorf("verify of a token with tampered %s succeeded", name)
+		}
+	}
+}
+
+func TestJWTRoleAndTenant(t *testing.T) {
+	tests := []struct {
+		name       string
+		role       any // the role claim, nil for none
+		tenant     any
+		wantRole   models.Role
+		wantTenant string
+	}{
+		{"Defaults", nil, nil, models.RoleViewer, "default"},
+		{"Role", "editor", "sales", models.RoleEditor, "sales"},
+		{"HighestRoleWins", []string{"viewer", "admin", "editor"}, nil, models.RoleAdmin, "default"},
+		{"UnknownRole", "superuser", nil, models.RoleViewer, "default"},
+		{"MalformedRole", 7, nil, models.RoleViewer, "default"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := map[string]any{"sub": "alice", "iss": "https://id.example.com", "aud": "contacts", "exp": unix(time.Hour)}
+			if tt.role != nil {
+				claims["role"] = tt.role
+			}
+			if tt.tenant != nil {
+				claims["tenant"] = tt.tenant
+			}
+			token := signToken(t, map[string]any{"alg": "HS256"}, claims, []byte(testSecret))
+
+			got, err := testVerifier(jwtKey{alg: algHS256, secret: []byte(testSecret)}).verify(token)
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if got.role != tt.wantRole || got.tenant != tt.wantTenant {
+				t.Errorf("verify role, tenant = %q, %q, want %q, %q", got.role, got.tenant, tt.wantRole, tt.wantTenant)
+			}
+		})
+	}
+}
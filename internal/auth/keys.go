@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"golang/internal/config"
+)
+
+// minHMACSecret is the shortest HS256 secret accepted (RFC 7518 §3.2)
+const minHMACSecret = 32
+
+// loadJWTKeys reads every key configured to check tokens against
+func loadJWTKeys(cfg config.JWTConfig) ([]jwtKey, error) {
+	var keys []jwtKey
+	if cfg.HMACSecret != "" {
+		if len(cfg.HMACSecret) < minHMACSecret {
+			return nil, fmt.Errorf("hmac_secret must be at least %d bytes", minHMACSecret)
+		}
+		keys = append(keys, jwtKey{alg: algHS256, secret: []byte(cfg.HMACSecret)})
+	}
+
+	for _, path := range cfg.PublicKeyFiles {
+		public, err := readPublicKey(path)
+		if err != nil {
+			return nil, fmt.Errorf("public key %s: %w", path, err)
+		}
+		keys = append(keys, jwtKey{alg: algRS256, public: public})
+	}
+
+	if cfg.JWKSFile != "" {
+		set, err := readJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, fmt.Errorf("jwks %s: %w", cfg.JWKSFile, err)
+		}
+		keys = append(keys, set...)
+	}
+	return keys, nil
+}
+
+// readPublicKey reads an RSA public key from a PEM file holding a
+// "PUBLIC KEY", an "RSA PUBLIC KEY" or a certificate
+func readPublicKey(path string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var public any
+	switch block.Type {
+	case "PUBLIC KEY":
+		public, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		public, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+			public = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := public.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// jwk is a member of a JSON Web Key Set (RFC 7517)
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"` // RSA modulus
+	E   string `json:"e"` // RSA exponent
+	K   string `json:"k"` // symmetric key
+}
+
+// readJWKS reads the RSA and symmetric signing keys of a JWKS file.
+// Keys of other types or meant for encryption are skipped.
+func readJWKS(path string) ([]jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	var keys []jwtKey
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch {
+		case k.Kty == "RSA" && (k.Alg == "" || k.Alg == algRS256):
+			public, err := k.rsaPublicKey()
+			if err != nil {
+				return nil, fmt.Errorf("keys[%d]: %w", i, err)
+			}
+			keys = append(keys, jwtKey{id: k.Kid, alg: algRS256, public: public})
+		case k.Kty == "oct" && (k.Alg == "" || k.Alg == algHS256):
+			secret, err := base64.RawURLEncoding.DecodeString(k.K)
+			if err != nil || len(secret) < minHMACSecret {
+				return nil, fmt.Errorf("keys[%d]: k must be a base64url secret of at least %d bytes", i, minHMACSecret)
+			}
+			keys = append(keys, jwtKey{id: k.Kid, alg: algHS256, secret: secret})
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no RS256 or HS256 signing key found")
+	}
+	return keys, nil
+}
+
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil || len(n) == 0 {
+		return nil, errors.New("invalid RSA modulus")
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil || len(e) == 0 || len(e) > 4 {
+		return nil, errors.New("invalid RSA exponent")
+	}
+	exponent := new(big.Int).SetBytes(e)
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
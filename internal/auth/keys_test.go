@@ -0,0 +1,150 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang/internal/config"
+)
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadJWTKeysHMACSecretLength(t *testing.T) {
+	tests := []struct {
+		secret  string
+		wantErr bool
+	}{
+		{strings.Repeat("s", minHMACSecret-1), true},
+		{strings.Repeat("s", minHMACSecret), false},
+		{strings.Repeat("s", 64), false},
+	}
+	for _, tt := range tests {
+		keys, err := loadJWTKeys(config.JWTConfig{HMACSecret: tt.secret})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("loadJWTKeys with a %d byte secret = %v, want error %t", len(tt.secret), err, tt.wantErr)
+		}
+		if err == nil && (len(keys) != 1 || keys[0].alg != algHS256) {
+			t.Errorf("loadJWTKeys with a %d byte secret = %+v, want one HS256 key", len(tt.secret), keys)
+		}
+	}
+}
+
+func TestReadJWKS(t *testing.T) {
+	public := testRSAKey(t).PublicKey
+	n := base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+	oct := func(size int) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(strings.Repeat("k", size)))
+	}
+
+	tests := []struct {
+		name     string
+		jwks     string
+		wantAlgs []string // algorithms of the keys read, nil when reading fails
+		wantIDs  []string
+	}{
+		{"RSA", `{"keys": [{"kty": "RSA", "kid": "r1", "n": "` + n + `", "e": "` + e + `"}]}`,
+			[]string{algRS256}, []string{"r1"}},
+		{"Oct", `{"keys": [{"kty": "oct", "kid": "h1", "alg": "HS256", "k": "` + oct(minHMACSecret) + `"}]}`,
+			[]string{algHS256}, []string{"h1"}},
+		{"OctTooShort", `{"keys": [{"kty": "oct", "kid": "h1", "k": "` + oct(minHMACSecret-1) + `"}]}`,
+			nil, nil},
+		{"OctNotBase64URL", `{"keys": [{"kty": "oct", "kid": "h1", "k": "` + strings.Repeat("+", 48) + `"}]}`,
+			nil, nil},
+		{"SkipsEncryptionAndOtherKeys", `{"keys": [
+			{"kty": "RSA", "kid": "enc", "use": "enc", "n": "` + n + `", "e": "` + e + `"},
+			{"kty": "EC", "kid": "ec", "crv": "P-256"},
+			{"kty": "RSA", "kid": "ps", "alg": "PS256", "n": "` + n + `", "e": "` + e + `"},
+			{"kty": "RSA", "kid": "sig", "use": "sig", "alg": "RS256", "n": "` + n + `", "e": "` + e + `"}]}`,
+			[]string{algRS256}, []string{"sig"}},
+		{"NoSigningKey", `{"keys": [{"kty": "EC", "kid": "ec"}]}`, nil, nil},
+		{"BadExponent", `{"keys": [{"kty": "RSA", "n": "` + n + `", "e": ""}]}`, nil, nil},
+		{"NotJSON", `keys`, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := readJWKS(writeTestFile(t, "jwks.json", tt.jwks))
+			if tt.wantAlgs == nil {
+				if err == nil {
+					t.Fatalf("readJWKS = %+v, want an error", keys)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readJWKS: %v", err)
+			}
+			var algs, ids []string
+			for _, k := range keys {
+				algs, ids = append(algs, k.alg), append(ids, k.id)
+			}
+			if strings.Join(algs, ",") != strings.Join(tt.wantAlgs, ",") || strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("readJWKS algs, kids = %v, %v, want %v, %v", algs, ids, tt.wantAlgs, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestReadPublicKey(t *testing.T) {
+	public := testRSAKey(t).PublicKey
+	pkix, err := x509.MarshalPKIXPublicKey(&public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ec, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecPKIX, err := x509.MarshalPKIXPublicKey(&ec.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		block   *pem.Block
+		wantErr bool
+	}{
+		{"PKIX", &pem.Block{Type: "PUBLIC KEY", Bytes: pkix}, false},
+		{"PKCS1", &pem.Block{Type: "RSA PUBLIC KEY", Bytes: x509.MarshalPKCS1PublicKey(&public)}, false},
+		{"NotRSA", &pem.Block{Type: "PUBLIC KEY", Bytes: ecPKIX}, true},
+		{"PrivateKey", &pem.Block{Type: "RSA PRIVATE KEY", Bytes: []byte("secret")}, true},
+		{"NotPEM", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "not a key"
+			if tt.block != nil {
+				content = string(pem.EncodeToMemory(tt.block))
+			}
+			got, err := readPublicKey(writeTestFile(t, "key.pem", content))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("readPublicKey succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readPublicKey: %v", err)
+			}
+			if !got.Equal(&public) {
+				t.Errorf("readPublicKey returned another key")
+			}
+		})
+	}
+}
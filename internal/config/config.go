@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
+
+	"golang/internal/models"
 )
 
 type StoreType string
@@ -18,6 +21,9 @@ type Config struct {
 	Store  StoreConfig  `json:"store"`
 	Server ServerConfig `json:"server"`
 	Email  EmailConfig  `json:"email"`
+	Outbox OutboxConfig `json:"outbox"`
+	Auth   AuthConfig   `json:"auth"`
+	Backup BackupConfig `json:"backup"`
 }
 
 type StoreConfig struct {
@@ -28,29 +34,132 @@ type StoreConfig struct {
 }
 
 type SQLiteConfig struct {
-	DBPath     string `json:"db_path"`
-	SchemaPath string `json:"schema_path"`
+	DBPath        string `json:"db_path"`
+	MigrationsDir string `json:"migrations_dir"`
+	SeedPath      string `json:"seed_path"`
 }
 
 type FileStoreConfig struct {
 	FilePath string `json:"file_path"`
+	// Generations is the number of earlier versions of each file kept to
+	// recover a damaged one from (default 3)
+	Generations int `json:"generations"`
 }
 
 type PostgresConfig struct {
-	Host       string `json:"host"`
-	Port       int    `json:"port"`
-	User       string `json:"user"`
-	Password   string `json:"password"`
-	DBName     string `json:"dbname"`
-	SchemaPath string `json:"schema_path"`
+	Host          string `json:"host"`
+	Port          int    `json:"port"`
+	User          string `json:"user"`
+	Password      string `json:"password"`
+	DBName        string `json:"dbname"`
+	MigrationsDir string `json:"migrations_dir"`
+	SeedPath      string `json:"seed_path"`
 }
 
 type ServerConfig struct {
-	Port string `json:"port"`
+	Port              string   `json:"port"`
+	ReadTimeout       Duration `json:"read_timeout"`
+	ReadHeaderTimeout Duration `json:"read_header_timeout"`
+	WriteTimeout      Duration `json:"write_timeout"`
+	IdleTimeout       Duration `json:"idle_timeout"`
+	// ShutdownTimeout bounds how long in-flight requests and notification
+	// deliveries may take to finish once a stop signal is received
+	ShutdownTimeout Duration `json:"shutdown_timeout"`
 }
 
+// EmailConfig selects the SMTP server used for notifications.
+// Without a host, messages are only logged (handy for local development).
 type EmailConfig struct {
-	Token string `json:"token"`
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`
+	Security string   `json:"security"` // "starttls" (default), "tls" or "none"
+	Auth     string   `json:"auth"`     // "plain" (default), "login" or "none"
+	Username string   `json:"username"`
+	Password string   `json:"password"`
+	Token    string   `json:"token"` // used as the password when none is set (API-key relays)
+	From     string   `json:"from"`
+	Timeout  Duration `json:"timeout"`
+}
+
+// OutboxConfig tunes the background delivery of queued notifications
+type OutboxConfig struct {
+	PollInterval Duration `json:"poll_interval"`
+	BatchSize    int      `json:"batch_size"`
+	MaxAttempts  int      `json:"max_attempts"`
+	BaseBackoff  Duration `json:"base_backoff"`
+	MaxBackoff   Duration `json:"max_backoff"`
+	// Retention is how long delivered messages are kept before being purged
+	Retention Duration `json:"retention"`
+}
+
+// BackupConfig schedules backups of the store while the HTTP server runs.
+// Backups are off unless Interval is set.
+type BackupConfig struct {
+	Dir      string   `json:"dir"`
+	Interval Duration `json:"interval"`
+	Keep     int      `json:"keep"` // newest archives kept in Dir (default 7)
+}
+
+// AuthConfig lists the credentials accepted by the HTTP server.
+// Requests must carry an API key or a JWT bearer token unless Disabled is set.
+type AuthConfig struct {
+	Disabled bool           `json:"disabled"`
+	APIKeys  []APIKeyConfig `json:"api_keys"`
+	JWT      JWTConfig      `json:"jwt"`
+	CLIRole  models.Role    `json:"cli_role"` // role of the CLI user (default admin)
+}
+
+// APIKeyConfig is a static API key, stored as "sha256:<hex digest of the key>"
+type APIKeyConfig struct {
+	Name   string      `json:"name"` // identifies the caller in audit entries
+	Hash   string      `json:"hash"`
+	Role   models.Role `json:"role"`   // default viewer
+	Tenant string      `json:"tenant"` // contact book the key works on (default "default")
+}
+
+// JWTConfig selects the keys that bearer tokens are checked against.
+// HMACSecret validates HS256 tokens; PublicKeyFiles (PEM) and JWKSFile
+// validate RS256 tokens (a JWKS may also hold HS256 "oct" keys).
+type JWTConfig struct {
+	Issuer         string   `json:"issuer"`
+	Audience       string   `json:"audience"`
+	HMACSecret     string   `json:"hmac_secret"`
+	PublicKeyFiles []string `json:"public_key_files"`
+	JWKSFile       string   `json:"jwks_file"`
+	Leeway         Duration `json:"leeway"` // tolerated clock skew
+	// RoleClaim names the claim holding the caller's role, a string or an
+	// array of strings (default "role"). Tokens without one get DefaultRole.
+	RoleClaim   string      `json:"role_claim"`
+	DefaultRole models.Role `json:"default_role"` // default viewer
+	// TenantClaim names the string claim holding the caller's tenant
+	// (default "tenant"). Tokens without one work on DefaultTenant.
+	TenantClaim   string `json:"tenant_claim"`
+	DefaultTenant string `json:"default_tenant"` // default "default"
+}
+
+// Enabled reports whether any key to check tokens against is configured
+func (c JWTConfig) Enabled() bool {
+	return c.HMACSecret != "" || len(c.PublicKeyFiles) > 0 || c.JWKSFile != ""
+}
+
+// Duration is a time.Duration written as a string such as "5s" or "1m30s"
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
 }
 
 func Load(configPath string) (*Config, error) {
@@ -70,5 +179,148 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("invalid store type: %s (must be sqlite, postgres, or filestore)", cfg.Store.Type)
 	}
 
+	switch cfg.Email.Security {
+	case "", "starttls", "tls", "none":
+	default:
+		return nil, fmt.Errorf("invalid email security: %s (must be starttls, tls, or none)", cfg.Email.Security)
+	}
+
+	switch cfg.Email.Auth {
+	case "", "plain", "login", "none":
+	default:
+		return nil, fmt.Errorf("invalid email auth: %s (must be plain, login, or none)", cfg.Email.Auth)
+	}
+
+	if cfg.Outbox.Retention < 0 {
+		return nil, fmt.Errorf("invalid outbox retention: %s (cannot be negative)", time.Duration(cfg.Outbox.Retention))
+	}
+
+	if cfg.Store.FileStore.Generations < 0 {
+		return nil, fmt.Errorf("invalid filestore generations: %d (cannot be negative)", cfg.Store.FileStore.Generations)
+	}
+
+	if cfg.Backup.Interval > 0 && cfg.Backup.Dir == "" {
+		return nil, fmt.Errorf("invalid backup settings: an interval needs a dir to write backups to")
+	}
+	if cfg.Backup.Interval < 0 || cfg.Backup.Keep < 0 {
+		return nil, fmt.Errorf("invalid backup settings: interval and keep cannot be negative")
+	}
+
+	cfg.applyDefaults()
+
+	roles := []models.Role{cfg.Auth.CLIRole, cfg.Auth.JWT.DefaultRole}
+	for _, key := range cfg.Auth.APIKeys {
+		roles = append(roles, key.Role)
+	}
+	for _, role := range roles {
+		if !role.Valid() {
+			return nil, fmt.Errorf("invalid role: %s (must be viewer, editor, or admin)", role)
+		}
+	}
+
+	tenants := []string{cfg.Auth.JWT.DefaultTenant}
+	for _, key := range cfg.Auth.APIKeys {
+		tenants = append(tenants, key.Tenant)
+	}
+	for _, tenant := range tenants {
+		if err := models.ValidateTenantID(tenant); err != nil {
+			return nil, fmt.Errorf("invalid tenant: %w", err)
+		}
+	}
+
 	return &cfg, nil
 }
+
+// applyDefaults fills in settings that the configuration file left out
+func (c *Config) applyDefaults() {
+	if c.Server.ReadTimeout == 0 {
+		c.Server.ReadTimeout = Duration(15 * time.Second)
+	}
+	if c.Server.ReadHeaderTimeout == 0 {
+		c.Server.ReadHeaderTimeout = Duration(5 * time.Second)
+	}
+	if c.Server.WriteTimeout == 0 {
+		c.Server.WriteTimeout = Duration(30 * time.Second)
+	}
+	if c.Server.IdleTimeout == 0 {
+		c.Server.IdleTimeout = Duration(2 * time.Minute)
+	}
+	if c.Server.ShutdownTimeout == 0 {
+		c.Server.ShutdownTimeout = Duration(20 * time.Second)
+	}
+	if c.Email.Security == "" {
+		c.Email.Security = "starttls"
+	}
+	if c.Email.Auth == "" {
+		c.Email.Auth = "plain"
+	}
+	if c.Email.Port == 0 {
+		switch c.Email.Security {
+		case "tls":
+			c.Email.Port = 465
+		case "none":
+			c.Email.Port = 25
+		default:
+			c.Email.Port = 587
+		}
+	}
+	if c.Email.Password == "" {
+		c.Email.Password = c.Email.Token
+	}
+	if c.Email.From == "" {
+		c.Email.From = "Contact Manager <no-reply@localhost>"
+	}
+	if c.Email.Timeout == 0 {
+		c.Email.Timeout = Duration(30 * time.Second)
+	}
+	if c.Outbox.PollInterval == 0 {
+		c.Outbox.PollInterval = Duration(2 * time.Second)
+	}
+	if c.Outbox.BatchSize == 0 {
+		c.Outbox.BatchSize = 20
+	}
+	if c.Outbox.MaxAttempts == 0 {
+		c.Outbox.MaxAttempts = 8
+	}
+	if c.Outbox.BaseBackoff == 0 {
+		c.Outbox.BaseBackoff = Duration(5 * time.Second)
+	}
+	if c.Outbox.MaxBackoff == 0 {
+		c.Outbox.MaxBackoff = Duration(30 * time.Minute)
+	}
+	if c.Outbox.Retention == 0 {
+		c.Outbox.Retention = Duration(7 * 24 * time.Hour)
+	}
+	if c.Store.FileStore.Generations == 0 {
+		c.Store.FileStore.Generations = 3
+	}
+	if c.Backup.Keep == 0 {
+		c.Backup.Keep = 7
+	}
+	if c.Auth.JWT.Leeway == 0 {
+		c.Auth.JWT.Leeway = Duration(30 * time.Second)
+	}
+	if c.Auth.JWT.RoleClaim == "" {
+		c.Auth.JWT.RoleClaim = "role"
+	}
+	if c.Auth.JWT.DefaultRole == "" {
+		c.Auth.JWT.DefaultRole = models.RoleViewer
+	}
+	if c.Auth.JWT.TenantClaim == "" {
+		c.Auth.JWT.TenantClaim = "tenant"
+	}
+	if c.Auth.JWT.DefaultTenant == "" {
+		c.Auth.JWT.DefaultTenant = models.DefaultTenant
+	}
+	if c.Auth.CLIRole == "" {
+		c.Auth.CLIRole = models.RoleAdmin
+	}
+	for i := range c.Auth.APIKeys {
+		if c.Auth.APIKeys[i].Role == "" {
+			c.Auth.APIKeys[i].Role = models.RoleViewer
+		}
+		if c.Auth.APIKeys[i].Tenant == "" {
+			c.Auth.APIKeys[i].Tenant = models.DefaultTenant
+		}
+	}
+}
@@ -1,18 +1,27 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 
 	"golang/internal/config"
+	"golang/internal/database/migrate"
 )
 
 type Database interface {
 	Connect() error
+	Migrate(ctx context.Context) error
 	Close() error
 	GetDB() *sql.DB
 }
 
+// Migratable is implemented by databases with a versioned SQL schema
+type Migratable interface {
+	Migrator() *migrate.Migrator
+	SeedPath() string
+}
+
 // New creates a new Database instance based on the configuration
 // This is the factory function that returns the appropriate database implementation
 func New(cfg *config.Config) (Database, error) {
@@ -27,3 +36,13 @@ func New(cfg *config.Config) (Database, error) {
 		return nil, fmt.Errorf("unsupported database type: %s", cfg.Store.Type)
 	}
 }
+
+// SchemaVersion returns the schema migration db is at, or 0 for a database
+// without a versioned schema
+func SchemaVersion(ctx context.Context, db Database) (int64, error) {
+	migratable, ok := db.(Migratable)
+	if !ok {
+		return 0, nil
+	}
+	return migratable.Migrator().Version(ctx)
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
@@ -39,3 +40,8 @@ func (f *FileStoreDB) Close() error {
 func (s *FileStoreDB) GetDB() *sql.DB {
 	return nil
 }
+
+// Migrate is a no-op: the file store has no schema
+func (f *FileStoreDB) Migrate(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,85 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Dialect names a SQL flavour; migration files live in a subdirectory of the
+// same name (e.g. db/migrations/sqlite)
+type Dialect string
+
+const (
+	SQLite   Dialect = "sqlite"
+	Postgres Dialect = "postgres"
+)
+
+// Dialects lists every dialect that migrations are written for
+var Dialects = []Dialect{SQLite, Postgres}
+
+// postgresLockID is the advisory lock key guarding schema_migrations
+const postgresLockID = 7262554107
+
+// dialectOps holds the statements that differ between databases.
+// Every migration runs as its own unit (begin/commit) while the whole run
+// is guarded by a database-wide lock so concurrent instances queue up.
+type dialectOps struct {
+	lock        func(ctx context.Context, conn *sql.Conn) error
+	unlock      func(ctx context.Context, conn *sql.Conn) error
+	begin       string
+	commit      string
+	rollback    string
+	placeholder func(n int) string
+	createTable string
+}
+
+func opsFor(d Dialect) (*dialectOps, error) {
+	switch d {
+	case SQLite:
+		// SQLite has no advisory locks: an IMMEDIATE transaction takes the
+		// database write lock for the whole run and each migration becomes
+		// a savepoint inside it
+		return &dialectOps{
+			lock: func(ctx context.Context, conn *sql.Conn) error {
+				_, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE")
+				return err
+			},
+			unlock: func(ctx context.Context, conn *sql.Conn) error {
+				_, err := conn.ExecContext(ctx, "COMMIT")
+				return err
+			},
+			begin:       "SAVEPOINT migration",
+			commit:      "RELEASE migration",
+			rollback:    "ROLLBACK TO migration; RELEASE migration",
+			placeholder: func(int) string { return "?" },
+			createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+				version INTEGER PRIMARY KEY,
+				name TEXT NOT NULL,
+				applied_at TIMESTAMP NOT NULL
+			)`,
+		}, nil
+	case Postgres:
+		return &dialectOps{
+			lock: func(ctx context.Context, conn *sql.Conn) error {
+				_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", postgresLockID)
+				return err
+			},
+			unlock: func(ctx context.Context, conn *sql.Conn) error {
+				_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", postgresLockID)
+				return err
+			},
+			begin:       "BEGIN",
+			commit:      "COMMIT",
+			rollback:    "ROLLBACK",
+			placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+			createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+				version BIGINT PRIMARY KEY,
+				name TEXT NOT NULL,
+				applied_at TIMESTAMPTZ NOT NULL
+			)`,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported migration dialect: %s", d)
+	}
+}
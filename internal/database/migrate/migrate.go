@@ -0,0 +1,308 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fileName matches migration files such as 0001_create_contacts.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one numbered schema change with its rollback
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status reports whether a migration has been applied
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies the migrations of one dialect to a database and records
+// them in the schema_migrations table
+type Migrator struct {
+	db      *sql.DB
+	dialect Dialect
+	dir     string
+}
+
+// New creates a Migrator reading files from dir/<dialect>
+func New(db *sql.DB, dialect Dialect, dir string) *Migrator {
+	return &Migrator{
+		db:      db,
+		dialect: dialect,
+		dir:     filepath.Join(dir, string(dialect)),
+	}
+}
+
+// Load reads and orders the migration files of the dialect
+func (m *Migrator) Load() ([]Migration, error) {
+	entries, err := os.ReadDir(m.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+		body, err := os.ReadFile(filepath.Join(m.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		mig, ok := byVersion[version]
+		if !ok {
+			mig = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = mig
+		} else if mig.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, mig.Name, match[2])
+		}
+		if match[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up file", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Up applies every pending migration in order and returns how many ran
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	migrations, err := m.Load()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = m.withLock(ctx, func(conn *sql.Conn, ops *dialectOps) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			insert := fmt.Sprintf("INSERT INTO schema_migrations (version, name, applied_at) VALUES (%s, %s, %s)",
+				ops.placeholder(1), ops.placeholder(2), ops.placeholder(3))
+			if err := m.run(ctx, conn, ops, mig.Up, insert, mig.Version, mig.Name, time.Now().UTC()); err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %w", mig.Version, mig.Name, err)
+			}
+			log.Printf("Migration applied: %04d_%s", mig.Version, mig.Name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Down rolls back the most recent applied migrations, at most steps of them
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	migrations, err := m.Load()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = m.withLock(ctx, func(conn *sql.Conn, ops *dialectOps) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+			mig := migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if mig.Down == "" {
+				return fmt.Errorf("migration %04d_%s has no down file", mig.Version, mig.Name)
+			}
+			remove := "DELETE FROM schema_migrations WHERE version = " + ops.placeholder(1)
+			if err := m.run(ctx, conn, ops, mig.Down, remove, mig.Version); err != nil {
+				return fmt.Errorf("rollback of %04d_%s failed: %w", mig.Version, mig.Name, err)
+			}
+			log.Printf("Migration rolled back: %04d_%s", mig.Version, mig.Name)
+			count++
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Status lists every known migration and whether it has been applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	migrations, err := m.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	err = m.withLock(ctx, func(conn *sql.Conn, ops *dialectOps) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range migrations {
+			at, ok := applied[mig.Version]
+			statuses = append(statuses, Status{Migration: mig, Applied: ok, AppliedAt: at})
+		}
+		return nil
+	})
+	return statuses, err
+}
+
+// Version returns the highest applied migration, or 0 for an empty database
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	var version int64
+	err := m.withLock(ctx, func(conn *sql.Conn, ops *dialectOps) error {
+		applied, err := m.applied(ctx, conn)
+		for v := range applied {
+			version = max(version, v)
+		}
+		return err
+	})
+	return version, err
+}
+
+// Seed executes a data file (sample or reference rows) outside of versioning.
+// Seed files are expected to be idempotent.
+func (m *Migrator) Seed(ctx context.Context, path string) error {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read seed file: %w", err)
+	}
+	if _, err := m.db.ExecContext(ctx, string(body)); err != nil {
+		return fmt.Errorf("failed to execute seed file: %w", err)
+	}
+	return nil
+}
+
+// withLock runs fn on a dedicated connection holding the migration lock
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn, ops *dialectOps) error) (err error) {
+	ops, err := opsFor(m.dialect)
+	if err != nil {
+		return err
+	}
+
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Close()
+
+	if err := ops.lock(ctx, conn); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if unlockErr := ops.unlock(context.WithoutCancel(ctx), conn); unlockErr != nil && err == nil {
+			err = fmt.Errorf("failed to release migration lock: %w", unlockErr)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, ops.createTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+	return fn(conn, ops)
+}
+
+// run executes a migration body and its bookkeeping statement as one unit
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, ops *dialectOps, body, record string, args ...any) error {
+	if _, err := conn.ExecContext(ctx, ops.begin); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, body); err != nil {
+		conn.ExecContext(ctx, ops.rollback)
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, record, args...); err != nil {
+		conn.ExecContext(ctx, ops.rollback)
+		return err
+	}
+	_, err := conn.ExecContext(ctx, ops.commit)
+	return err
+}
+
+func (m *Migrator) applied(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]time.Time{}
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+// Create writes empty up/down files for a new migration in every dialect
+// directory, numbered after the highest existing version
+func Create(dir, name string) ([]string, error) {
+	name = strings.ToLower(strings.Join(strings.Fields(name), "_"))
+	if !regexp.MustCompile(`^[a-z0-9_]+$`).MatchString(name) {
+		return nil, fmt.Errorf("invalid migration name %q: use letters, digits and underscores", name)
+	}
+
+	var next int64 = 1
+	for _, d := range Dialects {
+		migrations, err := New(nil, d, dir).Load()
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for _, mig := range migrations {
+			next = max(next, mig.Version+1)
+		}
+	}
+
+	var paths []string
+	for _, d := range Dialects {
+		dialectDir := filepath.Join(dir, string(d))
+		if err := os.MkdirAll(dialectDir, 0755); err != nil {
+			return nil, fmt.Errorf("failed to create migrations directory: %w", err)
+		}
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dialectDir, fmt.Sprintf("%04d_%s.%s.sql", next, name, direction))
+			header := fmt.Sprintf("-- %04d_%s (%s, %s)\n", next, name, d, direction)
+			if err := os.WriteFile(path, []byte(header), 0644); err != nil {
+				return nil, fmt.Errorf("failed to write migration file: %w", err)
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,317 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// writeMigrations writes SQLite migration files, by file name, to a fresh
+// migrations directory and returns it
+func writeMigrations(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, string(SQLite)), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, string(SQLite), name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// openDB opens a fresh SQLite database in a temp dir
+func openDB(t *testing.T) (*sql.DB, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.db")
+	return openPath(t, path), path
+}
+
+func openPath(t *testing.T, path string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+var twoMigrations = map[string]string{
+	"0001_create_people.up.sql":   "CREATE TABLE people (id INTEGER PRIMARY KEY, name TEXT);",
+	"0001_create_people.down.sql": "DROP TABLE people;",
+	"0002_create_pets.up.sql":     "CREATE TABLE pets (id INTEGER PRIMARY KEY); CREATE INDEX idx_pets ON pets (id);",
+	"0002_create_pets.down.sql":   "DROP TABLE pets;",
+	"README.md":                   "not a migration",
+}
+
+// tableExists tells whether the database has the named table
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n > 0
+}
+
+func assertVersion(t *testing.T, m *Migrator, want int64) {
+	t.Helper()
+	got, err := m.Version(context.Background())
+	if err != nil {
+		t.Fatalf("Version: %v", err)
+	}
+	if got != want {
+		t.Errorf("Version = %d, want %d", got, want)
+	}
+}
+
+func TestUpDownStatus(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openDB(t)
+	m := New(db, SQLite, writeMigrations(t, twoMigrations))
+	assertVersion(t, m, 0)
+
+	start := time.Now().UTC().Add(-time.Second)
+	if n, err := m.Up(ctx); err != nil || n != 2 {
+		t.Fatalf("Up = %d, %v, want 2", n, err)
+	}
+	if n, err := m.Up(ctx); err != nil || n != 0 {
+		t.Fatalf("second Up = %d, %v, want 0", n, err)
+	}
+	assertVersion(t, m, 2)
+	if !tableExists(t, db, "people") || !tableExists(t, db, "pets") {
+		t.Errorf("tables missing after Up")
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("Status = %+v, want 2 migrations", statuses)
+	}
+	for i, s := range statuses {
+		if s.Version != int64(i+1) || !s.Applied || s.AppliedAt.Before(start) {
+			t.Errorf("Status[%d] = %+v, want migration %d applied", i, s, i+1)
+		}
+	}
+	if statuses[1].Name != "create_pets" || !strings.Contains(statuses[1].Down, "DROP TABLE pets") {
+		t.Errorf("Status[1] = %+v, want create_pets with its down file", statuses[1])
+	}
+
+	// down rolls back the newest first
+	if n, err := m.Down(ctx, 1); err != nil || n != 1 {
+		t.Fatalf("Down(1) = %d, %v, want 1", n, err)
+	}
+	assertVersion(t, m, 1)
+	if !tableExists(t, db, "people") || tableExists(t, db, "pets") {
+		t.Errorf("Down(1) did not roll back pets alone")
+	}
+	statuses, err = m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if !statuses[0].Applied || statuses[1].Applied || !statuses[1].AppliedAt.IsZero() {
+		t.Errorf("Status after Down(1) = %+v, want only the first applied", statuses)
+	}
+
+	if n, err := m.Down(ctx, 5); err != nil || n != 1 {
+		t.Fatalf("Down(5) = %d, %v, want 1", n, err)
+	}
+	assertVersion(t, m, 0)
+	if tableExists(t, db, "people") {
+		t.Errorf("people left after rolling back everything")
+	}
+}
+
+func TestUpStopsAtFailedMigration(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openDB(t)
+	files := map[string]string{
+		"0001_create_people.up.sql": "CREATE TABLE people (id INTEGER PRIMARY KEY);",
+		// fails at its second statement
+		"0002_create_pets.up.sql":   "CREATE TABLE pets (id INTEGER PRIMARY KEY); INSERT INTO nowhere VALUES (1);",
+		"0003_create_plants.up.sql": "CREATE TABLE plants (id INTEGER PRIMARY KEY);",
+	}
+	dir := writeMigrations(t, files)
+	m := New(db, SQLite, dir)
+
+	n, err := m.Up(ctx)
+	if err == nil || !strings.Contains(err.Error(), "0002_create_pets") {
+		t.Fatalf("Up = %d, %v, want 0002_create_pets to fail", n, err)
+	}
+	if n != 1 {
+		t.Errorf("Up applied %d migrations before failing, want 1", n)
+	}
+
+	// the failed migration left neither its first statement nor a record
+	assertVersion(t, m, 1)
+	if !tableExists(t, db, "people") || tableExists(t, db, "pets") || tableExists(t, db, "plants") {
+		t.Errorf("tables after the failed Up: want people alone")
+	}
+	var recorded int
+	if err := db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&recorded); err != nil {
+		t.Fatal(err)
+	}
+	if recorded != 1 {
+		t.Errorf("schema_migrations holds %d rows, want 1", recorded)
+	}
+
+	// once fixed, the run picks up where it stopped
+	fixed := filepath.Join(dir, string(SQLite), "0002_create_pets.up.sql")
+	if err := os.WriteFile(fixed, []byte("CREATE TABLE pets (id INTEGER PRIMARY KEY);"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := m.Up(ctx); err != nil || n != 2 {
+		t.Fatalf("Up after the fix = %d, %v, want 2", n, err)
+	}
+	assertVersion(t, m, 3)
+}
+
+func TestDownWithoutDownFile(t *testing.T) {
+	ctx := context.Background()
+	db, _ := openDB(t)
+	m := New(db, SQLite, writeMigrations(t, map[string]string{
+		"0001_create_people.up.sql": "CREATE TABLE people (id INTEGER PRIMARY KEY);",
+	}))
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	if _, err := m.Down(ctx, 1); err == nil || !strings.Contains(err.Error(), "no down file") {
+		t.Errorf("Down = %v, want an error naming the missing down file", err)
+	}
+	assertVersion(t, m, 1)
+}
+
+func TestLoadRejectsBadFiles(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"ConflictingNames", map[string]string{
+			"0001_create_people.up.sql":    "SELECT 1;",
+			"0001_create_persons.down.sql": "SELECT 1;",
+		}, "conflicting names"},
+		{"DownOnly", map[string]string{
+			"0001_create_people.down.sql": "SELECT 1;",
+		}, "has no up file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := New(nil, SQLite, writeMigrations(t, tt.files)).Load()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Load = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestLockSerializesRuns checks that a run waits for the one holding the
+// migration lock rather than applying the same migrations alongside it
+func TestLockSerializesRuns(t *testing.T) {
+	ctx := context.Background()
+	db, path := openDB(t)
+	dir := writeMigrations(t, twoMigrations)
+
+	// another instance in the middle of its run
+	other := openPath(t, path)
+	conn, err := other.Conn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ops, err := opsFor(SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ops.lock(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.ExecContext(ctx, ops.createTable); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.ExecContext(ctx, twoMigrations["0001_create_people.up.sql"]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations (version, name, applied_at) VALUES (1, 'create_people', ?)",
+		time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		n   int
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		n, err := New(db, SQLite, dir).Up(ctx)
+		done <- result{n, err}
+	}()
+
+	select {
+	case r := <-done:
+		t.Fatalf("Up = %d, %v while another run held the lock", r.n, r.err)
+	case <-time.After(200 * time.Millisecond):
+	}
+	if err := ops.unlock(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+
+	// it then sees what the other run applied
+	r := <-done
+	if r.err != nil || r.n != 1 {
+		t.Fatalf("Up after the lock was released = %d, %v, want 1", r.n, r.err)
+	}
+	assertVersion(t, New(db, SQLite, dir), 2)
+}
+
+func TestCreate(t *testing.T) {
+	dir := writeMigrations(t, map[string]string{
+		"0003_create_people.up.sql":   "CREATE TABLE people (id INTEGER PRIMARY KEY);",
+		"0003_create_people.down.sql": "DROP TABLE people;",
+	})
+
+	paths, err := Create(dir, "Add  Phone numbers")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	var want []string
+	for _, d := range Dialects {
+		for _, direction := range []string{"up", "down"} {
+			want = append(want, filepath.Join(dir, string(d), "0004_add_phone_numbers."+direction+".sql"))
+		}
+	}
+	if strings.Join(paths, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Create = %q, want %q", paths, want)
+	}
+	for _, path := range paths {
+		body, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(body), "-- 0004_add_phone_numbers") {
+			t.Errorf("%s starts with %q, want a header naming the migration", path, body)
+		}
+	}
+
+	// the new migration is numbered past those of every dialect
+	paths, err = Create(dir, "next")
+	if err != nil || len(paths) == 0 || !strings.Contains(paths[0], "0005_next.up.sql") {
+		t.Errorf("second Create = %q, %v, want 0005_next", paths, err)
+	}
+
+	if _, err := Create(dir, "drop; table"); err == nil {
+		t.Errorf("Create accepted an invalid name")
+	}
+}
@@ -1,12 +1,13 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
-	"os"
 
 	"golang/internal/config"
+	"golang/internal/database/migrate"
 
 	_ "github.com/lib/pq"
 )
@@ -42,11 +43,6 @@ func (p *PostgresDB) Connect() error {
 		return fmt.Errorf("failed to ping PostgreSQL database: %w", err)
 	}
 
-	if err := p.executeSchema(); err != nil {
-		p.Close()
-		return fmt.Errorf("failed to initialize PostgreSQL schema: %w", err)
-	}
-
 	log.Printf("PostgreSQL database connected: %s:%d/%s", p.config.Host, p.config.Port, p.config.DBName)
 
 	return nil
@@ -64,15 +60,21 @@ func (p *PostgresDB) GetDB() *sql.DB {
 	return p.db
 }
 
-func (p *PostgresDB) executeSchema() error {
-	schema, err := os.ReadFile(p.config.SchemaPath)
-	if err != nil {
-		return fmt.Errorf("failed to read schema file: %w", err)
+// Migrate applies pending schema migrations. Seed data is only ever loaded
+// by the migrate seed command, so that deleted sample rows stay deleted.
+func (p *PostgresDB) Migrate(ctx context.Context) error {
+	if _, err := p.Migrator().Up(ctx); err != nil {
+		return fmt.Errorf("failed to migrate PostgreSQL schema: %w", err)
 	}
+	return nil
+}
 
-	if _, err := p.db.Exec(string(schema)); err != nil {
-		return fmt.Errorf("failed to execute schema: %w", err)
-	}
+// SeedPath returns the configured seed file, or "" when none is configured
+func (p *PostgresDB) SeedPath() string {
+	return p.config.SeedPath
+}
 
-	return nil
+// Migrator exposes the migration engine for the migrate command
+func (p *PostgresDB) Migrator() *migrate.Migrator {
+	return migrate.New(p.db, migrate.Postgres, p.config.MigrationsDir)
 }
@@ -1,16 +1,22 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
-	"os"
+	"strings"
 
 	"golang/internal/config"
+	"golang/internal/database/migrate"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+// ErrNoFTS5 is returned by Connect in binaries built without FTS5
+var ErrNoFTS5 = errors.New("SQLite support needs FTS5: build with -tags sqlite_fts5")
+
 type SQLiteDB struct {
 	config config.SQLiteConfig
 	db     *sql.DB
@@ -23,8 +29,23 @@ func NewSQLiteDB(cfg config.SQLiteConfig) *SQLiteDB {
 }
 
 func (s *SQLiteDB) Connect() error {
-	// Open database connection
-	db, err := sql.Open("sqlite3", s.config.DBPath)
+	if !FTS5 {
+		return ErrNoFTS5
+	}
+
+	// Open database connection. Transactions start IMMEDIATE so that a
+	// read-then-write transaction never fails to upgrade its lock, and
+	// writers wait for each other instead of failing with SQLITE_BUSY.
+	dsn := s.config.DBPath
+	if !strings.Contains(dsn, "?") {
+		dsn += "?_txlock=immediate&_busy_timeout=5000"
+	}
+	// SQLite only enforces foreign keys, and cascades deletes along them,
+	// on connections that ask for it
+	if !strings.Contains(dsn, "_foreign_keys=") && !strings.Contains(dsn, "_fk=") {
+		dsn += "&_foreign_keys=on"
+	}
+	db, err := sql.Open("sqlite3", dsn)
 	if err != nil {
 		return fmt.Errorf("failed to open SQLite database: %w", err)
 	}
@@ -32,9 +53,9 @@ func (s *SQLiteDB) Connect() error {
 	// Store the connection for later cleanup
 	s.db = db
 
-	if err := s.executeSchema(); err != nil {
+	if err := s.db.Ping(); err != nil {
 		s.Close()
-		return fmt.Errorf("failed to initialize SQLite schema: %w", err)
+		return fmt.Errorf("failed to ping SQLite database: %w", err)
 	}
 
 	log.Printf("SQLite database connected: %s", s.config.DBPath)
@@ -54,15 +75,21 @@ func (s *SQLiteDB) GetDB() *sql.DB {
 	return s.db
 }
 
-func (s *SQLiteDB) executeSchema() error {
-	schema, err := os.ReadFile(s.config.SchemaPath)
-	if err != nil {
-		return fmt.Errorf("failed to read schema file: %w", err)
+// Migrate applies pending schema migrations. Seed data is only ever loaded
+// by the migrate seed command, so that deleted sample rows stay deleted.
+func (s *SQLiteDB) Migrate(ctx context.Context) error {
+	if _, err := s.Migrator().Up(ctx); err != nil {
+		return fmt.Errorf("failed to migrate SQLite schema: %w", err)
 	}
+	return nil
+}
 
-	if _, err := s.db.Exec(string(schema)); err != nil {
-		return fmt.Errorf("failed to execute schema: %w", err)
-	}
+// SeedPath returns the configured seed file, or "" when none is configured
+func (s *SQLiteDB) SeedPath() string {
+	return s.config.SeedPath
+}
 
-	return nil
+// Migrator exposes the migration engine for the migrate command
+func (s *SQLiteDB) Migrator() *migrate.Migrator {
+	return migrate.New(s.db, migrate.SQLite, s.config.MigrationsDir)
 }
@@ -0,0 +1,7 @@
+//go:build sqlite_fts5 || fts5
+
+package database
+
+// FTS5 tells whether go-sqlite3 was compiled with the FTS5 extension, which
+// the contact search index of the SQLite migrations is built on
+const FTS5 = true
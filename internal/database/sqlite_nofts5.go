@@ -0,0 +1,8 @@
+//go:build !sqlite_fts5 && !fts5
+
+package database
+
+// Without the sqlite_fts5 build tag go-sqlite3 lacks FTS5, and Connect
+// refuses SQLite databases rather than failing on the first search.
+
+const FTS5 = false
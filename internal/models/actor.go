@@ -0,0 +1,21 @@
+package models
+
+import "context"
+
+// SystemActor is recorded for changes made without an identified caller
+const SystemActor = "system"
+
+type actorKey struct{}
+
+// WithActor records who is performing the operations carried out with ctx
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor stored by WithActor, or SystemActor
+func ActorFrom(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
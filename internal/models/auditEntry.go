@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+type AuditAction string
+
+const (
+	AuditCreate AuditAction = "create"
+	AuditUpdate AuditAction = "update"
+	AuditDelete AuditAction = "delete"
+	AuditMerge  AuditAction = "merge"
+)
+
+// AuditEntry is one immutable record of a contact mutation.
+// Before is nil for creations and After is nil for deletions. A merge is
+// recorded on both contacts, with the merged contact as After.
+type AuditEntry struct {
+	ID        int         `json:"id"`
+	Tenant    string      `json:"tenant"`
+	ContactID int         `json:"contact_id"`
+	Action    AuditAction `json:"action"`
+	Actor     string      `json:"actor"`
+	Timestamp time.Time   `json:"timestamp"`
+	Before    *Contact    `json:"before,omitempty"`
+	After     *Contact    `json:"after,omitempty"`
+}
@@ -1,12 +1,140 @@
 package models
 
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Length limits of the contact fields, in characters
+const (
+	MaxNameLength         = 100
+	MaxEmailLength        = 254 // longest address SMTP can deliver (RFC 5321)
+	MaxOrganizationLength = 200 // company and title
+	MaxNotesLength        = 10000
+)
+
 type Contact struct {
 	ID        int    `json:"id"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 	Email     string `json:"email"`
+
+	// Optional details
+	Phones       []Phone           `json:"phones,omitempty"`
+	Addresses    []Address         `json:"addresses,omitempty"`
+	Company      string            `json:"company,omitempty"`
+	Title        string            `json:"title,omitempty"`
+	Birthday     string            `json:"birthday,omitempty"` // YYYY-MM-DD
+	Notes        string            `json:"notes,omitempty"`
+	CustomFields map[string]string `json:"custom_fields,omitempty"`
+	Tags         []string          `json:"tags,omitempty"` // lower-case labels, sorted
+
+	// Version starts at 1 and is incremented by every update.
+	// Writes carrying a non-zero Version only apply to that version.
+	Version int `json:"version"`
 }
 
 func (c *Contact) FullName() string {
 	return c.FirstName + " " + c.LastName
 }
+
+// Normalize puts user input in canonical form before it is validated and
+// stored: fields are trimmed and NFC-normalized, so that visually identical
+// names compare equal, emails are lower-cased and phone numbers are put in
+// E.164 form. Empty lists and maps become nil.
+func (c *Contact) Normalize() {
+	c.FirstName = normalizeText(c.FirstName)
+	c.LastName = normalizeText(c.LastName)
+	c.Email = strings.ToLower(normalizeText(c.Email))
+	c.Company = normalizeText(c.Company)
+	c.Title = normalizeText(c.Title)
+	c.Birthday = normalizeText(c.Birthday)
+	c.Notes = strings.ReplaceAll(normalizeText(c.Notes), "\r\n", "\n")
+	c.normalizeDetails()
+}
+
+// Validate reports every invalid field as a *ValidationError
+func (c *Contact) Validate() error {
+	var verr ValidationError
+	validateName(&verr, "first_name", c.FirstName)
+	validateName(&verr, "last_name", c.LastName)
+	validateEmail(&verr, "email", c.Email)
+	validateText(&verr, "company", c.Company, MaxOrganizationLength)
+	validateText(&verr, "title", c.Title, MaxOrganizationLength)
+	validateBirthday(&verr, "birthday", c.Birthday)
+	validateNotes(&verr, "notes", c.Notes)
+	c.validateDetails(&verr)
+	return verr.Err()
+}
+
+func normalizeText(s string) string {
+	return strings.TrimSpace(norm.NFC.String(s))
+}
+
+func validateName(verr *ValidationError, field, value string) {
+	if value == "" {
+		verr.Add(field, "is required")
+		return
+	}
+	validateText(verr, field, value, MaxNameLength)
+}
+
+// validateText checks an optional single-line field
+func validateText(verr *ValidationError, field, value string, maxLength int) {
+	switch {
+	case !utf8.ValidString(value):
+		verr.Add(field, "is not valid UTF-8")
+	case utf8.RuneCountInString(value) > maxLength:
+		verr.Add(field, fmt.Sprintf("must be at most %d characters", maxLength))
+	case strings.IndexFunc(value, unicode.IsControl) >= 0:
+		verr.Add(field, "must not contain control characters")
+	}
+}
+
+// validateNotes checks free text, which may span several lines
+func validateNotes(verr *ValidationError, field, value string) {
+	switch {
+	case !utf8.ValidString(value):
+		verr.Add(field, "is not valid UTF-8")
+	case utf8.RuneCountInString(value) > MaxNotesLength:
+		verr.Add(field, fmt.Sprintf("must be at most %d characters", MaxNotesLength))
+	case strings.IndexFunc(value, func(r rune) bool { return unicode.IsControl(r) && r != '\n' && r != '\t' }) >= 0:
+		verr.Add(field, "must not contain control characters other than newlines and tabs")
+	}
+}
+
+// validateBirthday accepts a past calendar date written YYYY-MM-DD
+func validateBirthday(verr *ValidationError, field, value string) {
+	if value == "" {
+		return
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	switch {
+	case err != nil:
+		verr.Add(field, "must be a date written YYYY-MM-DD")
+	case date.After(time.Now()):
+		verr.Add(field, "must not be in the future")
+	}
+}
+
+// validateEmail accepts a bare RFC 5322 address, without display name
+func validateEmail(verr *ValidationError, field, value string) {
+	if value == "" {
+		verr.Add(field, "is required")
+		return
+	}
+	if utf8.RuneCountInString(value) > MaxEmailLength {
+		verr.Add(field, fmt.Sprintf("must be at most %d characters", MaxEmailLength))
+		return
+	}
+	addr, err := mail.ParseAddress(value)
+	if err != nil || addr.Name != "" || addr.Address != value {
+		verr.Add(field, "is not a valid email address")
+	}
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Limits of the optional contact details
+const (
+	MaxPhones            = 20
+	MaxAddresses         = 10
+	MaxCustomFields      = 50
+	MaxAddressLineLength = 200
+	MaxCustomKeyLength   = 64
+	MaxCustomValueLength = 1000
+	MaxTags              = 50
+	MaxTagLength         = 50
+)
+
+// PhoneType tells what a phone number is used for
+type PhoneType string
+
+const (
+	PhoneMobile PhoneType = "mobile"
+	PhoneHome   PhoneType = "home"
+	PhoneWork   PhoneType = "work"
+	PhoneFax    PhoneType = "fax"
+	PhoneOther  PhoneType = "other"
+)
+
+// Phone is a phone number in E.164 form, e.g. "+14155550123"
+type Phone struct {
+	Type   PhoneType `json:"type"`
+	Number string    `json:"number"`
+}
+
+// AddressType tells where a postal address leads
+type AddressType string
+
+const (
+	AddressHome  AddressType = "home"
+	AddressWork  AddressType = "work"
+	AddressOther AddressType = "other"
+)
+
+// Address is a postal address. Country is an ISO 3166-1 alpha-2 code.
+type Address struct {
+	Type       AddressType `json:"type"`
+	Street     string      `json:"street,omitempty"`
+	City       string      `json:"city,omitempty"`
+	Region     string      `json:"region,omitempty"`
+	PostalCode string      `json:"postal_code,omitempty"`
+	Country    string      `json:"country,omitempty"`
+}
+
+var (
+	e164Pattern      = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+	countryPattern   = regexp.MustCompile(`^[A-Z]{2}$`)
+	customKeyPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+)
+
+// NormalizePhoneNumber strips the punctuation people write phone numbers
+// with and turns an international "00" prefix into "+", e.g.
+// "0044 (20) 7946-0958" becomes "+442079460958". Numbers must be written in
+// international form: no country is assumed.
+func NormalizePhoneNumber(number string) string {
+	number = strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '-', '.', '(', ')', '/', '\u00a0':
+			return -1
+		}
+		return r
+	}, normalizeText(number))
+	if rest, ok := strings.CutPrefix(number, "00"); ok {
+		number = "+" + rest
+	}
+	return number
+}
+
+func (c *Contact) normalizeDetails() {
+	for i := range c.Phones {
+		p := &c.Phones[i]
+		p.Type = PhoneType(strings.ToLower(normalizeText(string(p.Type))))
+		if p.Type == "" {
+			p.Type = PhoneOther
+		}
+		p.Number = NormalizePhoneNumber(p.Number)
+	}
+	for i := range c.Addresses {
+		a := &c.Addresses[i]
+		a.Type = AddressType(strings.ToLower(normalizeText(string(a.Type))))
+		if a.Type == "" {
+			a.Type = AddressOther
+		}
+		a.Street = normalizeText(a.Street)
+		a.City = normalizeText(a.City)
+		a.Region = normalizeText(a.Region)
+		a.PostalCode = normalizeText(a.PostalCode)
+		a.Country = strings.ToUpper(normalizeText(a.Country))
+	}
+	if len(c.CustomFields) > 0 {
+		fields := make(map[string]string, len(c.CustomFields))
+		for key, value := range c.CustomFields {
+			fields[normalizeText(key)] = normalizeText(value)
+		}
+		c.CustomFields = fields
+	}
+
+	c.Tags = NormalizeTags(c.Tags)
+
+	if len(c.Phones) == 0 {
+		c.Phones = nil
+	}
+	if len(c.Addresses) == 0 {
+		c.Addresses = nil
+	}
+	if len(c.CustomFields) == 0 {
+		c.CustomFields = nil
+	}
+}
+
+// NormalizeTags lower-cases tags, drops empty and duplicate ones and sorts
+// the rest, returning nil when none is left
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	for _, tag := range tags {
+		if tag = strings.ToLower(normalizeText(tag)); tag != "" {
+			normalized = append(normalized, tag)
+		}
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+func (c *Contact) validateDetails(verr *ValidationError) {
+	if len(c.Phones) > MaxPhones {
+		verr.Add("phones", fmt.Sprintf("must hold at most %d numbers", MaxPhones))
+	}
+	for i, p := range c.Phones {
+		field := fmt.Sprintf("phones[%d]", i)
+		switch p.Type {
+		case PhoneMobile, PhoneHome, PhoneWork, PhoneFax, PhoneOther:
+		default:
+			verr.Add(field+".type", "must be mobile, home, work, fax or other")
+		}
+		if !e164Pattern.MatchString(p.Number) {
+			verr.Add(field+".number", "must be an international number, e.g. +14155550123")
+		}
+	}
+
+	if len(c.Addresses) > MaxAddresses {
+		verr.Add("addresses", fmt.Sprintf("must hold at most %d addresses", MaxAddresses))
+	}
+	for i, a := range c.Addresses {
+		field := fmt.Sprintf("addresses[%d]", i)
+		switch a.Type {
+		case AddressHome, AddressWork, AddressOther:
+		default:
+			verr.Add(field+".type", "must be home, work or other")
+		}
+		if a.Street == "" && a.City == "" && a.Region == "" && a.PostalCode == "" && a.Country == "" {
+			verr.Add(field, "must not be empty")
+		}
+		validateText(verr, field+".street", a.Street, MaxAddressLineLength)
+		validateText(verr, field+".city", a.City, MaxAddressLineLength)
+		validateText(verr, field+".region", a.Region, MaxAddressLineLength)
+		validateText(verr, field+".postal_code", a.PostalCode, MaxAddressLineLength)
+		if a.Country != "" && !countryPattern.MatchString(a.Country) {
+			verr.Add(field+".country", "must be a two-letter ISO 3166 country code")
+		}
+	}
+
+	if len(c.Tags) > MaxTags {
+		verr.Add("tags", fmt.Sprintf("must hold at most %d tags", MaxTags))
+	}
+	for i, tag := range c.Tags {
+		field := fmt.Sprintf("tags[%d]", i)
+		// commas separate tags in CSV cells and vCard categories
+		if strings.Contains(tag, ",") {
+			verr.Add(field, "must not contain commas")
+			continue
+		}
+		validateText(verr, field, tag, MaxTagLength)
+	}
+
+	if len(c.CustomFields) > MaxCustomFields {
+		verr.Add("custom_fields", fmt.Sprintf("must hold at most %d fields", MaxCustomFields))
+	}
+	for _, key := range slices.Sorted(maps.Keys(c.CustomFields)) {
+		value := c.CustomFields[key]
+		field := "custom_fields." + key
+		if len(key) > MaxCustomKeyLength || !customKeyPattern.MatchString(key) {
+			verr.Add(field, fmt.Sprintf("name must be at most %d letters, digits, '_', '.' and '-'", MaxCustomKeyLength))
+			continue
+		}
+		validateText(verr, field, value, MaxCustomValueLength)
+	}
+}
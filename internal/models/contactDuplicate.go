@@ -0,0 +1,325 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultDuplicateScore = 60
+	DefaultDuplicateLimit = 50
+	MaxDuplicateLimit     = 500
+)
+
+// Reasons why two contacts look like duplicates
+const (
+	ReasonSameEmail       = "same email"      // equal once plus-addressing and Gmail dots are ignored
+	ReasonSameEmailUser   = "same email user" // same local part at another domain
+	ReasonSamePhone       = "same phone"      // a phone number in common
+	ReasonSameLastName    = "same last name"  // equal once case and diacritics are ignored
+	ReasonSameFirstName   = "same first name"
+	ReasonNickname        = "nickname"         // e.g. Bob and Robert
+	ReasonSoundsAlike     = "sounds alike"     // same Soundex code, e.g. Smith and Smyth
+	ReasonSimilarSpelling = "similar spelling" // within MaxTypos edits
+	ReasonInitial         = "matching initial" // e.g. R. and Robert
+)
+
+// DuplicateQuery selects the suspected duplicates to list
+type DuplicateQuery struct {
+	MinScore int // pairs scoring less are left out, default DefaultDuplicateScore
+	Limit    int
+}
+
+// Normalize applies the defaults and rejects out of range values
+func (q *DuplicateQuery) Normalize() error {
+	if q.MinScore == 0 {
+		q.MinScore = DefaultDuplicateScore
+	}
+	if q.MinScore < 1 || q.MinScore > 100 {
+		return fmt.Errorf("%w: min_score must be between 1 and 100", ErrValidation)
+	}
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultDuplicateLimit
+	case q.Limit < 0:
+		return fmt.Errorf("%w: limit must be positive", ErrValidation)
+	case q.Limit > MaxDuplicateLimit:
+		q.Limit = MaxDuplicateLimit
+	}
+	return nil
+}
+
+// DuplicatePair is two contacts suspected to be the same person, lowest ID
+// first. Score goes from 1 to 100, where 100 is certain.
+type DuplicatePair struct {
+	Score    int       `json:"score"`
+	Reasons  []string  `json:"reasons"`
+	Contacts []Contact `json:"contacts"`
+}
+
+// DuplicateScore rates how likely a and b are the same person, from 0 to
+// 100, and tells why. The same email settles it; otherwise phones, the email
+// user and both names add up, so that "Bob Johnson" and "Robert Johnson"
+// score 75 from their last name and the nickname.
+func DuplicateScore(a, b Contact) (int, []string) {
+	if EmailIdentity(a.Email) == EmailIdentity(b.Email) {
+		return 100, []string{ReasonSameEmail}
+	}
+
+	score := 0
+	var reasons []string
+	add := func(points int, reason string) {
+		score += points
+		reasons = append(reasons, reason)
+	}
+	if sharePhone(a, b) {
+		add(40, ReasonSamePhone)
+	}
+	if emailUser(a.Email) == emailUser(b.Email) {
+		add(20, ReasonSameEmailUser)
+	}
+
+	last, lastReason := nameScore(nameKey(a.LastName), nameKey(b.LastName), false)
+	first, firstReason := nameScore(nameKey(a.FirstName), nameKey(b.FirstName), true)
+	// a first name alone is too common to mean anything
+	if last > 0 {
+		add(last, lastReason)
+		if first > 0 {
+			add(first, firstReason)
+		}
+	}
+	return min(score, 100), reasons
+}
+
+// nameScore compares two folded names: 40 when equal, 35 for nicknames,
+// 30 when they sound or are spelled alike and 15 for a matching initial
+func nameScore(a, b string, given bool) (int, string) {
+	switch {
+	case a == "" || b == "":
+		return 0, ""
+	case a == b:
+		if given {
+			return 40, ReasonSameFirstName
+		}
+		return 40, ReasonSameLastName
+	case given && areNicknames(a, b):
+		return 35, ReasonNickname
+	case Soundex(a) != "" && Soundex(a) == Soundex(b):
+		return 30, ReasonSoundsAlike
+	case EditDistance(a, b, MaxTypos(a)) <= MaxTypos(a):
+		return 30, ReasonSimilarSpelling
+	case given && isInitialOf(a, b):
+		return 15, ReasonInitial
+	default:
+		return 0, ""
+	}
+}
+
+// isInitialOf tells whether one of the names is the initial of the other
+func isInitialOf(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	return (len(ra) == 1 || len(rb) == 1) && ra[0] == rb[0]
+}
+
+// nameKey folds a name for comparisons: "Núñez-García" becomes "nunezgarcia"
+func nameKey(name string) string {
+	return strings.Join(SearchWords(name), "")
+}
+
+func sharePhone(a, b Contact) bool {
+	for _, p := range a.Phones {
+		for _, q := range b.Phones {
+			if p.Number == q.Number {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EmailIdentity reduces an email to the mailbox it delivers to: lower case,
+// without a "+tag" and, for Gmail, without dots in the user part.
+// "Bob.J+news@googlemail.com" becomes "bobj@gmail.com".
+func EmailIdentity(email string) string {
+	user, domain, ok := strings.Cut(strings.ToLower(email), "@")
+	if !ok {
+		return strings.ToLower(email)
+	}
+	user, _, _ = strings.Cut(user, "+")
+	if domain == "gmail.com" || domain == "googlemail.com" {
+		domain = "gmail.com"
+		user = strings.ReplaceAll(user, ".", "")
+	}
+	return user + "@" + domain
+}
+
+// emailUser returns the user part of EmailIdentity
+func emailUser(email string) string {
+	user, _, _ := strings.Cut(EmailIdentity(email), "@")
+	return user
+}
+
+// Soundex encodes how an English name sounds as its first letter and three
+// digits, e.g. R163 for both Robert and Rupert. Letters outside a-z are
+// ignored, and a name without any has the empty code.
+func Soundex(name string) string {
+	const codes = "01230120022455012623010202" // a to z
+	var code []byte
+	var last byte
+	for _, r := range nameKey(name) {
+		if r < 'a' || r > 'z' {
+			continue
+		}
+		digit := codes[r-'a']
+		if code == nil {
+			code = append(code, byte(r)-'a'+'A')
+			last = digit
+			continue
+		}
+		switch {
+		case r == 'h' || r == 'w':
+			// transparent: the letters around them are coded once
+		case digit == '0':
+			last = digit
+		case digit != last:
+			code = append(code, digit)
+			last = digit
+		}
+		if len(code) == 4 {
+			break
+		}
+	}
+	if code == nil {
+		return ""
+	}
+	return (string(code) + "000")[:4]
+}
+
+// FindDuplicates returns the pairs of contacts scoring at least q.MinScore,
+// best first. Only contacts sharing an email user, a phone number or the
+// Soundex code of their last name are compared, which keeps it well below
+// comparing every pair.
+func FindDuplicates(contacts []Contact, q DuplicateQuery) []DuplicatePair {
+	blocks := map[string][]int{}
+	for i, c := range contacts {
+		keys := []string{"u:" + emailUser(c.Email)}
+		if code := Soundex(c.LastName); code != "" {
+			keys = append(keys, "l:"+code)
+		}
+		for _, p := range c.Phones {
+			keys = append(keys, "p:"+p.Number)
+		}
+		for _, key := range keys {
+			blocks[key] = append(blocks[key], i)
+		}
+	}
+
+	type pairKey struct{ a, b int }
+	compared := map[pairKey]bool{}
+	var pairs []DuplicatePair
+	for _, block := range blocks {
+		for x, i := range block {
+			for _, j := range block[x+1:] {
+				key := pairKey{min(i, j), max(i, j)}
+				if i == j || compared[key] {
+					continue
+				}
+				compared[key] = true
+
+				a, b := contacts[key.a], contacts[key.b]
+				if a.ID > b.ID {
+					a, b = b, a
+				}
+				if score, reasons := DuplicateScore(a, b); score >= q.MinScore {
+					pairs = append(pairs, DuplicatePair{Score: score, Reasons: reasons, Contacts: []Contact{a, b}})
+				}
+			}
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		p, q := pairs[i], pairs[j]
+		if p.Score != q.Score {
+			return p.Score > q.Score
+		}
+		if p.Contacts[0].ID != q.Contacts[0].ID {
+			return p.Contacts[0].ID < q.Contacts[0].ID
+		}
+		return p.Contacts[1].ID < q.Contacts[1].ID
+	})
+	return pairs[:min(len(pairs), q.Limit)]
+}
+
+// nicknameGroups lists common English given names with their short forms.
+// A name may belong to several groups, e.g. Alex.
+var nicknameGroups = [][]string{
+	{"robert", "rob", "robbie", "bob", "bobby", "bert"},
+	{"william", "will", "willy", "bill", "billy", "liam"},
+	{"james", "jim", "jimmy", "jamie"},
+	{"john", "johnny", "jack", "jon"},
+	{"michael", "mike", "mikey", "mick"},
+	{"richard", "rick", "ricky", "rich", "dick"},
+	{"thomas", "tom", "tommy"},
+	{"anthony", "tony"},
+	{"david", "dave", "davey"},
+	{"daniel", "dan", "danny"},
+	{"joseph", "joe", "joey"},
+	{"christopher", "chris", "kit"},
+	{"christina", "christine", "chris", "tina"},
+	{"matthew", "matt"},
+	{"nicholas", "nick", "nicky"},
+	{"stephen", "steven", "steve"},
+	{"edward", "ed", "eddie", "ted", "ned"},
+	{"alexander", "alex", "sasha"},
+	{"alexandra", "alex", "alexa", "sandra", "sasha"},
+	{"samuel", "sam", "sammy"},
+	{"samantha", "sam", "sammy"},
+	{"benjamin", "ben", "benny"},
+	{"andrew", "andy", "drew"},
+	{"gregory", "greg"},
+	{"patrick", "pat", "paddy"},
+	{"patricia", "pat", "patty", "trish"},
+	{"lawrence", "larry"},
+	{"jeffrey", "jeff"},
+	{"kenneth", "ken", "kenny"},
+	{"ronald", "ron", "ronnie"},
+	{"donald", "don", "donny"},
+	{"charles", "charlie", "chuck"},
+	{"henry", "harry", "hank"},
+	{"timothy", "tim", "timmy"},
+	{"frederick", "fred", "freddie"},
+	{"nathan", "nathaniel", "nate"},
+	{"elizabeth", "liz", "lizzie", "beth", "betty", "eliza"},
+	{"katherine", "catherine", "kate", "katie", "kathy", "cathy", "kat"},
+	{"margaret", "maggie", "meg", "peggy"},
+	{"jennifer", "jen", "jenny"},
+	{"susan", "sue", "susie"},
+	{"rebecca", "becky"},
+	{"victoria", "vicky", "tori"},
+	{"abigail", "abby"},
+	{"deborah", "debbie", "deb"},
+	{"jessica", "jess", "jessie"},
+}
+
+// nicknames maps each name of nicknameGroups to the indexes of its groups
+var nicknames = func() map[string][]int {
+	m := map[string][]int{}
+	for i, group := range nicknameGroups {
+		for _, name := range group {
+			m[name] = append(m[name], i)
+		}
+	}
+	return m
+}()
+
+func areNicknames(a, b string) bool {
+	for _, i := range nicknames[a] {
+		for _, j := range nicknames[b] {
+			if i == j {
+				return true
+			}
+		}
+	}
+	return false
+}
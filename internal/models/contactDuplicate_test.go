@@ -0,0 +1,144 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSoundex(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Robert", "R163"},
+		{"Rupert", "R163"},
+		{"Rubin", "R150"},
+		{"Ashcraft", "A261"}, // h between s and c: coded once
+		{"Ashcroft", "A261"},
+		{"Tymczak", "T522"}, // vowel between z and k: coded twice
+		{"Pfister", "P236"}, // f coded like the first letter
+		{"Honeyman", "H555"},
+		{"Lee", "L000"},
+		{"Smith", "S530"},
+		{"Smyth", "S530"},
+		{"O'Brien", "O165"},
+		{"Núñez", "N520"},
+		{"", ""},
+		{"123", ""},
+	}
+	for _, tt := range tests {
+		if got := Soundex(tt.name); got != tt.want {
+			t.Errorf("Soundex(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestEmailIdentity(t *testing.T) {
+	tests := []struct {
+		email string
+		want  string
+	}{
+		{"bob@example.com", "bob@example.com"},
+		{"Bob@Example.COM", "bob@example.com"},
+		{"bob+news@example.com", "bob@example.com"},
+		{"bob+news+2024@example.com", "bob@example.com"},
+		// dots only fold at Gmail, where they do not change the mailbox
+		{"bob.j@example.com", "bob.j@example.com"},
+		{"b.o.b.j@gmail.com", "bobj@gmail.com"},
+		{"Bob.J+news@googlemail.com", "bobj@gmail.com"},
+		{"not an email", "not an email"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := EmailIdentity(tt.email); got != tt.want {
+			t.Errorf("EmailIdentity(%q) = %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestDuplicateScore(t *testing.T) {
+	person := func(first, last, email string, phones ...string) Contact {
+		c := Contact{FirstName: first, LastName: last, Email: email}
+		for _, number := range phones {
+			c.Phones = append(c.Phones, Phone{Type: PhoneMobile, Number: number})
+		}
+		return c
+	}
+
+	tests := []struct {
+		name        string
+		a, b        Contact
+		wantScore   int
+		wantReasons []string
+	}{
+		{"Nickname", person("Bob", "Johnson", "bob@example.com"), person("Robert", "Johnson", "bob.j@example.org"),
+			75, []string{ReasonSameLastName, ReasonNickname}},
+		{"GmailDotsAndTag", person("Bob", "Johnson", "bob.j@gmail.com"), person("Robert", "Jones", "BobJ+work@googlemail.com"),
+			100, []string{ReasonSameEmail}},
+		{"CappedAt100", person("Ada", "Lovelace", "ada@example.com", "+441234567890"), person("Ada", "Lovelace", "ada@lovelace.org", "+441234567890"),
+			100, []string{ReasonSamePhone, ReasonSameEmailUser, ReasonSameLastName, ReasonSameFirstName}},
+		{"SoundsAlike", person("John", "Smith", "john@example.com"), person("Jon", "Smyth", "jsmyth@example.org"),
+			65, []string{ReasonSoundsAlike, ReasonNickname}},
+		{"SimilarSpelling", person("Ada", "Lovelace", "ada@example.com"), person("Ada", "Lovelance", "countess@example.org"),
+			70, []string{ReasonSimilarSpelling, ReasonSameFirstName}},
+		{"Initial", person("R.", "Johnson", "rj@example.com"), person("Robert", "Johnson", "robert@example.org"),
+			55, []string{ReasonSameLastName, ReasonInitial}},
+		{"DiacriticsAndCase", person("José", "Núñez", "jose@example.com"), person("JOSE", "Nunez", "jnunez@example.org"),
+			80, []string{ReasonSameLastName, ReasonSameFirstName}},
+		{"PhoneOnly", person("Ada", "Lovelace", "ada@example.com", "+441234567890"), person("Grace", "Hopper", "grace@example.org", "+441234567890"),
+			40, []string{ReasonSamePhone}},
+		// a first name alone is too common to count
+		{"FirstNameOnly", person("Ada", "Lovelace", "ada@example.com"), person("Ada", "Byron", "countess@example.org"),
+			0, nil},
+		{"Strangers", person("Ada", "Lovelace", "ada@example.com"), person("Grace", "Hopper", "grace@example.org"),
+			0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, pair := range [][2]Contact{{tt.a, tt.b}, {tt.b, tt.a}} {
+				score, reasons := DuplicateScore(pair[0], pair[1])
+				if score != tt.wantScore || !reflect.DeepEqual(reasons, tt.wantReasons) {
+					t.Errorf("DuplicateScore(%s %s, %s %s) = %d, %q, want %d, %q", pair[0].FirstName, pair[0].LastName,
+						pair[1].FirstName, pair[1].LastName, score, reasons, tt.wantScore, tt.wantReasons)
+				}
+			}
+		})
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	contacts := []Contact{
+		{ID: 5, FirstName: "Grace", LastName: "Hopper", Email: "grace@navy.mil"},
+		{ID: 2, FirstName: "Robert", LastName: "Johnson", Email: "bob.j@example.org"},
+		{ID: 4, FirstName: "Ada", LastName: "Lovelace", Email: "ada.lovelace@gmail.com"},
+		{ID: 1, FirstName: "Bob", LastName: "Johnson", Email: "bob@example.com"},
+		{ID: 3, FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com"},
+		{ID: 6, FirstName: "Grace", LastName: "Hooper", Email: "ghooper@example.com"},
+	}
+
+	tests := []struct {
+		name  string
+		query DuplicateQuery
+		want  [][3]int // score and contact IDs of each pair, in order
+	}{
+		{"Default", DuplicateQuery{MinScore: DefaultDuplicateScore, Limit: DefaultDuplicateLimit},
+			[][3]int{{80, 3, 4}, {75, 1, 2}, {70, 5, 6}}},
+		{"MinScore", DuplicateQuery{MinScore: 75, Limit: DefaultDuplicateLimit},
+			[][3]int{{80, 3, 4}, {75, 1, 2}}},
+		{"Limit", DuplicateQuery{MinScore: DefaultDuplicateScore, Limit: 1},
+			[][3]int{{80, 3, 4}}},
+		{"NoneHighEnough", DuplicateQuery{MinScore: 90, Limit: DefaultDuplicateLimit},
+			nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][3]int
+			for _, pair := range FindDuplicates(contacts, tt.query) {
+				got = append(got, [3]int{pair.Score, pair.Contacts[0].ID, pair.Contacts[1].ID})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindDuplicates = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
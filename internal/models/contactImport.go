@@ -0,0 +1,154 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// ImportMode tells what an import does with a record whose email is already
+// taken by a contact
+type ImportMode string
+
+const (
+	ImportCreate ImportMode = "create" // fail the record as a conflict
+	ImportSkip   ImportMode = "skip"   // leave the contact as it is
+	ImportUpsert ImportMode = "upsert" // merge the record into the contact
+)
+
+// ImportOptions tune an import. A dry run reports what the import would do
+// without changing anything.
+type ImportOptions struct {
+	Mode   ImportMode `json:"mode,omitempty"`
+	DryRun bool       `json:"dry_run,omitempty"`
+}
+
+// Normalize defaults the mode to ImportCreate and rejects unknown ones
+func (o *ImportOptions) Normalize() error {
+	switch o.Mode {
+	case "":
+		o.Mode = ImportCreate
+	case ImportCreate, ImportSkip, ImportUpsert:
+	default:
+		return fmt.Errorf("%w: mode must be create, skip or upsert", ErrValidation)
+	}
+	return nil
+}
+
+// ImportAction is what an import did, or would do, with a record
+type ImportAction string
+
+const (
+	ImportCreated ImportAction = "create"
+	ImportUpdated ImportAction = "update"
+	ImportSkipped ImportAction = "skip"
+)
+
+// ImportResult reports on an import: the number of records of each outcome
+// and the records that failed, each failing on its own without stopping the
+// others. A dry run also lists the change each record would make.
+type ImportResult struct {
+	DryRun  bool            `json:"dry_run,omitempty"`
+	Created int             `json:"created"`
+	Updated int             `json:"updated"`
+	Skipped int             `json:"skipped"`
+	Changes []ImportChange  `json:"changes,omitempty"`
+	Failed  []ImportFailure `json:"failed"`
+}
+
+// ImportChange is the change a record makes: the contact it creates, or the
+// existing contact it updates (with the fields that change) or skips
+type ImportChange struct {
+	Record    int          `json:"record"`
+	Action    ImportAction `json:"action"`
+	ContactID int          `json:"contact_id,omitempty"`
+	Email     string       `json:"email"`
+	Fields    []string     `json:"fields,omitempty"`
+}
+
+// ImportFailure is a record that was not imported. Record is its position
+// in the file, counted from 1: the card of a vCard file or the line of a CSV
+// file. Row holds the fields of a CSV record as read, for the error report.
+type ImportFailure struct {
+	Record int          `json:"record"`
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields,omitempty"`
+	Row    []string     `json:"row,omitempty"`
+}
+
+// Count adds a change to the totals, and to the list of changes of a dry run
+func (r *ImportResult) Count(change ImportChange) {
+	switch change.Action {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportSkipped:
+		r.Skipped++
+	}
+	if r.DryRun {
+		r.Changes = append(r.Changes, change)
+	}
+}
+
+// Fail records that record could not be imported because of err
+func (r *ImportResult) Fail(record int, row []string, err error) {
+	failure := ImportFailure{Record: record, Error: err.Error(), Row: row}
+	var verr *ValidationError
+	if errors.As(err, &verr) {
+		failure.Fields = verr.Fields
+	}
+	r.Failed = append(r.Failed, failure)
+}
+
+// UpsertContact merges an imported record into the contact holding its
+// email, as MergeContacts does but taking every conflicting field from the
+// record
+func UpsertContact(contact, record Contact) Contact {
+	prefer := make(map[string]MergeSide, len(mergeFields)+len(record.CustomFields))
+	for _, field := range mergeFields {
+		prefer[field] = MergeDuplicate
+	}
+	for key := range record.CustomFields {
+		prefer["custom_fields."+key] = MergeDuplicate
+	}
+	return MergeContacts(contact, record, prefer).Contact
+}
+
+// ChangedFields lists the JSON names of the fields that differ between two
+// versions of a contact, custom fields as "custom_fields.<key>"
+func ChangedFields(before, after Contact) []string {
+	var fields []string
+	for _, f := range []struct {
+		name          string
+		before, after any
+	}{
+		{"first_name", before.FirstName, after.FirstName},
+		{"last_name", before.LastName, after.LastName},
+		{"email", before.Email, after.Email},
+		{"phones", before.Phones, after.Phones},
+		{"addresses", before.Addresses, after.Addresses},
+		{"company", before.Company, after.Company},
+		{"title", before.Title, after.Title},
+		{"birthday", before.Birthday, after.Birthday},
+		{"notes", before.Notes, after.Notes},
+		{"tags", before.Tags, after.Tags},
+	} {
+		if !reflect.DeepEqual(f.before, f.after) {
+			fields = append(fields, f.name)
+		}
+	}
+
+	keys := slices.Collect(maps.Keys(before.CustomFields))
+	keys = append(keys, slices.Collect(maps.Keys(after.CustomFields))...)
+	slices.Sort(keys)
+	for _, key := range slices.Compact(keys) {
+		value, ok := before.CustomFields[key]
+		if newValue, newOk := after.CustomFields[key]; newValue != value || newOk != ok {
+			fields = append(fields, "custom_fields."+key)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MergeSide names one of the two contacts of a merge
+type MergeSide string
+
+const (
+	MergeContact   MergeSide = "contact"   // the contact that is kept
+	MergeDuplicate MergeSide = "duplicate" // the contact merged into it, then deleted
+)
+
+// mergeFields are the single-valued fields a merge may find in conflict.
+// Custom fields are resolved key by key, as "custom_fields.<key>".
+var mergeFields = []string{"first_name", "last_name", "email", "company", "title", "birthday", "notes"}
+
+// ContactMerge folds a duplicate into a contact. A field set on one side only
+// is kept; lists are united; a field set to different values on both sides is
+// taken from the side named by Prefer, the contact by default.
+type ContactMerge struct {
+	DuplicateID int                  `json:"duplicate_id"`
+	Prefer      map[string]MergeSide `json:"prefer,omitempty"`
+	// Version and DuplicateVersion, when non-zero, make the merge conditional
+	// on the versions of the contact and the duplicate
+	Version          int  `json:"version,omitempty"`
+	DuplicateVersion int  `json:"duplicate_version,omitempty"`
+	DryRun           bool `json:"dry_run,omitempty"` // compute the result without saving it
+}
+
+// Validate rejects a merge without duplicate, or with unknown fields or
+// sides in Prefer
+func (m ContactMerge) Validate() error {
+	if m.DuplicateID <= 0 {
+		return fmt.Errorf("%w: duplicate_id is required", ErrValidation)
+	}
+	for _, field := range slices.Sorted(maps.Keys(m.Prefer)) {
+		key, custom := strings.CutPrefix(field, "custom_fields.")
+		switch {
+		case !slices.Contains(mergeFields, field) && !(custom && key != ""):
+			return fmt.Errorf("%w: %q is not a field that can be merged", ErrValidation, field)
+		case m.Prefer[field] != MergeContact && m.Prefer[field] != MergeDuplicate:
+			return fmt.Errorf(`%w: the side preferred for %q must be "contact" or "duplicate"`, ErrValidation, field)
+		}
+	}
+	return nil
+}
+
+// MergeConflict is a field set to different values on both sides
+type MergeConflict struct {
+	Field     string    `json:"field"`
+	Contact   string    `json:"contact"`
+	Duplicate string    `json:"duplicate"`
+	Chosen    MergeSide `json:"chosen"`
+}
+
+// MergeResult is the merged contact along with the conflicts resolved on
+// the way
+type MergeResult struct {
+	Contact   Contact         `json:"contact"`
+	Conflicts []MergeConflict `json:"conflicts"`
+}
+
+// MergeContacts folds duplicate into contact field by field, as described
+// by ContactMerge. The result keeps the ID and version of contact.
+func MergeContacts(contact, duplicate Contact, prefer map[string]MergeSide) MergeResult {
+	merged := contact
+	var conflicts []MergeConflict
+	resolve := func(field string, kept, other string) string {
+		switch {
+		case other == "" || other == kept:
+			return kept
+		case kept == "":
+			return other
+		}
+		side := prefer[field]
+		if side == "" {
+			side = MergeContact
+		}
+		conflicts = append(conflicts, MergeConflict{Field: field, Contact: kept, Duplicate: other, Chosen: side})
+		if side == MergeDuplicate {
+			return other
+		}
+		return kept
+	}
+
+	merged.FirstName = resolve("first_name", contact.FirstName, duplicate.FirstName)
+	merged.LastName = resolve("last_name", contact.LastName, duplicate.LastName)
+	merged.Email = resolve("email", contact.Email, duplicate.Email)
+	merged.Company = resolve("company", contact.Company, duplicate.Company)
+	merged.Title = resolve("title", contact.Title, duplicate.Title)
+	merged.Birthday = resolve("birthday", contact.Birthday, duplicate.Birthday)
+	merged.Notes = resolve("notes", contact.Notes, duplicate.Notes)
+
+	merged.Phones = slices.Clone(contact.Phones)
+	for _, p := range duplicate.Phones {
+		if !slices.ContainsFunc(merged.Phones, func(q Phone) bool { return q.Number == p.Number }) {
+			merged.Phones = append(merged.Phones, p)
+		}
+	}
+	merged.Addresses = slices.Clone(contact.Addresses)
+	for _, a := range duplicate.Addresses {
+		if !slices.Contains(merged.Addresses, a) {
+			merged.Addresses = append(merged.Addresses, a)
+		}
+	}
+	merged.Tags = append(slices.Clone(contact.Tags), duplicate.Tags...)
+
+	if len(contact.CustomFields)+len(duplicate.CustomFields) > 0 {
+		merged.CustomFields = maps.Clone(contact.CustomFields)
+		if merged.CustomFields == nil {
+			merged.CustomFields = map[string]string{}
+		}
+		for _, key := range slices.Sorted(maps.Keys(duplicate.CustomFields)) {
+			merged.CustomFields[key] = resolve("custom_fields."+key, contact.CustomFields[key], duplicate.CustomFields[key])
+		}
+	}
+
+	merged.Normalize()
+	return MergeResult{Contact: merged, Conflicts: conflicts}
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestMergeContacts(t *testing.T) {
+	contact := Contact{
+		ID: 1, FirstName: "Bob", LastName: "Johnson", Email: "bob@example.com",
+		Company:      "Acme",
+		Phones:       []Phone{{Type: PhoneMobile, Number: "+15550100"}},
+		Tags:         []string{"vip"},
+		CustomFields: map[string]string{"team": "sales"},
+		Version:      3,
+	}
+	duplicate := Contact{
+		ID: 2, FirstName: "Robert", LastName: "Johnson", Email: "bob.j@example.org",
+		Title:        "CTO",
+		Phones:       []Phone{{Type: PhoneWork, Number: "+15550100"}, {Type: PhoneHome, Number: "+15550199"}},
+		Addresses:    []Address{{Type: AddressWork, City: "Boston", Country: "US"}},
+		Tags:         []string{"speaker", "vip"},
+		CustomFields: map[string]string{"team": "ops", "desk": "12"},
+		Version:      7,
+	}
+	// merged is what every merge of the two shares: fields set on one side
+	// only are kept and lists are united, the contact's entries first
+	merged := func(edit func(c *Contact)) Contact {
+		c := contact
+		c.Title = "CTO"
+		c.Phones = []Phone{{Type: PhoneMobile, Number: "+15550100"}, {Type: PhoneHome, Number: "+15550199"}}
+		c.Addresses = []Address{{Type: AddressWork, City: "Boston", Country: "US"}}
+		c.Tags = []string{"speaker", "vip"}
+		c.CustomFields = map[string]string{"team": "sales", "desk": "12"}
+		edit(&c)
+		return c
+	}
+	conflicts := func(firstName, email, team MergeSide) []MergeConflict {
+		return []MergeConflict{
+			{Field: "first_name", Contact: "Bob", Duplicate: "Robert", Chosen: firstName},
+			{Field: "email", Contact: "bob@example.com", Duplicate: "bob.j@example.org", Chosen: email},
+			{Field: "custom_fields.team", Contact: "sales", Duplicate: "ops", Chosen: team},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		prefer        map[string]MergeSide
+		want          Contact
+		wantConflicts []MergeConflict
+	}{
+		{"ContactByDefault", nil,
+			merged(func(c *Contact) {}),
+			conflicts(MergeContact, MergeContact, MergeContact)},
+		{"PreferDuplicate", map[string]MergeSide{"first_name": MergeDuplicate, "custom_fields.team": MergeDuplicate},
+			merged(func(c *Contact) { c.FirstName, c.CustomFields["team"] = "Robert", "ops" }),
+			conflicts(MergeDuplicate, MergeContact, MergeDuplicate)},
+		{"PreferContactExplicitly", map[string]MergeSide{"email": MergeContact},
+			merged(func(c *Contact) {}),
+			conflicts(MergeContact, MergeContact, MergeContact)},
+		// a preference for a field without conflict changes nothing
+		{"PreferWithoutConflict", map[string]MergeSide{"last_name": MergeDuplicate, "company": MergeDuplicate, "title": MergeContact},
+			merged(func(c *Contact) {}),
+			conflicts(MergeContact, MergeContact, MergeContact)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergeContacts(contact, duplicate, tt.prefer)
+			if !reflect.DeepEqual(got.Contact, tt.want) {
+				t.Errorf("merged contact = %+v, want %+v", got.Contact, tt.want)
+			}
+			if !reflect.DeepEqual(got.Conflicts, tt.wantConflicts) {
+				t.Errorf("conflicts = %+v, want %+v", got.Conflicts, tt.wantConflicts)
+			}
+		})
+	}
+
+	if contact.Title != "" || len(contact.Phones) != 1 || len(contact.CustomFields) != 1 {
+		t.Errorf("MergeContacts changed its input to %+v", contact)
+	}
+}
+
+func TestMergeContactsWithoutConflicts(t *testing.T) {
+	contact := Contact{ID: 1, FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Version: 2}
+	duplicate := Contact{ID: 2, FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Notes: "First program"}
+
+	got := MergeContacts(contact, duplicate, nil)
+	want := contact
+	want.Notes = "First program"
+	if !reflect.DeepEqual(got.Contact, want) || got.Conflicts != nil {
+		t.Errorf("MergeContacts = %+v, want %+v without conflicts", got, want)
+	}
+}
+
+func TestContactMergeValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		merge   ContactMerge
+		wantErr bool
+	}{
+		{"Valid", ContactMerge{DuplicateID: 2, Prefer: map[string]MergeSide{"email": MergeDuplicate, "custom_fields.team": MergeContact}}, false},
+		{"NoDuplicate", ContactMerge{}, true},
+		{"UnknownField", ContactMerge{DuplicateID: 2, Prefer: map[string]MergeSide{"phones": MergeDuplicate}}, true},
+		{"EmptyCustomField", ContactMerge{DuplicateID: 2, Prefer: map[string]MergeSide{"custom_fields.": MergeDuplicate}}, true},
+		{"UnknownSide", ContactMerge{DuplicateID: 2, Prefer: map[string]MergeSide{"email": "both"}}, true},
+	}
+	for _, tt := range tests {
+		err := tt.merge.Validate()
+		if tt.wantErr != (err != nil) || (err != nil && !errors.Is(err, ErrValidation)) {
+			t.Errorf("Validate %s = %v, want error %t", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"golang/internal/utils/jsonpatch"
+)
+
+// ContactPatch lists the fields a partial update changes.
+// Nil fields are left as they are.
+type ContactPatch struct {
+	FirstName *string
+	LastName  *string
+	Email     *string
+	Phones    *[]Phone
+	Addresses *[]Address
+	Company   *string
+	Title     *string
+	Birthday  *string
+	Notes     *string
+	// CustomFields are merged key by key: a nil value removes the field
+	CustomFields map[string]*string
+	Tags         *[]string
+}
+
+// Apply writes the patched fields into c
+func (p ContactPatch) Apply(c *Contact) {
+	for _, f := range []struct {
+		patch *string
+		field *string
+	}{
+		{p.FirstName, &c.FirstName},
+		{p.LastName, &c.LastName},
+		{p.Email, &c.Email},
+		{p.Company, &c.Company},
+		{p.Title, &c.Title},
+		{p.Birthday, &c.Birthday},
+		{p.Notes, &c.Notes},
+	} {
+		if f.patch != nil {
+			*f.field = *f.patch
+		}
+	}
+	if p.Phones != nil {
+		c.Phones = *p.Phones
+	}
+	if p.Addresses != nil {
+		c.Addresses = *p.Addresses
+	}
+	if p.Tags != nil {
+		c.Tags = *p.Tags
+	}
+	if len(p.CustomFields) > 0 {
+		// c may share its map with the contact it was copied from
+		fields := maps.Clone(c.CustomFields)
+		if fields == nil {
+			fields = make(map[string]string, len(p.CustomFields))
+		}
+		for key, value := range p.CustomFields {
+			if value == nil {
+				delete(fields, key)
+			} else {
+				fields[key] = *value
+			}
+		}
+		c.CustomFields = fields
+	}
+}
+
+// Diff returns the patch turning from into to, holding only the fields
+// that differ
+func Diff(from, to Contact) ContactPatch {
+	var p ContactPatch
+	for _, f := range []struct {
+		patch    **string
+		from, to *string
+	}{
+		{&p.FirstName, &from.FirstName, &to.FirstName},
+		{&p.LastName, &from.LastName, &to.LastName},
+		{&p.Email, &from.Email, &to.Email},
+		{&p.Company, &from.Company, &to.Company},
+		{&p.Title, &from.Title, &to.Title},
+		{&p.Birthday, &from.Birthday, &to.Birthday},
+		{&p.Notes, &from.Notes, &to.Notes},
+	} {
+		if *f.from != *f.to {
+			*f.patch = f.to
+		}
+	}
+	if !slices.Equal(from.Phones, to.Phones) {
+		p.Phones = &to.Phones
+	}
+	if !slices.Equal(from.Addresses, to.Addresses) {
+		p.Addresses = &to.Addresses
+	}
+	if !slices.Equal(from.Tags, to.Tags) {
+		p.Tags = &to.Tags
+	}
+	for key, value := range to.CustomFields {
+		if old, ok := from.CustomFields[key]; !ok || old != value {
+			if p.CustomFields == nil {
+				p.CustomFields = make(map[string]*string)
+			}
+			p.CustomFields[key] = &value
+		}
+	}
+	for key := range from.CustomFields {
+		if _, ok := to.CustomFields[key]; !ok {
+			if p.CustomFields == nil {
+				p.CustomFields = make(map[string]*string)
+			}
+			p.CustomFields[key] = nil
+		}
+	}
+	return p
+}
+
+// IsEmpty reports whether the patch changes nothing
+func (p ContactPatch) IsEmpty() bool {
+	return p.FirstName == nil && p.LastName == nil && p.Email == nil &&
+		p.Phones == nil && p.Addresses == nil && p.Company == nil &&
+		p.Title == nil && p.Birthday == nil && p.Notes == nil &&
+		p.Tags == nil && len(p.CustomFields) == 0
+}
+
+// ParseMergePatch reads a JSON Merge Patch (RFC 7396) of the contact with
+// the given ID. Members set to null are removed, which for the required
+// contact fields means emptied. Lists are replaced as a whole while custom
+// fields are merged key by key; clearFields reports a null "custom_fields",
+// which removes those the patch does not set. A "version" member is returned
+// as the expected version.
+func ParseMergePatch(body []byte, id int) (patch ContactPatch, version int, clearFields bool, err error) {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(body, &members); err != nil || members == nil {
+		return patch, 0, false, fmt.Errorf("%w: a merge patch must be a JSON object", ErrValidation)
+	}
+
+	var verr ValidationError
+	for name, raw := range members {
+		switch name {
+		case "first_name", "last_name", "email", "company", "title", "birthday", "notes":
+			value, ok := decodeString(raw)
+			if !ok {
+				verr.Add(name, "must be a string")
+				continue
+			}
+			*patch.textField(name) = &value
+		case "phones":
+			var phones []Phone
+			if !isNull(raw) && json.Unmarshal(raw, &phones) != nil {
+				verr.Add(name, "must be an array of phone numbers")
+				continue
+			}
+			patch.Phones = &phones
+		case "addresses":
+			var addresses []Address
+			if !isNull(raw) && json.Unmarshal(raw, &addresses) != nil {
+				verr.Add(name, "must be an array of addresses")
+				continue
+			}
+			patch.Addresses = &addresses
+		case "tags":
+			var tags []string
+			if !isNull(raw) && json.Unmarshal(raw, &tags) != nil {
+				verr.Add(name, "must be an array of strings")
+				continue
+			}
+			patch.Tags = &tags
+		case "custom_fields":
+			var fields map[string]*string
+			if !isNull(raw) && json.Unmarshal(raw, &fields) != nil {
+				verr.Add(name, "must be an object of strings")
+				continue
+			}
+			if fields == nil {
+				fields = make(map[string]*string)
+			}
+			patch.CustomFields = fields
+			clearFields = isNull(raw)
+		case "id":
+			var v int
+			if json.Unmarshal(raw, &v) != nil || v != id {
+				verr.Add(name, "cannot be changed")
+			}
+		case "version":
+			if json.Unmarshal(raw, &version) != nil || version < 1 {
+				verr.Add(name, "must be a positive integer")
+			}
+		default:
+			verr.Add(name, "is not a contact field")
+		}
+	}
+	return patch, version, clearFields, verr.Err()
+}
+
+// ApplyJSONPatch runs a JSON Patch (RFC 6902) against current and returns
+// the changes it makes. Operations apply in order to the contact as a JSON
+// document and the whole patch fails if one does: with ErrConflict when a
+// test does not match, as the contact is not in the state expected, and
+// with ErrValidation for anything else.
+func ApplyJSONPatch(body []byte, current Contact) (ContactPatch, error) {
+	patch, err := jsonpatch.Decode(body)
+	if err != nil {
+		return ContactPatch{}, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	for i, op := range patch {
+		if err := checkContactPointers(op); err != nil {
+			return ContactPatch{}, fmt.Errorf("%w: operation %d: %v", ErrValidation, i, err)
+		}
+	}
+
+	doc, err := contactDocument(current)
+	if err != nil {
+		return ContactPatch{}, err
+	}
+	patchedDoc, err := patch.Apply(doc)
+	if err != nil {
+		category := ErrValidation
+		if errors.Is(err, jsonpatch.ErrTestFailed) {
+			category = ErrConflict
+		}
+		return ContactPatch{}, fmt.Errorf("%w: %v", category, err)
+	}
+
+	data, err := json.Marshal(patchedDoc)
+	if err != nil {
+		return ContactPatch{}, err
+	}
+	var patched Contact
+	if err := json.Unmarshal(data, &patched); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return ContactPatch{}, fmt.Errorf("%w: %s cannot hold a JSON %s", ErrValidation, typeErr.Field, typeErr.Value)
+		}
+		return ContactPatch{}, fmt.Errorf("%w: the patched contact is malformed", ErrValidation)
+	}
+	return Diff(current, patched), nil
+}
+
+// contactMembers lists the members of a contact document with their value
+// when the contact leaves them out
+var contactMembers = map[string]func() any{
+	"id":            nil,
+	"version":       nil,
+	"first_name":    func() any { return "" },
+	"last_name":     func() any { return "" },
+	"email":         func() any { return "" },
+	"phones":        func() any { return []any{} },
+	"addresses":     func() any { return []any{} },
+	"company":       func() any { return "" },
+	"title":         func() any { return "" },
+	"birthday":      func() any { return "" },
+	"notes":         func() any { return "" },
+	"custom_fields": func() any { return map[string]any{} },
+	"tags":          func() any { return []any{} },
+}
+
+// contactDocument turns a contact into the JSON document a patch applies
+// to, with every member present so that empty ones can be tested and
+// added to
+func contactDocument(c Contact) (map[string]any, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for name, empty := range contactMembers {
+		if _, ok := doc[name]; !ok && empty != nil {
+			doc[name] = empty()
+		}
+	}
+	return doc, nil
+}
+
+// checkContactPointers checks that the pointers of an operation designate a
+// contact member or something inside one, and that those it writes to are
+// not the read-only members. Removing a member empties it.
+func checkContactPointers(op jsonpatch.Operation) error {
+	if err := checkContactPointer(op.Path, op.Op != "test"); err != nil {
+		return err
+	}
+	if op.Op == "copy" || op.Op == "move" {
+		if err := checkContactPointer(op.From, op.Op == "move"); err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+	}
+	return nil
+}
+
+func checkContactPointer(pointer string, write bool) error {
+	tokens, err := jsonpatch.ParsePointer(pointer)
+	if err != nil || len(tokens) == 0 {
+		return fmt.Errorf("path %q is not a contact field", pointer)
+	}
+	if _, ok := contactMembers[tokens[0]]; !ok {
+		return fmt.Errorf("path %q is not a contact field", pointer)
+	}
+	if write && (tokens[0] == "id" || tokens[0] == "version") {
+		return fmt.Errorf("/%s cannot be changed", tokens[0])
+	}
+	return nil
+}
+
+// isNull reports whether a JSON value is null
+func isNull(raw json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(raw), []byte("null"))
+}
+
+// decodeString decodes a JSON string, treating null as the empty string
+func decodeString(raw json.RawMessage) (string, bool) {
+	if isNull(raw) {
+		return "", true
+	}
+	var s string
+	return s, json.Unmarshal(raw, &s) == nil
+}
+
+// textField points at the patch entry of a text field of a contact
+func (p *ContactPatch) textField(name string) **string {
+	switch name {
+	case "first_name":
+		return &p.FirstName
+	case "last_name":
+		return &p.LastName
+	case "company":
+		return &p.Company
+	case "title":
+		return &p.Title
+	case "birthday":
+		return &p.Birthday
+	case "notes":
+		return &p.Notes
+	default:
+		return &p.Email
+	}
+}
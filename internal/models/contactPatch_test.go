@@ -0,0 +1,158 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// patchBase is the contact the patch tests start from
+func patchBase() Contact {
+	return Contact{
+		ID: 1, FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com",
+		Phones:       []Phone{{Type: PhoneHome, Number: "+441234567890"}},
+		Company:      "Analytical Engines",
+		Title:        "Programmer",
+		Notes:        "First program",
+		CustomFields: map[string]string{"team": "math", "floor": "2"},
+		Tags:         []string{"vip"},
+		Version:      3,
+	}
+}
+
+func TestParseMergePatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		edit        func(c *Contact) // turns patchBase into the expected contact
+		version     int
+		clearFields bool
+	}{
+		{"Empty", `{}`, func(c *Contact) {}, 0, false},
+		{"SetMember", `{"title": "Countess"}`, func(c *Contact) { c.Title = "Countess" }, 0, false},
+		// RFC 7396: null removes a member, which empties a contact field
+		{"NullRemovesOptionalField", `{"company": null}`, func(c *Contact) { c.Company = "" }, 0, false},
+		{"NullEmptiesRequiredField", `{"first_name": null}`, func(c *Contact) { c.FirstName = "" }, 0, false},
+		{"NullRemovesList", `{"phones": null, "tags": null}`, func(c *Contact) { c.Phones, c.Tags = nil, nil }, 0, false},
+		{"NullNextToValues", `{"notes": null, "title": "CTO"}`, func(c *Contact) { c.Notes, c.Title = "", "CTO" }, 0, false},
+		// objects merge member by member, arrays are replaced as a whole
+		{"NullRemovesCustomField", `{"custom_fields": {"team": null}}`,
+			func(c *Contact) { c.CustomFields = map[string]string{"floor": "2"} }, 0, false},
+		{"CustomFieldsMerge", `{"custom_fields": {"floor": "3", "desk": "12"}}`,
+			func(c *Contact) { c.CustomFields = map[string]string{"team": "math", "floor": "3", "desk": "12"} }, 0, false},
+		{"NullCustomFieldsClearsThem", `{"custom_fields": null}`, func(c *Contact) {}, 0, true},
+		{"ArrayReplaced", `{"tags": ["speaker"]}`, func(c *Contact) { c.Tags = []string{"speaker"} }, 0, false},
+		{"SameID", `{"id": 1, "email": "ada@lovelace.org"}`, func(c *Contact) { c.Email = "ada@lovelace.org" }, 0, false},
+		{"Version", `{"version": 3, "title": "CTO"}`, func(c *Contact) { c.Title = "CTO" }, 3, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, version, clearFields, err := ParseMergePatch([]byte(tt.body), 1)
+			if err != nil {
+				t.Fatalf("ParseMergePatch: %v", err)
+			}
+			if version != tt.version || clearFields != tt.clearFields {
+				t.Errorf("ParseMergePatch version, clearFields = %d, %t, want %d, %t",
+					version, clearFields, tt.version, tt.clearFields)
+			}
+
+			got, want := patchBase(), patchBase()
+			patch.Apply(&got)
+			tt.edit(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("patched contact = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseMergePatchRejects(t *testing.T) {
+	for _, body := range []string{
+		`null`,
+		`[]`,
+		`{"nickname": "Ada"}`,
+		`{"id": 2}`,
+		`{"version": 0}`,
+		`{"email": 5}`,
+		`{"phones": "+441234567890"}`,
+		`{"custom_fields": {"team": 1}}`,
+	} {
+		_, _, _, err := ParseMergePatch([]byte(body), 1)
+		if !errors.Is(err, ErrValidation) {
+			t.Errorf("ParseMergePatch(%s) = %v, want ErrValidation", body, err)
+		}
+	}
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		edit func(c *Contact)
+	}{
+		{"Replace", `[{"op": "replace", "path": "/first_name", "value": "Augusta"}]`,
+			func(c *Contact) { c.FirstName = "Augusta" }},
+		{"RemoveEmptiesMember", `[{"op": "remove", "path": "/company"}]`,
+			func(c *Contact) { c.Company = "" }},
+		{"AppendTag", `[{"op": "add", "path": "/tags/-", "value": "speaker"}]`,
+			func(c *Contact) { c.Tags = []string{"vip", "speaker"} }},
+		{"InsertPhone", `[{"op": "add", "path": "/phones/0", "value": {"type": "work", "number": "+449876543210"}}]`,
+			func(c *Contact) {
+				c.Phones = []Phone{{Type: PhoneWork, Number: "+449876543210"}, {Type: PhoneHome, Number: "+441234567890"}}
+			}},
+		{"AddToEmptyMember", `[{"op": "add", "path": "/addresses/-", "value": {"type": "home", "city": "London"}}]`,
+			func(c *Contact) { c.Addresses = []Address{{Type: AddressHome, City: "London"}} }},
+		{"EscapedCustomFieldKey", `[{"op": "add", "path": "/custom_fields/a~1b~0c", "value": "x"}]`,
+			func(c *Contact) { c.CustomFields = map[string]string{"team": "math", "floor": "2", "a/b~c": "x"} }},
+		{"MoveCustomField", `[{"op": "move", "from": "/custom_fields/team", "path": "/custom_fields/group"}]`,
+			func(c *Contact) { c.CustomFields = map[string]string{"group": "math", "floor": "2"} }},
+		{"CopyField", `[{"op": "copy", "from": "/title", "path": "/notes"}]`,
+			func(c *Contact) { c.Notes = "Programmer" }},
+		{"TestThenReplace", `[{"op": "test", "path": "/version", "value": 3}, {"op": "test", "path": "/tags/0", "value": "vip"},
+			{"op": "replace", "path": "/email", "value": "ada@lovelace.org"}]`,
+			func(c *Contact) { c.Email = "ada@lovelace.org" }},
+		{"NoChange", `[{"op": "test", "path": "/first_name", "value": "Ada"}]`,
+			func(c *Contact) {}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := ApplyJSONPatch([]byte(tt.body), patchBase())
+			if err != nil {
+				t.Fatalf("ApplyJSONPatch: %v", err)
+			}
+			got, want := patchBase(), patchBase()
+			patch.Apply(&got)
+			tt.edit(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("patched contact = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyJSONPatchRejects(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want error
+	}{
+		{"NotAnArray", `{"op": "add"}`, ErrValidation},
+		{"TestFails", `[{"op": "test", "path": "/first_name", "value": "Grace"}]`, ErrConflict},
+		{"TestFailsAfterChanges", `[{"op": "replace", "path": "/title", "value": "CTO"}, {"op": "test", "path": "/title", "value": "CEO"}]`, ErrConflict},
+		{"ReplaceID", `[{"op": "replace", "path": "/id", "value": 2}]`, ErrValidation},
+		{"MoveVersion", `[{"op": "move", "from": "/version", "path": "/notes"}]`, ErrValidation},
+		{"UnknownMember", `[{"op": "add", "path": "/nickname", "value": "Ada"}]`, ErrValidation},
+		{"WholeDocument", `[{"op": "replace", "path": "", "value": {}}]`, ErrValidation},
+		{"WrongType", `[{"op": "copy", "from": "/id", "path": "/notes"}]`, ErrValidation},
+		{"MissingPath", `[{"op": "remove", "path": "/custom_fields/desk"}]`, ErrValidation},
+		{"IndexOutOfRange", `[{"op": "remove", "path": "/tags/1"}]`, ErrValidation},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ApplyJSONPatch([]byte(tt.body), patchBase())
+			if !errors.Is(err, tt.want) {
+				t.Errorf("ApplyJSONPatch = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 500
+)
+
+// SortField is a contact column that listings can be ordered by
+type SortField string
+
+const (
+	SortByID        SortField = "id"
+	SortByFirstName SortField = "first_name"
+	SortByLastName  SortField = "last_name"
+	SortByEmail     SortField = "email"
+)
+
+// ContactQuery describes one page of a contact listing.
+// Name and email filters are case-insensitive prefixes, and contacts must
+// carry every one of Tags; empty filters match everything.
+// Pages are keyset based: Cursor is the opaque NextCursor of the previous page.
+type ContactQuery struct {
+	Limit      int
+	Cursor     string
+	SortBy     SortField
+	Descending bool
+
+	FirstNamePrefix string
+	LastNamePrefix  string
+	EmailPrefix     string
+	Tags            []string
+}
+
+// ContactPage is a single page of results and the cursor to the next one
+type ContactPage struct {
+	Contacts   []Contact `json:"contacts"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+}
+
+// PageCursor is the decoded position after which the next page starts.
+// Sort is the sort spec of the listing it was taken from, since a position
+// means nothing in another order.
+type PageCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v,omitempty"`
+	ID    int    `json:"id"`
+}
+
+// SetSort parses a sort spec such as "last_name" or "-last_name" (descending)
+func (q *ContactQuery) SetSort(spec string) {
+	q.Descending = strings.HasPrefix(spec, "-")
+	q.SortBy = SortField(strings.TrimPrefix(spec, "-"))
+}
+
+// SortSpec returns the sort spec SetSort parses
+func (q *ContactQuery) SortSpec() string {
+	if q.Descending {
+		return "-" + string(q.SortBy)
+	}
+	return string(q.SortBy)
+}
+
+// Normalize applies defaults and rejects values stores cannot honor
+func (q *ContactQuery) Normalize() error {
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultPageSize
+	case q.Limit < 0:
+		return fmt.Errorf("%w: limit must be positive", ErrValidation)
+	case q.Limit > MaxPageSize:
+		q.Limit = MaxPageSize
+	}
+
+	switch q.SortBy {
+	case "":
+		q.SortBy = SortByID
+	case SortByID, SortByFirstName, SortByLastName, SortByEmail:
+	default:
+		return fmt.Errorf("%w: cannot sort by %q", ErrValidation, q.SortBy)
+	}
+
+	q.Tags = NormalizeTags(q.Tags)
+
+	if _, err := q.DecodeCursor(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// DecodeCursor returns the position encoded in Cursor, or nil for the first
+// page. A cursor of a listing sorted otherwise is rejected.
+func (q *ContactQuery) DecodeCursor() (*PageCursor, error) {
+	if q.Cursor == "" {
+		return nil, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrValidation)
+	}
+	var c PageCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrValidation)
+	}
+	if c.Sort != q.SortSpec() {
+		return nil, fmt.Errorf("%w: the cursor belongs to a listing sorted by %q, not %q",
+			ErrValidation, c.Sort, q.SortSpec())
+	}
+	return &c, nil
+}
+
+// CursorAfter builds the cursor pointing just past the given contact
+func (q *ContactQuery) CursorAfter(c Contact) string {
+	data, _ := json.Marshal(PageCursor{Sort: q.SortSpec(), Value: SortValue(c, q.SortBy), ID: c.ID})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Matches reports whether a contact passes the query filters.
+// SQL stores express the same rules in their WHERE clause.
+func (q *ContactQuery) Matches(c Contact) bool {
+	for _, tag := range q.Tags {
+		if !slices.Contains(c.Tags, tag) {
+			return false
+		}
+	}
+	return hasPrefixFold(c.FirstName, q.FirstNamePrefix) &&
+		hasPrefixFold(c.LastName, q.LastNamePrefix) &&
+		hasPrefixFold(c.Email, q.EmailPrefix)
+}
+
+// SortValue returns the value of the sort column for a contact
+func SortValue(c Contact, field SortField) string {
+	switch field {
+	case SortByFirstName:
+		return c.FirstName
+	case SortByLastName:
+		return c.LastName
+	case SortByEmail:
+		return c.Email
+	default:
+		return ""
+	}
+}
+
+// hasPrefixFold reports whether s starts with prefix under Unicode case
+// folding. Case variants may differ in length, e.g. "K" and the Kelvin sign,
+// so the prefix of s is taken rune by rune rather than byte by byte.
+func hasPrefixFold(s, prefix string) bool {
+	n := utf8.RuneCountInString(prefix)
+	for i := range s {
+		if n == 0 {
+			return strings.EqualFold(s[:i], prefix)
+		}
+		n--
+	}
+	return n == 0 && strings.EqualFold(s, prefix)
+}
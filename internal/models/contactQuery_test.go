@@ -0,0 +1,26 @@
+package models
+
+import "testing"
+
+func TestMatchesPrefixFold(t *testing.T) {
+	c := Contact{FirstName: "Émile", LastName: "Kelvin", Email: "emile@example.com"}
+	tests := []struct {
+		query ContactQuery
+		want  bool
+	}{
+		{ContactQuery{FirstNamePrefix: "émile"}, true},
+		{ContactQuery{FirstNamePrefix: "ÉM"}, true},
+		{ContactQuery{FirstNamePrefix: "emile"}, false},
+		{ContactQuery{FirstNamePrefix: "Émiles"}, false},
+		// the Kelvin sign folds to k but is three bytes long
+		{ContactQuery{LastNamePrefix: "\u212Ael"}, true},
+		{ContactQuery{LastNamePrefix: "\u212Aelvin"}, true},
+		{ContactQuery{LastNamePrefix: "\u212Aelvins"}, false},
+		{ContactQuery{EmailPrefix: "EMILE@"}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.query.Matches(c); got != tt.want {
+			t.Errorf("Matches(%+v) = %t, want %t", tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+	MaxSearchTerms     = 10
+)
+
+// ContactSearch is a free-text search over contact names and emails.
+// Every term of Text must match a word of the contact, either exactly, as a
+// prefix, or with a few typos (see MaxTypos).
+type ContactSearch struct {
+	Text  string
+	Limit int
+
+	// Terms are the words of Text, set by Normalize
+	Terms []string
+}
+
+// Normalize splits the text into terms and applies the limit defaults
+func (q *ContactSearch) Normalize() error {
+	switch {
+	case q.Limit == 0:
+		q.Limit = DefaultSearchLimit
+	case q.Limit < 0:
+		return fmt.Errorf("%w: limit must be positive", ErrValidation)
+	case q.Limit > MaxSearchLimit:
+		q.Limit = MaxSearchLimit
+	}
+
+	q.Terms = SearchWords(q.Text)
+	switch {
+	case len(q.Terms) == 0:
+		return fmt.Errorf("%w: the search text must contain a letter or digit", ErrValidation)
+	case len(q.Terms) > MaxSearchTerms:
+		return fmt.Errorf("%w: the search text must have at most %d words", ErrValidation, MaxSearchTerms)
+	}
+	return nil
+}
+
+// foldDiacritics turns "José" into "Jose", like the unicode61 tokenizer of
+// SQLite full-text search
+var foldDiacritics = transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+
+// SearchWords splits text into the lower-case words search matches on:
+// runs of letters and digits, without diacritics. "ada.lovelace@example.com"
+// is the words ada, lovelace, example and com.
+func SearchWords(text string) []string {
+	folded, _, err := transform.String(foldDiacritics, text)
+	if err != nil {
+		folded = text
+	}
+	return strings.FieldsFunc(strings.ToLower(folded), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// ContactWords returns the searchable words of a contact: its names, then
+// its email
+func ContactWords(c Contact) (names, email []string) {
+	return SearchWords(c.FirstName + " " + c.LastName), SearchWords(c.Email)
+}
+
+// MaxTypos is the edit distance a term of this length tolerates: none for
+// short terms, where a typo is as likely another word, then one, then two
+func MaxTypos(term string) int {
+	switch n := len([]rune(term)); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// MatchScore rates how well a term matches a word: 3 when equal, 2 when it
+// is a prefix of the word, 1 when within MaxTypos edits and 0 otherwise
+func MatchScore(term, word string) int {
+	switch {
+	case term == word:
+		return 3
+	case strings.HasPrefix(word, term):
+		return 2
+	case MaxTypos(term) > 0 && EditDistance(term, word, MaxTypos(term)) <= MaxTypos(term):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// EditDistance counts the insertions, deletions, substitutions and
+// transpositions of adjacent letters turning a into b. It stops counting
+// past limit and then returns limit+1.
+func EditDistance(a, b string, limit int) int {
+	s, t := []rune(a), []rune(b)
+	if d := len(s) - len(t); d > limit || -d > limit {
+		return limit + 1
+	}
+
+	// rows i-2, i-1 and i of the optimal string alignment matrix
+	prev2 := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	cur := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		cur[0] = i
+		best := cur[0]
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			best = min(best, cur[j])
+		}
+		if best > limit {
+			return limit + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return min(prev[len(t)], limit+1)
+}
+
+// SearchScore rates a contact against the terms, or returns 0 when a term
+// matches none of its words. Each term counts its best match, twice as much
+// in a name as in the email.
+func SearchScore(c Contact, terms []string) int {
+	names, email := ContactWords(c)
+	total := 0
+	for _, term := range terms {
+		best := 0
+		for _, word := range names {
+			best = max(best, 2*MatchScore(term, word))
+		}
+		for _, word := range email {
+			best = max(best, MatchScore(term, word))
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+	return total
+}
+
+// RankContacts keeps the candidates matching every term of q, best first
+// (ties by ID), up to q.Limit. Stores narrow down the candidates with their
+// indexes and leave the ranking to it, so that they all order results alike.
+func RankContacts(candidates []Contact, q ContactSearch) []Contact {
+	type hit struct {
+		contact Contact
+		score   int
+	}
+	var hits []hit
+	for _, c := range candidates {
+		if score := SearchScore(c, q.Terms); score > 0 {
+			hits = append(hits, hit{c, score})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].contact.ID < hits[j].contact.ID
+	})
+
+	results := make([]Contact, 0, min(len(hits), q.Limit))
+	for _, h := range hits[:min(len(hits), q.Limit)] {
+		results = append(results, h.contact)
+	}
+	return results
+}
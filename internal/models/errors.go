@@ -0,0 +1,26 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Domain errors shared by every layer.
+// Stores wrap their failures with one of these so that services and
+// presentations can tell them apart with errors.Is, whatever the backend.
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrValidation  = errors.New("validation failed")
+	ErrUnavailable = errors.New("unavailable")
+	// ErrUnauthenticated is returned when a caller's credentials are
+	// missing or invalid
+	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrForbidden is returned when the caller's role does not allow an
+	// operation
+	ErrForbidden = errors.New("forbidden")
+)
+
+// ErrVersionConflict is the ErrConflict returned when a write expected a
+// version of a record that has since changed
+var ErrVersionConflict = fmt.Errorf("%w: version mismatch", ErrConflict)
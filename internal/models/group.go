@@ -0,0 +1,37 @@
+package models
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxGroupDescriptionLength bounds the description of a group, in characters
+const MaxGroupDescriptionLength = 1000
+
+// Group is a named set of contacts of a tenant, e.g. a team or a mailing
+// list. A contact can belong to any number of groups.
+type Group struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Normalize trims and NFC-normalizes the group fields
+func (g *Group) Normalize() {
+	g.Name = normalizeText(g.Name)
+	g.Description = normalizeText(g.Description)
+}
+
+// Validate reports every invalid field as a *ValidationError
+func (g *Group) Validate() error {
+	var verr ValidationError
+	validateName(&verr, "name", g.Name)
+	if utf8.RuneCountInString(g.Description) > MaxGroupDescriptionLength {
+		verr.Add("description", fmt.Sprintf("must be at most %d characters", MaxGroupDescriptionLength))
+	} else {
+		validateNotes(&verr, "description", g.Description)
+	}
+	return verr.Err()
+}
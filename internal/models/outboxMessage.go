@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+type OutboxStatus string
+
+const (
+	OutboxPending   OutboxStatus = "pending"
+	OutboxDelivered OutboxStatus = "delivered"
+	OutboxDead      OutboxStatus = "dead"
+)
+
+// OutboxMessage is a notification recorded alongside the change that caused it
+// and delivered later by the outbox dispatcher
+type OutboxMessage struct {
+	ID            int          `json:"id"`
+	Tenant        string       `json:"tenant"` // tenant of the change that caused the message
+	Email         EmailMessage `json:"email"`
+	Status        OutboxStatus `json:"status"`
+	Attempts      int          `json:"attempts"`
+	NextAttemptAt time.Time    `json:"next_attempt_at"`
+	LastError     string       `json:"last_error,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	DeliveredAt   *time.Time   `json:"delivered_at,omitempty"`
+}
+
+// NewOutboxMessage queues an email of a tenant for immediate delivery
+func NewOutboxMessage(tenant string, email EmailMessage, now time.Time) OutboxMessage {
+	return OutboxMessage{
+		Tenant:        tenant,
+		Email:         email,
+		Status:        OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+}
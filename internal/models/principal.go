@@ -0,0 +1,54 @@
+package models
+
+import "context"
+
+// Role grants a caller the set of operations listed by the service policy
+type Role string
+
+const (
+	RoleViewer Role = "viewer" // reads contacts
+	RoleEditor Role = "editor" // also creates and updates them
+	RoleAdmin  Role = "admin"  // also deletes, bulk-operates and manages notifications and tenants
+)
+
+// Valid reports whether r is one of the known roles
+func (r Role) Valid() bool {
+	return r == RoleViewer || r == RoleEditor || r == RoleAdmin
+}
+
+// Principal is an authenticated caller
+type Principal struct {
+	Subject string // API key name, JWT "sub" claim or OS user
+	Method  string // how the caller authenticated, e.g. "api-key" or "jwt"
+	Role    Role
+	Tenant  string // the only tenant whose contacts the caller sees
+}
+
+// String identifies the principal in audit entries, e.g. "jwt:alice"
+func (p Principal) String() string {
+	return p.Method + ":" + p.Subject
+}
+
+// SystemPrincipal is the principal of a background job, such as the
+// notification dispatcher, named after the job. Jobs run as admins and are
+// recorded as "system:<job>".
+func SystemPrincipal(job string) Principal {
+	return Principal{Subject: job, Method: SystemActor, Role: RoleAdmin}
+}
+
+type principalKey struct{}
+
+// WithPrincipal records the authenticated caller of the operations carried
+// out with ctx. The principal also becomes the actor of the changes made,
+// and they are scoped to its tenant.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	ctx = context.WithValue(ctx, principalKey{}, p)
+	ctx = WithTenant(ctx, p.Tenant)
+	return WithActor(ctx, p.String())
+}
+
+// PrincipalFrom returns the principal stored by WithPrincipal, if any
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// DefaultTenant owns the contacts of callers that name no tenant, including
+// every contact created before tenants existed. It cannot be deleted.
+const DefaultTenant = "default"
+
+// MaxTenantIDLength bounds tenant IDs, which also name files in the file store
+const MaxTenantIDLength = 63
+
+// Tenant is an isolated contact book, e.g. one per department.
+// Emails are unique within a tenant only.
+type Tenant struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// ValidateTenantID checks that id is a lowercase slug such as "sales-emea"
+func ValidateTenantID(id string) error {
+	if len(id) > MaxTenantIDLength || !tenantIDPattern.MatchString(id) {
+		return fmt.Errorf("%w: tenant ID %q must be at most %d lowercase letters, digits and dashes, not starting with a dash",
+			ErrValidation, id, MaxTenantIDLength)
+	}
+	return nil
+}
+
+type tenantKey struct{}
+
+// WithTenant scopes the operations carried out with ctx to a tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFrom returns the tenant stored by WithTenant, or DefaultTenant
+func TenantFrom(ctx context.Context) string {
+	if tenant, ok := ctx.Value(tenantKey{}).(string); ok && tenant != "" {
+		return tenant
+	}
+	return DefaultTenant
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"strings"
+)
+
+// FieldError describes why one field of a value is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every invalid field of a value.
+// It matches ErrValidation with errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Add records a problem with field
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns e if it recorded a problem, nil otherwise
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		problems[i] = f.Field + " " + f.Message
+	}
+	return ErrValidation.Error() + ": " + strings.Join(problems, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrValidation
+}
@@ -3,8 +3,12 @@ package cli
 import (
 	"bufio"
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/user"
 	"strconv"
 	"strings"
 
@@ -16,17 +20,26 @@ import (
 type CLI struct {
 	service *service.Service
 	scanner *bufio.Scanner
+	eof     bool        // stdin is exhausted
+	role    models.Role // role the service policy applies to the user
+	tenant  string      // tenant whose contacts the commands work on
 }
 
-func NewCLI(svc *service.Service) *CLI {
+func NewCLI(svc *service.Service, role models.Role, tenant string) *CLI {
 	return &CLI{
 		service: svc,
 		scanner: bufio.NewScanner(os.Stdin),
+		role:    role,
+		tenant:  tenant,
 	}
 }
 
-func (c *CLI) Start() error {
-	ctx := context.Background()
+// shell runs the interactive menu until the user exits.
+// Queued notifications are delivered in the background meanwhile.
+func (c *CLI) shell(ctx context.Context) error {
+	dispatchCtx, stopDispatcher := context.WithCancel(ctx)
+	defer stopDispatcher()
+	go c.service.OutboxService.Run(dispatchCtx)
 
 	fmt.Println("========================================")
 	fmt.Println("   Contact Management System - CLI")
@@ -37,10 +50,17 @@ func (c *CLI) Start() error {
 		fmt.Println("2. Create contact")
 		fmt.Println("3. Update contact")
 		fmt.Println("4. Delete contact")
+		fmt.Println("5. Contact history")
+		fmt.Println("6. List failed notifications")
+		fmt.Println("7. Retry failed notification")
+		fmt.Println("8. Search contacts")
 		fmt.Println("0. Exit")
 		fmt.Print("\nChoice: ")
 
 		choice := c.readInput()
+		if c.eof {
+			return nil
+		}
 
 		switch choice {
 		case "1":
@@ -51,6 +71,14 @@ func (c *CLI) Start() error {
 			c.updateContact(ctx)
 		case "4":
 			c.deleteContact(ctx)
+		case "5":
+			c.contactHistory(ctx)
+		case "6":
+			c.listDeadLetters(ctx)
+		case "7":
+			c.replayDeadLetter(ctx)
+		case "8":
+			c.searchContacts(ctx)
 		case "0":
 			fmt.Println("Goodbye!")
 			return nil
@@ -62,82 +90,254 @@ func (c *CLI) Start() error {
 }
 
 func (c *CLI) listContacts(ctx context.Context) {
-	contacts, err := c.service.ContactService.GetAll(ctx)
-	if err != nil {
-		fmt.Printf("Error: %v\n", err)
+	fmt.Print("Filters (e.g. -sort=-last_name -email=bob -tag=vip -limit=20), blank for none: ")
+
+	var query models.ContactQuery
+	flags := newListFlags(&query)
+	if err := flags.Parse(strings.Fields(c.readInput())); err != nil {
 		return
 	}
 
 	fmt.Println("\nContacts:")
+	for {
+		page, err := c.service.ContactService.List(ctx, query)
+		if err != nil {
+			printError(os.Stdout, err)
+			return
+		}
+
+		for _, contact := range page.Contacts {
+			fmt.Printf("  [%d] %s - %s\n", contact.ID, contact.FullName(), contact.Email)
+		}
+
+		if page.NextCursor == "" {
+			return
+		}
+		fmt.Print("More? [y/N]: ")
+		if !strings.EqualFold(c.readInput(), "y") {
+			return
+		}
+		query.Cursor = page.NextCursor
+	}
+}
+
+func (c *CLI) searchContacts(ctx context.Context) {
+	fmt.Print("Search for: ")
+	contacts, err := c.service.ContactService.Search(ctx, models.ContactSearch{Text: c.readInput()})
+	if err != nil {
+		printError(os.Stdout, err)
+		return
+	}
+
+	if len(contacts) == 0 {
+		fmt.Println("No contacts found")
+		return
+	}
+	fmt.Println("\nBest matches:")
 	for _, contact := range contacts {
 		fmt.Printf("  [%d] %s - %s\n", contact.ID, contact.FullName(), contact.Email)
 	}
 }
 
+// newListFlags declares the listing flags, writing their values into query
+func newListFlags(query *models.ContactQuery) *flag.FlagSet {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	flags.SetOutput(os.Stdout)
+	flags.IntVar(&query.Limit, "limit", models.DefaultPageSize, "contacts per page")
+	flags.StringVar(&query.FirstNamePrefix, "first_name", "", "first name prefix")
+	flags.StringVar(&query.LastNamePrefix, "last_name", "", "last name prefix")
+	flags.StringVar(&query.EmailPrefix, "email", "", "email prefix")
+	flags.Func("tag", "only contacts with this tag (repeatable)", func(tag string) error {
+		query.Tags = append(query.Tags, tag)
+		return nil
+	})
+	flags.Func("sort", "sort field, prefix with - for descending (id, first_name, last_name, email)", func(spec string) error {
+		query.SetSort(spec)
+		return nil
+	})
+	return flags
+}
+
 func (c *CLI) createContact(ctx context.Context) {
-	fmt.Print("First Name: ")
-	firstName := c.readInput()
-	fmt.Print("Last Name: ")
-	lastName := c.readInput()
-	fmt.Print("Email: ")
-	email := c.readInput()
+	var contact models.Contact
+	err := c.promptContact(&contact, func() error {
+		created, err := c.service.ContactService.Create(ctx, contact)
+		if err == nil {
+			fmt.Printf("✓ Contact created with ID %d\n", created.ID)
+		}
+		return err
+	})
+	if err != nil {
+		printError(os.Stdout, err)
+	}
+}
+
+func (c *CLI) updateContact(ctx context.Context) {
+	fmt.Print("Contact ID: ")
+	id, err := strconv.Atoi(c.readInput())
+	if err != nil {
+		fmt.Println("Error: invalid contact ID")
+		return
+	}
+
+	// start from the stored contact so that the details not prompted for
+	// are kept, and so that a concurrent change is not overwritten
+	existing, err := c.service.ContactService.GetByID(ctx, id)
+	if err != nil {
+		printError(os.Stdout, err)
+		return
+	}
+	contact := *existing
+	err = c.promptContact(&contact, func() error {
+		updated, err := c.service.ContactService.UpdateAndNotify(ctx, contact)
+		if err == nil {
+			fmt.Printf("✓ Contact updated (version %d)\n", updated.Version)
+		}
+		return err
+	})
+	if err != nil {
+		printError(os.Stdout, err)
+	}
+}
 
-	contact := models.Contact{
-		FirstName: firstName,
-		LastName:  lastName,
-		Email:     email,
+// promptContact reads the contact fields and calls save. While save rejects
+// some fields, only those are asked again, with the problem next to the prompt.
+func (c *CLI) promptContact(contact *models.Contact, save func() error) error {
+	fields := []struct {
+		name, label string
+		value       *string
+	}{
+		{"first_name", "First Name", &contact.FirstName},
+		{"last_name", "Last Name", &contact.LastName},
+		{"email", "Email", &contact.Email},
 	}
 
-	created, err := c.service.ContactService.Create(ctx, contact)
+	var problems map[string]string
+	for {
+		for _, f := range fields {
+			problem, invalid := problems[f.name]
+			switch {
+			case invalid:
+				fmt.Printf("%s (%s): ", f.label, problem)
+			case problems == nil:
+				fmt.Printf("%s: ", f.label)
+			default:
+				continue
+			}
+			*f.value = c.readInput()
+		}
+
+		err := save()
+		var verr *models.ValidationError
+		if !errors.As(err, &verr) || c.eof {
+			return err
+		}
+		problems = map[string]string{}
+		for _, f := range verr.Fields {
+			problems[f.Field] = f.Message
+		}
+	}
+}
+
+func (c *CLI) deleteContact(ctx context.Context) {
+	fmt.Print("Contact ID: ")
+	id, err := strconv.Atoi(c.readInput())
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
+		fmt.Println("Error: invalid contact ID")
 		return
 	}
 
-	fmt.Printf("✓ Contact created with ID %d\n", created.ID)
+	if err := c.service.ContactService.Delete(ctx, id, 0); err != nil {
+		printError(os.Stdout, err)
+		return
+	}
+
+	fmt.Println("✓ Contact deleted")
 }
 
-func (c *CLI) updateContact(ctx context.Context) {
+func (c *CLI) contactHistory(ctx context.Context) {
 	fmt.Print("Contact ID: ")
-	idStr := c.readInput()
-	id, _ := strconv.Atoi(idStr)
+	id, err := strconv.Atoi(c.readInput())
+	if err != nil {
+		fmt.Println("Error: invalid contact ID")
+		return
+	}
 
-	fmt.Print("First Name: ")
-	firstName := c.readInput()
-	fmt.Print("Last Name: ")
-	lastName := c.readInput()
-	fmt.Print("Email: ")
-	email := c.readInput()
+	entries, err := c.service.ContactService.History(ctx, id)
+	if err != nil {
+		printError(os.Stdout, err)
+		return
+	}
 
-	contact := models.Contact{
-		ID:        id,
-		FirstName: firstName,
-		LastName:  lastName,
-		Email:     email,
+	fmt.Printf("\nHistory of contact %d:\n", id)
+	for _, e := range entries {
+		fmt.Printf("  %s  %-6s by %s\n", e.Timestamp.Local().Format("2006-01-02 15:04:05"), e.Action, e.Actor)
+		if e.Before != nil {
+			fmt.Printf("      before: %s - %s\n", e.Before.FullName(), e.Before.Email)
+		}
+		if e.After != nil {
+			fmt.Printf("      after:  %s - %s\n", e.After.FullName(), e.After.Email)
+		}
 	}
+}
 
-	if err := c.service.ContactService.UpdateAndNotify(ctx, contact); err != nil {
-		fmt.Printf("Error: %v\n", err)
+func (c *CLI) listDeadLetters(ctx context.Context) {
+	msgs, err := c.service.OutboxService.DeadLetters(ctx)
+	if err != nil {
+		printError(os.Stdout, err)
 		return
 	}
 
-	fmt.Println("✓ Contact updated and notification sent")
+	fmt.Println("\nFailed notifications:")
+	for _, msg := range msgs {
+		fmt.Printf("  [%d] to %s - %q after %d attempts: %s\n",
+			msg.ID, msg.Email.To, msg.Email.Subject, msg.Attempts, msg.LastError)
+	}
 }
 
-func (c *CLI) deleteContact(ctx context.Context) {
-	fmt.Print("Contact ID: ")
-	idStr := c.readInput()
-	id, _ := strconv.Atoi(idStr)
+func (c *CLI) replayDeadLetter(ctx context.Context) {
+	fmt.Print("Notification ID: ")
+	id, err := strconv.Atoi(c.readInput())
+	if err != nil {
+		fmt.Println("Error: invalid notification ID")
+		return
+	}
 
-	if err := c.service.ContactService.Delete(ctx, id); err != nil {
-		fmt.Printf("Error: %v\n", err)
+	if err := c.service.OutboxService.Replay(ctx, id); err != nil {
+		printError(os.Stdout, err)
 		return
 	}
 
-	fmt.Println("✓ Contact deleted")
+	fmt.Println("✓ Notification queued for delivery")
+}
+
+// printError reports a service error using the same categories as the HTTP API
+func printError(w io.Writer, err error) {
+	var verr *models.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		fmt.Fprintln(w, "Error: invalid input")
+		for _, f := range verr.Fields {
+			fmt.Fprintf(w, "  %s: %s\n", f.Field, f.Message)
+		}
+	case errors.Is(err, models.ErrUnavailable):
+		fmt.Fprintln(w, "Error: storage is unavailable, please try again later")
+	default:
+		fmt.Fprintf(w, "Error: %v\n", err)
+	}
+}
+
+// currentUser names the operating system account running the CLI
+func currentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return os.Getenv("USER")
 }
 
 func (c *CLI) readInput() string {
-	c.scanner.Scan()
+	if !c.scanner.Scan() {
+		c.eof = true
+	}
 	return strings.TrimSpace(c.scanner.Text())
 }
@@ -0,0 +1,848 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang/internal/models"
+	"golang/internal/service"
+	"golang/internal/utils/contactcsv"
+	"golang/internal/utils/vcard"
+)
+
+// Exit codes of Run, one per error category so that scripts can react to
+// the outcome without parsing messages
+const (
+	ExitOK              = 0
+	ExitFailure         = 1 // unexpected error
+	ExitUsage           = 2 // invalid command line
+	ExitNotFound        = 3 // contact, group or tenant
+	ExitConflict        = 4 // e.g. the email is already taken
+	ExitValidation      = 5
+	ExitUnavailable     = 6 // storage is down, retrying may help
+	ExitVersionConflict = 7 // the contact changed since -version was read
+	ExitForbidden       = 8 // the configured cli_role does not allow the command
+)
+
+// Usage describes the command line accepted by cmd/cli
+const Usage = `Usage: cli [-config path] [-tenant id] [-v] contacts|groups|tenants <command> [flags] [args]
+
+Contacts commands (of the tenant given by -tenant, default "default"):
+  list                    List contacts (-limit, -sort, -first_name, -last_name, -email, -tag, -cursor, -all)
+  search <text>           Find contacts by name or email, best match first, tolerating
+                          typos and word beginnings (-limit)
+  get <id>                Show a contact
+  create                  Create a contact (-first_name, -last_name, -email and the details below)
+  update <id>             Change the given fields of a contact (same flags, -version)
+  delete <id>             Delete a contact (-version)
+  history <id>            Show the audit trail of a contact
+  groups <id>             List the groups of a contact
+  duplicates              List suspected duplicates, most likely first (-min_score, -limit)
+  merge <id> <duplicate id>
+                          Merge a duplicate into a contact and delete it (-prefer field=contact|duplicate,
+                          -version, -duplicate_version, -dry_run)
+  import <file|->         Create the contacts of a vCard 3.0/4.0 or CSV file, or of stdin; records
+                          that fail are listed and do not stop the others (-format vcf|csv,
+                          -preset default|google|outlook, -map column=field, -mode create|skip|upsert,
+                          -dry_run, -report errors.csv)
+  export                  Write every contact to stdout as vCards (-format vcf, -version 3.0|4.0)
+  shell                   Start the interactive menu
+
+Groups commands (of the same tenant):
+  list                    List groups
+  get <id>                Show a group
+  create                  Create a group (-name, -description)
+  update <id>             Change the given fields of a group (same flags)
+  delete <id>             Delete a group, keeping its members
+  members <id>            List the contacts of a group
+  add <id> <contact id>   Add a contact to a group
+  remove <id> <contact id>
+                          Remove a contact from a group
+
+Tenants commands:
+  list                    List tenants
+  create <id>             Create a tenant (-name)
+  delete <id>             Delete a tenant with all of its contacts
+
+Contact details: -company, -title, -birthday YYYY-MM-DD, -notes, -phone [type:]number,
+-address JSON, -field key=value and -tag. -phone, -address and -tag can be repeated and
+replace every number, address or tag on update; -field merges, and -field key= removes a field.
+
+Every command but shell and export accepts -o table|json|csv|yaml (default table).
+
+Exit codes:
+  0 success, 1 unexpected error, 2 usage, 3 not found, 4 conflict,
+  5 validation, 6 storage unavailable, 7 version conflict, 8 forbidden
+`
+
+// errUsage marks mistakes in the command line
+var errUsage = errors.New("invalid usage")
+
+// flagError is a flag parsing failure, already reported by the flag package
+type flagError struct{ err error }
+
+func (e flagError) Error() string { return e.err.Error() }
+func (e flagError) Unwrap() []error {
+	return []error{errUsage, e.err}
+}
+
+// ExitCode maps an error to the exit code of its category
+func ExitCode(err error) int {
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, errUsage):
+		return ExitUsage
+	case errors.Is(err, models.ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, models.ErrVersionConflict):
+		return ExitVersionConflict
+	case errors.Is(err, models.ErrConflict):
+		return ExitConflict
+	case errors.Is(err, models.ErrValidation):
+		return ExitValidation
+	case errors.Is(err, models.ErrUnavailable):
+		return ExitUnavailable
+	case errors.Is(err, models.ErrForbidden):
+		return ExitForbidden
+	default:
+		return ExitFailure
+	}
+}
+
+// Run executes one command, e.g. ["contacts", "get", "3", "-o", "json"],
+// and returns the process exit code
+func (c *CLI) Run(ctx context.Context, args []string) int {
+	ctx = models.WithPrincipal(ctx, models.Principal{
+		Subject: currentUser(),
+		Method:  "cli",
+		Role:    c.role,
+		Tenant:  c.tenant,
+	})
+
+	if len(args) < 2 {
+		fmt.Fprint(os.Stderr, Usage)
+		return ExitUsage
+	}
+
+	var err error
+	switch args[0] {
+	case "contacts":
+		err = c.runContacts(ctx, args[1], args[2:])
+	case "groups":
+		err = c.runGroups(ctx, args[1], args[2:])
+	case "tenants":
+		err = c.runTenants(ctx, args[1], args[2:])
+	default:
+		fmt.Fprint(os.Stderr, Usage)
+		return ExitUsage
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+	var fe flagError
+	if err != nil && !errors.As(err, &fe) {
+		printError(os.Stderr, err)
+	}
+	return ExitCode(err)
+}
+
+func (c *CLI) runContacts(ctx context.Context, name string, args []string) error {
+	var err error
+	switch name {
+	case "list":
+		err = c.runList(ctx, args)
+	case "search":
+		err = c.runSearch(ctx, args)
+	case "duplicates":
+		err = c.runDuplicates(ctx, args)
+	case "merge":
+		err = c.runMerge(ctx, args)
+	case "import":
+		err = c.runImport(ctx, args)
+	case "export":
+		err = c.runExport(ctx, args)
+	case "get":
+		err = c.runGet(ctx, args)
+	case "create":
+		err = c.runCreate(ctx, args)
+	case "update":
+		err = c.runUpdate(ctx, args)
+	case "delete":
+		err = c.runDelete(ctx, args)
+	case "history":
+		err = c.runHistory(ctx, args)
+	case "groups":
+		err = c.runContactGroups(ctx, args)
+	case "shell":
+		err = c.shell(ctx)
+	default:
+		err = fmt.Errorf("%w: unknown command %q", errUsage, name)
+	}
+	return err
+}
+
+func (c *CLI) runGroups(ctx context.Context, name string, args []string) error {
+	switch name {
+	case "list":
+		return c.runListGroups(ctx, args)
+	case "get":
+		return c.runGetGroup(ctx, args)
+	case "create":
+		return c.runCreateGroup(ctx, args)
+	case "update":
+		return c.runUpdateGroup(ctx, args)
+	case "delete":
+		return c.runDeleteGroup(ctx, args)
+	case "members":
+		return c.runMembers(ctx, args)
+	case "add":
+		return c.runMembership(ctx, "groups add <id> <contact id>", args, c.service.GroupService.AddMember)
+	case "remove":
+		return c.runMembership(ctx, "groups remove <id> <contact id>", args, c.service.GroupService.RemoveMember)
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, name)
+	}
+}
+
+func (c *CLI) runTenants(ctx context.Context, name string, args []string) error {
+	switch name {
+	case "list":
+		return c.runListTenants(ctx, args)
+	case "create":
+		return c.runCreateTenant(ctx, args)
+	case "delete":
+		return c.runDeleteTenant(ctx, args)
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, name)
+	}
+}
+
+func (c *CLI) runList(ctx context.Context, args []string) error {
+	var query models.ContactQuery
+	var all bool
+	format := formatTable
+
+	flags := newListFlags(&query)
+	flags.SetOutput(os.Stderr)
+	flags.StringVar(&query.Cursor, "cursor", "", "next cursor printed by the previous page")
+	flags.BoolVar(&all, "all", false, "follow cursors to print every matching contact")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	if _, err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+
+	page, err := c.service.ContactService.List(ctx, query)
+	if err != nil {
+		return err
+	}
+	for all && page.NextCursor != "" {
+		query.Cursor = page.NextCursor
+		next, err := c.service.ContactService.List(ctx, query)
+		if err != nil {
+			return err
+		}
+		page.Contacts = append(page.Contacts, next.Contacts...)
+		page.NextCursor = next.NextCursor
+	}
+
+	// JSON carries the cursor in the page itself
+	if page.NextCursor != "" && format != formatJSON {
+		fmt.Fprintf(os.Stderr, "next cursor: %s\n", page.NextCursor)
+	}
+	return format.write(os.Stdout, contactsView(page, page.Contacts))
+}
+
+func (c *CLI) runSearch(ctx context.Context, args []string) error {
+	var search models.ContactSearch
+	format := formatTable
+	flags := newFlags("contacts search <text>")
+	flags.IntVar(&search.Limit, "limit", models.DefaultSearchLimit, "maximum number of contacts")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	words, err := parseArgs(flags, args, -1)
+	if err != nil {
+		return err
+	}
+	if len(words) == 0 {
+		flags.Usage()
+		return flagError{errors.New("expected the text to search for")}
+	}
+	search.Text = strings.Join(words, " ")
+
+	contacts, err := c.service.ContactService.Search(ctx, search)
+	if err != nil {
+		return err
+	}
+	page := &models.ContactPage{Contacts: contacts}
+	if page.Contacts == nil {
+		page.Contacts = []models.Contact{}
+	}
+	return format.write(os.Stdout, contactsView(page, page.Contacts))
+}
+
+func (c *CLI) runGet(ctx context.Context, args []string) error {
+	format := formatTable
+	flags := newFlags("contacts get <id>")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	id, err := parseIDArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	contact, err := c.service.ContactService.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return format.write(os.Stdout, contactView(contact))
+}
+
+func (c *CLI) runCreate(ctx context.Context, args []string) error {
+	var contact models.Contact
+	var phones phonesFlag
+	var addresses addressesFlag
+	var tags tagsFlag
+	fields := fieldsFlag{}
+	format := formatTable
+	flags := newFlags("contacts create")
+	flags.StringVar(&contact.FirstName, "first_name", "", "first name")
+	flags.StringVar(&contact.LastName, "last_name", "", "last name")
+	flags.StringVar(&contact.Email, "email", "", "email address")
+	declareDetailFlags(flags, &contact, &phones, &addresses, &tags, fields)
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	if _, err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+	contact.Phones = phones.phones
+	contact.Addresses = addresses.addresses
+	contact.Tags = tags.tags
+	contact.CustomFields = fields.values()
+
+	created, err := c.service.ContactService.Create(ctx, contact)
+	if err != nil {
+		return err
+	}
+	return format.write(os.Stdout, contactView(created))
+}
+
+// runUpdate changes only the fields given as flags
+func (c *CLI) runUpdate(ctx context.Context, args []string) error {
+	var changes models.Contact
+	var phones phonesFlag
+	var addresses addressesFlag
+	var tags tagsFlag
+	fields := fieldsFlag{}
+	var version int
+	format := formatTable
+	flags := newFlags("contacts update <id>")
+	flags.StringVar(&changes.FirstName, "first_name", "", "new first name")
+	flags.StringVar(&changes.LastName, "last_name", "", "new last name")
+	flags.StringVar(&changes.Email, "email", "", "new email address")
+	declareDetailFlags(flags, &changes, &phones, &addresses, &tags, fields)
+	flags.IntVar(&version, "version", 0, "only update if the contact is still at this version")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	id, err := parseIDArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	patch := models.ContactPatch{CustomFields: fields}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "first_name":
+			patch.FirstName = &changes.FirstName
+		case "last_name":
+			patch.LastName = &changes.LastName
+		case "email":
+			patch.Email = &changes.Email
+		case "company":
+			patch.Company = &changes.Company
+		case "title":
+			patch.Title = &changes.Title
+		case "birthday":
+			patch.Birthday = &changes.Birthday
+		case "notes":
+			patch.Notes = &changes.Notes
+		}
+	})
+	// repeated flags replace the whole list
+	if phones.set {
+		patch.Phones = &phones.phones
+	}
+	if addresses.set {
+		patch.Addresses = &addresses.addresses
+	}
+	if tags.set {
+		patch.Tags = &tags.tags
+	}
+
+	updated, err := c.service.ContactService.Patch(ctx, id, version, patch)
+	if err != nil {
+		return err
+	}
+	return format.write(os.Stdout, contactView(updated))
+}
+
+func (c *CLI) runDelete(ctx context.Context, args []string) error {
+	var version int
+	flags := newFlags("contacts delete <id>")
+	flags.IntVar(&version, "version", 0, "only delete if the contact is still at this version")
+	id, err := parseIDArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	return c.service.ContactService.Delete(ctx, id, version)
+}
+
+func (c *CLI) runHistory(ctx context.Context, args []string) error {
+	format := formatTable
+	flags := newFlags("contacts history <id>")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	id, err := parseIDArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	entries, err := c.service.ContactService.History(ctx, id)
+	if err != nil {
+		return err
+	}
+	return format.write(os.Stdout, historyView(entries))
+}
+
+func (c *CLI) runContactGroups(ctx context.Context, args []string) error {
+	format := formatTable
+	flags := newFlags("contacts groups <id>")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	id, err := parseIDArgs(flags, args)
+	if err != nil {
+		return err
+	}
+
+	groups, err := c.service.GroupService.ContactGroups(ctx, id)
+	if err != nil {
+		return err
+	}
+	return format.write(os.Stdout, groupsView(groups))
+}
+
+func (c *CLI) runDuplicates(ctx context.Context, args []string) error {
+	var query models.DuplicateQuery
+	format := formatTable
+	flags := newFlags("contacts duplicates")
+	flags.IntVar(&query.MinScore, "min_score", models.DefaultDuplicateScore, "lowest score listed, from 1 to 100")
+	flags.IntVar(&query.Limit, "limit", models.DefaultDuplicateLimit, "maximum number of pairs")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	if _, err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+
+	pairs, err := c.service.DedupService.Duplicates(ctx, query)
+	if err != nil {
+		return err
+	}
+	return format.write(os.Stdout, duplicatesView(pairs))
+}
+
+func (c *CLI) runMerge(ctx context.Context, args []string) error {
+	merge := models.ContactMerge{Prefer: preferFlag{}}
+	format := formatTable
+	flags := newFlags("contacts merge <id> <duplicate id>")
+	flags.Var(preferFlag(merge.Prefer), "prefer", "side a conflicting field is taken from, e.g. email=duplicate (repeatable)")
+	flags.IntVar(&merge.Version, "version", 0, "only merge if the contact is at this version")
+	flags.IntVar(&merge.DuplicateVersion, "duplicate_version", 0, "only merge if the duplicate is at this version")
+	flags.BoolVar(&merge.DryRun, "dry_run", false, "show the result without merging")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	ids, err := parseIDs(flags, args, "contact", "duplicate")
+	if err != nil {
+		return err
+	}
+	merge.DuplicateID = ids[1]
+
+	result, err := c.service.DedupService.Merge(ctx, ids[0], merge)
+	if err != nil {
+		return err
+	}
+	// JSON carries the conflicts in the result itself
+	if format != formatJSON {
+		for _, conflict := range result.Conflicts {
+			kept, dropped := conflict.Contact, conflict.Duplicate
+			if conflict.Chosen == models.MergeDuplicate {
+				kept, dropped = dropped, kept
+			}
+			fmt.Fprintf(os.Stderr, "conflict on %s: kept %q, dropped %q\n", conflict.Field, kept, dropped)
+		}
+	}
+	return format.write(os.Stdout, mergeView(result))
+}
+
+func (c *CLI) runImport(ctx context.Context, args []string) error {
+	var from fileFormat
+	var preset, report string
+	var mapping mappingFlag
+	var opts models.ImportOptions
+	format := formatTable
+	flags := newFlags("contacts import <file|->")
+	flags.Var(&from, "format", "file format: vcf or csv (default from the file name, else vcf)")
+	flags.StringVar(&preset, "preset", "default", "CSV column mapping: default, google or outlook")
+	flags.Var(&mapping, "map", `CSV column mapping on top of the preset, e.g. "Work Email=email" (repeatable)`)
+	flags.StringVar((*string)(&opts.Mode), "mode", string(models.ImportCreate),
+		"when the email is taken: create (fail), skip or upsert")
+	flags.BoolVar(&opts.DryRun, "dry_run", false, "show what would change without importing")
+	flags.StringVar(&report, "report", "", "write the records that fail to this CSV file")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	positional, err := parseArgs(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	name := positional[0]
+	if from == "" {
+		from = fileVCard
+		if strings.EqualFold(filepath.Ext(name), ".csv") {
+			from = fileCSV
+		}
+	}
+
+	in := os.Stdin
+	if name != "-" {
+		if in, err = os.Open(name); err != nil {
+			return err
+		}
+		defer in.Close()
+	}
+
+	var dec service.ContactDecoder = vcard.NewDecoder(in)
+	var header []string
+	if from == fileCSV {
+		m, err := contactcsv.NewMapping(preset, mapping)
+		if err != nil {
+			return err
+		}
+		csvDec, err := contactcsv.NewDecoder(in, m)
+		if err != nil {
+			return err
+		}
+		if unmapped := csvDec.Unmapped(); len(unmapped) > 0 {
+			fmt.Fprintf(os.Stderr, "ignored columns: %s\n", strings.Join(unmapped, ", "))
+		}
+		dec, header = csvDec, csvDec.Header()
+	}
+
+	result, err := c.service.ContactService.Import(ctx, dec, opts)
+	if err != nil {
+		if result != nil {
+			fmt.Fprintf(os.Stderr, "imported %d contact(s) before the error\n", result.Created+result.Updated)
+		}
+		return err
+	}
+	summary := "imported"
+	if opts.DryRun {
+		summary = "dry run"
+	}
+	fmt.Fprintf(os.Stderr, "%s: %d created, %d updated, %d skipped, %d failed\n",
+		summary, result.Created, result.Updated, result.Skipped, len(result.Failed))
+
+	if report != "" && len(result.Failed) > 0 {
+		if err := writeReport(report, header, result.Failed); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "failed records written to %s\n", report)
+	}
+	if result.Failed == nil {
+		result.Failed = []models.ImportFailure{}
+	}
+	if len(result.Changes)+len(result.Failed) > 0 || format == formatJSON {
+		if err := format.write(os.Stdout, importView(result)); err != nil {
+			return err
+		}
+	}
+	if len(result.Failed) > 0 {
+		return fmt.Errorf("%w: %d record(s) could not be imported", models.ErrValidation, len(result.Failed))
+	}
+	return nil
+}
+
+// writeReport saves the failed records of an import as a CSV file
+func writeReport(name string, header []string, failed []models.ImportFailure) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := contactcsv.WriteReport(f, header, failed); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func (c *CLI) runExport(ctx context.Context, args []string) error {
+	to := fileVCard
+	var versionFlag string
+	flags := newFlags("contacts export")
+	flags.Var(&to, "format", "file format: vcf")
+	flags.StringVar(&versionFlag, "version", string(vcard.V3), "vCard version: 3.0 or 4.0")
+	if _, err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+	if to != fileVCard {
+		return fmt.Errorf("%w: contacts are exported as vcf only", errUsage)
+	}
+	version, ok := vcard.ParseVersion(versionFlag)
+	if !ok {
+		return fmt.Errorf("%w: vCard version must be 3.0 or 4.0", errUsage)
+	}
+
+	contacts, err := c.service.ContactService.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	enc := vcard.NewEncoder(os.Stdout, version)
+	for _, contact := range contacts {
+		if err := enc.Encode(contact); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *CLI) runListGroups(ctx context.Context, args []string) error {
+	format := formatTable
+	flags := newFlags("groups list")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	if _, err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+
+	groups, err := c.service.GroupService.List(ctx)
+	if err != nil {
+		return err
+	}
+	return format.write(os.Stdout, groupsView(groups))
+}
+
+func (c *CLI) runGetGroup(ctx context.Context, args []string) error {
+	format := formatTable
+	flags := newFlags("groups get <id>")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	ids, err := parseIDs(flags, args, "group")
+	if err != nil {
+		return err
+	}
+
+	group, err := c.service.GroupService.GetByID(ctx, ids[0])
+	if err != nil {
+		return err
+	}
+	return format.write(os.Stdout, groupsView([]models.Group{*group}))
+}
+
+func (c *CLI) runCreateGroup(ctx context.Context, args []string) error {
+	var group models.Group
+	format := formatTable
+	flags := newFlags("groups create")
+	flags.StringVar(&group.Name, "name", "", "group name")
+	flags.StringVar(&group.Description, "description", "", "what the group is for")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	if _, err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+
+	created, err := c.service.GroupService.Create(ctx, group)
+	if err != nil {
+		return err
+	}
+	return format.write(os.Stdout, groupsView([]models.Group{*created}))
+}
+
+// runUpdateGroup changes only the fields given as flags
+func (c *CLI) runUpdateGroup(ctx context.Context, args []string) error {
+	var changes models.Group
+	format := formatTable
+	flags := newFlags("groups update <id>")
+	flags.StringVar(&changes.Name, "name", "", "new name")
+	flags.StringVar(&changes.Description, "description", "", "new description")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	ids, err := parseIDs(flags, args, "group")
+	if err != nil {
+		return err
+	}
+
+	group, err := c.service.GroupService.GetByID(ctx, ids[0])
+	if err != nil {
+		return err
+	}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			group.Name = changes.Name
+		case "description":
+			group.Description = changes.Description
+		}
+	})
+
+	updated, err := c.service.GroupService.Update(ctx, *group)
+	if err != nil {
+		return err
+	}
+	return format.write(os.Stdout, groupsView([]models.Group{*updated}))
+}
+
+func (c *CLI) runDeleteGroup(ctx context.Context, args []string) error {
+	flags := newFlags("groups delete <id>")
+	ids, err := parseIDs(flags, args, "group")
+	if err != nil {
+		return err
+	}
+
+	return c.service.GroupService.Delete(ctx, ids[0])
+}
+
+func (c *CLI) runMembers(ctx context.Context, args []string) error {
+	format := formatTable
+	flags := newFlags("groups members <id>")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	ids, err := parseIDs(flags, args, "group")
+	if err != nil {
+		return err
+	}
+
+	contacts, err := c.service.GroupService.Members(ctx, ids[0])
+	if err != nil {
+		return err
+	}
+	return format.write(os.Stdout, contactsView(contacts, contacts))
+}
+
+// runMembership adds a contact to a group or removes it, as change does
+func (c *CLI) runMembership(ctx context.Context, synopsis string, args []string, change func(ctx context.Context, id, contactID int) error) error {
+	flags := newFlags(synopsis)
+	ids, err := parseIDs(flags, args, "group", "contact")
+	if err != nil {
+		return err
+	}
+
+	return change(ctx, ids[0], ids[1])
+}
+
+func (c *CLI) runListTenants(ctx context.Context, args []string) error {
+	format := formatTable
+	flags := newFlags("tenants list")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	if _, err := parseArgs(flags, args, 0); err != nil {
+		return err
+	}
+
+	tenants, err := c.service.TenantService.List(ctx)
+	if err != nil {
+		return err
+	}
+	return format.write(os.Stdout, tenantsView(tenants))
+}
+
+func (c *CLI) runCreateTenant(ctx context.Context, args []string) error {
+	var name string
+	format := formatTable
+	flags := newFlags("tenants create <id>")
+	flags.StringVar(&name, "name", "", "display name (default the ID)")
+	flags.Var(&format, "o", "output format: table, json, csv or yaml")
+	positional, err := parseArgs(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	tenant, err := c.service.TenantService.Create(ctx, positional[0], name)
+	if err != nil {
+		return err
+	}
+	return format.write(os.Stdout, tenantsView([]models.Tenant{*tenant}))
+}
+
+func (c *CLI) runDeleteTenant(ctx context.Context, args []string) error {
+	flags := newFlags("tenants delete <id>")
+	positional, err := parseArgs(flags, args, 1)
+	if err != nil {
+		return err
+	}
+
+	return c.service.TenantService.Delete(ctx, positional[0])
+}
+
+// declareDetailFlags declares the flags of the optional contact details
+func declareDetailFlags(flags *flag.FlagSet, contact *models.Contact, phones *phonesFlag, addresses *addressesFlag,
+	tags *tagsFlag, fields fieldsFlag) {
+	flags.StringVar(&contact.Company, "company", "", "company")
+	flags.StringVar(&contact.Title, "title", "", "job title")
+	flags.StringVar(&contact.Birthday, "birthday", "", "birthday, YYYY-MM-DD")
+	flags.StringVar(&contact.Notes, "notes", "", "free-text notes")
+	flags.Var(phones, "phone", "phone number as [type:]number, e.g. work:+14155550123 (repeatable)")
+	flags.Var(addresses, "address", `postal address as JSON, e.g. {"type":"home","city":"Paris","country":"FR"} (repeatable)`)
+	flags.Var(fields, "field", "custom field as key=value, key= removes it (repeatable)")
+	flags.Var(tags, "tag", "tag, e.g. vip (repeatable)")
+}
+
+// newFlags creates the flag set of a command, reporting to stderr
+func newFlags(synopsis string) *flag.FlagSet {
+	flags := flag.NewFlagSet(synopsis, flag.ContinueOnError)
+	flags.SetOutput(os.Stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: cli %s [flags]\n", synopsis)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseArgs parses flags wherever they appear among the arguments and
+// returns the n positional ones, or all of them when n is negative
+func parseArgs(flags *flag.FlagSet, args []string, n int) ([]string, error) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, err
+			}
+			return nil, flagError{err}
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+
+	if n >= 0 && len(positional) != n {
+		flags.Usage()
+		return nil, flagError{fmt.Errorf("expected %d argument(s), got %d", n, len(positional))}
+	}
+	return positional, nil
+}
+
+// parseIDArgs parses flags and the single contact ID argument
+func parseIDArgs(flags *flag.FlagSet, args []string) (int, error) {
+	ids, err := parseIDs(flags, args, "contact")
+	if err != nil {
+		return 0, err
+	}
+	return ids[0], nil
+}
+
+// parseIDs parses flags and one ID argument per entity named, in order
+func parseIDs(flags *flag.FlagSet, args []string, entities ...string) ([]int, error) {
+	positional, err := parseArgs(flags, args, len(entities))
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, len(entities))
+	for i, entity := range entities {
+		if ids[i], err = strconv.Atoi(positional[i]); err != nil {
+			return nil, fmt.Errorf("%w: invalid %s ID %q", errUsage, entity, positional[i])
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,149 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang/internal/models"
+)
+
+// phonesFlag collects repeated -phone flags written "[type:]number".
+// An empty value is skipped, so that -phone "" clears the numbers on update.
+type phonesFlag struct {
+	phones []models.Phone
+	set    bool
+}
+
+func (f *phonesFlag) String() string { return "" }
+
+func (f *phonesFlag) Set(s string) error {
+	f.set = true
+	if s == "" {
+		return nil
+	}
+	phone := models.Phone{Number: s}
+	// numbers hold no letters, so "work:+1 415" cannot be mistaken for one
+	if kind, number, ok := strings.Cut(s, ":"); ok {
+		phone = models.Phone{Type: models.PhoneType(kind), Number: number}
+	}
+	f.phones = append(f.phones, phone)
+	return nil
+}
+
+// addressesFlag collects repeated -address flags, each a JSON object such as
+// {"type":"home","street":"1 Main St","city":"Springfield","country":"US"}.
+// An empty value is skipped, so that -address "" clears the addresses on update.
+type addressesFlag struct {
+	addresses []models.Address
+	set       bool
+}
+
+func (f *addressesFlag) String() string { return "" }
+
+func (f *addressesFlag) Set(s string) error {
+	f.set = true
+	if s == "" {
+		return nil
+	}
+	var address models.Address
+	if err := json.Unmarshal([]byte(s), &address); err != nil {
+		return fmt.Errorf("address must be a JSON object: %v", err)
+	}
+	f.addresses = append(f.addresses, address)
+	return nil
+}
+
+// tagsFlag collects repeated -tag flags.
+// An empty value is skipped, so that -tag "" clears the tags on update.
+type tagsFlag struct {
+	tags []string
+	set  bool
+}
+
+func (f *tagsFlag) String() string { return "" }
+
+func (f *tagsFlag) Set(s string) error {
+	f.set = true
+	if s != "" {
+		f.tags = append(f.tags, s)
+	}
+	return nil
+}
+
+// fieldsFlag collects repeated -field flags written "key=value".
+// "key=" removes the field on update.
+type fieldsFlag map[string]*string
+
+func (f fieldsFlag) String() string { return "" }
+
+func (f fieldsFlag) Set(s string) error {
+	key, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("field must be written key=value")
+	}
+	if value == "" {
+		f[key] = nil
+	} else {
+		f[key] = &value
+	}
+	return nil
+}
+
+// values returns the fields that are set, as stored on a contact
+func (f fieldsFlag) values() map[string]string {
+	values := make(map[string]string, len(f))
+	for key, value := range f {
+		if value != nil {
+			values[key] = *value
+		}
+	}
+	return values
+}
+
+// preferFlag collects repeated -prefer flags written "field=contact" or
+// "field=duplicate", the side a merge takes conflicting fields from
+type preferFlag map[string]models.MergeSide
+
+func (f preferFlag) String() string { return "" }
+
+func (f preferFlag) Set(s string) error {
+	field, side, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("prefer must be written field=contact or field=duplicate")
+	}
+	f[field] = models.MergeSide(side)
+	return nil
+}
+
+// fileFormat selects the file format contacts are imported from and
+// exported to
+type fileFormat string
+
+const (
+	fileVCard fileFormat = "vcf"
+	fileCSV   fileFormat = "csv"
+)
+
+func (f *fileFormat) String() string {
+	return string(*f)
+}
+
+func (f *fileFormat) Set(s string) error {
+	switch fileFormat(s) {
+	case fileVCard, fileCSV:
+		*f = fileFormat(s)
+		return nil
+	}
+	return fmt.Errorf("unknown file format %q (vcf or csv)", s)
+}
+
+// mappingFlag collects repeated -map flags written "column=field"
+type mappingFlag []string
+
+func (f *mappingFlag) String() string { return "" }
+
+func (f *mappingFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
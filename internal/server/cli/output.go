@@ -0,0 +1,244 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang/internal/models"
+)
+
+// outputFormat selects how commands print their results
+type outputFormat string
+
+const (
+	formatTable outputFormat = "table"
+	formatJSON  outputFormat = "json"
+	formatCSV   outputFormat = "csv"
+	formatYAML  outputFormat = "yaml"
+)
+
+func (f *outputFormat) String() string {
+	return string(*f)
+}
+
+// Set lets an outputFormat be used as a flag value
+func (f *outputFormat) Set(s string) error {
+	switch outputFormat(s) {
+	case formatTable, formatJSON, formatCSV, formatYAML:
+		*f = outputFormat(s)
+		return nil
+	}
+	return fmt.Errorf("unknown output format %q (table, json, csv or yaml)", s)
+}
+
+// view is what a command prints: value is encoded as is in JSON, while the
+// other formats show it as rows of scalar columns
+type view struct {
+	value   any
+	columns []string
+	rows    [][]any
+}
+
+func contactRow(c models.Contact) []any {
+	return []any{c.ID, c.FirstName, c.LastName, c.Email, c.Version}
+}
+
+// contactView shows one contact with its details, which lists leave out
+func contactView(c *models.Contact) view {
+	return view{
+		value: c,
+		columns: []string{"id", "first_name", "last_name", "email", "phones", "addresses", "company", "title",
+			"birthday", "notes", "custom_fields", "tags", "version"},
+		rows: [][]any{{c.ID, c.FirstName, c.LastName, c.Email, formatPhones(c.Phones), formatAddresses(c.Addresses),
+			c.Company, c.Title, c.Birthday, c.Notes, formatFields(c.CustomFields), strings.Join(c.Tags, ", "), c.Version}},
+	}
+}
+
+// formatPhones writes phones as "mobile:+14155550123, work:+442079460958"
+func formatPhones(phones []models.Phone) string {
+	parts := make([]string, len(phones))
+	for i, p := range phones {
+		parts[i] = string(p.Type) + ":" + p.Number
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatAddresses writes addresses as "home: 1 Main St, Springfield, IL, 62701, US"
+// and separates them with semicolons
+func formatAddresses(addresses []models.Address) string {
+	parts := make([]string, len(addresses))
+	for i, a := range addresses {
+		var lines []string
+		for _, line := range []string{a.Street, a.City, a.Region, a.PostalCode, a.Country} {
+			if line != "" {
+				lines = append(lines, line)
+			}
+		}
+		parts[i] = string(a.Type) + ": " + strings.Join(lines, ", ")
+	}
+	return strings.Join(parts, "; ")
+}
+
+// formatFields writes custom fields as "key=value" pairs sorted by key
+func formatFields(fields map[string]string) string {
+	parts := make([]string, 0, len(fields))
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		parts = append(parts, key+"="+fields[key])
+	}
+	return strings.Join(parts, ", ")
+}
+
+func contactsView(value any, contacts []models.Contact) view {
+	v := view{value: value, columns: []string{"id", "first_name", "last_name", "email", "version"}}
+	for _, c := range contacts {
+		v.rows = append(v.rows, contactRow(c))
+	}
+	return v
+}
+
+func historyView(entries []models.AuditEntry) view {
+	v := view{value: entries, columns: []string{"id", "timestamp", "action", "actor", "before", "after"}}
+	summary := func(c *models.Contact) string {
+		if c == nil {
+			return ""
+		}
+		return c.FullName() + " <" + c.Email + ">"
+	}
+	for _, e := range entries {
+		v.rows = append(v.rows, []any{e.ID, e.Timestamp, string(e.Action), e.Actor, summary(e.Before), summary(e.After)})
+	}
+	return v
+}
+
+func tenantsView(tenants []models.Tenant) view {
+	v := view{value: tenants, columns: []string{"id", "name", "created_at"}}
+	for _, t := range tenants {
+		v.rows = append(v.rows, []any{t.ID, t.Name, t.CreatedAt})
+	}
+	return v
+}
+
+func groupsView(groups []models.Group) view {
+	v := view{value: groups, columns: []string{"id", "name", "description", "created_at"}}
+	for _, g := range groups {
+		v.rows = append(v.rows, []any{g.ID, g.Name, g.Description, g.CreatedAt})
+	}
+	return v
+}
+
+func duplicatesView(pairs []models.DuplicatePair) view {
+	v := view{value: pairs, columns: []string{"score", "id", "name", "email", "duplicate_id", "duplicate_name",
+		"duplicate_email", "reasons"}}
+	for _, p := range pairs {
+		a, b := p.Contacts[0], p.Contacts[1]
+		v.rows = append(v.rows, []any{p.Score, a.ID, a.FullName(), a.Email, b.ID, b.FullName(), b.Email,
+			strings.Join(p.Reasons, ", ")})
+	}
+	return v
+}
+
+// mergeView shows the merged contact; JSON also carries the conflicts
+func mergeView(result *models.MergeResult) view {
+	v := contactView(&result.Contact)
+	v.value = result
+	return v
+}
+
+// importView lists the records of an import that failed and, on a dry run,
+// the change each other record would make, in the order of the file. JSON
+// carries the whole result.
+func importView(result *models.ImportResult) view {
+	v := view{value: result, columns: []string{"record", "action", "contact_id", "email", "detail"}}
+	for _, c := range result.Changes {
+		v.rows = append(v.rows, []any{c.Record, c.Action, c.ContactID, c.Email, strings.Join(c.Fields, ", ")})
+	}
+	for _, f := range result.Failed {
+		v.rows = append(v.rows, []any{f.Record, "fail", 0, "", f.Error})
+	}
+	slices.SortStableFunc(v.rows, func(a, b []any) int { return a[0].(int) - b[0].(int) })
+	return v
+}
+
+// write prints v to w in format f
+func (f outputFormat) write(w io.Writer, v view) error {
+	switch f {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v.value)
+	case formatCSV:
+		return writeCSV(w, v)
+	case formatYAML:
+		return writeYAML(w, v)
+	default:
+		return writeTable(w, v)
+	}
+}
+
+func writeTable(w io.Writer, v view) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(v.columns, "\t")))
+	for _, row := range v.rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			// keep multi-line notes on their row
+			cells[i] = strings.NewReplacer("\n", " ", "\t", " ").Replace(formatCell(cell))
+		}
+		fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, v view) error {
+	cw := csv.NewWriter(w)
+	cw.Write(v.columns)
+	for _, row := range v.rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = formatCell(cell)
+		}
+		cw.Write(cells)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeYAML prints the rows as a sequence of mappings. Strings are emitted
+// double-quoted, whose escapes YAML shares with Go, so any value round-trips.
+func writeYAML(w io.Writer, v view) error {
+	if len(v.rows) == 0 {
+		_, err := fmt.Fprintln(w, "[]")
+		return err
+	}
+	for _, row := range v.rows {
+		for i, cell := range row {
+			prefix := "  "
+			if i == 0 {
+				prefix = "- "
+			}
+			value := formatCell(cell)
+			if _, isInt := cell.(int); !isInt {
+				value = strconv.Quote(value)
+			}
+			if _, err := fmt.Fprintf(w, "%s%s: %s\n", prefix, v.columns[i], value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func formatCell(cell any) string {
+	if t, ok := cell.(time.Time); ok {
+		return t.Format(time.RFC3339)
+	}
+	return fmt.Sprint(cell)
+}
@@ -0,0 +1,66 @@
+package http
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"golang/internal/auth"
+	"golang/internal/models"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"   // carries the API key of a caller
+	tenantHeader = "X-Tenant-ID" // selects the tenant when authentication is disabled
+)
+
+// authenticate requires an API key or a JWT bearer token on every request and
+// attributes what follows to the authenticated principal
+func authenticate(authn *auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, err := authenticateRequest(authn, r)
+			if err != nil {
+				challenge := `Bearer realm="contacts"`
+				if r.Header.Get("Authorization") != "" {
+					challenge += `, error="invalid_token"`
+				}
+				w.Header().Set("WWW-Authenticate", challenge)
+				respondServiceError(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(models.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+func authenticateRequest(authn *auth.Authenticator, r *http.Request) (models.Principal, error) {
+	if key := r.Header.Get(apiKeyHeader); key != "" {
+		return authn.AuthenticateAPIKey(key)
+	}
+	scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+	if strings.EqualFold(scheme, "Bearer") && token != "" {
+		return authn.AuthenticateToken(strings.TrimSpace(token))
+	}
+	return models.Principal{}, fmt.Errorf("%w: send an %s header or a bearer token", models.ErrUnauthenticated, apiKeyHeader)
+}
+
+// actorMiddleware lets every caller act as an admin named after its address
+// when authentication is disabled. Without credentials to take the tenant
+// from, callers pick it with the X-Tenant-ID header.
+func actorMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		tenant := r.Header.Get(tenantHeader)
+		if tenant != "" && models.ValidateTenantID(tenant) != nil {
+			respondProblem(w, r, problemBadRequest, fmt.Sprintf("invalid %s header", tenantHeader))
+			return
+		}
+		principal := models.Principal{Subject: host, Method: "http", Role: models.RoleAdmin, Tenant: tenant}
+		next.ServeHTTP(w, r.WithContext(models.WithPrincipal(r.Context(), principal)))
+	})
+}
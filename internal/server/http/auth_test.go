@@ -0,0 +1,138 @@
+package http
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang/internal/auth"
+	"golang/internal/config"
+	"golang/internal/models"
+)
+
+const testSecret = "0123456789abcdef0123456789abcdef"
+
+// newTestAuthenticator accepts the API keys "viewer-key" and "editor-key"
+// and the HS256 tokens signed with testSecret
+func newTestAuthenticator(t *testing.T) *auth.Authenticator {
+	t.Helper()
+	hash := func(key string) string {
+		digest := sha256.Sum256([]byte(key))
+		return "sha256:" + hex.EncodeToString(digest[:])
+	}
+	authn, err := auth.New(config.AuthConfig{
+		APIKeys: []config.APIKeyConfig{
+			{Name: "dashboard", Hash: hash("viewer-key"), Role: models.RoleViewer},
+			{Name: "ci", Hash: hash("editor-key"), Role: models.RoleEditor},
+		},
+		JWT: config.JWTConfig{HMACSecret: testSecret, RoleClaim: "role", DefaultRole: models.RoleViewer, TenantClaim: "tenant"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authn
+}
+
+// signToken returns an HS256 token holding claims, signed with secret
+func signToken(t *testing.T, claims map[string]any, secret string) string {
+	t.Helper()
+	segment := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+	input := segment(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + segment(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestAuthenticateRefuses(t *testing.T) {
+	s := newTestServer(t, newTestAuthenticator(t))
+	expired := signToken(t, map[string]any{"sub": "alice", "exp": time.Now().Add(-time.Hour).Unix()}, testSecret)
+	forged := signToken(t, map[string]any{"sub": "alice", "exp": time.Now().Add(time.Hour).Unix()}, "another secret of 32 bytes or so")
+
+	tests := []struct {
+		name      string
+		header    []string
+		challenge string
+	}{
+		{"NoCredentials", nil, `Bearer realm="contacts"`},
+		{"OtherScheme", []string{"Authorization", "Basic YWxpY2U6c2VjcmV0"}, `Bearer realm="contacts", error="invalid_token"`},
+		{"EmptyBearer", []string{"Authorization", "Bearer "}, `Bearer realm="contacts", error="invalid_token"`},
+		{"MalformedBearer", []string{"Authorization", "Bearer not-a-token"}, `Bearer realm="contacts", error="invalid_token"`},
+		{"ExpiredBearer", []string{"Authorization", "Bearer " + expired}, `Bearer realm="contacts", error="invalid_token"`},
+		{"ForgedBearer", []string{"Authorization", "Bearer " + forged}, `Bearer realm="contacts", error="invalid_token"`},
+		{"UnknownAPIKey", []string{apiKeyHeader, "other-key"}, `Bearer realm="contacts"`},
+		// an API key is checked alone, even next to a valid token
+		{"UnknownAPIKeyAndToken", []string{apiKeyHeader, "other-key", "Authorization", "Bearer " + forged}, `Bearer realm="contacts", error="invalid_token"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, http.MethodGet, "/contacts", "", tt.header...)
+			assertProblem(t, w, problemUnauthorized)
+			if got := w.Header().Get("WWW-Authenticate"); got != tt.challenge {
+				t.Errorf("WWW-Authenticate = %q, want %q", got, tt.challenge)
+			}
+		})
+	}
+
+	// /health stays open
+	if w := serve(s, http.MethodGet, "/health", ""); w.Code != http.StatusOK {
+		t.Errorf("GET /health without credentials = %d, want 200", w.Code)
+	}
+}
+
+// TestAuthenticatePrincipal checks that the service acts as the
+// authenticated caller: with its role, and recording it as the actor
+func TestAuthenticatePrincipal(t *testing.T) {
+	authn := newTestAuthenticator(t)
+	alice := signToken(t, map[string]any{"sub": "alice", "role": "editor", "exp": time.Now().Add(time.Hour).Unix()}, testSecret)
+	bob := signToken(t, map[string]any{"sub": "bob", "exp": time.Now().Add(time.Hour).Unix()}, testSecret)
+
+	tests := []struct {
+		name   string
+		header []string
+		actor  string // empty when the caller may only read
+	}{
+		{"EditorAPIKey", []string{apiKeyHeader, "editor-key"}, "api-key:ci"},
+		{"ViewerAPIKey", []string{apiKeyHeader, "viewer-key"}, ""},
+		{"EditorToken", []string{"Authorization", "Bearer " + alice}, "jwt:alice"},
+		{"LowercaseScheme", []string{"Authorization", "bearer " + alice}, "jwt:alice"},
+		{"ViewerToken", []string{"Authorization", "Bearer " + bob}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, authn)
+			if w := serve(s, http.MethodGet, "/contacts", "", tt.header...); w.Code != http.StatusOK {
+				t.Fatalf("GET /contacts = %d, want 200: %s", w.Code, w.Body)
+			}
+
+			w := serve(s, http.MethodPost, "/contacts", adaJSON, tt.header...)
+			if tt.actor == "" {
+				assertProblem(t, w, problemForbidden)
+				return
+			}
+			if w.Code != http.StatusCreated {
+				t.Fatalf("POST /contacts = %d, want 201: %s", w.Code, w.Body)
+			}
+			var c models.Contact
+			decodeBody(t, w, &c)
+
+			w = serve(s, http.MethodGet, "/contacts/"+strconv.Itoa(c.ID)+"/history", "", tt.header...)
+			var entries []models.AuditEntry
+			decodeBody(t, w, &entries)
+			if len(entries) != 1 || entries[0].Actor != tt.actor {
+				t.Errorf("history = %+v, want a creation by %s", entries, tt.actor)
+			}
+		})
+	}
+}
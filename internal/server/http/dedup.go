@@ -0,0 +1,79 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"golang/internal/models"
+)
+
+func (s *Server) handleDuplicates(w http.ResponseWriter, r *http.Request) {
+	query, err := parseDuplicateQuery(r)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+
+	pairs, err := s.service.DedupService.Duplicates(r.Context(), query)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+	if pairs == nil {
+		pairs = []models.DuplicatePair{}
+	}
+	respondJSON(w, http.StatusOK, pairs)
+}
+
+// handleMerge folds the duplicate named in the body into the contact of the
+// path. If-Match applies to that contact, like for an update.
+func (s *Server) handleMerge(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var merge models.ContactMerge
+	if err := json.NewDecoder(r.Body).Decode(&merge); err != nil {
+		respondProblem(w, r, problemBadRequest, "The body is not a valid merge JSON object.")
+		return
+	}
+
+	version, ok := s.expectedVersion(w, r, id)
+	if !ok {
+		return
+	}
+	if version != 0 {
+		merge.Version = version
+	}
+
+	result, err := s.service.DedupService.Merge(r.Context(), id, merge)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+	if result.Conflicts == nil {
+		result.Conflicts = []models.MergeConflict{}
+	}
+	w.Header().Set("ETag", contactETag(&result.Contact))
+	respondJSON(w, http.StatusOK, result)
+}
+
+// parseDuplicateQuery reads the min_score and limit parameters
+func parseDuplicateQuery(r *http.Request) (models.DuplicateQuery, error) {
+	var query models.DuplicateQuery
+	params := r.URL.Query()
+	for name, value := range map[string]*int{"min_score": &query.MinScore, "limit": &query.Limit} {
+		if params.Get(name) == "" {
+			continue
+		}
+		n, err := strconv.Atoi(params.Get(name))
+		if err != nil {
+			return query, fmt.Errorf("%w: %s must be an integer", models.ErrValidation, name)
+		}
+		*value = n
+	}
+	return query, nil
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang/internal/models"
+)
+
+// contactETag is the strong entity tag of a contact: its quoted version
+func contactETag(c *models.Contact) string {
+	return strconv.Quote(strconv.Itoa(c.Version))
+}
+
+// parseETags splits an If-Match or If-None-Match header into its entity tags
+func parseETags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// notModified reports whether If-None-Match already names the contact's
+// current representation (weak comparison)
+func notModified(r *http.Request, c *models.Contact) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	etag := contactETag(c)
+	for _, tag := range parseETags(header) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// isEntityTag reports whether tag is an entity tag of RFC 9110: a quoted
+// string of etagc characters, weak when prefixed with W/
+func isEntityTag(tag string) bool {
+	opaque := strings.TrimPrefix(tag, "W/")
+	if len(opaque) < 2 || opaque[0] != '"' || opaque[len(opaque)-1] != '"' {
+		return false
+	}
+	for _, c := range []byte(opaque[1 : len(opaque)-1]) {
+		if c == '"' || c <= ' ' || c == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+// expectedVersion turns If-Match into the version a write must apply to.
+// It returns 0 when the request sets no precondition, and answers itself
+// with 400 when the header is malformed and 412 when none of the listed tags
+// can match.
+func (s *Server) expectedVersion(w http.ResponseWriter, r *http.Request, id int) (int, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return 0, true
+	}
+
+	tags := parseETags(header)
+	if slices.Contains(tags, "*") {
+		return 0, true
+	}
+	for _, tag := range tags {
+		if !isEntityTag(tag) {
+			respondProblem(w, r, problemBadRequest, `If-Match must list quoted entity tags, e.g. "3".`)
+			return 0, false
+		}
+	}
+
+	var versions []int
+	for _, tag := range tags {
+		// If-Match uses the strong comparison, so weak tags never match
+		if strings.HasPrefix(tag, "W/") {
+			continue
+		}
+		if v, err := strconv.Atoi(tag[1 : len(tag)-1]); err == nil && v > 0 {
+			versions = append(versions, v)
+		}
+	}
+
+	switch len(versions) {
+	case 0:
+		respondServiceError(w, r, models.ErrVersionConflict)
+		return 0, false
+	case 1:
+		return versions[0], true
+	}
+
+	// several candidates: the write is pinned to whichever one is current
+	current, err := s.service.ContactService.GetByID(r.Context(), id)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return 0, false
+	}
+	if !slices.Contains(versions, current.Version) {
+		respondServiceError(w, r, models.ErrVersionConflict)
+		return 0, false
+	}
+	return current.Version, true
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    int
+	}{
+		{"Current", `"1"`, http.StatusOK},
+		{"AmongOthers", `"4", "1"`, http.StatusOK},
+		{"Any", `*`, http.StatusOK},
+		{"Stale", `"2"`, http.StatusPreconditionFailed},
+		{"AllStale", `"2", "3"`, http.StatusPreconditionFailed},
+		{"Weak", `W/"1"`, http.StatusPreconditionFailed},
+		{"NotAVersion", `"abc"`, http.StatusPreconditionFailed},
+		{"Unquoted", `1`, http.StatusBadRequest},
+		{"Unterminated", `"1`, http.StatusBadRequest},
+		{"OneMalformed", `"1", 2`, http.StatusBadRequest},
+		{"InnerSpace", `"1 2"`, http.StatusBadRequest},
+	}
+	for _, method := range []string{http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		for _, tt := range tests {
+			t.Run(method+"/"+tt.name, func(t *testing.T) {
+				s := newTestServer(t, nil)
+				createAda(t, s)
+
+				w := serve(s, method, "/contacts/1", `{"first_name": "Augusta", "last_name": "Lovelace", "email": "ada@example.com"}`,
+					"Content-Type", mediaMergePatch, "If-Match", tt.ifMatch)
+				if w.Code != tt.want {
+					t.Fatalf("%s with If-Match %s = %d, want %d: %s", method, tt.ifMatch, w.Code, tt.want, w.Body)
+				}
+				switch tt.want {
+				case http.StatusPreconditionFailed:
+					assertProblem(t, w, problemPreconditionFailed)
+				case http.StatusBadRequest:
+					assertProblem(t, w, problemBadRequest)
+				}
+
+				// only a successful write changed the contact
+				want := http.StatusNotModified
+				switch {
+				case tt.want != http.StatusOK:
+				case method == http.MethodDelete:
+					want = http.StatusNotFound
+				default:
+					want = http.StatusOK
+				}
+				if w = serve(s, http.MethodGet, "/contacts/1", "", "If-None-Match", `"1"`); w.Code != want {
+					t.Errorf("GET after the %s = %d, want %d", method, w.Code, want)
+				}
+			})
+		}
+	}
+}
+
+func TestIfNoneMatch(t *testing.T) {
+	s := newTestServer(t, nil)
+	createAda(t, s)
+
+	tests := []struct {
+		ifNoneMatch string
+		want        int
+	}{
+		{"", http.StatusOK},
+		{`"1"`, http.StatusNotModified},
+		{`W/"1"`, http.StatusNotModified}, // weak comparison
+		{`"3", "1"`, http.StatusNotModified},
+		{`*`, http.StatusNotModified},
+		{`"2"`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		w := serve(s, http.MethodGet, "/contacts/1", "", "If-None-Match", tt.ifNoneMatch)
+		if w.Code != tt.want {
+			t.Errorf("GET with If-None-Match %s = %d, want %d", tt.ifNoneMatch, w.Code, tt.want)
+		}
+		if etag := w.Header().Get("ETag"); etag != `"1"` {
+			t.Errorf("GET with If-None-Match %s: ETag = %s, want \"1\"", tt.ifNoneMatch, etag)
+		}
+		if tt.want == http.StatusNotModified && w.Body.Len() != 0 {
+			t.Errorf("304 has a body: %s", w.Body)
+		}
+	}
+}
+
+func TestETagChangesAfterPatch(t *testing.T) {
+	s := newTestServer(t, nil)
+	createAda(t, s)
+
+	w := serve(s, http.MethodGet, "/contacts/1", "")
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || etag != `"1"` {
+		t.Fatalf("GET = %d with ETag %s, want 200 with \"1\"", w.Code, etag)
+	}
+
+	w = serve(s, http.MethodPatch, "/contacts/1", `{"title": "Countess"}`, "Content-Type", mediaMergePatch, "If-Match", etag)
+	patched := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || patched != `"2"` {
+		t.Fatalf("PATCH = %d with ETag %s, want 200 with \"2\": %s", w.Code, patched, w.Body)
+	}
+
+	// the old tag no longer matches, the new one does
+	w = serve(s, http.MethodGet, "/contacts/1", "", "If-None-Match", etag)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != patched {
+		t.Errorf("GET with the old ETag = %d with ETag %s, want 200 with %s", w.Code, w.Header().Get("ETag"), patched)
+	}
+	if w = serve(s, http.MethodGet, "/contacts/1", "", "If-None-Match", patched); w.Code != http.StatusNotModified {
+		t.Errorf("GET with the new ETag = %d, want 304", w.Code)
+	}
+	w = serve(s, http.MethodPatch, "/contacts/1", `{"title": "Lady"}`, "Content-Type", mediaMergePatch, "If-Match", etag)
+	assertProblem(t, w, problemPreconditionFailed)
+}
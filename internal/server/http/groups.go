@@ -0,0 +1,173 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"golang/internal/models"
+)
+
+func (s *Server) handleListGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := s.service.GroupService.List(r.Context())
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+	if groups == nil {
+		groups = []models.Group{}
+	}
+	respondJSON(w, http.StatusOK, groups)
+}
+
+func (s *Server) handleGetGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	group, err := s.service.GroupService.GetByID(r.Context(), id)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, group)
+}
+
+func (s *Server) handleCreateGroup(w http.ResponseWriter, r *http.Request) {
+	var group models.Group
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		respondProblem(w, r, problemBadRequest, "The body is not a valid group JSON object.")
+		return
+	}
+
+	created, err := s.service.GroupService.Create(r.Context(), group)
+	if err != nil {
+		respondGroupError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, created)
+}
+
+func (s *Server) handleUpdateGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	var group models.Group
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		respondProblem(w, r, problemBadRequest, "The body is not a valid group JSON object.")
+		return
+	}
+	group.ID = id
+
+	updated, err := s.service.GroupService.Update(r.Context(), group)
+	if err != nil {
+		respondGroupError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, updated)
+}
+
+func (s *Server) handleDeleteGroup(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.service.GroupService.Delete(r.Context(), id); err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Group deleted"})
+}
+
+func (s *Server) handleListMembers(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	contacts, err := s.service.GroupService.Members(r.Context(), id)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+	if contacts == nil {
+		contacts = []models.Contact{}
+	}
+	respondJSON(w, http.StatusOK, contacts)
+}
+
+func (s *Server) handleAddMember(w http.ResponseWriter, r *http.Request) {
+	id, contactID, ok := parseMembership(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.service.GroupService.AddMember(r.Context(), id, contactID); err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Member added"})
+}
+
+func (s *Server) handleRemoveMember(w http.ResponseWriter, r *http.Request) {
+	id, contactID, ok := parseMembership(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.service.GroupService.RemoveMember(r.Context(), id, contactID); err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Member removed"})
+}
+
+func (s *Server) handleContactGroups(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	groups, err := s.service.GroupService.ContactGroups(r.Context(), id)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+	if groups == nil {
+		groups = []models.Group{}
+	}
+	respondJSON(w, http.StatusOK, groups)
+}
+
+// parseMembership reads the {id} and {contactID} URL parameters of
+// /groups/{id}/members/{contactID}
+func parseMembership(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return 0, 0, false
+	}
+	contactID, err := strconv.Atoi(chi.URLParam(r, "contactID"))
+	if err != nil {
+		respondProblem(w, r, problemBadRequest, "The contact ID in the path must be an integer.")
+		return 0, 0, false
+	}
+	return id, contactID, true
+}
+
+// respondGroupError reports invalid groups as such, and any other error
+// like respondServiceError
+func respondGroupError(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *models.ValidationError
+	if errors.As(err, &verr) {
+		respondProblem(w, r, problemInvalidGroup, "One or more fields are invalid.", verr.Fields...)
+		return
+	}
+	respondServiceError(w, r, err)
+}
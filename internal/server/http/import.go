@@ -0,0 +1,90 @@
+package http
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"golang/internal/models"
+	"golang/internal/service"
+	"golang/internal/utils/contactcsv"
+	"golang/internal/utils/vcard"
+)
+
+// maxImportSize bounds the body of an import
+const maxImportSize = 32 << 20
+
+// mediaCSV is the media type of CSV imports and error reports
+const mediaCSV = "text/csv"
+
+// handleImport adds the contacts of a vCard or CSV file, with the options of
+// the query (mode, dry_run, and for CSV preset and map). Records that fail
+// are listed in the result and do not stop the others; Accept: text/csv asks
+// for them as a CSV error report instead.
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	opts := models.ImportOptions{Mode: models.ImportMode(params.Get("mode"))}
+	if v := params.Get("dry_run"); v != "" {
+		dryRun, err := strconv.ParseBool(v)
+		if err != nil {
+			respondProblem(w, r, problemBadRequest, "dry_run must be true or false.")
+			return
+		}
+		opts.DryRun = dryRun
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	var dec service.ContactDecoder
+	var header []string
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case slices.Contains(vcard.MediaTypes, mediaType):
+		dec = vcard.NewDecoder(body)
+	case mediaType == mediaCSV:
+		mapping, err := contactcsv.NewMapping(params.Get("preset"), params["map"])
+		if err != nil {
+			respondServiceError(w, r, err)
+			return
+		}
+		csvDec, err := contactcsv.NewDecoder(body, mapping)
+		if err != nil {
+			respondImportError(w, r, err)
+			return
+		}
+		dec, header = csvDec, csvDec.Header()
+	default:
+		respondProblem(w, r, problemUnsupportedMediaType, "POST /contacts/import accepts "+vcard.MediaType+" and "+mediaCSV+".")
+		return
+	}
+
+	result, err := s.service.ContactService.Import(r.Context(), dec, opts)
+	if err != nil {
+		respondImportError(w, r, err)
+		return
+	}
+
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Accept")); mediaType == mediaCSV {
+		w.Header().Set("Content-Type", mediaCSV+"; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="import-errors.csv"`)
+		w.WriteHeader(http.StatusOK)
+		contactcsv.WriteReport(w, header, result.Failed)
+		return
+	}
+	if result.Failed == nil {
+		result.Failed = []models.ImportFailure{}
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+// respondImportError reports a failed import, telling apart a body that is
+// too large
+func respondImportError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondProblem(w, r, problemBadRequest, "The file exceeds "+strconv.Itoa(maxImportSize>>20)+" MiB; split it and import the parts.")
+		return
+	}
+	respondServiceError(w, r, err)
+}
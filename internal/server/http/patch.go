@@ -0,0 +1,106 @@
+package http
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"golang/internal/models"
+)
+
+// Media types accepted by PATCH /contacts/{id}
+const (
+	mediaMergePatch = "application/merge-patch+json" // RFC 7396
+	mediaJSONPatch  = "application/json-patch+json"  // RFC 6902
+)
+
+// acceptPatch is advertised when a PATCH uses another media type
+const acceptPatch = mediaMergePatch + ", " + mediaJSONPatch
+
+// maxPatchSize bounds the body of a PATCH request
+const maxPatchSize = 1 << 20
+
+func (s *Server) handlePatch(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != mediaMergePatch && mediaType != mediaJSONPatch && mediaType != "application/json" {
+		w.Header().Set("Accept-Patch", acceptPatch)
+		respondProblem(w, r, problemUnsupportedMediaType, "PATCH accepts "+acceptPatch+".")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPatchSize))
+	if err != nil {
+		respondProblem(w, r, problemBadRequest, "The body could not be read.")
+		return
+	}
+
+	version, ok := s.expectedVersion(w, r, id)
+	if !ok {
+		return
+	}
+
+	var patch models.ContactPatch
+	if mediaType == mediaJSONPatch {
+		// JSON Patch operations depend on the current state, so they are
+		// evaluated against the contact at the version the update applies to
+		current, err := s.service.ContactService.GetByID(r.Context(), id)
+		if err != nil {
+			respondServiceError(w, r, err)
+			return
+		}
+		if version != 0 && version != current.Version {
+			respondServiceError(w, r, models.ErrVersionConflict)
+			return
+		}
+		version = current.Version
+		patch, err = models.ApplyJSONPatch(body, *current)
+		if err != nil {
+			respondServiceError(w, r, err)
+			return
+		}
+	} else {
+		var bodyVersion int
+		var clearFields bool
+		patch, bodyVersion, clearFields, err = models.ParseMergePatch(body, id)
+		if err != nil {
+			respondServiceError(w, r, err)
+			return
+		}
+		// If-Match takes precedence over a version sent in the body
+		if version == 0 {
+			version = bodyVersion
+		}
+		if clearFields {
+			// removing every custom field needs to know which ones exist
+			current, err := s.service.ContactService.GetByID(r.Context(), id)
+			if err != nil {
+				respondServiceError(w, r, err)
+				return
+			}
+			if version != 0 && version != current.Version {
+				respondServiceError(w, r, models.ErrVersionConflict)
+				return
+			}
+			version = current.Version
+			for key := range current.CustomFields {
+				if _, ok := patch.CustomFields[key]; !ok {
+					patch.CustomFields[key] = nil
+				}
+			}
+		}
+	}
+
+	updated, err := s.service.ContactService.Patch(r.Context(), id, version, patch)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", contactETag(updated))
+	respondJSON(w, http.StatusOK, updated)
+}
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"golang/internal/models"
+)
+
+func TestPatchContentTypes(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantTitle   string
+	}{
+		{"MergePatch", mediaMergePatch, `{"title": "Countess"}`, "Countess"},
+		{"MergePatchWithCharset", mediaMergePatch + "; charset=utf-8", `{"title": "Countess"}`, "Countess"},
+		{"JSONPatch", mediaJSONPatch, `[{"op": "test", "path": "/first_name", "value": "Ada"}, {"op": "add", "path": "/title", "value": "Countess"}]`, "Countess"},
+		// plain JSON is read as a merge patch
+		{"PlainJSON", "application/json", `{"title": "Countess"}`, "Countess"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, nil)
+			createAda(t, s)
+
+			w := serve(s, http.MethodPatch, "/contacts/1", tt.body, "Content-Type", tt.contentType)
+			if w.Code != http.StatusOK {
+				t.Fatalf("PATCH = %d, want 200: %s", w.Code, w.Body)
+			}
+			var c models.Contact
+			decodeBody(t, w, &c)
+			if c.Title != tt.wantTitle || c.FirstName != "Ada" || c.Email != "ada@example.com" || c.Version != 2 {
+				t.Errorf("patched contact = %+v, want Ada titled %s at version 2", c, tt.wantTitle)
+			}
+			if etag := w.Header().Get("ETag"); etag != `"2"` {
+				t.Errorf("ETag = %s, want \"2\"", etag)
+			}
+		})
+	}
+}
+
+func TestPatchRefuses(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        problemType
+	}{
+		{"NoContentType", "", `{"title": "Countess"}`, problemUnsupportedMediaType},
+		{"TextPlain", "text/plain", `{"title": "Countess"}`, problemUnsupportedMediaType},
+		{"XML", "application/xml", `<title>Countess</title>`, problemUnsupportedMediaType},
+		{"FailedTest", mediaJSONPatch, `[{"op": "test", "path": "/first_name", "value": "Grace"}, {"op": "add", "path": "/title", "value": "Countess"}]`, problemConflict},
+		{"UnknownOp", mediaJSONPatch, `[{"op": "rename", "path": "/title"}]`, problemBadRequest},
+		{"MergePatchAsJSONPatch", mediaJSONPatch, `{"title": "Countess"}`, problemBadRequest},
+		{"JSONPatchAsMergePatch", mediaMergePatch, `[{"op": "add", "path": "/title", "value": "Countess"}]`, problemBadRequest},
+		{"InvalidField", mediaMergePatch, `{"email": "ada"}`, problemInvalidContact},
+		{"InvalidFieldByJSONPatch", mediaJSONPatch, `[{"op": "replace", "path": "/email", "value": "ada"}]`, problemInvalidContact},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t, nil)
+			createAda(t, s)
+
+			w := serve(s, http.MethodPatch, "/contacts/1", tt.body, "Content-Type", tt.contentType)
+			assertProblem(t, w, tt.want)
+			accept := w.Header().Get("Accept-Patch")
+			if tt.want == problemUnsupportedMediaType && accept != acceptPatch {
+				t.Errorf("Accept-Patch = %q, want %q", accept, acceptPatch)
+			}
+			if tt.want != problemUnsupportedMediaType && accept != "" {
+				t.Errorf("Accept-Patch = %q, want none", accept)
+			}
+
+			// the contact is left as it was
+			if w := serve(s, http.MethodGet, "/contacts/1", "", "If-None-Match", `"1"`); w.Code != http.StatusNotModified {
+				t.Errorf("GET after a refused PATCH = %d, want 304", w.Code)
+			}
+		})
+	}
+}
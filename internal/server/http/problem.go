@@ -0,0 +1,149 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"golang/internal/models"
+)
+
+// problemBaseURI prefixes the type of every problem; the types are listed
+// in the README
+const problemBaseURI = "/problems/"
+
+// Problem is an RFC 7807 problem details object
+type Problem struct {
+	Type      string              `json:"type"`
+	Title     string              `json:"title"`
+	Status    int                 `json:"status"`
+	Detail    string              `json:"detail,omitempty"`
+	Instance  string              `json:"instance,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+	Errors    []models.FieldError `json:"errors,omitempty"`
+}
+
+// problemType is one kind of problem the API reports
+type problemType struct {
+	slug   string
+	title  string
+	status int
+}
+
+var (
+	problemBadRequest           = problemType{"bad-request", "Invalid request", http.StatusBadRequest}
+	problemInvalidContact       = problemType{"invalid-contact", "Invalid contact", http.StatusUnprocessableEntity}
+	problemInvalidGroup         = problemType{"invalid-group", "Invalid group", http.StatusUnprocessableEntity}
+	problemUnauthorized         = problemType{"unauthorized", "Authentication required", http.StatusUnauthorized}
+	problemForbidden            = problemType{"forbidden", "Forbidden", http.StatusForbidden}
+	problemNotFound             = problemType{"not-found", "Resource not found", http.StatusNotFound}
+	problemMethodNotAllowed     = problemType{"method-not-allowed", "Method not allowed", http.StatusMethodNotAllowed}
+	problemConflict             = problemType{"conflict", "Conflict", http.StatusConflict}
+	problemUnsupportedMediaType = problemType{"unsupported-media-type", "Unsupported media type", http.StatusUnsupportedMediaType}
+	problemPreconditionFailed   = problemType{"precondition-failed", "Precondition failed", http.StatusPreconditionFailed}
+	problemUnavailable          = problemType{"unavailable", "Service temporarily unavailable", http.StatusServiceUnavailable}
+	problemInternal             = problemType{"internal", "Internal server error", http.StatusInternalServerError}
+)
+
+// respondProblem writes a problem of type pt about the current request
+func respondProblem(w http.ResponseWriter, r *http.Request, pt problemType, detail string, fields ...models.FieldError) {
+	problem := Problem{
+		Type:      problemBaseURI + pt.slug,
+		Title:     pt.title,
+		Status:    pt.status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: middleware.GetReqID(r.Context()),
+		Errors:    fields,
+	}
+	response, _ := json.Marshal(problem)
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(pt.status)
+	w.Write(response)
+}
+
+// respondServiceError is the single mapping from domain errors to problems.
+// Only the messages our layers attach to a domain error are echoed back;
+// anything else is logged and reported without details.
+func respondServiceError(w http.ResponseWriter, r *http.Request, err error) {
+	var verr *models.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		respondProblem(w, r, problemInvalidContact, "One or more fields are invalid.", verr.Fields...)
+	case errors.Is(err, models.ErrUnauthenticated):
+		respondProblem(w, r, problemUnauthorized, publicDetail(err, models.ErrUnauthenticated))
+	case errors.Is(err, models.ErrForbidden):
+		respondProblem(w, r, problemForbidden, publicDetail(err, models.ErrForbidden))
+	case errors.Is(err, models.ErrNotFound):
+		detail := "The resource does not exist."
+		if what := publicDetail(err, models.ErrNotFound); what != "" {
+			detail = "No " + what + " exists."
+		}
+		respondProblem(w, r, problemNotFound, detail)
+	case errors.Is(err, models.ErrVersionConflict):
+		respondProblem(w, r, problemPreconditionFailed, "The contact was modified since the version you sent; fetch it again and retry.")
+	case errors.Is(err, models.ErrConflict):
+		respondProblem(w, r, problemConflict, publicDetail(err, models.ErrConflict))
+	case errors.Is(err, models.ErrValidation):
+		respondProblem(w, r, problemBadRequest, publicDetail(err, models.ErrValidation))
+	case errors.Is(err, models.ErrUnavailable):
+		log.Printf("Error [%s]: %v", middleware.GetReqID(r.Context()), err)
+		respondProblem(w, r, problemUnavailable, "The storage is unavailable, please retry later.")
+	default:
+		log.Printf("Error [%s]: %v", middleware.GetReqID(r.Context()), err)
+		respondProblem(w, r, problemInternal, "")
+	}
+}
+
+// publicDetail returns the message a layer attached to sentinel when it
+// wrapped it as "sentinel: message", skipping the context added by callers
+// on the way up (e.g. "failed to update contact: ...")
+func publicDetail(err, sentinel error) string {
+	prefix := sentinel.Error() + ": "
+	for ; err != nil; err = errors.Unwrap(err) {
+		if msg, ok := strings.CutPrefix(err.Error(), prefix); ok {
+			return msg
+		}
+	}
+	return ""
+}
+
+// requestIDHeader echoes the ID set by middleware.RequestID so that clients
+// can quote it when reporting a problem
+func requestIDHeader(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(middleware.RequestIDHeader, middleware.GetReqID(r.Context()))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recoverer turns a panicking handler into an internal-error problem
+func recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			log.Printf("Panic [%s]: %v\n%s", middleware.GetReqID(r.Context()), rec, debug.Stack())
+			respondProblem(w, r, problemInternal, "")
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+func handleNotFound(w http.ResponseWriter, r *http.Request) {
+	respondProblem(w, r, problemNotFound, "No route matches "+r.URL.Path+".")
+}
+
+func handleMethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	respondProblem(w, r, problemMethodNotAllowed, r.Method+" is not supported on "+r.URL.Path+".")
+}
@@ -0,0 +1,123 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/go-chi/chi/v5/middleware"
+
+	"golang/internal/models"
+)
+
+func TestRespondServiceError(t *testing.T) {
+	fields := []models.FieldError{
+		{Field: "email", Message: "is not a valid email address"},
+		{Field: "phones[0].number", Message: "is required"},
+	}
+	tests := []struct {
+		name   string
+		err    error
+		want   problemType
+		detail string
+		fields []models.FieldError
+	}{
+		{"Validation", fmt.Errorf("failed to create contact: %w", &models.ValidationError{Fields: fields}),
+			problemInvalidContact, "One or more fields are invalid.", fields},
+		{"Unauthenticated", fmt.Errorf("%w: unknown API key", models.ErrUnauthenticated),
+			problemUnauthorized, "unknown API key", nil},
+		{"Forbidden", fmt.Errorf("%w: viewers cannot create contacts", models.ErrForbidden),
+			problemForbidden, "viewers cannot create contacts", nil},
+		{"NotFound", fmt.Errorf("failed to get contact: %w", fmt.Errorf("%w: contact 7", models.ErrNotFound)),
+			problemNotFound, "No contact 7 exists.", nil},
+		{"NotFoundBare", models.ErrNotFound, problemNotFound, "The resource does not exist.", nil},
+		{"VersionConflict", fmt.Errorf("failed to update contact: %w", models.ErrVersionConflict),
+			problemPreconditionFailed, "The contact was modified since the version you sent; fetch it again and retry.", nil},
+		{"Conflict", fmt.Errorf("failed to update contact: %w", fmt.Errorf("%w: email ada@example.com is taken", models.ErrConflict)),
+			problemConflict, "email ada@example.com is taken", nil},
+		{"BadRequest", fmt.Errorf("%w: limit must be an integer", models.ErrValidation),
+			problemBadRequest, "limit must be an integer", nil},
+		// the causes of these are logged, never sent
+		{"Unavailable", fmt.Errorf("%w: dial tcp 10.0.0.7:5432: connection refused", models.ErrUnavailable),
+			problemUnavailable, "The storage is unavailable, please retry later.", nil},
+		{"Unexpected", errors.New("open /var/lib/contacts/secret.json: permission denied"), problemInternal, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/contacts/7?x=1", nil)
+			r = r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, "host/abc-000001"))
+			w := httptest.NewRecorder()
+			respondServiceError(w, r, tt.err)
+
+			got := assertProblem(t, w, tt.want)
+			want := Problem{
+				Type:      problemBaseURI + tt.want.slug,
+				Title:     tt.want.title,
+				Status:    tt.want.status,
+				Detail:    tt.detail,
+				Instance:  "/contacts/7",
+				RequestID: "host/abc-000001",
+				Errors:    tt.fields,
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("problem = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+// TestProblemThroughServer checks the problems of requests going through
+// the middleware, which sets and echoes their request ID
+func TestProblemThroughServer(t *testing.T) {
+	s := newTestServer(t, nil)
+	createAda(t, s)
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		want         problemType
+		fields       []string
+	}{
+		{"InvalidFields", http.MethodPost, "/contacts", `{"first_name": "", "last_name": "Hopper", "email": "grace"}`,
+			problemInvalidContact, []string{"first_name", "email"}},
+		{"EmailTaken", http.MethodPost, "/contacts", adaJSON, problemConflict, nil},
+		{"MalformedBody", http.MethodPost, "/contacts", `{`, problemBadRequest, nil},
+		{"BadID", http.MethodGet, "/contacts/abc", "", problemBadRequest, nil},
+		{"MissingContact", http.MethodGet, "/contacts/9", "", problemNotFound, nil},
+		{"NoRoute", http.MethodGet, "/nowhere", "", problemNotFound, nil},
+		{"NoMethod", http.MethodPost, "/contacts/1", "", problemMethodNotAllowed, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(s, tt.method, tt.path, tt.body)
+			p := assertProblem(t, w, tt.want)
+			if p.Instance != tt.path {
+				t.Errorf("instance = %q, want %q", p.Instance, tt.path)
+			}
+			if p.RequestID == "" || w.Header().Get(middleware.RequestIDHeader) != p.RequestID {
+				t.Errorf("request_id = %q, %s header = %q, want the same ID", p.RequestID,
+					middleware.RequestIDHeader, w.Header().Get(middleware.RequestIDHeader))
+			}
+			var got []string
+			for _, f := range p.Errors {
+				got = append(got, f.Field)
+			}
+			if !reflect.DeepEqual(got, tt.fields) {
+				t.Errorf("invalid fields = %v, want %v", got, tt.fields)
+			}
+		})
+	}
+
+	// the ID a client sends is kept
+	w := serve(s, http.MethodGet, "/contacts/9", "", middleware.RequestIDHeader, "client-42")
+	p := assertProblem(t, w, problemNotFound)
+	if p.RequestID != "client-42" || w.Header().Get(middleware.RequestIDHeader) != "client-42" {
+		t.Errorf("request_id = %q, %s header = %q, want client-42", p.RequestID,
+			middleware.RequestIDHeader, w.Header().Get(middleware.RequestIDHeader))
+	}
+}
@@ -2,13 +2,15 @@ package http
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 
+	"golang/internal/auth"
 	"golang/internal/models"
 	"golang/internal/service"
 )
@@ -19,23 +21,54 @@ type Server struct {
 	service *service.Service
 }
 
-func NewServer(svc *service.Service) *Server {
+// NewServer routes the API. Every route but /health requires the credentials
+// checked by authn; a nil authn disables authentication.
+func NewServer(svc *service.Service, authn *auth.Authenticator) *Server {
 	s := &Server{
 		router:  chi.NewRouter(),
 		service: svc,
 	}
 
 	// Middleware
+	s.router.Use(middleware.RequestID)
+	s.router.Use(requestIDHeader)
 	s.router.Use(middleware.Logger)
-	s.router.Use(middleware.Recoverer)
+	s.router.Use(recoverer)
+	s.router.NotFound(handleNotFound)
+	s.router.MethodNotAllowed(handleMethodNotAllowed)
 
 	// Routes
 	s.router.Get("/health", s.handleHealth)
-	s.router.Get("/contacts", s.handleGetAll)
-	s.router.Get("/contacts/{id}", s.handleGetByID)
-	s.router.Post("/contacts", s.handleCreate)
-	s.router.Put("/contacts/{id}", s.handleUpdate)
-	s.router.Delete("/contacts/{id}", s.handleDelete)
+	s.router.Group(func(r chi.Router) {
+		if authn != nil {
+			r.Use(authenticate(authn))
+		} else {
+			r.Use(actorMiddleware)
+		}
+		r.Get("/contacts", s.handleGetAll)
+		r.Get("/contacts/search", s.handleSearch)
+		r.Get("/contacts/duplicates", s.handleDuplicates)
+		r.Get("/contacts/export", s.handleExport)
+		r.Get("/contacts/{id}", s.handleGetByID)
+		r.Get("/contacts/{id}/history", s.handleHistory)
+		r.Get("/contacts/{id}/groups", s.handleContactGroups)
+		r.Post("/contacts", s.handleCreate)
+		r.Post("/contacts/import", s.handleImport)
+		r.Post("/contacts/{id}/merge", s.handleMerge)
+		r.Put("/contacts/{id}", s.handleUpdate)
+		r.Patch("/contacts/{id}", s.handlePatch)
+		r.Delete("/contacts/{id}", s.handleDelete)
+		r.Get("/groups", s.handleListGroups)
+		r.Get("/groups/{id}", s.handleGetGroup)
+		r.Post("/groups", s.handleCreateGroup)
+		r.Put("/groups/{id}", s.handleUpdateGroup)
+		r.Delete("/groups/{id}", s.handleDeleteGroup)
+		r.Get("/groups/{id}/members", s.handleListMembers)
+		r.Put("/groups/{id}/members/{contactID}", s.handleAddMember)
+		r.Delete("/groups/{id}/members/{contactID}", s.handleRemoveMember)
+		r.Get("/outbox/dead", s.handleDeadLetters)
+		r.Post("/outbox/{id}/replay", s.handleReplay)
+	})
 
 	return s
 }
@@ -44,75 +77,192 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.router.ServeHTTP(w, r)
 }
 
+// Walk calls fn for every route of the API
+func (s *Server) Walk(fn func(method, route string)) {
+	chi.Walk(s.router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		fn(method, route)
+		return nil
+	})
+}
+
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, http.StatusOK, map[string]string{"status": "healthy"})
 }
 
 func (s *Server) handleGetAll(w http.ResponseWriter, r *http.Request) {
-	contacts, err := s.service.ContactService.GetAll(r.Context())
+	query, err := parseContactQuery(r)
 	if err != nil {
-		log.Printf("Error: %v", err)
-		respondError(w, http.StatusInternalServerError, "Failed to fetch contacts")
+		respondServiceError(w, r, err)
 		return
 	}
-	respondJSON(w, http.StatusOK, contacts)
+
+	page, err := s.service.ContactService.List(r.Context(), query)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+	// the body stays a plain array; the next page is linked in the header
+	if page.NextCursor != "" {
+		w.Header().Set("Link", nextPageLink(r, page.NextCursor))
+	}
+	if page.Contacts == nil {
+		page.Contacts = []models.Contact{}
+	}
+	respondJSON(w, http.StatusOK, page.Contacts)
 }
 
 func (s *Server) handleGetByID(w http.ResponseWriter, r *http.Request) {
-	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
 	contact, err := s.service.ContactService.GetByID(r.Context(), id)
 	if err != nil {
-		respondError(w, http.StatusNotFound, "Contact not found")
+		respondServiceError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", contactETag(contact))
+	w.Header().Set("Vary", "Accept")
+	if notModified(r, contact) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if version, ok := acceptsVCard(r); ok {
+		respondVCard(w, contact, version)
 		return
 	}
 	respondJSON(w, http.StatusOK, contact)
 }
 
+// handleSearch answers with the best matches of ?q=, in the same shape as the
+// contact list but without a next page
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	search, err := parseContactSearch(r)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+
+	contacts, err := s.service.ContactService.Search(r.Context(), search)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+	if contacts == nil {
+		contacts = []models.Contact{}
+	}
+	respondJSON(w, http.StatusOK, contacts)
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := s.service.ContactService.History(r.Context(), id)
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, entries)
+}
+
 func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
 	var contact models.Contact
 	if err := json.NewDecoder(r.Body).Decode(&contact); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request")
+		respondProblem(w, r, problemBadRequest, "The body is not a valid contact JSON object.")
 		return
 	}
 
 	created, err := s.service.ContactService.Create(r.Context(), contact)
 	if err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to create contact")
+		respondServiceError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", contactETag(created))
 	respondJSON(w, http.StatusCreated, created)
 }
 
 func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
-	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
 
 	var contact models.Contact
 	if err := json.NewDecoder(r.Body).Decode(&contact); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request")
+		respondProblem(w, r, problemBadRequest, "The body is not a valid contact JSON object.")
 		return
 	}
 	contact.ID = id
 
-	if err := s.service.ContactService.UpdateAndNotify(r.Context(), contact); err != nil {
-		respondError(w, http.StatusInternalServerError, err.Error())
+	// If-Match takes precedence over a version sent in the body
+	version, ok := s.expectedVersion(w, r, id)
+	if !ok {
+		return
+	}
+	if version != 0 {
+		contact.Version = version
+	}
+
+	updated, err := s.service.ContactService.UpdateAndNotify(r.Context(), contact)
+	if err != nil {
+		respondServiceError(w, r, err)
 		return
 	}
 
+	w.Header().Set("ETag", contactETag(updated))
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Contact updated"})
 }
 
 func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
-	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	version, ok := s.expectedVersion(w, r, id)
+	if !ok {
+		return
+	}
 
-	if err := s.service.ContactService.Delete(r.Context(), id); err != nil {
-		respondError(w, http.StatusInternalServerError, "Failed to delete contact")
+	if err := s.service.ContactService.Delete(r.Context(), id, version); err != nil {
+		respondServiceError(w, r, err)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{"message": "Contact deleted"})
 }
 
+func (s *Server) handleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	msgs, err := s.service.OutboxService.DeadLetters(r.Context())
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+	if msgs == nil {
+		msgs = []models.OutboxMessage{}
+	}
+	respondJSON(w, http.StatusOK, msgs)
+}
+
+func (s *Server) handleReplay(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := s.service.OutboxService.Replay(r.Context(), id); err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Message requeued"})
+}
+
 func respondJSON(w http.ResponseWriter, code int, payload interface{}) {
 	response, _ := json.Marshal(payload)
 	w.Header().Set("Content-Type", "application/json")
@@ -120,6 +270,58 @@ func respondJSON(w http.ResponseWriter, code int, payload interface{}) {
 	w.Write(response)
 }
 
-func respondError(w http.ResponseWriter, code int, message string) {
-	respondJSON(w, code, map[string]string{"error": message})
+// parseContactQuery builds a listing query from the URL, e.g.
+// /contacts?limit=20&sort=-last_name&email=bob&tag=vip&tag=client&cursor=...
+func parseContactQuery(r *http.Request) (models.ContactQuery, error) {
+	params := r.URL.Query()
+	query := models.ContactQuery{
+		Cursor:          params.Get("cursor"),
+		FirstNamePrefix: params.Get("first_name"),
+		LastNamePrefix:  params.Get("last_name"),
+		EmailPrefix:     params.Get("email"),
+		Tags:            params["tag"],
+	}
+	query.SetSort(params.Get("sort"))
+
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return query, fmt.Errorf("%w: limit must be an integer", models.ErrValidation)
+		}
+		query.Limit = n
+	}
+	return query, nil
+}
+
+// nextPageLink builds the RFC 8288 Link header value pointing to the page
+// after r, which keeps every parameter of r but the cursor
+func nextPageLink(r *http.Request, cursor string) string {
+	params := r.URL.Query()
+	params.Set("cursor", cursor)
+	next := url.URL{Path: r.URL.Path, RawQuery: params.Encode()}
+	return fmt.Sprintf(`<%s>; rel="next"`, next.String())
+}
+
+// parseContactSearch reads the q and limit search parameters
+func parseContactSearch(r *http.Request) (models.ContactSearch, error) {
+	params := r.URL.Query()
+	search := models.ContactSearch{Text: params.Get("q")}
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return search, fmt.Errorf("%w: limit must be an integer", models.ErrValidation)
+		}
+		search.Limit = n
+	}
+	return search, nil
+}
+
+// parseID reads the {id} URL parameter, answering 400 when it is not a number
+func parseID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		respondProblem(w, r, problemBadRequest, "The ID in the path must be an integer.")
+		return 0, false
+	}
+	return id, true
 }
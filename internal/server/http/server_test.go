@@ -0,0 +1,79 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang/internal/auth"
+	"golang/internal/config"
+	"golang/internal/models"
+	"golang/internal/service"
+	"golang/internal/store/filestore"
+)
+
+// newTestServer returns a server on a fresh file store; a nil authn
+// disables authentication
+func newTestServer(t *testing.T, authn *auth.Authenticator) *Server {
+	t.Helper()
+	store, err := filestore.NewStorage(filepath.Join(t.TempDir(), "contacts.json"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return NewServer(service.NewService(store, nil, config.OutboxConfig{}), authn)
+}
+
+// serve sends a request with the given body and headers, given as name and
+// value pairs, to s and records the response
+func serve(s http.Handler, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, r)
+	return w
+}
+
+const adaJSON = `{"first_name": "Ada", "last_name": "Lovelace", "email": "ada@example.com"}`
+
+// createAda creates a contact through the API, sending the given headers,
+// and returns it
+func createAda(t *testing.T, s http.Handler, header ...string) models.Contact {
+	t.Helper()
+	w := serve(s, http.MethodPost, "/contacts", adaJSON, header...)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /contacts = %d %s", w.Code, w.Body)
+	}
+	var c models.Contact
+	decodeBody(t, w, &c)
+	return c
+}
+
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder, v any) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %s: %v", w.Body, err)
+	}
+}
+
+// assertProblem checks that w is a problem of type pt
+func assertProblem(t *testing.T, w *httptest.ResponseRecorder, pt problemType) Problem {
+	t.Helper()
+	if w.Code != pt.status {
+		t.Errorf("status = %d, want %d: %s", w.Code, pt.status, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Content-Type = %q, want application/problem+json", ct)
+	}
+	var p Problem
+	decodeBody(t, w, &p)
+	if p.Type != problemBaseURI+pt.slug || p.Title != pt.title || p.Status != pt.status {
+		t.Errorf("problem = %+v, want type %s", p, pt.slug)
+	}
+	return p
+}
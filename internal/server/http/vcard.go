@@ -0,0 +1,81 @@
+package http
+
+import (
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang/internal/models"
+	"golang/internal/utils/vcard"
+)
+
+// handleExport answers with every contact of the tenant as a vCard file, in
+// the version asked by ?version= or the Accept header, 3.0 by default
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	version, ok := vcard.V3, true
+	if v := r.URL.Query().Get("version"); v != "" {
+		version, ok = vcard.ParseVersion(v)
+	} else if accepted, vcf := acceptsVCard(r); vcf {
+		version = accepted
+	}
+	if !ok {
+		respondProblem(w, r, problemBadRequest, "version must be 3.0 or 4.0.")
+		return
+	}
+
+	contacts, err := s.service.ContactService.GetAll(r.Context())
+	if err != nil {
+		respondServiceError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", vcard.MediaType+"; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="contacts.vcf"`)
+	w.WriteHeader(http.StatusOK)
+	enc := vcard.NewEncoder(w, version)
+	for _, c := range contacts {
+		if err := enc.Encode(c); err != nil {
+			return // the client went away
+		}
+	}
+}
+
+// respondVCard writes a contact as a single card
+func respondVCard(w http.ResponseWriter, c *models.Contact, version vcard.Version) {
+	w.Header().Set("Content-Type", vcard.MediaType+"; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	vcard.NewEncoder(w, version).Encode(*c)
+}
+
+// acceptsVCard tells whether the Accept header prefers a vCard to JSON, and
+// in which version: its version parameter, 3.0 by default. Between equal
+// weights the media range listed first wins; */* stands for JSON.
+func acceptsVCard(r *http.Request) (vcard.Version, bool) {
+	best, bestQ, vcf := vcard.V3, 0.0, false
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(accepted)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, err := strconv.ParseFloat(params["q"], 64); err == nil {
+			q = v
+		}
+		if q <= bestQ {
+			continue
+		}
+		switch {
+		case slices.Contains(vcard.MediaTypes, mediaType):
+			version, ok := vcard.ParseVersion(params["version"])
+			if !ok {
+				version = vcard.V3
+			}
+			best, bestQ, vcf = version, q, true
+		case mediaType == "application/json" || mediaType == "application/*" || mediaType == "*/*":
+			bestQ, vcf = q, false
+		}
+	}
+	return best, vcf
+}
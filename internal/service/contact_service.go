@@ -2,85 +2,427 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"html"
+	"io"
 	"log"
-	"strings"
+	"time"
 
 	"golang/internal/models"
 	"golang/internal/store/interfaces"
-	"golang/internal/utils/messaging"
 )
 
 // ContactService handles business logic for contacts
+// Every operation is scoped to the tenant carried by ctx (see models.TenantFrom).
 type ContactService struct {
-	repo        interfaces.ContactRepositoryInterface
-	emailClient *messaging.EmailClient
+	repo    interfaces.ContactRepositoryInterface
+	tenants interfaces.TenantRepositoryInterface
+	outbox  interfaces.OutboxRepositoryInterface
+	audit   interfaces.AuditRepositoryInterface
+	tx      interfaces.TransactorInterface
+	policy  Policy
 }
 
-func NewContactService(repo interfaces.ContactRepositoryInterface, emailClient *messaging.EmailClient) *ContactService {
+func NewContactService(
+	repo interfaces.ContactRepositoryInterface,
+	tenants interfaces.TenantRepositoryInterface,
+	outbox interfaces.OutboxRepositoryInterface,
+	audit interfaces.AuditRepositoryInterface,
+	tx interfaces.TransactorInterface,
+	policy Policy,
+) *ContactService {
 	return &ContactService{
-		repo:        repo,
-		emailClient: emailClient,
+		repo:    repo,
+		tenants: tenants,
+		outbox:  outbox,
+		audit:   audit,
+		tx:      tx,
+		policy:  policy,
 	}
 }
 
 func (s *ContactService) GetAll(ctx context.Context) ([]models.Contact, error) {
-	return s.repo.GetAll(ctx)
+	if err := s.policy.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAll(ctx, models.TenantFrom(ctx))
+}
+
+// List returns one page of contacts matching the query's filters and sort order
+func (s *ContactService) List(ctx context.Context, query models.ContactQuery) (*models.ContactPage, error) {
+	if err := s.policy.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, models.TenantFrom(ctx), query)
+}
+
+// Search returns the contacts matching a free-text search, best first
+func (s *ContactService) Search(ctx context.Context, search models.ContactSearch) ([]models.Contact, error) {
+	if err := s.policy.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+	return s.repo.Search(ctx, models.TenantFrom(ctx), search)
 }
 
 func (s *ContactService) GetByID(ctx context.Context, id int) (*models.Contact, error) {
-	return s.repo.GetByID(ctx, id)
+	if err := s.policy.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, models.TenantFrom(ctx), id)
 }
 
 func (s *ContactService) Create(ctx context.Context, contact models.Contact) (*models.Contact, error) {
-	id, err := s.repo.Create(ctx, contact)
+	if err := s.policy.Authorize(ctx, ActionWrite); err != nil {
+		return nil, err
+	}
+	return s.create(ctx, contact)
+}
+
+// ContactDecoder reads the contacts of an import file one at a time, like
+// vcard.Decoder. Decode returns io.EOF after the last one; a record it
+// cannot read fails with an error matching models.ErrValidation, and the
+// next call goes on with the following record.
+type ContactDecoder interface {
+	Decode() (models.Contact, error)
+}
+
+// RecordDecoder is a ContactDecoder that knows where the last record came
+// from, like contactcsv.Decoder: its position in the file and its fields as
+// read, which import failures report instead of the record count
+type RecordDecoder interface {
+	ContactDecoder
+	Record() (position int, fields []string)
+}
+
+// Import adds every contact read from dec, each on its own as by Create. A
+// record whose email is taken is failed, skipped or merged into the contact
+// holding it, depending on opts.Mode. A record that cannot be read, is
+// invalid or conflicts with a contact is reported in the result and the
+// import goes on; any other error stops it and is returned with the result
+// so far. A dry run checks every record, including against the ones before
+// it in the file, without writing anything.
+func (s *ContactService) Import(ctx context.Context, dec ContactDecoder, opts models.ImportOptions) (*models.ImportResult, error) {
+	if err := s.policy.Authorize(ctx, ActionBulk); err != nil {
+		return nil, err
+	}
+	if err := opts.Normalize(); err != nil {
+		return nil, err
+	}
+	if _, err := s.tenants.GetByID(ctx, models.TenantFrom(ctx)); err != nil {
+		return nil, err
+	}
+
+	result := &models.ImportResult{DryRun: opts.DryRun}
+	imported := map[string]*models.Contact{} // by email, for dry runs
+	for record := 1; ; record++ {
+		contact, err := dec.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		position, fields := record, []string(nil)
+		if rd, ok := dec.(RecordDecoder); ok {
+			position, fields = rd.Record()
+		}
+
+		var change models.ImportChange
+		if err == nil {
+			change, err = s.importContact(ctx, contact, opts, imported)
+		}
+		switch {
+		case err == nil:
+			change.Record = position
+			result.Count(change)
+		case errors.Is(err, models.ErrValidation) || errors.Is(err, models.ErrConflict):
+			result.Fail(position, fields, err)
+		default:
+			return result, err
+		}
+	}
+
+	log.Printf("Service: Imported contacts (dry run: %t): %d created, %d updated, %d skipped, %d failed",
+		opts.DryRun, result.Created, result.Updated, result.Skipped, len(result.Failed))
+	return result, nil
+}
+
+// importContact adds one imported contact, or tells what it would do on a
+// dry run. Dry runs keep the contacts they would have written in imported,
+// so that later records find them.
+func (s *ContactService) importContact(ctx context.Context, contact models.Contact, opts models.ImportOptions,
+	imported map[string]*models.Contact) (models.ImportChange, error) {
+	contact.Normalize()
+	if err := contact.Validate(); err != nil {
+		return models.ImportChange{}, err
+	}
+
+	existing, err := s.findByEmail(ctx, contact.Email)
+	if err != nil {
+		return models.ImportChange{}, err
+	}
+	if pending, ok := imported[contact.Email]; ok {
+		existing = pending
+	}
+
+	change := models.ImportChange{Action: models.ImportCreated, Email: contact.Email}
+	switch {
+	case existing == nil:
+		if opts.DryRun {
+			imported[contact.Email] = &contact
+			return change, nil
+		}
+		created, err := s.create(ctx, contact)
+		if err != nil {
+			return change, err
+		}
+		change.ContactID = created.ID
+		return change, nil
+	case opts.Mode == models.ImportCreate:
+		return change, fmt.Errorf("%w: a contact with this email already exists", models.ErrConflict)
+	}
+
+	change.Action, change.ContactID = models.ImportSkipped, existing.ID
+	if opts.Mode == models.ImportSkip {
+		return change, nil
+	}
+	before := *existing
+	before.Normalize()
+	merged := models.UpsertContact(before, contact)
+	if change.Fields = models.ChangedFields(before, merged); len(change.Fields) == 0 {
+		return change, nil
+	}
+	change.Action = models.ImportUpdated
+	if opts.DryRun {
+		imported[contact.Email] = &merged
+		return change, nil
+	}
+	_, _, err = s.update(ctx, merged)
+	return change, err
+}
+
+// findByEmail returns the contact holding an email, or nil
+func (s *ContactService) findByEmail(ctx context.Context, email string) (*models.Contact, error) {
+	contact, err := s.repo.GetByEmail(ctx, models.TenantFrom(ctx), email)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, nil
+	}
+	return contact, err
+}
+
+// create validates and adds a contact, once the caller is authorized
+func (s *ContactService) create(ctx context.Context, contact models.Contact) (*models.Contact, error) {
+	contact.Normalize()
+	if err := contact.Validate(); err != nil {
+		return nil, err
+	}
+
+	tenant := models.TenantFrom(ctx)
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// contacts can only be added to provisioned tenants
+		if _, err := s.tenants.GetByID(ctx, tenant); err != nil {
+			return err
+		}
+		id, err := s.repo.Create(ctx, tenant, contact)
+		if err != nil {
+			return err
+		}
+		contact.ID = id
+		contact.Version = 1 // every store creates contacts at version 1
+		return recordChange(ctx, s.audit, models.AuditCreate, contact.ID, nil, &contact)
+	})
 	if err != nil {
 		return nil, err
 	}
-	contact.ID = id
 	return &contact, nil
 }
 
-// UpdateAndNotify updates a contact and sends a notification email
-// This demonstrates business logic: multiple operations orchestrated together
-func (s *ContactService) UpdateAndNotify(ctx context.Context, contact models.Contact) error {
+// UpdateAndNotify updates a contact and queues a notification email
+// This demonstrates business logic: multiple operations orchestrated together.
+// The notification is written to the outbox in the same transaction as the
+// update, so it is delivered (by OutboxService) if and only if the update commits.
+// A non-zero contact.Version makes the update conditional on that version;
+// the updated contact carries its new version.
+func (s *ContactService) UpdateAndNotify(ctx context.Context, contact models.Contact) (*models.Contact, error) {
+	if err := s.policy.Authorize(ctx, ActionWrite); err != nil {
+		return nil, err
+	}
 	log.Printf("Service: Updating contact ID %d", contact.ID)
 
-	// Step 1: Validate email format (business rule)
-	contact.Email = strings.ToLower(strings.TrimSpace(contact.Email))
-	if !strings.Contains(contact.Email, "@") {
-		return fmt.Errorf("invalid email format")
+	updated, notified, err := s.update(ctx, contact)
+	if err != nil {
+		return nil, err
+	}
+
+	if notified {
+		log.Printf("Service: Contact updated and notification queued")
+	} else {
+		log.Printf("Service: Contact updated")
+	}
+	return updated, nil
+}
+
+// update is UpdateAndNotify once the caller is authorized. It tells whether
+// a notification was queued.
+func (s *ContactService) update(ctx context.Context, contact models.Contact) (*models.Contact, bool, error) {
+	// Step 1: Validate the new data (business rule)
+	contact.Normalize()
+	if err := contact.Validate(); err != nil {
+		return nil, false, err
 	}
 
-	// Step 2: Get old contact data (to compare)
-	oldContact, err := s.repo.GetByID(ctx, contact.ID)
+	tenant := models.TenantFrom(ctx)
+	notified := false
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// Step 2: Get old contact data (to compare)
+		oldContact, err := s.repo.GetByID(ctx, tenant, contact.ID)
+		if err != nil {
+			return err
+		}
+
+		// Step 3: Update in database and keep a trace of the change.
+		// Pinning the version read above keeps a concurrent writer from
+		// slipping in between the read and the write.
+		if contact.Version == 0 {
+			contact.Version = oldContact.Version
+		}
+		if err := s.repo.Update(ctx, tenant, contact); err != nil {
+			return fmt.Errorf("failed to update contact: %w", err)
+		}
+		contact.Version = oldContact.Version + 1
+		if err := recordChange(ctx, s.audit, models.AuditUpdate, contact.ID, oldContact, &contact); err != nil {
+			return err
+		}
+
+		// Step 4: Queue notification if email changed (business orchestration)
+		notified, err = notifyEmailChange(ctx, s.outbox, oldContact, &contact)
+		return err
+	})
 	if err != nil {
-		return fmt.Errorf("contact not found: %w", err)
+		return nil, false, err
 	}
+	return &contact, notified, nil
+}
 
-	// Step 3: Update in database
-	if err := s.repo.Update(ctx, contact); err != nil {
-		return fmt.Errorf("failed to update contact: %w", err)
+// Patch changes only the fields set in patch, as a partial UpdateAndNotify:
+// the result is validated as a whole, only the changed fields are written and
+// an email change is notified the same way. A non-zero version makes the
+// update conditional on that version.
+func (s *ContactService) Patch(ctx context.Context, id int, version int, patch models.ContactPatch) (*models.Contact, error) {
+	if err := s.policy.Authorize(ctx, ActionWrite); err != nil {
+		return nil, err
 	}
+	tenant := models.TenantFrom(ctx)
+	var updated models.Contact
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		oldContact, err := s.repo.GetByID(ctx, tenant, id)
+		if err != nil {
+			return err
+		}
+		if version != 0 && version != oldContact.Version {
+			return fmt.Errorf("%w: contact %d", models.ErrVersionConflict, id)
+		}
+
+		updated = *oldContact
+		patch.Apply(&updated)
+		updated.Normalize()
+		if err := updated.Validate(); err != nil {
+			return err
+		}
+
+		changes := models.Diff(*oldContact, updated)
+		if changes.IsEmpty() {
+			return nil
+		}
+		if err := s.repo.Patch(ctx, tenant, id, oldContact.Version, changes); err != nil {
+			return fmt.Errorf("failed to patch contact: %w", err)
+		}
+		updated.Version = oldContact.Version + 1
+		if err := recordChange(ctx, s.audit, models.AuditUpdate, id, oldContact, &updated); err != nil {
+			return err
+		}
 
-	// Step 4: Send notification if email changed (business orchestration)
-	if oldContact.Email != contact.Email {
-		go func() {
-			email := models.EmailMessage{
-				To:      contact.Email,
-				Subject: "Contact Information Updated",
-				Body:    fmt.Sprintf("Hi %s, your contact information has been updated.", contact.FirstName),
-			}
-			if err := s.emailClient.SendEmail(email); err != nil {
-				log.Printf("Warning: Failed to send notification: %v", err)
-			}
-		}()
+		notified, err := notifyEmailChange(ctx, s.outbox, oldContact, &updated)
+		if notified {
+			log.Printf("Service: Contact %d patched and notification queued", id)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
+	return &updated, nil
+}
 
-	log.Printf("Service: Contact updated and notification sent")
-	return nil
+// notifyEmailChange queues the notification of a contact whose email changed
+func notifyEmailChange(ctx context.Context, outbox interfaces.OutboxRepositoryInterface, before, after *models.Contact) (bool, error) {
+	if before.Email == after.Email {
+		return false, nil
+	}
+	email := models.EmailMessage{
+		To:       after.Email,
+		Subject:  "Contact Information Updated",
+		Body:     fmt.Sprintf("Hi %s, your contact information has been updated.", after.FirstName),
+		HTMLBody: fmt.Sprintf("<p>Hi %s, your contact information has been updated.</p>", html.EscapeString(after.FirstName)),
+	}
+	if _, err := outbox.Enqueue(ctx, models.NewOutboxMessage(models.TenantFrom(ctx), email, time.Now().UTC())); err != nil {
+		return false, fmt.Errorf("failed to queue notification: %w", err)
+	}
+	return true, nil
 }
 
-func (s *ContactService) Delete(ctx context.Context, id int) error {
-	return s.repo.Delete(ctx, id)
+// Delete removes a contact, only if it is still at version when that is non-zero
+func (s *ContactService) Delete(ctx context.Context, id int, version int) error {
+	if err := s.policy.Authorize(ctx, ActionDelete); err != nil {
+		return err
+	}
+	tenant := models.TenantFrom(ctx)
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		oldContact, err := s.repo.GetByID(ctx, tenant, id)
+		if err != nil {
+			return err
+		}
+		if version == 0 {
+			version = oldContact.Version
+		}
+		if err := s.repo.Delete(ctx, tenant, id, version); err != nil {
+			return err
+		}
+		return recordChange(ctx, s.audit, models.AuditDelete, id, oldContact, nil)
+	})
+}
+
+// History returns every recorded change of a contact, oldest first.
+// Deleted contacts keep their history.
+func (s *ContactService) History(ctx context.Context, id int) ([]models.AuditEntry, error) {
+	if err := s.policy.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+	tenant := models.TenantFrom(ctx)
+	entries, err := s.audit.ListByContact(ctx, tenant, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		// distinguish "never changed" from "never existed"
+		if _, err := s.repo.GetByID(ctx, tenant, id); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// recordChange appends an audit entry attributed to the actor carried by ctx
+func recordChange(ctx context.Context, audit interfaces.AuditRepositoryInterface, action models.AuditAction, contactID int, before, after *models.Contact) error {
+	entry := models.AuditEntry{
+		Tenant:    models.TenantFrom(ctx),
+		ContactID: contactID,
+		Action:    action,
+		Actor:     models.ActorFrom(ctx),
+		Timestamp: time.Now().UTC(),
+		Before:    before,
+		After:     after,
+	}
+	if _, err := audit.Append(ctx, entry); err != nil {
+		return fmt.Errorf("failed to record audit entry: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang/internal/models"
+	"golang/internal/store/filestore"
+	"golang/internal/utils/contactcsv"
+)
+
+// recordsDecoder returns its records in turn, a non-nil error standing for a
+// record that cannot be read
+type recordsDecoder struct {
+	records []any // models.Contact or error
+}
+
+func (d *recordsDecoder) Decode() (models.Contact, error) {
+	if len(d.records) == 0 {
+		return models.Contact{}, io.EOF
+	}
+	record := d.records[0]
+	d.records = d.records[1:]
+	if err, ok := record.(error); ok {
+		return models.Contact{}, err
+	}
+	return record.(models.Contact), nil
+}
+
+// newImportService returns a contact service on a fresh file store holding
+// Ada Lovelace, and the context of an admin of the default tenant
+func newImportService(t *testing.T) (*ContactService, context.Context) {
+	t.Helper()
+	store, err := filestore.NewStorage(filepath.Join(t.TempDir(), "contacts.json"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	s := NewContactService(store.Contact, store.Tenant, store.Outbox, store.Audit, store.Tx, DefaultPolicy)
+	ctx := models.WithPrincipal(context.Background(), models.Principal{Subject: "test", Method: "test", Role: models.RoleAdmin})
+
+	if _, err := s.Create(ctx, models.Contact{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Company: "Analytical Engines"}); err != nil {
+		t.Fatal(err)
+	}
+	return s, ctx
+}
+
+func TestImport(t *testing.T) {
+	records := func() []any {
+		return []any{
+			models.Contact{FirstName: "Ada", LastName: "Lovelace", Email: " ADA@example.com", Title: "Countess", Company: "Babbage & Co"},
+			models.Contact{FirstName: "Grace", LastName: "Hopper", Email: "grace@example.com"},
+			models.Contact{FirstName: "Nameless"},
+			fmt.Errorf("%w: line 4: bad quote", models.ErrValidation),
+			models.Contact{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Company: "Analytical Engines"},
+		}
+	}
+	ada := models.Contact{ID: 1, FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Company: "Analytical Engines", Version: 1}
+
+	tests := []struct {
+		mode                      models.ImportMode
+		created, updated, skipped int
+		failed                    []int // records
+		wantAda                   models.Contact
+	}{
+		{models.ImportCreate, 1, 0, 0, []int{1, 3, 4, 5}, ada},
+		{models.ImportSkip, 1, 0, 2, []int{3, 4}, ada},
+		// the last record brings the company back, and its title stays
+		{models.ImportUpsert, 1, 2, 0, []int{3, 4},
+			models.Contact{ID: 1, FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com",
+				Company: "Analytical Engines", Title: "Countess", Version: 3}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			s, ctx := newImportService(t)
+			result, err := s.Import(ctx, &recordsDecoder{records()}, models.ImportOptions{Mode: tt.mode})
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+
+			var failed []int
+			for _, f := range result.Failed {
+				failed = append(failed, f.Record)
+			}
+			if result.Created != tt.created || result.Updated != tt.updated || result.Skipped != tt.skipped ||
+				!reflect.DeepEqual(failed, tt.failed) || result.Changes != nil {
+				t.Errorf("Import = %+v, want %d created, %d updated, %d skipped, records %v failed",
+					result, tt.created, tt.updated, tt.skipped, tt.failed)
+			}
+
+			got, err := s.GetByID(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*got, tt.wantAda) {
+				t.Errorf("Ada after Import = %+v, want %+v", *got, tt.wantAda)
+			}
+			if _, err := s.repo.GetByEmail(ctx, models.DefaultTenant, "grace@example.com"); err != nil {
+				t.Errorf("Grace was not imported: %v", err)
+			}
+		})
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	records := func() []any {
+		return []any{
+			models.Contact{FirstName: "Grace", LastName: "Hopper", Email: "grace@example.com"},
+			models.Contact{FirstName: "Grace", LastName: "Hopper", Email: "Grace@Example.com", Title: "Rear Admiral"},
+			models.Contact{FirstName: "Grace", LastName: "Hopper", Email: "grace@example.com", Title: "Rear Admiral"},
+			models.Contact{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Title: "Countess"},
+		}
+	}
+	change := func(record int, action models.ImportAction, id int, email string, fields ...string) models.ImportChange {
+		return models.ImportChange{Record: record, Action: action, ContactID: id, Email: email, Fields: fields}
+	}
+
+	tests := []struct {
+		mode        models.ImportMode
+		wantChanges []models.ImportChange
+		wantFailed  []int
+	}{
+		// records find the ones before them in the file, as if written
+		{models.ImportCreate, []models.ImportChange{
+			change(1, models.ImportCreated, 0, "grace@example.com"),
+		}, []int{2, 3, 4}},
+		{models.ImportSkip, []models.ImportChange{
+			change(1, models.ImportCreated, 0, "grace@example.com"),
+			change(2, models.ImportSkipped, 0, "grace@example.com"),
+			change(3, models.ImportSkipped, 0, "grace@example.com"),
+			change(4, models.ImportSkipped, 1, "ada@example.com"),
+		}, nil},
+		{models.ImportUpsert, []models.ImportChange{
+			change(1, models.ImportCreated, 0, "grace@example.com"),
+			change(2, models.ImportUpdated, 0, "grace@example.com", "title"),
+			change(3, models.ImportSkipped, 0, "grace@example.com"),
+			change(4, models.ImportUpdated, 1, "ada@example.com", "title"),
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.mode), func(t *testing.T) {
+			s, ctx := newImportService(t)
+			result, err := s.Import(ctx, &recordsDecoder{records()}, models.ImportOptions{Mode: tt.mode, DryRun: true})
+			if err != nil {
+				t.Fatalf("Import: %v", err)
+			}
+			if !result.DryRun || !reflect.DeepEqual(result.Changes, tt.wantChanges) {
+				t.Errorf("Import changes = %+v, want %+v", result.Changes, tt.wantChanges)
+			}
+			var failed []int
+			for _, f := range result.Failed {
+				failed = append(failed, f.Record)
+			}
+			if !reflect.DeepEqual(failed, tt.wantFailed) {
+				t.Errorf("Import failed records %v, want %v", failed, tt.wantFailed)
+			}
+
+			// nothing was written
+			all, err := s.GetAll(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(all) != 1 || all[0].Title != "" || all[0].Version != 1 {
+				t.Errorf("contacts after a dry run = %+v, want Ada alone, unchanged", all)
+			}
+		})
+	}
+}
+
+func TestImportCSVReportsLines(t *testing.T) {
+	s, ctx := newImportService(t)
+	file := "first_name,last_name,email\n" +
+		"Grace,Hopper,grace@example.com\n" +
+		"\n" +
+		"Ada,Lovelace,ada@example.com\n" +
+		"Alan,Turing,not an email\n"
+	dec, err := contactcsv.NewDecoder(strings.NewReader(file), contactcsv.Mapping{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.Import(ctx, dec, models.ImportOptions{})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if result.Created != 1 || len(result.Failed) != 2 {
+		t.Fatalf("Import = %+v, want 1 created and 2 failed", result)
+	}
+	for i, want := range []models.ImportFailure{
+		{Record: 4, Row: []string{"Ada", "Lovelace", "ada@example.com"}},
+		{Record: 5, Row: []string{"Alan", "Turing", "not an email"}, Fields: []models.FieldError{{Field: "email"}}},
+	} {
+		got := result.Failed[i]
+		if got.Record != want.Record || !reflect.DeepEqual(got.Row, want.Row) || len(got.Fields) != len(want.Fields) ||
+			(len(want.Fields) > 0 && got.Fields[0].Field != want.Fields[0].Field) {
+			t.Errorf("failure %d = %+v, want record %d, row %q, fields %+v", i, got, want.Record, want.Row, want.Fields)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// DedupService finds contacts that are likely the same person and merges
+// them. Every operation is scoped to the tenant carried by ctx (see
+// models.TenantFrom).
+type DedupService struct {
+	repo   interfaces.ContactRepositoryInterface
+	groups interfaces.GroupRepositoryInterface
+	outbox interfaces.OutboxRepositoryInterface
+	audit  interfaces.AuditRepositoryInterface
+	tx     interfaces.TransactorInterface
+	policy Policy
+}
+
+func NewDedupService(
+	repo interfaces.ContactRepositoryInterface,
+	groups interfaces.GroupRepositoryInterface,
+	outbox interfaces.OutboxRepositoryInterface,
+	audit interfaces.AuditRepositoryInterface,
+	tx interfaces.TransactorInterface,
+	policy Policy,
+) *DedupService {
+	return &DedupService{
+		repo:   repo,
+		groups: groups,
+		outbox: outbox,
+		audit:  audit,
+		tx:     tx,
+		policy: policy,
+	}
+}
+
+// Duplicates scores the contacts of the tenant against each other and
+// returns the suspected duplicates, best first (see models.FindDuplicates)
+func (s *DedupService) Duplicates(ctx context.Context, query models.DuplicateQuery) ([]models.DuplicatePair, error) {
+	if err := s.policy.Authorize(ctx, ActionBulk); err != nil {
+		return nil, err
+	}
+	if err := query.Normalize(); err != nil {
+		return nil, err
+	}
+	contacts, err := s.repo.GetAll(ctx, models.TenantFrom(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return models.FindDuplicates(contacts, query), nil
+}
+
+// Merge folds the duplicate into contact id, as described by
+// models.ContactMerge: the contact is updated with the merged fields and
+// joins the groups of the duplicate, which is deleted. Both get a merge entry
+// in their history, and an email change is notified like an update.
+// A dry run returns the same result without changing anything.
+func (s *DedupService) Merge(ctx context.Context, id int, merge models.ContactMerge) (*models.MergeResult, error) {
+	if err := s.policy.Authorize(ctx, ActionDelete); err != nil {
+		return nil, err
+	}
+	if err := merge.Validate(); err != nil {
+		return nil, err
+	}
+	if merge.DuplicateID == id {
+		return nil, fmt.Errorf("%w: a contact cannot be merged with itself", models.ErrValidation)
+	}
+
+	tenant := models.TenantFrom(ctx)
+	var result models.MergeResult
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		contact, err := s.repo.GetByID(ctx, tenant, id)
+		if err != nil {
+			return err
+		}
+		duplicate, err := s.repo.GetByID(ctx, tenant, merge.DuplicateID)
+		if err != nil {
+			return err
+		}
+		if merge.Version != 0 && merge.Version != contact.Version {
+			return fmt.Errorf("%w: contact %d", models.ErrVersionConflict, id)
+		}
+		if merge.DuplicateVersion != 0 && merge.DuplicateVersion != duplicate.Version {
+			return fmt.Errorf("%w: contact %d", models.ErrVersionConflict, duplicate.ID)
+		}
+
+		result = models.MergeContacts(*contact, *duplicate, merge.Prefer)
+		if err := result.Contact.Validate(); err != nil {
+			return err
+		}
+		if merge.DryRun {
+			return nil
+		}
+
+		// the duplicate goes first, as the contact may take its email
+		groups, err := s.groups.ListByContact(ctx, tenant, duplicate.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, tenant, duplicate.ID, duplicate.Version); err != nil {
+			return fmt.Errorf("failed to delete duplicate: %w", err)
+		}
+		if err := s.repo.Update(ctx, tenant, result.Contact); err != nil {
+			return fmt.Errorf("failed to update contact: %w", err)
+		}
+		result.Contact.Version = contact.Version + 1
+		for _, g := range groups {
+			if err := s.groups.AddMember(ctx, tenant, g.ID, id); err != nil {
+				return err
+			}
+		}
+
+		if err := recordChange(ctx, s.audit, models.AuditMerge, id, contact, &result.Contact); err != nil {
+			return err
+		}
+		if err := recordChange(ctx, s.audit, models.AuditMerge, duplicate.ID, duplicate, &result.Contact); err != nil {
+			return err
+		}
+		_, err = notifyEmailChange(ctx, s.outbox, contact, &result.Contact)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !merge.DryRun {
+		log.Printf("Service: Contact %d merged into contact %d", merge.DuplicateID, id)
+	}
+	return &result, nil
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// GroupService manages contact groups and their members.
+// Every operation is scoped to the tenant carried by ctx (see models.TenantFrom).
+type GroupService struct {
+	repo    interfaces.GroupRepositoryInterface
+	tenants interfaces.TenantRepositoryInterface
+	tx      interfaces.TransactorInterface
+	policy  Policy
+}
+
+func NewGroupService(
+	repo interfaces.GroupRepositoryInterface,
+	tenants interfaces.TenantRepositoryInterface,
+	tx interfaces.TransactorInterface,
+	policy Policy,
+) *GroupService {
+	return &GroupService{
+		repo:    repo,
+		tenants: tenants,
+		tx:      tx,
+		policy:  policy,
+	}
+}
+
+func (s *GroupService) List(ctx context.Context) ([]models.Group, error) {
+	if err := s.policy.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, models.TenantFrom(ctx))
+}
+
+func (s *GroupService) GetByID(ctx context.Context, id int) (*models.Group, error) {
+	if err := s.policy.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, models.TenantFrom(ctx), id)
+}
+
+func (s *GroupService) Create(ctx context.Context, group models.Group) (*models.Group, error) {
+	if err := s.policy.Authorize(ctx, ActionWrite); err != nil {
+		return nil, err
+	}
+	group.Normalize()
+	if err := group.Validate(); err != nil {
+		return nil, err
+	}
+
+	tenant := models.TenantFrom(ctx)
+	group.CreatedAt = time.Now().UTC()
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		// groups can only be added to provisioned tenants
+		if _, err := s.tenants.GetByID(ctx, tenant); err != nil {
+			return err
+		}
+		id, err := s.repo.Create(ctx, tenant, group)
+		group.ID = id
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Service: Group %d created", group.ID)
+	return &group, nil
+}
+
+// Update renames a group or changes its description
+func (s *GroupService) Update(ctx context.Context, group models.Group) (*models.Group, error) {
+	if err := s.policy.Authorize(ctx, ActionWrite); err != nil {
+		return nil, err
+	}
+	group.Normalize()
+	if err := group.Validate(); err != nil {
+		return nil, err
+	}
+
+	tenant := models.TenantFrom(ctx)
+	var updated *models.Group
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, tenant, group); err != nil {
+			return err
+		}
+		var err error
+		updated, err = s.repo.GetByID(ctx, tenant, group.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Delete removes a group. Its members are kept.
+func (s *GroupService) Delete(ctx context.Context, id int) error {
+	if err := s.policy.Authorize(ctx, ActionDelete); err != nil {
+		return err
+	}
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.repo.Delete(ctx, models.TenantFrom(ctx), id)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Service: Group %d deleted", id)
+	return nil
+}
+
+// Members returns the contacts of a group ordered by ID
+func (s *GroupService) Members(ctx context.Context, id int) ([]models.Contact, error) {
+	if err := s.policy.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMembers(ctx, models.TenantFrom(ctx), id)
+}
+
+// AddMember puts a contact in a group; adding a member again is not an error
+func (s *GroupService) AddMember(ctx context.Context, id int, contactID int) error {
+	if err := s.policy.Authorize(ctx, ActionWrite); err != nil {
+		return err
+	}
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.repo.AddMember(ctx, models.TenantFrom(ctx), id, contactID)
+	})
+}
+
+func (s *GroupService) RemoveMember(ctx context.Context, id int, contactID int) error {
+	if err := s.policy.Authorize(ctx, ActionWrite); err != nil {
+		return err
+	}
+	return s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.repo.RemoveMember(ctx, models.TenantFrom(ctx), id, contactID)
+	})
+}
+
+// ContactGroups returns the groups a contact belongs to, ordered by name
+func (s *GroupService) ContactGroups(ctx context.Context, contactID int) ([]models.Group, error) {
+	if err := s.policy.Authorize(ctx, ActionRead); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByContact(ctx, models.TenantFrom(ctx), contactID)
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"golang/internal/config"
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+	"golang/internal/utils/messaging"
+)
+
+// claimLease is how long a claimed message is hidden from other dispatchers.
+// It only has to outlive one delivery attempt.
+const claimLease = 2 * time.Minute
+
+// purgeInterval is how often Run purges the delivered messages older than
+// the retention period
+const purgeInterval = time.Hour
+
+// OutboxService delivers queued notifications and manages dead letters
+type OutboxService struct {
+	repo   interfaces.OutboxRepositoryInterface
+	sender messaging.Sender
+	cfg    config.OutboxConfig
+	policy Policy
+}
+
+func NewOutboxService(
+	repo interfaces.OutboxRepositoryInterface,
+	sender messaging.Sender,
+	cfg config.OutboxConfig,
+	policy Policy,
+) *OutboxService {
+	return &OutboxService{
+		repo:   repo,
+		sender: sender,
+		cfg:    cfg,
+		policy: policy,
+	}
+}
+
+// Run dispatches due messages every poll interval until ctx is cancelled,
+// and purges delivered messages once they are older than the retention
+// period. It returns once the delivery in progress, if any, has completed.
+func (s *OutboxService) Run(ctx context.Context) {
+	ctx = models.WithPrincipal(ctx, models.SystemPrincipal("outbox"))
+	ticker := time.NewTicker(time.Duration(s.cfg.PollInterval))
+	defer ticker.Stop()
+
+	var purged time.Time
+	for {
+		if _, err := s.DispatchDue(ctx); err != nil {
+			log.Printf("Outbox: dispatch failed: %v", err)
+		}
+		if time.Since(purged) >= purgeInterval {
+			purged = time.Now()
+			if _, err := s.PurgeDelivered(ctx); err != nil {
+				log.Printf("Outbox: purge failed: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue claims one batch of due messages and attempts to deliver them.
+// It returns how many were delivered.
+func (s *OutboxService) DispatchDue(ctx context.Context) (int, error) {
+	now := time.Now().UTC()
+	msgs, err := s.repo.Claim(ctx, now, claimLease, s.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for _, msg := range msgs {
+		if ctx.Err() != nil {
+			// unfinished claims expire with their lease and are retried
+			break
+		}
+		// a delivery that has started is allowed to finish during shutdown
+		if s.deliver(context.WithoutCancel(ctx), msg) {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// PurgeDelivered removes the delivered messages older than the retention
+// period and returns how many it removed
+func (s *OutboxService) PurgeDelivered(ctx context.Context) (int, error) {
+	n, err := s.repo.PurgeDelivered(ctx, time.Now().UTC().Add(-time.Duration(s.cfg.Retention)))
+	if n > 0 {
+		log.Printf("Outbox: purged %d delivered message(s)", n)
+	}
+	return n, err
+}
+
+// deliver sends one message and records the outcome
+func (s *OutboxService) deliver(ctx context.Context, msg models.OutboxMessage) bool {
+	msg.Attempts++
+	sendErr := s.sender.SendEmail(ctx, msg.Email)
+
+	now := time.Now().UTC()
+	switch {
+	case sendErr == nil:
+		msg.Status = models.OutboxDelivered
+		msg.DeliveredAt = &now
+		msg.LastError = ""
+	case msg.Attempts >= s.cfg.MaxAttempts:
+		msg.Status = models.OutboxDead
+		msg.LastError = sendErr.Error()
+		log.Printf("Outbox: message %d moved to dead letters after %d attempts: %v", msg.ID, msg.Attempts, sendErr)
+	default:
+		msg.NextAttemptAt = now.Add(s.backoff(msg.Attempts))
+		msg.LastError = sendErr.Error()
+		log.Printf("Outbox: message %d attempt %d failed, retrying at %s: %v",
+			msg.ID, msg.Attempts, msg.NextAttemptAt.Format(time.RFC3339), sendErr)
+	}
+
+	if err := s.repo.Save(ctx, msg); err != nil {
+		log.Printf("Outbox: failed to record state of message %d: %v", msg.ID, err)
+	}
+	return sendErr == nil
+}
+
+// backoff doubles the delay with every attempt, capped at MaxBackoff, with up
+// to 20% jitter so that retries from a burst of failures spread out
+func (s *OutboxService) backoff(attempts int) time.Duration {
+	delay := time.Duration(s.cfg.BaseBackoff)
+	for i := 1; i < attempts && delay < time.Duration(s.cfg.MaxBackoff); i++ {
+		delay *= 2
+	}
+	delay = min(delay, time.Duration(s.cfg.MaxBackoff))
+	return delay + time.Duration(rand.Int64N(int64(delay)/5+1))
+}
+
+// DeadLetters lists the messages of the caller's tenant that exhausted their
+// delivery attempts
+func (s *OutboxService) DeadLetters(ctx context.Context) ([]models.OutboxMessage, error) {
+	if err := s.policy.Authorize(ctx, ActionOutbox); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByStatus(ctx, models.TenantFrom(ctx), models.OutboxDead)
+}
+
+// Replay puts a dead letter back in the queue with a fresh attempt budget
+func (s *OutboxService) Replay(ctx context.Context, id int) error {
+	if err := s.policy.Authorize(ctx, ActionOutbox); err != nil {
+		return err
+	}
+	msg, err := s.repo.GetByID(ctx, models.TenantFrom(ctx), id)
+	if err != nil {
+		return err
+	}
+	if msg.Status != models.OutboxDead {
+		return fmt.Errorf("%w: outbox message %d is %s, only dead letters can be replayed", models.ErrConflict, id, msg.Status)
+	}
+
+	msg.Status = models.OutboxPending
+	msg.Attempts = 0
+	msg.NextAttemptAt = time.Now().UTC()
+	return s.repo.Save(ctx, *msg)
+}
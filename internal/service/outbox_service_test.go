@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang/internal/config"
+	"golang/internal/models"
+	"golang/internal/store/filestore"
+	"golang/internal/store/interfaces"
+)
+
+// failingSender fails every delivery while err is set, and counts them
+type failingSender struct {
+	err  error
+	sent int
+}
+
+func (s *failingSender) SendEmail(ctx context.Context, email models.EmailMessage) error {
+	s.sent++
+	return s.err
+}
+
+var testOutboxConfig = config.OutboxConfig{
+	BatchSize:   10,
+	MaxAttempts: 3,
+	BaseBackoff: config.Duration(time.Second),
+	MaxBackoff:  config.Duration(10 * time.Second),
+}
+
+// newOutboxService returns an outbox service on a fresh file store holding
+// one due message, and the context of an admin of the default tenant
+func newOutboxService(t *testing.T, sender *failingSender) (*OutboxService, interfaces.OutboxRepositoryInterface, int, context.Context) {
+	t.Helper()
+	store, err := filestore.NewStorage(filepath.Join(t.TempDir(), "contacts.json"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	msg := models.NewOutboxMessage(models.DefaultTenant, models.EmailMessage{To: "ada@example.com", Subject: "Hello"}, time.Now().UTC())
+	id, err := store.Outbox.Enqueue(context.Background(), msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := models.WithPrincipal(context.Background(), models.Principal{Subject: "test", Method: "test", Role: models.RoleAdmin})
+	return NewOutboxService(store.Outbox, sender, testOutboxConfig, DefaultPolicy), store.Outbox, id, ctx
+}
+
+func TestBackoff(t *testing.T) {
+	s := &OutboxService{cfg: testOutboxConfig}
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second}, // capped
+		{50, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		// up to 20% of jitter on top
+		for range 20 {
+			if got := s.backoff(tt.attempts); got < tt.want || got > tt.want+tt.want/5 {
+				t.Errorf("backoff(%d) = %s, want %s plus up to 20%%", tt.attempts, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestDispatchDueDelivers(t *testing.T) {
+	sender := &failingSender{}
+	s, repo, id, ctx := newOutboxService(t, sender)
+
+	delivered, err := s.DispatchDue(ctx)
+	if err != nil || delivered != 1 {
+		t.Fatalf("DispatchDue = %d, %v, want 1 delivered", delivered, err)
+	}
+	msg, err := repo.GetByID(ctx, models.DefaultTenant, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Status != models.OutboxDelivered || msg.Attempts != 1 || msg.DeliveredAt == nil || msg.LastError != "" {
+		t.Errorf("message after delivery = %+v, want it delivered at the first attempt", *msg)
+	}
+
+	// nothing left to deliver
+	if delivered, err := s.DispatchDue(ctx); err != nil || delivered != 0 || sender.sent != 1 {
+		t.Errorf("second DispatchDue = %d, %v after %d sends, want nothing sent", delivered, err, sender.sent)
+	}
+}
+
+func TestDispatchDueRetriesThenDeadLetters(t *testing.T) {
+	sender := &failingSender{err: errors.New("connection refused")}
+	s, repo, id, ctx := newOutboxService(t, sender)
+
+	for attempt := 1; attempt <= testOutboxConfig.MaxAttempts; attempt++ {
+		before := time.Now().UTC()
+		delivered, err := s.DispatchDue(ctx)
+		if err != nil || delivered != 0 {
+			t.Fatalf("DispatchDue of attempt %d = %d, %v, want a failure", attempt, delivered, err)
+		}
+		msg, err := repo.GetByID(ctx, models.DefaultTenant, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.Attempts != attempt || msg.LastError != "connection refused" {
+			t.Fatalf("message after attempt %d = %+v, want the attempt and its error recorded", attempt, *msg)
+		}
+
+		if attempt == testOutboxConfig.MaxAttempts {
+			if msg.Status != models.OutboxDead {
+				t.Errorf("message after the last attempt is %s, want dead", msg.Status)
+			}
+			break
+		}
+		backoff := s.backoff(attempt)
+		if msg.Status != models.OutboxPending || msg.NextAttemptAt.Before(before.Add(backoff*5/6)) {
+			t.Errorf("message after attempt %d = %+v, want it pending until about %s", attempt, *msg, before.Add(backoff))
+		}
+
+		// not retried before its time, then made due
+		if _, err := s.DispatchDue(ctx); err != nil || sender.sent != attempt {
+			t.Errorf("DispatchDue before the next attempt sent %d times, %v, want %d", sender.sent, err, attempt)
+		}
+		msg.NextAttemptAt = time.Now().UTC()
+		if err := repo.Save(ctx, *msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// dead letters are not retried
+	if _, err := s.DispatchDue(ctx); err != nil || sender.sent != testOutboxConfig.MaxAttempts {
+		t.Errorf("DispatchDue of a dead letter sent %d times, %v, want %d", sender.sent, err, testOutboxConfig.MaxAttempts)
+	}
+	dead, err := s.DeadLetters(ctx)
+	if err != nil || len(dead) != 1 || dead[0].ID != id {
+		t.Errorf("DeadLetters = %+v, %v, want message %d", dead, err, id)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	sender := &failingSender{}
+	s, repo, id, ctx := newOutboxService(t, sender)
+
+	err := s.Replay(ctx, id)
+	if !errors.Is(err, models.ErrConflict) {
+		t.Errorf("Replay of a pending message = %v, want ErrConflict", err)
+	}
+	err = s.Replay(ctx, id+1)
+	if !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Replay of a missing message = %v, want ErrNotFound", err)
+	}
+
+	msg, err := repo.GetByID(ctx, models.DefaultTenant, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.Status, msg.Attempts, msg.LastError = models.OutboxDead, testOutboxConfig.MaxAttempts, "connection refused"
+	msg.NextAttemptAt = time.Now().UTC().Add(time.Hour)
+	if err := repo.Save(ctx, *msg); err != nil {
+		t.Fatal(err)
+	}
+	viewer := models.WithPrincipal(context.Background(), models.Principal{Subject: "test", Method: "test", Role: models.RoleViewer})
+	if err := s.Replay(viewer, id); !errors.Is(err, models.ErrForbidden) {
+		t.Errorf("Replay by a viewer = %v, want ErrForbidden", err)
+	}
+
+	if err := s.Replay(ctx, id); err != nil {
+		t.Fatalf("Replay of a dead letter: %v", err)
+	}
+	msg, err = repo.GetByID(ctx, models.DefaultTenant, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg.Status != models.OutboxPending || msg.Attempts != 0 {
+		t.Errorf("message after Replay = %+v, want it pending with a fresh attempt budget", *msg)
+	}
+	if delivered, err := s.DispatchDue(ctx); err != nil || delivered != 1 {
+		t.Errorf("DispatchDue after Replay = %d, %v, want it delivered", delivered, err)
+	}
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"golang/internal/models"
+)
+
+// Action is an operation subject to authorization
+type Action string
+
+const (
+	ActionRead   Action = "contacts:read"   // list, get and history, also of groups
+	ActionWrite  Action = "contacts:write"  // create, update and patch, also groups and members
+	ActionDelete Action = "contacts:delete" // delete contacts and groups
+	ActionBulk   Action = "contacts:bulk"   // operations on many contacts at once
+	ActionOutbox Action = "outbox:manage"   // list and replay dead letters
+	ActionTenant Action = "tenants:manage"  // provision and delete tenants
+)
+
+// Policy lists the actions each role may perform.
+// The services enforce it, so every presentation applies the same rules.
+type Policy map[models.Role][]Action
+
+// DefaultPolicy lets viewers read, editors also create and update, and
+// admins do everything
+var DefaultPolicy = Policy{
+	models.RoleViewer: {ActionRead},
+	models.RoleEditor: {ActionRead, ActionWrite},
+	models.RoleAdmin:  {ActionRead, ActionWrite, ActionDelete, ActionBulk, ActionOutbox, ActionTenant},
+}
+
+// Authorize fails with models.ErrForbidden unless the principal carried by
+// ctx may perform action. Operations without a principal are refused:
+// background jobs and requests served with authentication disabled carry an
+// explicit one.
+func (p Policy) Authorize(ctx context.Context, action Action) error {
+	principal, ok := models.PrincipalFrom(ctx)
+	if !ok {
+		return fmt.Errorf("%w: no caller to perform %s", models.ErrForbidden, action)
+	}
+	if !slices.Contains(p[principal.Role], action) {
+		return fmt.Errorf("%w: role %q may not perform %s", models.ErrForbidden, principal.Role, action)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golang/internal/models"
+)
+
+func TestDefaultPolicy(t *testing.T) {
+	actions := []Action{ActionRead, ActionWrite, ActionDelete, ActionBulk, ActionOutbox, ActionTenant}
+	tests := []struct {
+		role    models.Role
+		allowed []Action
+	}{
+		{models.RoleViewer, []Action{ActionRead}},
+		{models.RoleEditor, []Action{ActionRead, ActionWrite}},
+		{models.RoleAdmin, actions},
+		{"superuser", nil},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		ctx := models.WithPrincipal(context.Background(), models.Principal{Subject: "alice", Method: "test", Role: tt.role})
+		for _, action := range actions {
+			allowed := false
+			for _, a := range tt.allowed {
+				allowed = allowed || a == action
+			}
+			err := DefaultPolicy.Authorize(ctx, action)
+			if allowed && err != nil {
+				t.Errorf("role %q, %s: Authorize = %v, want nil", tt.role, action, err)
+			}
+			if !allowed && !errors.Is(err, models.ErrForbidden) {
+				t.Errorf("role %q, %s: Authorize = %v, want ErrForbidden", tt.role, action, err)
+			}
+		}
+	}
+}
+
+func TestAuthorizeWithoutPrincipal(t *testing.T) {
+	ctx := models.WithActor(models.WithTenant(context.Background(), "sales"), "http:127.0.0.1")
+	for _, action := range []Action{ActionRead, ActionWrite, ActionTenant} {
+		if err := DefaultPolicy.Authorize(ctx, action); !errors.Is(err, models.ErrForbidden) {
+			t.Errorf("%s without a principal: Authorize = %v, want ErrForbidden", action, err)
+		}
+	}
+}
+
+func TestSystemPrincipal(t *testing.T) {
+	ctx := models.WithPrincipal(context.Background(), models.SystemPrincipal("outbox"))
+	if err := DefaultPolicy.Authorize(ctx, ActionOutbox); err != nil {
+		t.Errorf("Authorize = %v, want nil", err)
+	}
+	if got := models.ActorFrom(ctx); got != "system:outbox" {
+		t.Errorf("ActorFrom = %q, want %q", got, "system:outbox")
+	}
+}
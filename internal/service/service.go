@@ -1,19 +1,29 @@
 package service
 
 import (
+	"golang/internal/config"
 	"golang/internal/store/interfaces"
 	"golang/internal/utils/messaging"
 )
 
 type Service struct {
 	ContactService *ContactService
+	OutboxService  *OutboxService
+	TenantService  *TenantService
+	GroupService   *GroupService
+	DedupService   *DedupService
 }
 
 func NewService(
 	store *interfaces.Store,
-	emailClient *messaging.EmailClient,
+	sender messaging.Sender,
+	outboxCfg config.OutboxConfig,
 ) *Service {
 	return &Service{
-		ContactService: NewContactService(store.Contact, emailClient),
+		ContactService: NewContactService(store.Contact, store.Tenant, store.Outbox, store.Audit, store.Tx, DefaultPolicy),
+		OutboxService:  NewOutboxService(store.Outbox, sender, outboxCfg, DefaultPolicy),
+		TenantService:  NewTenantService(store.Tenant, store.Tx, DefaultPolicy),
+		GroupService:   NewGroupService(store.Group, store.Tenant, store.Tx, DefaultPolicy),
+		DedupService:   NewDedupService(store.Contact, store.Group, store.Outbox, store.Audit, store.Tx, DefaultPolicy),
 	}
 }
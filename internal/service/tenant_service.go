@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// TenantService provisions and deletes tenants
+type TenantService struct {
+	repo   interfaces.TenantRepositoryInterface
+	tx     interfaces.TransactorInterface
+	policy Policy
+}
+
+func NewTenantService(
+	repo interfaces.TenantRepositoryInterface,
+	tx interfaces.TransactorInterface,
+	policy Policy,
+) *TenantService {
+	return &TenantService{
+		repo:   repo,
+		tx:     tx,
+		policy: policy,
+	}
+}
+
+func (s *TenantService) List(ctx context.Context) ([]models.Tenant, error) {
+	if err := s.policy.Authorize(ctx, ActionTenant); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx)
+}
+
+// Create provisions an empty tenant. The name defaults to the ID.
+func (s *TenantService) Create(ctx context.Context, id string, name string) (*models.Tenant, error) {
+	if err := s.policy.Authorize(ctx, ActionTenant); err != nil {
+		return nil, err
+	}
+	if err := models.ValidateTenantID(id); err != nil {
+		return nil, err
+	}
+
+	tenant := models.Tenant{ID: id, Name: strings.TrimSpace(name), CreatedAt: time.Now().UTC()}
+	if tenant.Name == "" {
+		tenant.Name = id
+	}
+	if err := s.repo.Create(ctx, tenant); err != nil {
+		return nil, err
+	}
+	log.Printf("Service: Tenant %s created", id)
+	return &tenant, nil
+}
+
+// Delete removes a tenant with all of its contacts
+func (s *TenantService) Delete(ctx context.Context, id string) error {
+	if err := s.policy.Authorize(ctx, ActionTenant); err != nil {
+		return err
+	}
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return s.repo.Delete(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Service: Tenant %s deleted", id)
+	return nil
+}
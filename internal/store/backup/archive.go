@@ -0,0 +1,202 @@
+// Package backup saves the records of a store to a portable archive and
+// reads them back, so that a backup taken of one kind of store can be
+// restored into any other.
+//
+// An archive is a gzip-compressed file of JSON lines, each holding a kind and
+// a record. The header comes first, then tenants, contacts, groups, outbox
+// messages and audit entries in the order storecopy copies them, and the
+// trailer last. The trailer holds the count and checksum of the records of
+// each kind and the SHA-256 of every line before it, so that an archive cut
+// short or altered is told apart from a good one.
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+	"golang/internal/store/storecopy"
+)
+
+// Format and FormatVersion identify the archives this package writes. The
+// version changes whenever a record changes in a way older readers cannot
+// load. Version 2 added the tenant of outbox messages; the messages of
+// version 1 archives belong to the default tenant.
+const (
+	Format        = "contacts-backup"
+	FormatVersion = 2
+)
+
+// Kinds of the lines that frame the records
+const (
+	headerKind  = "header"
+	trailerKind = "trailer"
+)
+
+// ErrInvalidArchive reports an archive that is not one, is of an unknown
+// format version, or was truncated or altered since it was written
+var ErrInvalidArchive = errors.New("invalid backup archive")
+
+// Header describes the store an archive was taken of
+type Header struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	StoreType string    `json:"store_type"`
+	// SchemaVersion is the schema migration the SQL stores were at, and 0
+	// for the file store
+	SchemaVersion int64 `json:"schema_version"`
+}
+
+// Trailer closes an archive
+type Trailer struct {
+	Records  map[string]storecopy.Summary `json:"records"`
+	Checksum string                       `json:"checksum"` // SHA-256 of the lines before the trailer
+}
+
+// line is one line of an archive
+type line struct {
+	Kind string          `json:"kind"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Write takes a snapshot of store and writes its records to w as an archive.
+// header only needs the store type and schema version; the format and, if
+// unset, the time are filled in.
+func Write(ctx context.Context, w io.Writer, store *interfaces.Store, header Header) (Trailer, error) {
+	var trailer Trailer
+	err := store.Snapshot.Snapshot(ctx, func(ctx context.Context, snapshot *interfaces.Store) error {
+		var err error
+		trailer, err = encode(ctx, w, header, storecopy.FromStore(snapshot))
+		return err
+	})
+	return trailer, err
+}
+
+// WriteFile writes an archive of store to path. The archive is written next
+// to it and renamed into place once complete, so that path is never left
+// holding a partial archive, and the directory is synced for the rename to
+// survive a crash.
+func WriteFile(ctx context.Context, path string, store *interfaces.Store, header Header) (Trailer, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return Trailer{}, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer os.Remove(f.Name()) // fails harmlessly once renamed
+
+	trailer, err := Write(ctx, f, store, header)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return Trailer{}, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return Trailer{}, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := syncDir(filepath.Dir(path)); err != nil {
+		return Trailer{}, fmt.Errorf("failed to sync backup directory: %w", err)
+	}
+	return trailer, nil
+}
+
+// encoder writes the lines of an archive
+type encoder struct {
+	w          *bufio.Writer
+	hash       hash.Hash
+	summarizer *storecopy.Summarizer
+}
+
+// encode writes the records of src to w as an archive
+func encode(ctx context.Context, w io.Writer, header Header, src storecopy.Source) (Trailer, error) {
+	header.Format, header.Version = Format, FormatVersion
+	if header.CreatedAt.IsZero() {
+		header.CreatedAt = time.Now().UTC()
+	}
+
+	gz := gzip.NewWriter(w)
+	e := &encoder{w: bufio.NewWriter(gz), hash: sha256.New(), summarizer: storecopy.NewSummarizer()}
+	if err := e.line(headerKind, header); err != nil {
+		return Trailer{}, err
+	}
+
+	tenants, err := src.Tenants(ctx)
+	if err != nil {
+		return Trailer{}, err
+	}
+	if err := records[models.Tenant](e, storecopy.Tenants)(tenants); err != nil {
+		return Trailer{}, err
+	}
+	if err := src.Contacts(ctx, records[storecopy.Contact](e, storecopy.Contacts)); err != nil {
+		return Trailer{}, err
+	}
+	if err := src.Groups(ctx, records[storecopy.Group](e, storecopy.Groups)); err != nil {
+		return Trailer{}, err
+	}
+	if err := src.Outbox(ctx, records[models.OutboxMessage](e, storecopy.Outbox)); err != nil {
+		return Trailer{}, err
+	}
+	if err := src.Audit(ctx, records[models.AuditEntry](e, storecopy.Audit)); err != nil {
+		return Trailer{}, err
+	}
+
+	trailer := Trailer{Records: e.summarizer.Summaries(), Checksum: hex.EncodeToString(e.hash.Sum(nil))}
+	if err := e.line(trailerKind, trailer); err != nil {
+		return Trailer{}, err
+	}
+	if err := e.w.Flush(); err != nil {
+		return Trailer{}, err
+	}
+	if err := gz.Close(); err != nil {
+		return Trailer{}, err
+	}
+	return trailer, nil
+}
+
+// records returns a batch function writing records of a kind
+func records[T any](e *encoder, kind string) func([]T) error {
+	return func(batch []T) error {
+		for _, record := range batch {
+			if err := e.summarizer.Add(record); err != nil {
+				return err
+			}
+			if err := e.line(kind, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// line writes a line, adding it to the checksum unless it is the trailer
+func (e *encoder) line(kind string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", kind, err)
+	}
+	data, err = json.Marshal(line{Kind: kind, Data: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", kind, err)
+	}
+	data = append(data, '\n')
+	if kind != trailerKind {
+		e.hash.Write(data)
+	}
+	_, err = e.w.Write(data)
+	return err
+}
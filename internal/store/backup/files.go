@@ -0,0 +1,88 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Archives in a backup directory are named after the time they were taken,
+// to the nanosecond so that two backups never share a name, and so that
+// their names sort in that order
+const (
+	filePrefix = "contacts-"
+	fileSuffix = ".jsonl.gz"
+	fileTime   = "20060102T150405.000000000Z"
+	// parseTime reads the names of fileTime as well as those of whole
+	// seconds taken before, as Go accepts a fraction after the seconds
+	parseTime = "20060102T150405Z"
+)
+
+// FileName names the archive taken at t
+func FileName(t time.Time) string {
+	return filePrefix + t.UTC().Format(fileTime) + fileSuffix
+}
+
+// File is an archive of a backup directory
+type File struct {
+	Path      string
+	CreatedAt time.Time
+}
+
+// List returns the archives of dir named by FileName, oldest first
+func List(dir string) ([]File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []File
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !strings.HasPrefix(name, filePrefix) || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		t, err := time.Parse(parseTime, strings.TrimSuffix(strings.TrimPrefix(name, filePrefix), fileSuffix))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, File{Path: filepath.Join(dir, name), CreatedAt: t})
+	}
+	slices.SortFunc(backups, func(a, b File) int { return a.CreatedAt.Compare(b.CreatedAt) })
+	return backups, nil
+}
+
+// Latest returns the newest archive of dir taken at or before at, the one
+// to restore to go back to that time
+func Latest(dir string, at time.Time) (File, error) {
+	backups, err := List(dir)
+	if err != nil {
+		return File{}, err
+	}
+	for i := len(backups) - 1; i >= 0; i-- {
+		if !backups[i].CreatedAt.After(at) {
+			return backups[i], nil
+		}
+	}
+	return File{}, fmt.Errorf("no backup in %s was taken at or before %s", dir, at.UTC().Format(time.RFC3339))
+}
+
+// Rotate removes all but the newest keep archives of dir and returns the
+// paths it removed
+func Rotate(dir string, keep int) ([]string, error) {
+	backups, err := List(dir)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for len(backups) > max(keep, 0) {
+		if err := os.Remove(backups[0].Path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, backups[0].Path)
+		backups = backups[1:]
+	}
+	return removed, nil
+}
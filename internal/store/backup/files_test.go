@@ -0,0 +1,69 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileNamesOfTheSameSecond(t *testing.T) {
+	dir := t.TempDir()
+	taken := time.Date(2026, 1, 1, 2, 0, 0, 0, time.UTC)
+	times := []time.Time{
+		taken.Add(900 * time.Millisecond),
+		taken.Add(time.Nanosecond),
+		taken.Add(-time.Hour),
+		taken.Add(500 * time.Millisecond),
+	}
+	names := []string{"contacts-20260101T003000Z.jsonl.gz"} // named before FileName had a fraction
+	for _, at := range times[:3] {
+		names = append(names, FileName(at))
+	}
+	names = append(names, "contacts-latest.jsonl.gz", "notes.txt")
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := FileName(times[1]), "contacts-20260101T020000.000000001Z.jsonl.gz"; got != want {
+		t.Errorf("FileName = %q, want %q", got, want)
+	}
+
+	backups, err := List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []time.Time{taken.Add(-90 * time.Minute), times[2], times[1], times[0]}
+	if len(backups) != len(want) {
+		t.Fatalf("List = %+v, want %d archives", backups, len(want))
+	}
+	for i, b := range backups {
+		if !b.CreatedAt.Equal(want[i]) {
+			t.Errorf("archive %d taken at %s, want %s", i, b.CreatedAt, want[i])
+		}
+	}
+
+	latest, err := Latest(dir, times[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !latest.CreatedAt.Equal(times[1]) {
+		t.Errorf("Latest = %s, want %s", latest.CreatedAt, times[1])
+	}
+
+	removed, err := Rotate(dir, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 || filepath.Base(removed[0]) != names[0] || filepath.Base(removed[1]) != FileName(times[2]) {
+		t.Errorf("Rotate removed %q, want the two oldest", removed)
+	}
+	backups, err = List(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 2 || !backups[0].CreatedAt.Equal(times[1]) || !backups[1].CreatedAt.Equal(times[0]) {
+		t.Errorf("archives after Rotate = %+v, want the two of 02:00:00", backups)
+	}
+}
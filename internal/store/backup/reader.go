@@ -0,0 +1,261 @@
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"golang/internal/models"
+	"golang/internal/store/storecopy"
+)
+
+// Archive is an archive file opened for reading. It is a storecopy.Source
+// that reads the file again for each kind of records, so that restoring an
+// archive takes no more memory than copying a store.
+type Archive struct {
+	path   string
+	Header Header
+}
+
+// Open reads the header of the archive at path. Validate checks the rest.
+func Open(path string) (*Archive, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s is not gzip-compressed: %w", ErrInvalidArchive, path, err)
+	}
+	first, err := bufio.NewReader(gz).ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s has no header: %w", ErrInvalidArchive, path, err)
+	}
+
+	a := &Archive{path: path}
+	var l line
+	if err := decode(first, &l); err != nil || l.Kind != headerKind {
+		return nil, fmt.Errorf("%w: %s has no header", ErrInvalidArchive, path)
+	}
+	if err := decode(l.Data, &a.Header); err != nil {
+		return nil, fmt.Errorf("%w: bad header: %w", ErrInvalidArchive, err)
+	}
+	if a.Header.Format != Format {
+		return nil, fmt.Errorf("%w: %s is not a %s archive", ErrInvalidArchive, path, Format)
+	}
+	if a.Header.Version < 1 || a.Header.Version > FormatVersion {
+		return nil, fmt.Errorf("%w: format version %d is not supported (this build reads versions 1 to %d)",
+			ErrInvalidArchive, a.Header.Version, FormatVersion)
+	}
+	return a, nil
+}
+
+// Validate reads the whole archive, checking that it is complete, that
+// every record decodes, and that the records match the checksums of the
+// trailer. It returns the trailer.
+func (a *Archive) Validate(ctx context.Context) (Trailer, error) {
+	s := storecopy.NewSummarizer()
+	trailer, err := a.scan(ctx, func(kind string, record any) error {
+		return s.Add(record)
+	})
+	if err != nil {
+		return Trailer{}, err
+	}
+
+	var mismatched []string
+	for kind, sum := range s.Summaries() {
+		if trailer.Records[kind] != sum {
+			mismatched = append(mismatched, kind)
+		}
+	}
+	if len(mismatched) > 0 {
+		slices.Sort(mismatched)
+		return Trailer{}, fmt.Errorf("%w: the %s do not match the checksums of the trailer",
+			ErrInvalidArchive, strings.Join(mismatched, ", "))
+	}
+	return trailer, nil
+}
+
+func (a *Archive) Tenants(ctx context.Context) ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	err := each(ctx, a, storecopy.Tenants, func(batch []models.Tenant) error {
+		tenants = append(tenants, batch...)
+		return nil
+	})
+	return tenants, err
+}
+
+func (a *Archive) Contacts(ctx context.Context, fn func([]storecopy.Contact) error) error {
+	return each(ctx, a, storecopy.Contacts, fn)
+}
+
+func (a *Archive) Groups(ctx context.Context, fn func([]storecopy.Group) error) error {
+	return each(ctx, a, storecopy.Groups, fn)
+}
+
+func (a *Archive) Outbox(ctx context.Context, fn func([]models.OutboxMessage) error) error {
+	return each(ctx, a, storecopy.Outbox, func(msgs []models.OutboxMessage) error {
+		for i := range msgs {
+			// version 1 archives do not record the tenant
+			if msgs[i].Tenant == "" {
+				msgs[i].Tenant = models.DefaultTenant
+			}
+		}
+		return fn(msgs)
+	})
+}
+
+func (a *Archive) Audit(ctx context.Context, fn func([]models.AuditEntry) error) error {
+	return each(ctx, a, storecopy.Audit, fn)
+}
+
+// each hands the records of a kind to fn in batches of storecopy.BatchSize
+func each[T any](ctx context.Context, a *Archive, kind string, fn func([]T) error) error {
+	var batch []T
+	_, err := a.scan(ctx, func(k string, record any) error {
+		if k != kind {
+			return nil
+		}
+		batch = append(batch, record.(T))
+		if len(batch) < storecopy.BatchSize {
+			return nil
+		}
+		err := fn(batch)
+		batch = nil
+		return err
+	})
+	if err == nil && len(batch) > 0 {
+		err = fn(batch)
+	}
+	return err
+}
+
+// scan reads the archive line by line, handing each record to fn, and
+// returns the trailer once it has checked the lines against it
+func (a *Archive) scan(ctx context.Context, fn func(kind string, record any) error) (Trailer, error) {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return Trailer{}, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return Trailer{}, fmt.Errorf("%w: %w", ErrInvalidArchive, err)
+	}
+
+	r := bufio.NewReader(gz)
+	hash := sha256.New()
+	var trailer *Trailer
+	position := 0 // index in storecopy.Kinds of the current kind
+	for n := 1; ; n++ {
+		if err := ctx.Err(); err != nil {
+			return Trailer{}, err
+		}
+		data, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(data) == 0 {
+			break
+		}
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF // the last line is cut short
+		}
+		if err != nil {
+			return Trailer{}, fmt.Errorf("%w: line %d: %w", ErrInvalidArchive, n, err)
+		}
+		if trailer != nil {
+			return Trailer{}, fmt.Errorf("%w: line %d follows the trailer", ErrInvalidArchive, n)
+		}
+
+		var l line
+		if err := decode(data, &l); err != nil {
+			return Trailer{}, fmt.Errorf("%w: line %d: %w", ErrInvalidArchive, n, err)
+		}
+		switch {
+		case n == 1 || l.Kind == headerKind:
+			if n != 1 || l.Kind != headerKind {
+				return Trailer{}, fmt.Errorf("%w: the header is not on the first line", ErrInvalidArchive)
+			}
+		case l.Kind == trailerKind:
+			trailer = &Trailer{}
+			if err := decode(l.Data, trailer); err != nil {
+				return Trailer{}, fmt.Errorf("%w: bad trailer: %w", ErrInvalidArchive, err)
+			}
+			if hex.EncodeToString(hash.Sum(nil)) != trailer.Checksum {
+				return Trailer{}, fmt.Errorf("%w: the checksum does not match the content", ErrInvalidArchive)
+			}
+			continue
+		default:
+			i := slices.Index(storecopy.Kinds, l.Kind)
+			if i < position {
+				return Trailer{}, fmt.Errorf("%w: line %d holds an unexpected %q record", ErrInvalidArchive, n, l.Kind)
+			}
+			position = i
+			record, err := decodeRecord(l.Kind, l.Data)
+			if err != nil {
+				return Trailer{}, fmt.Errorf("%w: line %d: bad %s record: %w", ErrInvalidArchive, n, l.Kind, err)
+			}
+			if err := fn(l.Kind, record); err != nil {
+				return Trailer{}, err
+			}
+		}
+		hash.Write(data)
+	}
+
+	if trailer == nil {
+		return Trailer{}, fmt.Errorf("%w: the archive is truncated, its trailer is missing", ErrInvalidArchive)
+	}
+	return *trailer, nil
+}
+
+// decodeRecord decodes a record of a kind into its type
+func decodeRecord(kind string, data []byte) (any, error) {
+	var record any
+	var err error
+	switch kind {
+	case storecopy.Tenants:
+		record, err = decodeAs[models.Tenant](data)
+	case storecopy.Contacts:
+		record, err = decodeAs[storecopy.Contact](data)
+	case storecopy.Groups:
+		record, err = decodeAs[storecopy.Group](data)
+	case storecopy.Outbox:
+		record, err = decodeAs[models.OutboxMessage](data)
+	case storecopy.Audit:
+		record, err = decodeAs[models.AuditEntry](data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func decodeAs[T any](data []byte) (any, error) {
+	var record T
+	err := decode(data, &record)
+	return record, err
+}
+
+// decode decodes JSON strictly: fields this build does not know about are
+// an error rather than data silently lost
+func decode(data []byte, v any) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+	if d.More() {
+		return errors.New("unexpected data after the value")
+	}
+	return nil
+}
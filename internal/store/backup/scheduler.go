@@ -0,0 +1,76 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang/internal/config"
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// Scheduler backs a store up to a directory at a fixed interval, keeping the
+// newest archives only
+type Scheduler struct {
+	store  *interfaces.Store
+	header Header
+	cfg    config.BackupConfig
+}
+
+// NewScheduler creates a scheduler writing archives with header to the
+// directory of cfg
+func NewScheduler(store *interfaces.Store, header Header, cfg config.BackupConfig) *Scheduler {
+	return &Scheduler{store: store, header: header, cfg: cfg}
+}
+
+// Run takes a backup every interval until ctx is cancelled. A backup in
+// progress when ctx is cancelled is abandoned, leaving no partial archive.
+func (s *Scheduler) Run(ctx context.Context) {
+	ctx = models.WithPrincipal(ctx, models.SystemPrincipal("backup"))
+	ticker := time.NewTicker(time.Duration(s.cfg.Interval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		path, err := s.Backup(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Backup: failed: %v", err)
+			}
+			continue
+		}
+		log.Printf("Backup: wrote %s", path)
+	}
+}
+
+// Backup writes an archive to the directory, then removes the archives
+// past the number to keep. It returns the path of the archive.
+func (s *Scheduler) Backup(ctx context.Context) (string, error) {
+	if err := os.MkdirAll(s.cfg.Dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	header := s.header
+	header.CreatedAt = time.Now().UTC()
+	path := filepath.Join(s.cfg.Dir, FileName(header.CreatedAt))
+	if _, err := WriteFile(ctx, path, s.store, header); err != nil {
+		return "", err
+	}
+
+	removed, err := Rotate(s.cfg.Dir, s.cfg.Keep)
+	for _, old := range removed {
+		log.Printf("Backup: removed %s", old)
+	}
+	if err != nil {
+		return path, fmt.Errorf("failed to remove old backups: %w", err)
+	}
+	return path, nil
+}
@@ -26,7 +26,7 @@ func New(cfg *config.Config, db *sql.DB) (*interfaces.Store, error) {
 		}
 		return postgres.NewStorage(db), nil
 	case config.FileStore:
-		return filestore.NewStorage(cfg.Store.FileStore.FilePath)
+		return filestore.NewStorage(cfg.Store.FileStore.FilePath, cfg.Store.FileStore.Generations)
 	default:
 		return nil, fmt.Errorf("unsupported store type: %s", cfg.Store.Type)
 	}
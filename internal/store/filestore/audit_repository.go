@@ -0,0 +1,94 @@
+package filestore
+
+import (
+	"context"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// AuditRepository is the file-based implementation of AuditRepositoryInterface.
+// Entries are only ever appended to the file, never rewritten or removed.
+type AuditRepository struct {
+	db        *DB
+	file_name string
+}
+
+// NewAuditRepository creates an audit repository backed by file_name inside db
+func NewAuditRepository(db *DB, file_name string) interfaces.AuditRepositoryInterface {
+	return &AuditRepository{
+		db:        db,
+		file_name: file_name,
+	}
+}
+
+func (r *AuditRepository) readEntries(tx *fileTx) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	if err := tx.read(r.file_name, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *AuditRepository) Append(ctx context.Context, entry models.AuditEntry) (int, error) {
+	err := r.db.update(ctx, func(tx *fileTx) error {
+		entries, err := r.readEntries(tx)
+		if err != nil {
+			return err
+		}
+
+		entry.ID = 1
+		if len(entries) > 0 {
+			entry.ID = entries[len(entries)-1].ID + 1
+		}
+		return tx.write(r.file_name, append(entries, entry))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return entry.ID, nil
+}
+
+func (r *AuditRepository) ListByContact(ctx context.Context, tenant string, contactID int) ([]models.AuditEntry, error) {
+	var matched []models.AuditEntry
+	err := r.db.view(ctx, func(tx *fileTx) error {
+		entries, err := r.readEntries(tx)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			// entries written before tenants existed belong to the default one
+			if e.Tenant == "" {
+				e.Tenant = models.DefaultTenant
+			}
+			if e.Tenant == tenant && e.ContactID == contactID {
+				matched = append(matched, e)
+			}
+		}
+		return nil
+	})
+	return matched, err
+}
+
+func (r *AuditRepository) List(ctx context.Context, afterID int, limit int) ([]models.AuditEntry, error) {
+	var listed []models.AuditEntry
+	err := r.db.view(ctx, func(tx *fileTx) error {
+		entries, err := r.readEntries(tx)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if len(listed) == limit {
+				break
+			}
+			if e.ID > afterID {
+				if e.Tenant == "" {
+					e.Tenant = models.DefaultTenant
+				}
+				listed = append(listed, e)
+			}
+		}
+		return nil
+	})
+	return listed, err
+}
@@ -2,9 +2,9 @@ package filestore
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
+	"slices"
+	"sort"
 	"sync"
 
 	"golang/internal/models"
@@ -14,76 +14,134 @@ import (
 
 // ContactRepository is the file-based implementation of ContactRepositoryInterface
 // It stores contacts in a JSON file, demonstrating an alternative to SQL storage
+// Each tenant has its own file, see tenantFileName.
 type ContactRepository struct {
-	file_path string
-	mu        sync.RWMutex // Protects concurrent access to the file
+	db          *DB
+	file_name   string
+	groups_file string // base name of the per-tenant groups files
+
+	indexMu sync.Mutex
+	indexes map[string]*searchIndex // by tenant, see Search
 }
 
-func NewContactRepository(file_path string) (interfaces.ContactRepositoryInterface, error) {
-	repo := &ContactRepository{
-		file_path: file_path,
+// NewContactRepository creates a contact repository backed by file_name inside
+// db, whose deleted contacts are also removed from the groups in groups_file
+func NewContactRepository(db *DB, file_name string, groups_file string) interfaces.ContactRepositoryInterface {
+	return &ContactRepository{
+		db:          db,
+		file_name:   file_name,
+		groups_file: groups_file,
 	}
+}
 
-	if _, err := os.Stat(file_path); os.IsNotExist(err) {
-		if err := repo.writeContacts([]models.Contact{}); err != nil {
-			return nil, fmt.Errorf("failed to initialize file store: %w", err)
+func (r *ContactRepository) readContacts(tx *fileTx, tenant string) ([]models.Contact, error) {
+	var contacts []models.Contact
+	if err := tx.read(tenantFileName(r.file_name, tenant), &contacts); err != nil {
+		return nil, err
+	}
+	// files written before versioning behave like the SQL column default
+	for i := range contacts {
+		if contacts[i].Version == 0 {
+			contacts[i].Version = 1
 		}
 	}
+	return contacts, nil
+}
 
-	return repo, nil
+func (r *ContactRepository) writeContacts(tx *fileTx, tenant string, contacts []models.Contact) error {
+	return tx.write(tenantFileName(r.file_name, tenant), contacts)
 }
 
-func (r *ContactRepository) readContacts() ([]models.Contact, error) {
-	data, err := os.ReadFile(r.file_path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read contacts file: %w", err)
+// getNextID hands out the ID of a new contact of the tenant, never that of
+// a deleted one
+func (r *ContactRepository) getNextID(tx *fileTx, tenant string, contacts []models.Contact) (int, error) {
+	maxID := 0
+	for _, c := range contacts {
+		if c.ID > maxID {
+			maxID = c.ID
+		}
 	}
+	return tx.nextID(tenantFileName(r.file_name, tenant), maxID)
+}
 
-	var contacts []models.Contact
-	if len(data) > 0 {
-		if err := json.Unmarshal(data, &contacts); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal contacts: %w", err)
+// checkEmailAvailable mirrors the UNIQUE constraint the SQL stores put on email
+func (r *ContactRepository) checkEmailAvailable(contacts []models.Contact, email string, exceptID int) error {
+	for _, c := range contacts {
+		if c.ID != exceptID && c.Email == email {
+			return fmt.Errorf("%w: a contact with this email already exists", models.ErrConflict)
 		}
 	}
+	return nil
+}
 
-	return contacts, nil
+func (r *ContactRepository) GetAll(ctx context.Context, tenant string) ([]models.Contact, error) {
+	var contacts []models.Contact
+	err := r.db.view(ctx, func(tx *fileTx) error {
+		var err error
+		contacts, err = r.readContacts(tx, tenant)
+		return err
+	})
+	return contacts, err
 }
 
-func (r *ContactRepository) writeContacts(contacts []models.Contact) error {
-	data, err := json.MarshalIndent(contacts, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal contacts: %w", err)
+// List filters and sorts the file contents in memory, then cuts the requested page
+func (r *ContactRepository) List(ctx context.Context, tenant string, q models.ContactQuery) (*models.ContactPage, error) {
+	if err := q.Normalize(); err != nil {
+		return nil, err
 	}
+	cursor, _ := q.DecodeCursor()
 
-	if err := os.WriteFile(r.file_path, data, 0644); err != nil {
-		return fmt.Errorf("failed to write contacts file: %w", err)
+	contacts, err := r.GetAll(ctx, tenant)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
-}
+	// less orders contacts by (sort value, id), honoring the direction
+	less := func(a, b models.Contact) bool {
+		if q.Descending {
+			a, b = b, a
+		}
+		av, bv := models.SortValue(a, q.SortBy), models.SortValue(b, q.SortBy)
+		if av != bv {
+			return av < bv
+		}
+		return a.ID < b.ID
+	}
+	var after models.Contact
+	if cursor != nil {
+		after = models.Contact{ID: cursor.ID}
+		switch q.SortBy {
+		case models.SortByFirstName:
+			after.FirstName = cursor.Value
+		case models.SortByLastName:
+			after.LastName = cursor.Value
+		case models.SortByEmail:
+			after.Email = cursor.Value
+		}
+	}
 
-func (r *ContactRepository) getNextID(contacts []models.Contact) int {
-	maxID := 0
+	matched := make([]models.Contact, 0, len(contacts))
 	for _, c := range contacts {
-		if c.ID > maxID {
-			maxID = c.ID
+		if !q.Matches(c) {
+			continue
+		}
+		if cursor != nil && !less(after, c) {
+			continue
 		}
+		matched = append(matched, c)
 	}
-	return maxID + 1
-}
-
-func (r *ContactRepository) GetAll(ctx context.Context) ([]models.Contact, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	sort.Slice(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
 
-	return r.readContacts()
+	page := &models.ContactPage{Contacts: matched}
+	if len(matched) > q.Limit {
+		page.Contacts = matched[:q.Limit]
+		page.NextCursor = q.CursorAfter(page.Contacts[q.Limit-1])
+	}
+	return page, nil
 }
 
-func (r *ContactRepository) GetByID(ctx context.Context, id int) (*models.Contact, error) {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	contacts, err := r.readContacts()
+func (r *ContactRepository) GetByID(ctx context.Context, tenant string, id int) (*models.Contact, error) {
+	contacts, err := r.GetAll(ctx, tenant)
 	if err != nil {
 		return nil, err
 	}
@@ -94,69 +152,148 @@ func (r *ContactRepository) GetByID(ctx context.Context, id int) (*models.Contac
 		}
 	}
 
-	return nil, fmt.Errorf("contact not found")
+	return nil, fmt.Errorf("%w: contact %d", models.ErrNotFound, id)
 }
 
-func (r *ContactRepository) Create(ctx context.Context, contact models.Contact) (int, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	contacts, err := r.readContacts()
+func (r *ContactRepository) GetByEmail(ctx context.Context, tenant string, email string) (*models.Contact, error) {
+	contacts, err := r.GetAll(ctx, tenant)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	for _, c := range contacts {
+		if c.Email == email {
+			return &c, nil
+		}
 	}
 
-	contact.ID = r.getNextID(contacts)
+	return nil, fmt.Errorf("%w: contact with email %s", models.ErrNotFound, email)
+}
+
+func (r *ContactRepository) Create(ctx context.Context, tenant string, contact models.Contact) (int, error) {
+	err := r.db.update(ctx, func(tx *fileTx) error {
+		contacts, err := r.readContacts(tx, tenant)
+		if err != nil {
+			return err
+		}
+
+		if err := r.checkEmailAvailable(contacts, contact.Email, 0); err != nil {
+			return err
+		}
+
+		contact.ID, err = r.getNextID(tx, tenant, contacts)
+		if err != nil {
+			return err
+		}
+		contact.Version = 1
 
-	contacts = append(contacts, contact)
+		contacts = append(contacts, contact)
 
-	if err := r.writeContacts(contacts); err != nil {
+		return r.writeContacts(tx, tenant, contacts)
+	})
+	if err != nil {
 		return 0, err
 	}
 
 	return contact.ID, nil
 }
 
-func (r *ContactRepository) Update(ctx context.Context, contact models.Contact) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+func (r *ContactRepository) Update(ctx context.Context, tenant string, contact models.Contact) error {
+	return r.db.update(ctx, func(tx *fileTx) error {
+		contacts, err := r.readContacts(tx, tenant)
+		if err != nil {
+			return err
+		}
 
-	contacts, err := r.readContacts()
-	if err != nil {
-		return err
-	}
+		i, err := r.indexOf(contacts, contact.ID, contact.Version)
+		if err != nil {
+			return err
+		}
 
-	found := false
-	for i, c := range contacts {
-		if c.ID == contact.ID {
-			contacts[i] = contact
-			found = true
-			break
+		if err := r.checkEmailAvailable(contacts, contact.Email, contact.ID); err != nil {
+			return err
 		}
-	}
 
-	if !found {
-		return fmt.Errorf("contact not found")
-	}
+		contact.Version = contacts[i].Version + 1
+		contacts[i] = contact
 
-	return r.writeContacts(contacts)
+		return r.writeContacts(tx, tenant, contacts)
+	})
 }
 
-func (r *ContactRepository) Delete(ctx context.Context, id int) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+func (r *ContactRepository) Patch(ctx context.Context, tenant string, id int, version int, patch models.ContactPatch) error {
+	return r.db.update(ctx, func(tx *fileTx) error {
+		contacts, err := r.readContacts(tx, tenant)
+		if err != nil {
+			return err
+		}
 
-	contacts, err := r.readContacts()
-	if err != nil {
+		i, err := r.indexOf(contacts, id, version)
+		if err != nil {
+			return err
+		}
+
+		if patch.Email != nil {
+			if err := r.checkEmailAvailable(contacts, *patch.Email, id); err != nil {
+				return err
+			}
+		}
+
+		patch.Apply(&contacts[i])
+		contacts[i].Version++
+
+		return r.writeContacts(tx, tenant, contacts)
+	})
+}
+
+func (r *ContactRepository) Delete(ctx context.Context, tenant string, id int, version int) error {
+	return r.db.update(ctx, func(tx *fileTx) error {
+		contacts, err := r.readContacts(tx, tenant)
+		if err != nil {
+			return err
+		}
+
+		i, err := r.indexOf(contacts, id, version)
+		if err != nil {
+			return err
+		}
+
+		if err := r.leaveGroups(tx, tenant, id); err != nil {
+			return err
+		}
+		return r.writeContacts(tx, tenant, append(contacts[:i], contacts[i+1:]...))
+	})
+}
+
+// leaveGroups removes a contact from every group of its tenant
+func (r *ContactRepository) leaveGroups(tx *fileTx, tenant string, id int) error {
+	var groups []groupRecord
+	if err := tx.read(tenantFileName(r.groups_file, tenant), &groups); err != nil {
 		return err
 	}
+	changed := false
+	for i := range groups {
+		if j := slices.Index(groups[i].Members, id); j >= 0 {
+			groups[i].Members = slices.Delete(groups[i].Members, j, j+1)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return tx.write(tenantFileName(r.groups_file, tenant), groups)
+}
 
-	newContacts := make([]models.Contact, 0, len(contacts))
-	for _, c := range contacts {
+// indexOf finds a contact, checking its version when version is non-zero
+func (r *ContactRepository) indexOf(contacts []models.Contact, id int, version int) (int, error) {
+	for i, c := range contacts {
 		if c.ID != id {
-			newContacts = append(newContacts, c)
+			continue
 		}
+		if version != 0 && c.Version != version {
+			return 0, fmt.Errorf("%w: contact %d", models.ErrVersionConflict, id)
+		}
+		return i, nil
 	}
-
-	return r.writeContacts(newContacts)
+	return 0, fmt.Errorf("%w: contact %d", models.ErrNotFound, id)
 }
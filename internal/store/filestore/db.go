@@ -0,0 +1,499 @@
+package filestore
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang/internal/models"
+)
+
+// journalName records the files of a commit that is being installed.
+// Its presence on startup means a commit was interrupted and must be replayed.
+const journalName = "commit.journal"
+
+// tempSuffix ends the names of files written before being renamed into place
+const tempSuffix = ".tmp"
+
+// lockName is the file every process using the directory locks, so that a
+// read-modify-write in one process cannot interleave with another's
+const lockName = ".lock"
+
+// DB coordinates the JSON files of the file store, playing the role *sql.DB
+// plays for the SQL stores: a single lock guards every file, and writes made
+// inside a transaction are staged and installed together on commit.
+// The in-process lock is backed by an advisory lock on lockName so that
+// several processes can share the directory.
+//
+// Files are replaced, never written in place, and synced to disk before and
+// after being renamed into place. The versions they replace are kept as
+// numbered generations (contacts.json.1 the newest), from which a file found
+// damaged on startup is recovered.
+type DB struct {
+	dir         string
+	ext         string // of the contacts files, see isDataFile
+	generations int
+	mu          sync.RWMutex
+	lock        *os.File
+
+	// readers counts the holders of mu's read lock; the first one takes the
+	// shared file lock and the last one releases it
+	readersMu sync.Mutex
+	readers   int
+
+	// commits counts the commits installed by this process, under the write
+	// lock, so that in-memory caches of the files can tell they are stale
+	commits uint64
+}
+
+type txKey struct{}
+
+// fileTx is the view of the files during one operation.
+// Writes are staged in memory until the outermost transaction commits.
+type fileTx struct {
+	db     *DB
+	staged map[string][]byte
+}
+
+// OpenDB prepares dir for use, finishing any interrupted commit and
+// recovering damaged files. file_name is the contacts file of the default
+// tenant, whose extension those of every tenant share. Commits keep the
+// given number of generations of each file they replace.
+func OpenDB(dir string, file_name string, generations int) (*DB, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create file store directory: %w", err)
+	}
+
+	lock, err := os.OpenFile(filepath.Join(dir, lockName), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file store lock: %w", err)
+	}
+
+	db := &DB{dir: dir, ext: filepath.Ext(file_name), generations: generations, lock: lock}
+	err = db.exclusive(db.recover)
+	if err != nil {
+		lock.Close()
+		return nil, fmt.Errorf("failed to recover file store: %w", err)
+	}
+	return db, nil
+}
+
+// Close releases the lock file
+func (d *DB) Close() error {
+	return d.lock.Close()
+}
+
+// WithinTransaction implements interfaces.TransactorInterface.
+// The write lock is held for the whole of fn.
+func (d *DB) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*fileTx); ok && tx.db == d {
+		return fn(ctx)
+	}
+
+	return d.exclusive(func() error {
+		tx := &fileTx{db: d, staged: map[string][]byte{}}
+		if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+			return err
+		}
+		return d.commit(tx.staged)
+	})
+}
+
+// view runs fn under the read lock, or inside the caller's transaction
+func (d *DB) view(ctx context.Context, fn func(tx *fileTx) error) error {
+	if tx, ok := ctx.Value(txKey{}).(*fileTx); ok && tx.db == d {
+		return fn(tx)
+	}
+
+	if err := d.rlock(); err != nil {
+		return err
+	}
+	defer d.runlock()
+	return fn(&fileTx{db: d})
+}
+
+// update runs fn under the write lock and commits what it wrote
+func (d *DB) update(ctx context.Context, fn func(tx *fileTx) error) error {
+	return d.WithinTransaction(ctx, func(ctx context.Context) error {
+		return fn(ctx.Value(txKey{}).(*fileTx))
+	})
+}
+
+// exclusive runs fn holding the write lock of this process and of the directory
+func (d *DB) exclusive(fn func() error) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := lockFile(d.lock, true); err != nil {
+		return fmt.Errorf("%w: failed to lock file store: %w", models.ErrUnavailable, err)
+	}
+	defer unlockFile(d.lock)
+	return fn()
+}
+
+// rlock takes the read lock of this process and a shared lock on the directory
+func (d *DB) rlock() error {
+	d.mu.RLock()
+
+	d.readersMu.Lock()
+	defer d.readersMu.Unlock()
+	if d.readers == 0 {
+		if err := lockFile(d.lock, false); err != nil {
+			d.mu.RUnlock()
+			return fmt.Errorf("%w: failed to lock file store: %w", models.ErrUnavailable, err)
+		}
+	}
+	d.readers++
+	return nil
+}
+
+func (d *DB) runlock() {
+	d.readersMu.Lock()
+	d.readers--
+	if d.readers == 0 {
+		unlockFile(d.lock)
+	}
+	d.readersMu.Unlock()
+
+	d.mu.RUnlock()
+}
+
+// read decodes the named file into v, leaving v untouched if the file is
+// missing or empty
+func (tx *fileTx) read(name string, v any) error {
+	data, ok := tx.staged[name]
+	if !ok {
+		var err error
+		data, err = os.ReadFile(filepath.Join(tx.db.dir, name))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%w: failed to read %s: %w", models.ErrUnavailable, name, err)
+		}
+	}
+
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %w", name, err)
+	}
+	return nil
+}
+
+// write stages v as the new content of the named file
+func (tx *fileTx) write(name string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", name, err)
+	}
+	tx.staged[name] = data
+	return nil
+}
+
+// remove stages the removal of the named file. Removals are staged as empty
+// content, which write never produces.
+func (tx *fileTx) remove(name string) {
+	tx.staged[name] = []byte{}
+}
+
+// commit installs staged files by writing them next to their targets and
+// renaming them into place. When more than one file changes, the list is
+// journaled first so that an interrupted commit is completed on restart.
+// Everything is synced to disk before the first rename, so that a crash
+// leaves either the old or the new content of each file, never a part.
+func (d *DB) commit(staged map[string][]byte) error {
+	if len(staged) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(staged))
+	for name, data := range staged {
+		if err := writeFileSync(d.tempPath(name), data); err != nil {
+			return fmt.Errorf("%w: failed to write %s: %w", models.ErrUnavailable, name, err)
+		}
+		names = append(names, name)
+	}
+
+	if len(names) > 1 {
+		journal, _ := json.Marshal(names)
+		if err := writeFileSync(filepath.Join(d.dir, journalName), journal); err != nil {
+			return fmt.Errorf("%w: failed to write commit journal: %w", models.ErrUnavailable, err)
+		}
+	}
+	if err := syncDir(d.dir); err != nil {
+		return fmt.Errorf("%w: failed to sync file store directory: %w", models.ErrUnavailable, err)
+	}
+
+	if err := d.install(names, false); err != nil {
+		return fmt.Errorf("%w: %w", models.ErrUnavailable, err)
+	}
+	return nil
+}
+
+// install renames staged files into place, keeping the files they replace
+// as generations, or removes the files whose staged content is empty along
+// with their generations, and clears the journal. A replay of a journal
+// does not keep again the generation an interrupted install already kept.
+func (d *DB) install(names []string, replay bool) error {
+	d.commits++
+	for _, name := range names {
+		info, err := os.Stat(d.tempPath(name))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // installed before the commit was interrupted
+		}
+		if err != nil {
+			return fmt.Errorf("failed to install %s: %w", name, err)
+		}
+
+		if info.Size() == 0 {
+			err = d.removeGenerations(name)
+			if err == nil {
+				err = os.Remove(filepath.Join(d.dir, name))
+			}
+			if err == nil || errors.Is(err, fs.ErrNotExist) {
+				err = os.Remove(d.tempPath(name))
+			}
+		} else {
+			if !replay || !d.generationKept(name) {
+				err = d.keepGeneration(name)
+			}
+			if err == nil {
+				err = os.Rename(d.tempPath(name), filepath.Join(d.dir, name))
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("failed to install %s: %w", name, err)
+		}
+	}
+	if err := syncDir(d.dir); err != nil {
+		return fmt.Errorf("failed to sync file store directory: %w", err)
+	}
+
+	err := os.Remove(filepath.Join(d.dir, journalName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to clear commit journal: %w", err)
+	}
+	return nil
+}
+
+// keepGeneration shifts the generations of the named file one up, dropping
+// the oldest, and links the current file as the newest. The link shares the
+// content of the file, already on disk, rather than copying it.
+func (d *DB) keepGeneration(name string) error {
+	path := filepath.Join(d.dir, name)
+	if _, err := os.Stat(path); d.generations == 0 || errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+
+	for n := d.generations; n > 1; n-- {
+		err := os.Rename(d.generationPath(name, n-1), d.generationPath(name, n))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	newest := d.generationPath(name, 1)
+	if err := os.Remove(newest); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.Link(path, newest); err != nil {
+		// file systems without hard links get a copy
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return writeFileSync(newest, data)
+	}
+	return nil
+}
+
+// generationKept tells whether the newest generation of the named file
+// already holds its current content: the link keepGeneration made, or the
+// copy it made on file systems without hard links
+func (d *DB) generationKept(name string) bool {
+	if d.generations == 0 {
+		return false
+	}
+	current, err := os.Stat(filepath.Join(d.dir, name))
+	if err != nil {
+		return false
+	}
+	newest, err := os.Stat(d.generationPath(name, 1))
+	if err != nil {
+		return false
+	}
+	if os.SameFile(current, newest) {
+		return true
+	}
+	if current.Size() != newest.Size() {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(d.dir, name))
+	if err != nil {
+		return false
+	}
+	kept, err := os.ReadFile(d.generationPath(name, 1))
+	return err == nil && bytes.Equal(data, kept)
+}
+
+// removeGenerations removes every generation of the named file
+func (d *DB) removeGenerations(name string) error {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		generation := entry.Name()
+		if _, ok := generationOf(generation); !ok || strings.TrimSuffix(generation, filepath.Ext(generation)) != name {
+			continue
+		}
+		if err := os.Remove(filepath.Join(d.dir, generation)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// recover replays a journaled commit, drops temp files of commits that
+// never reached their journal, then recovers damaged files. Only the temp
+// files the store writes, those of its data files, are removed: other
+// programs sharing the directory keep theirs.
+func (d *DB) recover() error {
+	journal, err := os.ReadFile(filepath.Join(d.dir, journalName))
+	if err == nil {
+		// a journal cut short was never complete, so its commit never
+		// started renaming and the temp files below are simply discarded
+		var names []string
+		if json.Unmarshal(journal, &names) != nil {
+			names = nil
+		}
+		if err := d.install(names, true); err != nil {
+			return err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read commit journal: %w", err)
+	}
+
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), tempSuffix)
+		if ok && entry.Type().IsRegular() && d.isDataFile(name) {
+			os.Remove(filepath.Join(d.dir, entry.Name()))
+		}
+	}
+	return d.repair()
+}
+
+// repair checks that every file holds valid JSON. Writes never leave a file
+// empty or cut short, but a crash of a system that did not honour the syncs,
+// or a damaged disk, may. Such a file is replaced with its newest valid
+// generation; one without any is an error, rather than data silently lost.
+func (d *DB) repair() error {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !d.isDataFile(name) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(d.dir, name))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		if json.Valid(data) {
+			continue
+		}
+
+		good, n := d.lastGoodGeneration(name)
+		switch {
+		case good != nil:
+			if err := writeFileSync(d.tempPath(name), good); err != nil {
+				return fmt.Errorf("failed to recover %s: %w", name, err)
+			}
+			if err := os.Rename(d.tempPath(name), filepath.Join(d.dir, name)); err != nil {
+				return fmt.Errorf("failed to recover %s: %w", name, err)
+			}
+			if err := syncDir(d.dir); err != nil {
+				return fmt.Errorf("failed to recover %s: %w", name, err)
+			}
+			log.Printf("FileStore: %s was damaged, recovered it from %s", name, filepath.Base(d.generationPath(name, n)))
+		case len(data) == 0:
+			// an empty file holds no records, as if missing
+		default:
+			return fmt.Errorf("%s is damaged and has no valid generation to recover from", name)
+		}
+	}
+	return nil
+}
+
+// lastGoodGeneration returns the content and number of the newest
+// generation of the named file holding valid JSON, or nil
+func (d *DB) lastGoodGeneration(name string) ([]byte, int) {
+	for n := 1; n <= max(d.generations, 1); n++ {
+		data, err := os.ReadFile(d.generationPath(name, n))
+		if err == nil && len(data) > 0 && json.Valid(data) {
+			return data, n
+		}
+	}
+	return nil, 0
+}
+
+// generationPath is the path of generation n of the named file
+func (d *DB) generationPath(name string, n int) string {
+	return filepath.Join(d.dir, name+"."+strconv.Itoa(n))
+}
+
+// generationOf tells whether a file name is that of a generation, and its
+// number
+func generationOf(name string) (int, bool) {
+	ext := filepath.Ext(name)
+	n, err := strconv.Atoi(strings.TrimPrefix(ext, "."))
+	return n, err == nil && n > 0 && len(ext) > 1
+}
+
+// isDataFile tells whether a file name is that of a JSON file of the store,
+// rather than its lock, journal, a temp file, a generation, or a file of
+// something else sharing the directory, such as a SQLite database. The
+// contacts files take the extension of the configured one, the other files
+// of the store .json.
+func (d *DB) isDataFile(name string) bool {
+	ext := filepath.Ext(name)
+	return ext == ".json" || ext == d.ext && d.ext != ""
+}
+
+// writeFileSync writes data to a new file at path and syncs it to disk
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// tempPath is the path the named file is written to before being renamed
+// into place
+func (d *DB) tempPath(name string) string {
+	return filepath.Join(d.dir, name+tempSuffix)
+}
@@ -0,0 +1,300 @@
+package filestore
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+func TestCommitKeepsGenerations(t *testing.T) {
+	dir := t.TempDir()
+	store := openStore(t, dir, 2)
+	for i := range 4 {
+		createContact(t, store, i)
+	}
+
+	// the file and its two previous versions, the oldest dropped
+	for name, want := range map[string]int{"contacts.json": 4, "contacts.json.1": 3, "contacts.json.2": 2} {
+		var contacts []models.Contact
+		readJSON(t, filepath.Join(dir, name), &contacts)
+		if len(contacts) != want {
+			t.Errorf("%s holds %d contacts, want %d", name, len(contacts), want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "contacts.json.3")); err == nil {
+		t.Errorf("contacts.json.3 exists, want only 2 generations")
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(leftovers) > 0 {
+		t.Errorf("temp files left behind: %v", leftovers)
+	}
+}
+
+func TestRemovedFileDropsGenerations(t *testing.T) {
+	dir := t.TempDir()
+	store := openStore(t, dir, 2)
+	ctx := context.Background()
+	if err := store.Tenant.Create(ctx, models.Tenant{ID: "sales", Name: "Sales"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 2 {
+		if _, err := store.Contact.Create(ctx, "sales", contact(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.Tenant.Delete(ctx, "sales"); err != nil {
+		t.Fatal(err)
+	}
+
+	if left, _ := filepath.Glob(filepath.Join(dir, "contacts.sales.json*")); len(left) > 0 {
+		t.Errorf("files of the deleted tenant left behind: %v", left)
+	}
+}
+
+func TestOpenRecoversDamagedFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		damage func(data []byte) []byte
+	}{
+		{"Truncated", func(data []byte) []byte { return data[:len(data)/2] }},
+		{"Empty", func(data []byte) []byte { return nil }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store := openStore(t, dir, 2)
+			for i := range 3 {
+				createContact(t, store, i)
+			}
+			path := filepath.Join(dir, "contacts.json")
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tt.damage(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			// the last good copy holds every contact but the latest
+			contacts, err := openStore(t, dir, 2).Contact.GetAll(context.Background(), models.DefaultTenant)
+			if err != nil {
+				t.Fatalf("GetAll after recovery: %v", err)
+			}
+			if len(contacts) != 2 {
+				t.Errorf("recovered %d contacts, want 2", len(contacts))
+			}
+		})
+	}
+}
+
+func TestOpenRefusesDamagedFileWithoutGeneration(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "contacts.json"), []byte(`[{"id": 1, "first_na`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStorage(filepath.Join(dir, "contacts.json"), 2); err == nil {
+		t.Errorf("NewStorage opened a damaged file without generations")
+	}
+
+	// files of other programs sharing the directory are left alone
+	if err := os.Remove(filepath.Join(dir, "contacts.json")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "contacts.db"), []byte("SQLite format 3\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewStorage(filepath.Join(dir, "contacts.json"), 2); err != nil {
+		t.Errorf("NewStorage next to a non-JSON file: %v", err)
+	}
+}
+
+func TestOpenKeepsForeignTempFiles(t *testing.T) {
+	dir := t.TempDir()
+	createContact(t, openStore(t, dir, 2), 0)
+
+	// a commit cut short leaves the store's temp files, which are dropped,
+	// next to those of other programs, which are not
+	ours := []string{"contacts.json.tmp", "outbox.json.tmp"}
+	foreign := []string{"notes.txt.tmp", ".contacts-20260101T000000Z.jsonl.gz.123.tmp", "report.tmp"}
+	for _, name := range append(ours, foreign...) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("partial"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	openStore(t, dir, 2)
+
+	for _, name := range ours {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("%s left behind", name)
+		}
+	}
+	for _, name := range foreign {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s of another program removed: %v", name, err)
+		}
+	}
+}
+
+func TestOpenReplaysJournalOnce(t *testing.T) {
+	dir := t.TempDir()
+	store := openStore(t, dir, 3)
+	for i := range 2 {
+		createContact(t, store, i)
+	}
+
+	// a commit of the contacts and the outbox cut short once the contacts
+	// file was kept as a generation, before it was renamed over
+	if err := store.Closer.(*DB).keepGeneration("contacts.json"); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+	staged, _ := json.Marshal([]models.Contact{contact(0), contact(1), contact(2)})
+	for name, data := range map[string][]byte{"contacts.json.tmp": staged, "outbox.json.tmp": []byte("[]"),
+		journalName: []byte(`["contacts.json","outbox.json"]`)} {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	openStore(t, dir, 3)
+
+	// the replay keeps no second copy of the generation
+	for name, want := range map[string]int{"contacts.json": 3, "contacts.json.1": 2, "contacts.json.2": 1} {
+		var contacts []models.Contact
+		readJSON(t, filepath.Join(dir, name), &contacts)
+		if len(contacts) != want {
+			t.Errorf("%s holds %d contacts, want %d", name, len(contacts), want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "contacts.json.3")); err == nil {
+		t.Errorf("contacts.json.3 exists, want 2 generations")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "outbox.json")); err != nil {
+		t.Errorf("outbox.json not installed: %v", err)
+	}
+}
+
+func TestOpenRecoversFilesOfConfiguredExtension(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "contacts.data")
+	store, err := NewStorage(path, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range 2 {
+		createContact(t, store, i)
+	}
+	store.Close()
+
+	if err := os.WriteFile(path, []byte(`[{"id": 2, "first_na`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".tmp", []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store, err = NewStorage(path, 2)
+	if err != nil {
+		t.Fatalf("NewStorage: %v", err)
+	}
+	defer store.Close()
+
+	contacts, err := store.Contact.GetAll(context.Background(), models.DefaultTenant)
+	if err != nil {
+		t.Fatalf("GetAll after recovery: %v", err)
+	}
+	if len(contacts) != 1 {
+		t.Errorf("recovered %d contacts, want 1", len(contacts))
+	}
+	if _, err := os.Stat(path + ".tmp"); err == nil {
+		t.Errorf("contacts.data.tmp left behind")
+	}
+}
+
+func openStore(t *testing.T, dir string, generations int) *interfaces.Store {
+	t.Helper()
+	store, err := NewStorage(filepath.Join(dir, "contacts.json"), generations)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func contact(i int) models.Contact {
+	return models.Contact{
+		FirstName: fmt.Sprintf("First%d", i),
+		LastName:  fmt.Sprintf("Last%d", i),
+		Email:     fmt.Sprintf("contact%d@example.com", i),
+	}
+}
+
+func createContact(t *testing.T, store *interfaces.Store, i int) {
+	t.Helper()
+	if _, err := store.Contact.Create(context.Background(), models.DefaultTenant, contact(i)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+}
+
+func readJSON(t *testing.T, path string, v any) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+}
+
+func TestSearchIndexSeesSameSizeRewrites(t *testing.T) {
+	dir := t.TempDir()
+	store, other := openStore(t, dir, 1), openStore(t, dir, 1)
+	ctx := context.Background()
+	createContact(t, store, 0)
+	path := filepath.Join(dir, "contacts.json")
+
+	// rename the contact keeping the file size and time, as a rewrite within
+	// the file system's time resolution would
+	rename := func(store *interfaces.Store, name string) {
+		t.Helper()
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := store.Contact.GetByID(ctx, models.DefaultTenant, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.FirstName = name
+		if err := store.Contact.Update(ctx, models.DefaultTenant, *c); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(text string) int {
+		t.Helper()
+		found, err := store.Contact.Search(ctx, models.DefaultTenant, models.ContactSearch{Text: text})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(found)
+	}
+
+	if n := search("First0"); n != 1 {
+		t.Fatalf("Search found %d contacts, want 1", n)
+	}
+	rename(store, "Second")
+	if n := search("Second"); n != 1 {
+		t.Errorf("Search after a rewrite by this store found %d contacts, want 1", n)
+	}
+	rename(other, "Thirds")
+	if n := search("Thirds"); n != 1 {
+		t.Errorf("Search after a rewrite by another store found %d contacts, want 1", n)
+	}
+}
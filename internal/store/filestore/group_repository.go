@@ -0,0 +1,269 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// GroupRepository is the file-based implementation of GroupRepositoryInterface.
+// Each tenant has its own file, see tenantFileName, holding the groups along
+// with the IDs of their members.
+type GroupRepository struct {
+	db        *DB
+	file_name string
+	contacts  *ContactRepository
+}
+
+// groupRecord is a group as stored in the file
+type groupRecord struct {
+	models.Group
+	Members []int `json:"members,omitempty"`
+}
+
+// NewGroupRepository creates a group repository backed by file_name inside
+// db, for the contacts stored next to it in contacts_file
+func NewGroupRepository(db *DB, file_name string, contacts_file string) interfaces.GroupRepositoryInterface {
+	return &GroupRepository{
+		db:        db,
+		file_name: file_name,
+		contacts:  &ContactRepository{db: db, file_name: contacts_file, groups_file: file_name},
+	}
+}
+
+func (r *GroupRepository) readGroups(tx *fileTx, tenant string) ([]groupRecord, error) {
+	var groups []groupRecord
+	if err := tx.read(tenantFileName(r.file_name, tenant), &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func (r *GroupRepository) writeGroups(tx *fileTx, tenant string, groups []groupRecord) error {
+	return tx.write(tenantFileName(r.file_name, tenant), groups)
+}
+
+// indexOf finds a group in the file contents
+func (r *GroupRepository) indexOf(groups []groupRecord, id int) (int, error) {
+	for i, g := range groups {
+		if g.ID == id {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: group %d", models.ErrNotFound, id)
+}
+
+// checkNameAvailable mirrors the UNIQUE constraint the SQL stores put on name
+func (r *GroupRepository) checkNameAvailable(groups []groupRecord, name string, exceptID int) error {
+	for _, g := range groups {
+		if g.ID != exceptID && g.Name == name {
+			return fmt.Errorf("%w: a group named %q already exists", models.ErrConflict, name)
+		}
+	}
+	return nil
+}
+
+func (r *GroupRepository) List(ctx context.Context, tenant string) ([]models.Group, error) {
+	var groups []models.Group
+	err := r.db.view(ctx, func(tx *fileTx) error {
+		stored, err := r.readGroups(tx, tenant)
+		groups = sortedGroups(stored, func(groupRecord) bool { return true })
+		return err
+	})
+	return groups, err
+}
+
+func (r *GroupRepository) GetByID(ctx context.Context, tenant string, id int) (*models.Group, error) {
+	var group models.Group
+	err := r.db.view(ctx, func(tx *fileTx) error {
+		groups, err := r.readGroups(tx, tenant)
+		if err != nil {
+			return err
+		}
+		i, err := r.indexOf(groups, id)
+		if err != nil {
+			return err
+		}
+		group = groups[i].Group
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+func (r *GroupRepository) Create(ctx context.Context, tenant string, group models.Group) (int, error) {
+	err := r.db.update(ctx, func(tx *fileTx) error {
+		groups, err := r.readGroups(tx, tenant)
+		if err != nil {
+			return err
+		}
+		if err := r.checkNameAvailable(groups, group.Name, 0); err != nil {
+			return err
+		}
+
+		highest := 0
+		for _, g := range groups {
+			highest = max(highest, g.ID)
+		}
+		// the ID of a deleted group is not handed out again
+		group.ID, err = tx.nextID(tenantFileName(r.file_name, tenant), highest)
+		if err != nil {
+			return err
+		}
+		return r.writeGroups(tx, tenant, append(groups, groupRecord{Group: group}))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return group.ID, nil
+}
+
+func (r *GroupRepository) Update(ctx context.Context, tenant string, group models.Group) error {
+	return r.db.update(ctx, func(tx *fileTx) error {
+		groups, err := r.readGroups(tx, tenant)
+		if err != nil {
+			return err
+		}
+		i, err := r.indexOf(groups, group.ID)
+		if err != nil {
+			return err
+		}
+		if err := r.checkNameAvailable(groups, group.Name, group.ID); err != nil {
+			return err
+		}
+
+		groups[i].Name = group.Name
+		groups[i].Description = group.Description
+		return r.writeGroups(tx, tenant, groups)
+	})
+}
+
+func (r *GroupRepository) Delete(ctx context.Context, tenant string, id int) error {
+	return r.db.update(ctx, func(tx *fileTx) error {
+		groups, err := r.readGroups(tx, tenant)
+		if err != nil {
+			return err
+		}
+		i, err := r.indexOf(groups, id)
+		if err != nil {
+			return err
+		}
+		return r.writeGroups(tx, tenant, append(groups[:i], groups[i+1:]...))
+	})
+}
+
+func (r *GroupRepository) AddMember(ctx context.Context, tenant string, groupID, contactID int) error {
+	return r.db.update(ctx, func(tx *fileTx) error {
+		groups, err := r.readGroups(tx, tenant)
+		if err != nil {
+			return err
+		}
+		i, err := r.indexOf(groups, groupID)
+		if err != nil {
+			return err
+		}
+		contacts, err := r.contacts.readContacts(tx, tenant)
+		if err != nil {
+			return err
+		}
+		if _, err := r.contacts.indexOf(contacts, contactID, 0); err != nil {
+			return err
+		}
+
+		if slices.Contains(groups[i].Members, contactID) {
+			return nil
+		}
+		groups[i].Members = append(groups[i].Members, contactID)
+		slices.Sort(groups[i].Members)
+		return r.writeGroups(tx, tenant, groups)
+	})
+}
+
+func (r *GroupRepository) RemoveMember(ctx context.Context, tenant string, groupID, contactID int) error {
+	return r.db.update(ctx, func(tx *fileTx) error {
+		groups, err := r.readGroups(tx, tenant)
+		if err != nil {
+			return err
+		}
+		i, err := r.indexOf(groups, groupID)
+		j := -1
+		if err == nil {
+			j = slices.Index(groups[i].Members, contactID)
+		}
+		if j < 0 {
+			return fmt.Errorf("%w: membership of contact %d in group %d", models.ErrNotFound, contactID, groupID)
+		}
+
+		groups[i].Members = slices.Delete(groups[i].Members, j, j+1)
+		return r.writeGroups(tx, tenant, groups)
+	})
+}
+
+func (r *GroupRepository) ListMembers(ctx context.Context, tenant string, groupID int) ([]models.Contact, error) {
+	var members []models.Contact
+	err := r.db.view(ctx, func(tx *fileTx) error {
+		groups, err := r.readGroups(tx, tenant)
+		if err != nil {
+			return err
+		}
+		i, err := r.indexOf(groups, groupID)
+		if err != nil {
+			return err
+		}
+		contacts, err := r.contacts.readContacts(tx, tenant)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range contacts {
+			if slices.Contains(groups[i].Members, c.ID) {
+				members = append(members, c)
+			}
+		}
+		sort.Slice(members, func(i, j int) bool { return members[i].ID < members[j].ID })
+		return nil
+	})
+	return members, err
+}
+
+func (r *GroupRepository) ListByContact(ctx context.Context, tenant string, contactID int) ([]models.Group, error) {
+	var groups []models.Group
+	err := r.db.view(ctx, func(tx *fileTx) error {
+		contacts, err := r.contacts.readContacts(tx, tenant)
+		if err != nil {
+			return err
+		}
+		if _, err := r.contacts.indexOf(contacts, contactID, 0); err != nil {
+			return err
+		}
+		stored, err := r.readGroups(tx, tenant)
+		groups = sortedGroups(stored, func(g groupRecord) bool {
+			return slices.Contains(g.Members, contactID)
+		})
+		return err
+	})
+	return groups, err
+}
+
+// sortedGroups returns the groups that keep accepts, ordered by name
+func sortedGroups(stored []groupRecord, keep func(groupRecord) bool) []models.Group {
+	var groups []models.Group
+	for _, g := range stored {
+		if keep(g) {
+			groups = append(groups, g.Group)
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Name != groups[j].Name {
+			return groups[i].Name < groups[j].Name
+		}
+		return groups[i].ID < groups[j].ID
+	})
+	return groups
+}
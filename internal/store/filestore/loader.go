@@ -0,0 +1,126 @@
+package filestore
+
+import (
+	"context"
+	"slices"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// Loader is the file-based implementation of LoaderInterface. Records are
+// inserted in ID order, which the files of the outbox and the audit log rely
+// on to number the next record.
+type Loader struct {
+	db       *DB
+	contacts *ContactRepository
+	groups   *GroupRepository
+	outbox   *OutboxRepository
+	audit    *AuditRepository
+}
+
+// NewLoader creates a loader writing to the files the repositories of db use
+func NewLoader(db *DB, contacts_file string, groups_file string, outbox_file string, audit_file string) interfaces.LoaderInterface {
+	contacts := &ContactRepository{db: db, file_name: contacts_file, groups_file: groups_file}
+	return &Loader{
+		db:       db,
+		contacts: contacts,
+		groups:   &GroupRepository{db: db, file_name: groups_file, contacts: contacts},
+		outbox:   &OutboxRepository{db: db, file_name: outbox_file},
+		audit:    &AuditRepository{db: db, file_name: audit_file},
+	}
+}
+
+func (l *Loader) LoadContact(ctx context.Context, tenant string, contact models.Contact) (bool, error) {
+	var loaded bool
+	err := l.db.update(ctx, func(tx *fileTx) error {
+		contacts, err := l.contacts.readContacts(tx, tenant)
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(contacts, func(c models.Contact) bool { return c.ID == contact.ID }) {
+			return nil
+		}
+		if err := l.contacts.checkEmailAvailable(contacts, contact.Email, 0); err != nil {
+			return err
+		}
+
+		loaded = true
+		if err := tx.useID(tenantFileName(l.contacts.file_name, tenant), contact.ID); err != nil {
+			return err
+		}
+		contacts = insertByID(contacts, contact, func(c models.Contact) int { return c.ID })
+		return l.contacts.writeContacts(tx, tenant, contacts)
+	})
+	return loaded, err
+}
+
+func (l *Loader) LoadGroup(ctx context.Context, tenant string, group models.Group, memberIDs []int) (bool, error) {
+	var loaded bool
+	err := l.db.update(ctx, func(tx *fileTx) error {
+		groups, err := l.groups.readGroups(tx, tenant)
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(groups, func(g groupRecord) bool { return g.ID == group.ID }) {
+			return nil
+		}
+		if err := l.groups.checkNameAvailable(groups, group.Name, 0); err != nil {
+			return err
+		}
+
+		loaded = true
+		if err := tx.useID(tenantFileName(l.groups.file_name, tenant), group.ID); err != nil {
+			return err
+		}
+		record := groupRecord{Group: group, Members: slices.Sorted(slices.Values(memberIDs))}
+		groups = insertByID(groups, record, func(g groupRecord) int { return g.ID })
+		return l.groups.writeGroups(tx, tenant, groups)
+	})
+	return loaded, err
+}
+
+func (l *Loader) LoadOutboxMessage(ctx context.Context, msg models.OutboxMessage) (bool, error) {
+	var loaded bool
+	err := l.db.update(ctx, func(tx *fileTx) error {
+		msgs, err := l.outbox.readMessages(tx)
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(msgs, func(m models.OutboxMessage) bool { return m.ID == msg.ID }) {
+			return nil
+		}
+
+		loaded = true
+		msgs = insertByID(msgs, msg, func(m models.OutboxMessage) int { return m.ID })
+		return tx.write(l.outbox.file_name, msgs)
+	})
+	return loaded, err
+}
+
+func (l *Loader) LoadAuditEntry(ctx context.Context, entry models.AuditEntry) (bool, error) {
+	var loaded bool
+	err := l.db.update(ctx, func(tx *fileTx) error {
+		entries, err := l.audit.readEntries(tx)
+		if err != nil {
+			return err
+		}
+		if slices.ContainsFunc(entries, func(e models.AuditEntry) bool { return e.ID == entry.ID }) {
+			return nil
+		}
+
+		loaded = true
+		entries = insertByID(entries, entry, func(e models.AuditEntry) int { return e.ID })
+		return tx.write(l.audit.file_name, entries)
+	})
+	return loaded, err
+}
+
+// insertByID inserts record before the first one with a higher ID
+func insertByID[T any](records []T, record T, id func(T) int) []T {
+	i := slices.IndexFunc(records, func(r T) bool { return id(r) > id(record) })
+	if i < 0 {
+		return append(records, record)
+	}
+	return slices.Insert(records, i, record)
+}
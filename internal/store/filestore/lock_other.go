@@ -0,0 +1,15 @@
+//go:build !unix
+
+package filestore
+
+import "os"
+
+// Without flock the file store only serializes access within one process.
+
+func lockFile(f *os.File, exclusive bool) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
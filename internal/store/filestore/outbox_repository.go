@@ -0,0 +1,164 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// OutboxRepository is the file-based implementation of OutboxRepositoryInterface
+type OutboxRepository struct {
+	db        *DB
+	file_name string
+}
+
+// NewOutboxRepository creates an outbox repository backed by file_name inside db
+func NewOutboxRepository(db *DB, file_name string) interfaces.OutboxRepositoryInterface {
+	return &OutboxRepository{
+		db:        db,
+		file_name: file_name,
+	}
+}
+
+func (r *OutboxRepository) readMessages(tx *fileTx) ([]models.OutboxMessage, error) {
+	var msgs []models.OutboxMessage
+	if err := tx.read(r.file_name, &msgs); err != nil {
+		return nil, err
+	}
+	for i := range msgs {
+		// messages queued before tenants existed belong to the default one
+		if msgs[i].Tenant == "" {
+			msgs[i].Tenant = models.DefaultTenant
+		}
+	}
+	return msgs, nil
+}
+
+func (r *OutboxRepository) Enqueue(ctx context.Context, msg models.OutboxMessage) (int, error) {
+	err := r.db.update(ctx, func(tx *fileTx) error {
+		msgs, err := r.readMessages(tx)
+		if err != nil {
+			return err
+		}
+
+		msg.ID = 1
+		if len(msgs) > 0 {
+			msg.ID = msgs[len(msgs)-1].ID + 1
+		}
+		return tx.write(r.file_name, append(msgs, msg))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return msg.ID, nil
+}
+
+func (r *OutboxRepository) GetByID(ctx context.Context, tenant string, id int) (*models.OutboxMessage, error) {
+	var found *models.OutboxMessage
+	err := r.db.view(ctx, func(tx *fileTx) error {
+		msgs, err := r.readMessages(tx)
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			if msg.ID == id && msg.Tenant == tenant {
+				found = &msg
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: outbox message %d", models.ErrNotFound, id)
+	})
+	return found, err
+}
+
+func (r *OutboxRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxMessage, error) {
+	var claimed []models.OutboxMessage
+	err := r.db.update(ctx, func(tx *fileTx) error {
+		msgs, err := r.readMessages(tx)
+		if err != nil {
+			return err
+		}
+
+		for i := range msgs {
+			if len(claimed) == limit {
+				break
+			}
+			if msgs[i].Status == models.OutboxPending && !msgs[i].NextAttemptAt.After(now) {
+				msgs[i].NextAttemptAt = now.Add(lease)
+				claimed = append(claimed, msgs[i])
+			}
+		}
+		if len(claimed) == 0 {
+			return nil
+		}
+		return tx.write(r.file_name, msgs)
+	})
+	return claimed, err
+}
+
+func (r *OutboxRepository) Save(ctx context.Context, msg models.OutboxMessage) error {
+	return r.db.update(ctx, func(tx *fileTx) error {
+		msgs, err := r.readMessages(tx)
+		if err != nil {
+			return err
+		}
+
+		for i := range msgs {
+			if msgs[i].ID == msg.ID {
+				msgs[i] = msg
+				return tx.write(r.file_name, msgs)
+			}
+		}
+		return fmt.Errorf("%w: outbox message %d", models.ErrNotFound, msg.ID)
+	})
+}
+
+func (r *OutboxRepository) ListByStatus(ctx context.Context, tenant string, status models.OutboxStatus) ([]models.OutboxMessage, error) {
+	var matched []models.OutboxMessage
+	err := r.db.view(ctx, func(tx *fileTx) error {
+		msgs, err := r.readMessages(tx)
+		if err != nil {
+			return err
+		}
+		for _, msg := range msgs {
+			if msg.Tenant == tenant && msg.Status == status {
+				matched = append(matched, msg)
+			}
+		}
+		return nil
+	})
+	return matched, err
+}
+
+// PurgeDelivered keeps the newest message whatever its state, since the next
+// ID is counted from it
+func (r *OutboxRepository) PurgeDelivered(ctx context.Context, before time.Time) (int, error) {
+	purged := 0
+	err := r.db.update(ctx, func(tx *fileTx) error {
+		msgs, err := r.readMessages(tx)
+		if err != nil {
+			return err
+		}
+
+		kept := msgs[:0]
+		for i, msg := range msgs {
+			if i < len(msgs)-1 && msg.Status == models.OutboxDelivered &&
+				msg.DeliveredAt != nil && msg.DeliveredAt.Before(before) {
+				purged++
+				continue
+			}
+			kept = append(kept, msg)
+		}
+		if purged == 0 {
+			return nil
+		}
+		return tx.write(r.file_name, kept)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
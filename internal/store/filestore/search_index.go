@@ -0,0 +1,157 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang/internal/models"
+)
+
+// searchIndex is an inverted index of the words of one tenant's contacts,
+// kept in memory and rebuilt when the contacts file changes
+type searchIndex struct {
+	version    fileVersion
+	contacts   map[int]models.Contact
+	words      map[string][]int // word -> IDs of the contacts using it
+	vocabulary []string         // the words, sorted for prefix lookups
+}
+
+// fileVersion tells whether a file changed since it was read. Commits of
+// this process are counted. Those of another process sharing the directory
+// rename a new file into place, which os.SameFile tells apart from the one
+// read; the time and size guard against the new file reusing its inode.
+type fileVersion struct {
+	commits uint64
+	info    fs.FileInfo // nil when the file is missing
+}
+
+func (v fileVersion) same(other fileVersion) bool {
+	if v.commits != other.commits || (v.info == nil) != (other.info == nil) {
+		return false
+	}
+	return v.info == nil || os.SameFile(v.info, other.info) &&
+		v.info.ModTime().Equal(other.info.ModTime()) && v.info.Size() == other.info.Size()
+}
+
+func newSearchIndex(contacts []models.Contact) *searchIndex {
+	index := &searchIndex{
+		contacts: make(map[int]models.Contact, len(contacts)),
+		words:    make(map[string][]int),
+	}
+	for _, c := range contacts {
+		index.contacts[c.ID] = c
+		names, email := models.ContactWords(c)
+		for _, word := range append(names, email...) {
+			ids := index.words[word]
+			if len(ids) == 0 || ids[len(ids)-1] != c.ID {
+				index.words[word] = append(ids, c.ID)
+			}
+		}
+	}
+	for word := range index.words {
+		index.vocabulary = append(index.vocabulary, word)
+	}
+	sort.Strings(index.vocabulary)
+	return index
+}
+
+// candidates returns the contacts having, for every term, a word it is a
+// prefix of or within its typo tolerance of
+func (index *searchIndex) candidates(terms []string) []models.Contact {
+	var matched map[int]bool
+	for _, term := range terms {
+		ids := map[int]bool{}
+		add := func(word string) {
+			for _, id := range index.words[word] {
+				if matched == nil || matched[id] {
+					ids[id] = true
+				}
+			}
+		}
+
+		start := sort.SearchStrings(index.vocabulary, term)
+		for _, word := range index.vocabulary[start:] {
+			if !strings.HasPrefix(word, term) {
+				break
+			}
+			add(word)
+		}
+		if typos := models.MaxTypos(term); typos > 0 {
+			for _, word := range index.vocabulary {
+				if !strings.HasPrefix(word, term) && models.EditDistance(term, word, typos) <= typos {
+					add(word)
+				}
+			}
+		}
+		matched = ids
+	}
+
+	contacts := make([]models.Contact, 0, len(matched))
+	for id := range matched {
+		contacts = append(contacts, index.contacts[id])
+	}
+	return contacts
+}
+
+// Search looks the terms up in the tenant's inverted index
+func (r *ContactRepository) Search(ctx context.Context, tenant string, search models.ContactSearch) ([]models.Contact, error) {
+	if err := search.Normalize(); err != nil {
+		return nil, err
+	}
+
+	var results []models.Contact
+	err := r.db.view(ctx, func(tx *fileTx) error {
+		index, err := r.searchIndex(tx, tenant)
+		if err != nil {
+			return err
+		}
+		results = models.RankContacts(index.candidates(search.Terms), search)
+		return nil
+	})
+	return results, err
+}
+
+// searchIndex returns the index of a tenant's contacts, building it again
+// when the file changed since. Contacts staged by an ongoing transaction are
+// indexed for it alone, as they may still be rolled back.
+func (r *ContactRepository) searchIndex(tx *fileTx, tenant string) (*searchIndex, error) {
+	name := tenantFileName(r.file_name, tenant)
+	_, staged := tx.staged[name]
+
+	version := fileVersion{commits: tx.db.commits}
+	if !staged {
+		info, err := os.Stat(filepath.Join(tx.db.dir, name))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: failed to read %s: %w", models.ErrUnavailable, name, err)
+		}
+		if err == nil {
+			version.info = info
+		}
+	}
+
+	r.indexMu.Lock()
+	defer r.indexMu.Unlock()
+	if index, ok := r.indexes[tenant]; ok && !staged && index.version.same(version) {
+		return index, nil
+	}
+
+	contacts, err := r.readContacts(tx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	index := newSearchIndex(contacts)
+	index.version = version
+	if !staged {
+		if r.indexes == nil {
+			r.indexes = make(map[string]*searchIndex)
+		}
+		r.indexes[tenant] = index
+	}
+	return index, nil
+}
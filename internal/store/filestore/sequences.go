@@ -0,0 +1,35 @@
+package filestore
+
+// sequencesFileName keeps, for each file numbering its records on its own,
+// the highest ID it ever held. As with the sequences of the SQL stores, the
+// ID of a deleted record is never handed out again, so that its history in
+// the audit log stays its own.
+const sequencesFileName = "sequences.json"
+
+// nextID returns the ID of a new record of the named file, past the highest
+// one it ever held and past highest, that of its current records, which
+// files written before sequencesFileName existed only have, and records it
+func (tx *fileTx) nextID(name string, highest int) (int, error) {
+	var last map[string]int
+	if err := tx.read(sequencesFileName, &last); err != nil {
+		return 0, err
+	}
+	id := max(last[name], highest) + 1
+	return id, tx.useID(name, id)
+}
+
+// useID records that the named file holds a record with the given ID
+func (tx *fileTx) useID(name string, id int) error {
+	var last map[string]int
+	if err := tx.read(sequencesFileName, &last); err != nil {
+		return err
+	}
+	if id <= last[name] {
+		return nil
+	}
+	if last == nil {
+		last = map[string]int{}
+	}
+	last[name] = id
+	return tx.write(sequencesFileName, last)
+}
@@ -0,0 +1,83 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// Snapshotter is the file store implementation of SnapshotterInterface. It
+// copies the files of the store to a temporary directory under the read
+// lock, which holds off commits for the time of the copy only.
+type Snapshotter struct {
+	db        *DB
+	file_name string
+}
+
+// NewSnapshotter creates a file store snapshotter for the store whose
+// default tenant's contacts are in file_name
+func NewSnapshotter(db *DB, file_name string) interfaces.SnapshotterInterface {
+	return &Snapshotter{db: db, file_name: file_name}
+}
+
+func (s *Snapshotter) Snapshot(ctx context.Context, fn func(ctx context.Context, snapshot *interfaces.Store) error) error {
+	dir, err := os.MkdirTemp("", "contacts-snapshot-")
+	if err != nil {
+		return fmt.Errorf("%w: failed to create snapshot directory: %w", models.ErrUnavailable, err)
+	}
+	defer os.RemoveAll(dir)
+
+	err = s.db.view(ctx, func(tx *fileTx) error {
+		return s.db.copyFiles(dir)
+	})
+	if err != nil {
+		return fmt.Errorf("%w: failed to snapshot the file store: %w", models.ErrUnavailable, err)
+	}
+
+	db, err := OpenDB(dir, s.file_name, 0)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return fn(ctx, newStorage(db, s.file_name))
+}
+
+// copyFiles copies the data files of the store to the directory to
+func (d *DB) copyFiles(to string) error {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.Type().IsRegular() || !d.isDataFile(name) {
+			continue
+		}
+		if err := copyFile(filepath.Join(d.dir, name), filepath.Join(to, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.Create(to)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
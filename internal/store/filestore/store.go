@@ -2,16 +2,54 @@ package filestore
 
 import (
 	"fmt"
+	"golang/internal/models"
 	"golang/internal/store/interfaces"
+	"path/filepath"
+	"strings"
 )
 
-func NewStorage(file_path string) (*interfaces.Store, error) {
-	contactRepo, err := NewContactRepository(file_path)
+// Files kept next to the contacts file
+const (
+	outboxFileName  = "outbox.json"
+	auditFileName   = "audit.json"
+	tenantsFileName = "tenants.json"
+	groupsFileName  = "groups.json" // per tenant, see tenantFileName
+)
+
+// NewStorage opens the file store whose default tenant's contacts are in
+// file_path, keeping the given number of generations of each file. Close the
+// store to release its lock on the directory.
+func NewStorage(file_path string, generations int) (*interfaces.Store, error) {
+	db, err := OpenDB(filepath.Dir(file_path), filepath.Base(file_path), generations)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create file-based contact repository: %w", err)
+		return nil, fmt.Errorf("failed to create file-based store: %w", err)
 	}
+	return newStorage(db, filepath.Base(file_path)), nil
+}
 
+// newStorage creates the repositories of the files of db named after
+// file_name, the contacts file of the default tenant
+func newStorage(db *DB, file_name string) *interfaces.Store {
 	return &interfaces.Store{
-		Contact: contactRepo,
-	}, nil
+		Tx:       db,
+		Tenant:   NewTenantRepository(db, tenantsFileName, file_name, groupsFileName, outboxFileName),
+		Contact:  NewContactRepository(db, file_name, groupsFileName),
+		Group:    NewGroupRepository(db, groupsFileName, file_name),
+		Outbox:   NewOutboxRepository(db, outboxFileName),
+		Audit:    NewAuditRepository(db, auditFileName),
+		Loader:   NewLoader(db, file_name, groupsFileName, outboxFileName, auditFileName),
+		Snapshot: NewSnapshotter(db, file_name),
+		Closer:   db,
+	}
+}
+
+// tenantFileName names the file of a tenant after the configured one:
+// contacts.json for the default tenant, which keeps the files written
+// before tenants existed, and e.g. contacts.sales.json for the others
+func tenantFileName(file_name string, tenant string) string {
+	if tenant == models.DefaultTenant {
+		return file_name
+	}
+	ext := filepath.Ext(file_name)
+	return strings.TrimSuffix(file_name, ext) + "." + tenant + ext
 }
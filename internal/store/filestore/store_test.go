@@ -0,0 +1,23 @@
+package filestore
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golang/internal/store/interfaces"
+	"golang/internal/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, newTestStore)
+}
+
+// newTestStore opens a store in a fresh temp dir
+func newTestStore(t *testing.T) *interfaces.Store {
+	store, err := NewStorage(filepath.Join(t.TempDir(), "contacts.json"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
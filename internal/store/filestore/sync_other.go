@@ -0,0 +1,10 @@
+//go:build !unix
+
+package filestore
+
+// Directories cannot be synced on these systems; renames are durable once
+// the file system flushes its metadata.
+
+func syncDir(dir string) error {
+	return nil
+}
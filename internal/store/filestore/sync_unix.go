@@ -0,0 +1,16 @@
+//go:build unix
+
+package filestore
+
+import "os"
+
+// syncDir syncs the entries of dir to disk, making the files created, renamed
+// and removed in it durable
+func syncDir(dir string) error {
+	f, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
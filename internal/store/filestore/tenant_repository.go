@@ -0,0 +1,131 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// TenantRepository is the file-based implementation of TenantRepositoryInterface.
+// The default tenant is implied and never written to the file.
+type TenantRepository struct {
+	db            *DB
+	file_name     string
+	contacts_file string // base name of the per-tenant contacts files
+	groups_file   string // base name of the per-tenant groups files
+	outbox        *OutboxRepository
+}
+
+// NewTenantRepository creates a tenant repository backed by file_name inside
+// db, for the contacts and groups stored next to it in contacts_file and
+// groups_file and the notifications queued in outbox_file
+func NewTenantRepository(db *DB, file_name string, contacts_file string, groups_file string, outbox_file string) interfaces.TenantRepositoryInterface {
+	return &TenantRepository{
+		db:            db,
+		file_name:     file_name,
+		contacts_file: contacts_file,
+		groups_file:   groups_file,
+		outbox:        &OutboxRepository{db: db, file_name: outbox_file},
+	}
+}
+
+func (r *TenantRepository) readTenants(tx *fileTx) ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	if err := tx.read(r.file_name, &tenants); err != nil {
+		return nil, err
+	}
+	return tenants, nil
+}
+
+func (r *TenantRepository) List(ctx context.Context) ([]models.Tenant, error) {
+	tenants := []models.Tenant{{ID: models.DefaultTenant, Name: "Default"}}
+	err := r.db.view(ctx, func(tx *fileTx) error {
+		stored, err := r.readTenants(tx)
+		tenants = append(tenants, stored...)
+		return err
+	})
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].ID < tenants[j].ID })
+	return tenants, err
+}
+
+func (r *TenantRepository) GetByID(ctx context.Context, id string) (*models.Tenant, error) {
+	tenants, err := r.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tenants {
+		if t.ID == id {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: tenant %s", models.ErrNotFound, id)
+}
+
+func (r *TenantRepository) Create(ctx context.Context, tenant models.Tenant) error {
+	return r.db.update(ctx, func(tx *fileTx) error {
+		tenants, err := r.readTenants(tx)
+		if err != nil {
+			return err
+		}
+		for _, t := range append(tenants, models.Tenant{ID: models.DefaultTenant}) {
+			if t.ID == tenant.ID {
+				return fmt.Errorf("%w: tenant %s already exists", models.ErrConflict, tenant.ID)
+			}
+		}
+		return tx.write(r.file_name, append(tenants, tenant))
+	})
+}
+
+func (r *TenantRepository) Delete(ctx context.Context, id string) error {
+	if id == models.DefaultTenant {
+		return fmt.Errorf("%w: the %s tenant cannot be deleted", models.ErrConflict, id)
+	}
+	return r.db.update(ctx, func(tx *fileTx) error {
+		tenants, err := r.readTenants(tx)
+		if err != nil {
+			return err
+		}
+		for i, t := range tenants {
+			if t.ID == id {
+				tx.remove(tenantFileName(r.contacts_file, id))
+				tx.remove(tenantFileName(r.groups_file, id))
+				if err := r.deleteMessages(tx, id); err != nil {
+					return err
+				}
+				return tx.write(r.file_name, append(tenants[:i], tenants[i+1:]...))
+			}
+		}
+		return fmt.Errorf("%w: tenant %s", models.ErrNotFound, id)
+	})
+}
+
+// removedTenant owns the placeholder left of the newest message of a deleted
+// tenant. It is not a valid tenant ID, so the placeholder is never listed.
+const removedTenant = "-"
+
+// deleteMessages removes the notifications queued for a tenant. The newest
+// message is replaced with an empty placeholder, since the next ID is counted
+// from it; being delivered, it is purged later on.
+func (r *TenantRepository) deleteMessages(tx *fileTx, tenant string) error {
+	msgs, err := r.outbox.readMessages(tx)
+	if err != nil {
+		return err
+	}
+
+	kept := msgs[:0]
+	for i, msg := range msgs {
+		switch {
+		case msg.Tenant != tenant:
+			kept = append(kept, msg)
+		case i == len(msgs)-1:
+			now := time.Now().UTC()
+			kept = append(kept, models.OutboxMessage{ID: msg.ID, Tenant: removedTenant, Status: models.OutboxDelivered,
+				NextAttemptAt: msg.NextAttemptAt, CreatedAt: msg.CreatedAt, DeliveredAt: &now})
+		}
+	}
+	return tx.write(r.outbox.file_name, kept)
+}
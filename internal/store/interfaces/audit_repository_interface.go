@@ -0,0 +1,17 @@
+package interfaces
+
+import (
+	"context"
+
+	"golang/internal/models"
+)
+
+// AuditRepositoryInterface defines the contract for the append-only audit log
+type AuditRepositoryInterface interface {
+	Append(ctx context.Context, entry models.AuditEntry) (int, error)
+	// ListByContact returns the history of a contact of tenant, oldest first
+	ListByContact(ctx context.Context, tenant string, contactID int) ([]models.AuditEntry, error)
+	// List returns up to limit entries of every tenant whose ID is above
+	// afterID, oldest first
+	List(ctx context.Context, afterID int, limit int) ([]models.AuditEntry, error)
+}
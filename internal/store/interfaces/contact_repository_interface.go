@@ -7,10 +7,25 @@ import (
 
 // ContactRepositoryInterface defines the contract for contact data access
 // This abstraction allows us to swap implementations (SQLite, Postgres, etc.)
+// Every method is scoped to one tenant: contacts of other tenants are
+// invisible to it, as if they did not exist.
 type ContactRepositoryInterface interface {
-	GetAll(ctx context.Context) ([]models.Contact, error)
-	GetByID(ctx context.Context, id int) (*models.Contact, error)
-	Create(ctx context.Context, contact models.Contact) (int, error)
-	Update(ctx context.Context, contact models.Contact) error
-	Delete(ctx context.Context, id int) error
+	GetAll(ctx context.Context, tenant string) ([]models.Contact, error)
+	List(ctx context.Context, tenant string, query models.ContactQuery) (*models.ContactPage, error)
+	// Search returns the contacts matching a free-text search, best first.
+	// Stores find the candidates with their own indexes and rank them with
+	// models.RankContacts.
+	Search(ctx context.Context, tenant string, search models.ContactSearch) ([]models.Contact, error)
+	GetByID(ctx context.Context, tenant string, id int) (*models.Contact, error)
+	// GetByEmail returns the contact holding exactly this email, which
+	// callers normalize first, or fails with models.ErrNotFound
+	GetByEmail(ctx context.Context, tenant string, email string) (*models.Contact, error)
+	Create(ctx context.Context, tenant string, contact models.Contact) (int, error)
+	// Update, Patch and Delete fail with models.ErrVersionConflict when
+	// given a non-zero version that no longer matches the stored one.
+	// Patch writes only the fields set in patch, and Delete also removes the
+	// contact from its groups.
+	Update(ctx context.Context, tenant string, contact models.Contact) error
+	Patch(ctx context.Context, tenant string, id int, version int, patch models.ContactPatch) error
+	Delete(ctx context.Context, tenant string, id int, version int) error
 }
@@ -0,0 +1,32 @@
+package interfaces
+
+import (
+	"context"
+
+	"golang/internal/models"
+)
+
+// GroupRepositoryInterface defines the contract for contact groups and their
+// memberships. Groups belong to a tenant and only hold contacts of the same
+// tenant.
+type GroupRepositoryInterface interface {
+	// List returns the groups of a tenant ordered by name
+	List(ctx context.Context, tenant string) ([]models.Group, error)
+	GetByID(ctx context.Context, tenant string, id int) (*models.Group, error)
+	// Create fails with models.ErrConflict when the name is taken
+	Create(ctx context.Context, tenant string, group models.Group) (int, error)
+	// Update changes the name and description of a group
+	Update(ctx context.Context, tenant string, group models.Group) error
+	// Delete removes a group and its memberships, not its contacts
+	Delete(ctx context.Context, tenant string, id int) error
+
+	// AddMember fails with models.ErrNotFound when the group or the contact
+	// does not exist. Adding a member twice is not an error.
+	AddMember(ctx context.Context, tenant string, groupID, contactID int) error
+	// RemoveMember fails with models.ErrNotFound when the contact is not a member
+	RemoveMember(ctx context.Context, tenant string, groupID, contactID int) error
+	// ListMembers returns the contacts of a group ordered by ID
+	ListMembers(ctx context.Context, tenant string, groupID int) ([]models.Contact, error)
+	// ListByContact returns the groups a contact belongs to, ordered by name
+	ListByContact(ctx context.Context, tenant string, contactID int) ([]models.Group, error)
+}
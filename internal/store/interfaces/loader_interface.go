@@ -0,0 +1,21 @@
+package interfaces
+
+import (
+	"context"
+
+	"golang/internal/models"
+)
+
+// LoaderInterface writes records copied from another store as they are,
+// keeping their IDs, versions and timestamps, for store-to-store migrations.
+// Loading a record whose ID is already taken changes nothing and reports
+// false, so that an interrupted migration can simply be run again.
+type LoaderInterface interface {
+	// LoadContact fails with models.ErrConflict when the email is taken
+	LoadContact(ctx context.Context, tenant string, contact models.Contact) (bool, error)
+	// LoadGroup also loads the memberships of the group, whose contacts are
+	// expected to be loaded already
+	LoadGroup(ctx context.Context, tenant string, group models.Group, memberIDs []int) (bool, error)
+	LoadOutboxMessage(ctx context.Context, msg models.OutboxMessage) (bool, error)
+	LoadAuditEntry(ctx context.Context, entry models.AuditEntry) (bool, error)
+}
@@ -0,0 +1,25 @@
+package interfaces
+
+import (
+	"context"
+	"time"
+
+	"golang/internal/models"
+)
+
+// OutboxRepositoryInterface defines the contract for queued notifications.
+// Messages are read within the tenant they were queued for, except by the
+// dispatcher, which claims and saves them across tenants.
+type OutboxRepositoryInterface interface {
+	Enqueue(ctx context.Context, msg models.OutboxMessage) (int, error)
+	GetByID(ctx context.Context, tenant string, id int) (*models.OutboxMessage, error)
+	// Claim returns up to limit pending messages of every tenant due at now
+	// and leases them until now+lease so that concurrent dispatchers skip them
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxMessage, error)
+	// Save persists the delivery state (status, attempts, schedule, errors)
+	Save(ctx context.Context, msg models.OutboxMessage) error
+	ListByStatus(ctx context.Context, tenant string, status models.OutboxStatus) ([]models.OutboxMessage, error)
+	// PurgeDelivered removes the messages delivered before the given time and
+	// returns how many it removed. IDs of removed messages are not reused.
+	PurgeDelivered(ctx context.Context, before time.Time) (int, error)
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import "context"
+
+// SnapshotterInterface freezes the data of a store for backups.
+// Snapshot calls fn with a store holding the data as of one instant,
+// however long fn reads it, while the live store goes on serving writes.
+// The snapshot is only valid until fn returns, and must not be written to.
+type SnapshotterInterface interface {
+	Snapshot(ctx context.Context, fn func(ctx context.Context, snapshot *Store) error) error
+}
@@ -1,5 +1,27 @@
 package interfaces
 
+import "io"
+
 type Store struct {
-	Contact ContactRepositoryInterface
+	Tx       TransactorInterface
+	Tenant   TenantRepositoryInterface
+	Contact  ContactRepositoryInterface
+	Group    GroupRepositoryInterface
+	Outbox   OutboxRepositoryInterface
+	Audit    AuditRepositoryInterface
+	Loader   LoaderInterface
+	Snapshot SnapshotterInterface
+
+	// Closer releases what the store holds besides the database connection,
+	// such as the lock of the file store; nil when it holds nothing
+	Closer io.Closer
+}
+
+// Close releases the store. The database connection of the SQL stores is
+// closed with the database, not here.
+func (s *Store) Close() error {
+	if s.Closer == nil {
+		return nil
+	}
+	return s.Closer.Close()
 }
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+
+	"golang/internal/models"
+)
+
+// TenantRepositoryInterface defines the contract for the tenant registry.
+// models.DefaultTenant always exists.
+type TenantRepositoryInterface interface {
+	List(ctx context.Context) ([]models.Tenant, error)
+	GetByID(ctx context.Context, id string) (*models.Tenant, error)
+	// Create fails with models.ErrConflict when the ID is taken
+	Create(ctx context.Context, tenant models.Tenant) error
+	// Delete removes the tenant and every one of its contacts and groups.
+	// The audit history of the contacts is kept.
+	Delete(ctx context.Context, id string) error
+}
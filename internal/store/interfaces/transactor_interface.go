@@ -0,0 +1,10 @@
+package interfaces
+
+import "context"
+
+// TransactorInterface runs several repository calls atomically.
+// Repositories called with the ctx handed to fn take part in the transaction;
+// nested calls join the outer transaction instead of starting a new one.
+type TransactorInterface interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
@@ -0,0 +1,100 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// AuditRepository is the PostgreSQL implementation of AuditRepositoryInterface
+type AuditRepository struct {
+	db *sql.DB
+}
+
+// NewAuditRepository creates a PostgreSQL audit repository
+func NewAuditRepository(db *sql.DB) interfaces.AuditRepositoryInterface {
+	return &AuditRepository{db: db}
+}
+
+func (r *AuditRepository) Append(ctx context.Context, entry models.AuditEntry) (int, error) {
+	before, err := marshalSnapshot(entry.Before)
+	if err != nil {
+		return 0, err
+	}
+	after, err := marshalSnapshot(entry.After)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `INSERT INTO audit_log (tenant_id, contact_id, action, actor, created_at, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	var id int
+	err = conn(ctx, r.db).QueryRowContext(ctx, query,
+		entry.Tenant, entry.ContactID, entry.Action, entry.Actor, entry.Timestamp.UTC(), before, after).Scan(&id)
+	return id, mapError(err)
+}
+
+func (r *AuditRepository) ListByContact(ctx context.Context, tenant string, contactID int) ([]models.AuditEntry, error) {
+	query := `SELECT id, tenant_id, contact_id, action, actor, created_at, before, after FROM audit_log
+		WHERE tenant_id = $1 AND contact_id = $2 ORDER BY id`
+	return r.query(ctx, query, tenant, contactID)
+}
+
+func (r *AuditRepository) List(ctx context.Context, afterID int, limit int) ([]models.AuditEntry, error) {
+	query := `SELECT id, tenant_id, contact_id, action, actor, created_at, before, after FROM audit_log
+		WHERE id > $1 ORDER BY id LIMIT $2`
+	return r.query(ctx, query, afterID, limit)
+}
+
+// query collects the entries selected by query
+func (r *AuditRepository) query(ctx context.Context, query string, args ...any) ([]models.AuditEntry, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var entries []models.AuditEntry
+	for rows.Next() {
+		var e models.AuditEntry
+		var before, after sql.NullString
+		if err := rows.Scan(&e.ID, &e.Tenant, &e.ContactID, &e.Action, &e.Actor, &e.Timestamp, &before, &after); err != nil {
+			return nil, mapError(err)
+		}
+		if e.Before, err = unmarshalSnapshot(before); err != nil {
+			return nil, err
+		}
+		if e.After, err = unmarshalSnapshot(after); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, mapError(rows.Err())
+}
+
+// marshalSnapshot encodes a contact snapshot as JSON, or NULL when absent
+func marshalSnapshot(c *models.Contact) (sql.NullString, error) {
+	if c == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return sql.NullString{}, fmt.Errorf("failed to marshal audit snapshot: %w", err)
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
+
+func unmarshalSnapshot(s sql.NullString) (*models.Contact, error) {
+	if !s.Valid {
+		return nil, nil
+	}
+	var c models.Contact
+	if err := json.Unmarshal([]byte(s.String), &c); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal audit snapshot: %w", err)
+	}
+	return &c, nil
+}
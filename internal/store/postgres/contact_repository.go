@@ -3,7 +3,10 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 
 	"golang/internal/models"
 	"golang/internal/store/interfaces"
@@ -14,58 +17,402 @@ type ContactRepository struct {
 	db *sql.DB
 }
 
+// contactColumns lists the columns scanned into models.Contact by scanContact
+const contactColumns = "id, first_name, last_name, email, company, title, birthday, notes, " +
+	"phones, addresses, custom_fields, tags, version"
+
 // NewContactRepository creates a PostgreSQL contact repository
 func NewContactRepository(db *sql.DB) interfaces.ContactRepositoryInterface {
 	return &ContactRepository{db: db}
 }
 
-func (r *ContactRepository) GetAll(ctx context.Context) ([]models.Contact, error) {
-	query := "SELECT id, first_name, last_name, email FROM contacts"
-	rows, err := r.db.QueryContext(ctx, query)
+func (r *ContactRepository) GetAll(ctx context.Context, tenant string) ([]models.Contact, error) {
+	query := "SELECT " + contactColumns + " FROM contacts WHERE tenant_id = $1"
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, tenant)
 	if err != nil {
+		return nil, mapError(err)
+	}
+	return collectContacts(rows, nil)
+}
+
+// List returns one page of contacts using keyset pagination on (sort column, id).
+// Text columns are compared with the "C" collation so that ordering matches
+// the other stores regardless of the database locale.
+func (r *ContactRepository) List(ctx context.Context, tenant string, q models.ContactQuery) (*models.ContactPage, error) {
+	if err := q.Normalize(); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	cursor, _ := q.DecodeCursor()
 
-	var contacts []models.Contact
-	for rows.Next() {
-		var c models.Contact
-		if err := rows.Scan(&c.ID, &c.FirstName, &c.LastName, &c.Email); err != nil {
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where = append(where, "tenant_id = "+arg(tenant))
+
+	for _, f := range []struct{ column, prefix string }{
+		{"first_name", q.FirstNamePrefix},
+		{"last_name", q.LastNamePrefix},
+		{"email", q.EmailPrefix},
+	} {
+		if f.prefix != "" {
+			where = append(where, f.column+" ILIKE "+arg(escapeLike(f.prefix)+"%"))
+		}
+	}
+	if len(q.Tags) > 0 {
+		tags, err := marshalList(q.Tags)
+		if err != nil {
 			return nil, err
 		}
-		contacts = append(contacts, c)
+		where = append(where, "tags @> "+arg(tags)+"::jsonb")
+	}
+
+	op, dir := ">", "ASC"
+	if q.Descending {
+		op, dir = "<", "DESC"
+	}
+	if cursor != nil {
+		if q.SortBy == models.SortByID {
+			where = append(where, "id "+op+" "+arg(cursor.ID))
+		} else {
+			where = append(where, fmt.Sprintf(`(%s COLLATE "C", id) %s (%s, %s)`, q.SortBy, op, arg(cursor.Value), arg(cursor.ID)))
+		}
 	}
-	return contacts, rows.Err()
+
+	query := "SELECT " + contactColumns + " FROM contacts WHERE " + strings.Join(where, " AND ")
+	if q.SortBy == models.SortByID {
+		query += " ORDER BY id " + dir
+	} else {
+		query += fmt.Sprintf(` ORDER BY %s COLLATE "C" %s, id %s`, q.SortBy, dir, dir)
+	}
+	query += " LIMIT " + arg(q.Limit+1)
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	contacts, err := collectContacts(rows, make([]models.Contact, 0, q.Limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.ContactPage{Contacts: contacts}
+	if len(contacts) > q.Limit {
+		page.Contacts = contacts[:q.Limit]
+		page.NextCursor = q.CursorAfter(page.Contacts[q.Limit-1])
+	}
+	return page, nil
 }
 
-func (r *ContactRepository) GetByID(ctx context.Context, id int) (*models.Contact, error) {
-	query := "SELECT id, first_name, last_name, email FROM contacts WHERE id = $1"
-	var c models.Contact
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&c.ID, &c.FirstName, &c.LastName, &c.Email)
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("contact not found")
+// searchCandidates caps the contacts ranked by a search, the closest ones
+// by trigram similarity first
+const searchCandidates = 1000
+
+// searchTypoThreshold is the word similarity from which a word counts as a
+// candidate typo of a term; the finer check is left to models.RankContacts
+const searchTypoThreshold = 0.3
+
+// Search finds the candidates with the search_vector index, where each term
+// must match a word as a prefix, and the trigram index of search_text for
+// terms that tolerate typos
+func (r *ContactRepository) Search(ctx context.Context, tenant string, search models.ContactSearch) ([]models.Contact, error) {
+	if err := search.Normalize(); err != nil {
+		return nil, err
 	}
+
+	var where []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	where = append(where, "tenant_id = "+arg(tenant))
+	for _, term := range search.Terms {
+		condition := "search_vector @@ to_tsquery('simple', " + arg(term+":*") + ")"
+		if models.MaxTypos(term) > 0 {
+			condition = "(" + condition + " OR " + arg(term) + " <% search_text)"
+		}
+		where = append(where, condition)
+	}
+	query := "SELECT " + contactColumns + " FROM contacts WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY similarity(search_text, " + arg(strings.Join(search.Terms, " ")) + ") DESC, id" +
+		" LIMIT " + arg(searchCandidates)
+
+	var candidates []models.Contact
+	err := NewTransactor(r.db).WithinTransaction(ctx, func(ctx context.Context) error {
+		// lasts until the end of the transaction, the caller's if any
+		_, err := conn(ctx, r.db).ExecContext(ctx,
+			fmt.Sprintf("SET LOCAL pg_trgm.word_similarity_threshold = %g", searchTypoThreshold))
+		if err != nil {
+			return mapError(err)
+		}
+		rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+		if err != nil {
+			return mapError(err)
+		}
+		candidates, err = collectContacts(rows, nil)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return models.RankContacts(candidates, search), nil
+}
+
+func (r *ContactRepository) GetByID(ctx context.Context, tenant string, id int) (*models.Contact, error) {
+	query := "SELECT " + contactColumns + " FROM contacts WHERE tenant_id = $1 AND id = $2"
+	c, err := scanContact(conn(ctx, r.db).QueryRowContext(ctx, query, tenant, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: contact %d", models.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &c, nil
+}
+
+func (r *ContactRepository) GetByEmail(ctx context.Context, tenant string, email string) (*models.Contact, error) {
+	query := "SELECT " + contactColumns + " FROM contacts WHERE tenant_id = $1 AND email = $2"
+	c, err := scanContact(conn(ctx, r.db).QueryRowContext(ctx, query, tenant, email))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: contact with email %s", models.ErrNotFound, email)
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
 	return &c, nil
 }
 
-func (r *ContactRepository) Create(ctx context.Context, contact models.Contact) (int, error) {
-	query := "INSERT INTO contacts (first_name, last_name, email) VALUES ($1, $2, $3) RETURNING id"
+func (r *ContactRepository) Create(ctx context.Context, tenant string, contact models.Contact) (int, error) {
+	details, err := marshalDetails(contact)
+	if err != nil {
+		return 0, err
+	}
+	query := `INSERT INTO contacts (tenant_id, first_name, last_name, email, company, title, birthday, notes,
+		phones, addresses, custom_fields, tags) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`
 	var id int
-	err := r.db.QueryRowContext(ctx, query, contact.FirstName, contact.LastName, contact.Email).Scan(&id)
-	return id, err
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, tenant, contact.FirstName, contact.LastName, contact.Email,
+		contact.Company, contact.Title, contact.Birthday, contact.Notes,
+		details.phones, details.addresses, details.customFields, details.tags).Scan(&id)
+	return id, mapError(err)
 }
 
-func (r *ContactRepository) Update(ctx context.Context, contact models.Contact) error {
-	query := "UPDATE contacts SET first_name = $1, last_name = $2, email = $3 WHERE id = $4"
-	_, err := r.db.ExecContext(ctx, query, contact.FirstName, contact.LastName, contact.Email, contact.ID)
-	return err
+func (r *ContactRepository) Update(ctx context.Context, tenant string, contact models.Contact) error {
+	details, err := marshalDetails(contact)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE contacts SET first_name = $1, last_name = $2, email = $3, company = $4, title = $5,
+		birthday = $6, notes = $7, phones = $8, addresses = $9, custom_fields = $10, tags = $11,
+		version = version + 1
+		WHERE tenant_id = $12 AND id = $13 AND ($14 = 0 OR version = $14)`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		contact.FirstName, contact.LastName, contact.Email, contact.Company, contact.Title, contact.Birthday,
+		contact.Notes, details.phones, details.addresses, details.customFields, details.tags,
+		tenant, contact.ID, contact.Version)
+	if err != nil {
+		return mapError(err)
+	}
+	return r.requireCurrent(ctx, result, tenant, contact.ID)
+}
+
+// Patch updates only the columns of the fields set in patch
+func (r *ContactRepository) Patch(ctx context.Context, tenant string, id int, version int, patch models.ContactPatch) error {
+	var set []string
+	var args []any
+	arg := func(v any) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	for _, f := range []struct {
+		column string
+		value  *string
+	}{
+		{"first_name", patch.FirstName},
+		{"last_name", patch.LastName},
+		{"email", patch.Email},
+		{"company", patch.Company},
+		{"title", patch.Title},
+		{"birthday", patch.Birthday},
+		{"notes", patch.Notes},
+	} {
+		if f.value != nil {
+			set = append(set, f.column+" = "+arg(*f.value))
+		}
+	}
+	if patch.Phones != nil {
+		phones, err := marshalList(*patch.Phones)
+		if err != nil {
+			return err
+		}
+		set = append(set, "phones = "+arg(phones)+"::jsonb")
+	}
+	if patch.Addresses != nil {
+		addresses, err := marshalList(*patch.Addresses)
+		if err != nil {
+			return err
+		}
+		set = append(set, "addresses = "+arg(addresses)+"::jsonb")
+	}
+	if patch.Tags != nil {
+		tags, err := marshalList(*patch.Tags)
+		if err != nil {
+			return err
+		}
+		set = append(set, "tags = "+arg(tags)+"::jsonb")
+	}
+	if len(patch.CustomFields) > 0 {
+		// values are strings, so the only nulls left are the removed fields
+		changes, err := json.Marshal(patch.CustomFields)
+		if err != nil {
+			return fmt.Errorf("failed to marshal custom fields: %w", err)
+		}
+		set = append(set, "custom_fields = jsonb_strip_nulls(custom_fields || "+arg(string(changes))+"::jsonb)")
+	}
+	set = append(set, "version = version + 1")
+
+	tenantArg, idArg, versionArg := arg(tenant), arg(id), arg(version)
+
+	query := fmt.Sprintf("UPDATE contacts SET %s WHERE tenant_id = %s AND id = %s AND (%s = 0 OR version = %s)",
+		strings.Join(set, ", "), tenantArg, idArg, versionArg, versionArg)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return mapError(err)
+	}
+	return r.requireCurrent(ctx, result, tenant, id)
 }
 
-func (r *ContactRepository) Delete(ctx context.Context, id int) error {
-	query := "DELETE FROM contacts WHERE id = $1"
-	_, err := r.db.ExecContext(ctx, query, id)
+func (r *ContactRepository) Delete(ctx context.Context, tenant string, id int, version int) error {
+	query := "DELETE FROM contacts WHERE tenant_id = $1 AND id = $2 AND ($3 = 0 OR version = $3)"
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, tenant, id, version)
+	if err != nil {
+		return mapError(err)
+	}
+	return r.requireCurrent(ctx, result, tenant, id)
+}
+
+// requireCurrent tells a missing contact from a stale version when a
+// versioned statement did not touch any row
+func (r *ContactRepository) requireCurrent(ctx context.Context, result sql.Result, tenant string, id int) error {
+	err := requireAffected(result, "contact", id)
+	if !errors.Is(err, models.ErrNotFound) {
+		return err
+	}
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM contacts WHERE tenant_id = $1 AND id = $2)"
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, tenant, id).Scan(&exists); err != nil {
+		return mapError(err)
+	}
+	if exists {
+		return fmt.Errorf("%w: contact %d", models.ErrVersionConflict, id)
+	}
 	return err
 }
+
+// scanContact reads one row selected with contactColumns
+func scanContact(row interface{ Scan(dest ...any) error }) (models.Contact, error) {
+	var c models.Contact
+	var details detailColumns
+	if err := row.Scan(&c.ID, &c.FirstName, &c.LastName, &c.Email, &c.Company, &c.Title, &c.Birthday, &c.Notes,
+		&details.phones, &details.addresses, &details.customFields, &details.tags, &c.Version); err != nil {
+		return c, err
+	}
+	if err := unmarshalDetails(&c, details); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// collectContacts appends the scanned rows to contacts
+func collectContacts(rows *sql.Rows, contacts []models.Contact) ([]models.Contact, error) {
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanContact(rows)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		contacts = append(contacts, c)
+	}
+	return contacts, mapError(rows.Err())
+}
+
+// detailColumns holds the JSON columns of a contact, encoded
+type detailColumns struct {
+	phones, addresses, customFields, tags string
+}
+
+// marshalDetails encodes the JSON columns of a contact
+func marshalDetails(c models.Contact) (detailColumns, error) {
+	var d detailColumns
+	var err error
+	if d.phones, err = marshalList(c.Phones); err != nil {
+		return d, err
+	}
+	if d.addresses, err = marshalList(c.Addresses); err != nil {
+		return d, err
+	}
+	if d.tags, err = marshalList(c.Tags); err != nil {
+		return d, err
+	}
+	d.customFields = "{}"
+	if len(c.CustomFields) > 0 {
+		data, err := json.Marshal(c.CustomFields)
+		if err != nil {
+			return d, fmt.Errorf("failed to marshal custom fields: %w", err)
+		}
+		d.customFields = string(data)
+	}
+	return d, nil
+}
+
+// marshalList encodes a list column, writing an empty list rather than null
+func marshalList[T any](list []T) (string, error) {
+	if len(list) == 0 {
+		return "[]", nil
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal contact details: %w", err)
+	}
+	return string(data), nil
+}
+
+// unmarshalDetails decodes the JSON columns of a contact, leaving empty
+// lists and maps nil as models.Contact.Normalize does
+func unmarshalDetails(c *models.Contact, d detailColumns) error {
+	if err := json.Unmarshal([]byte(d.phones), &c.Phones); err != nil {
+		return fmt.Errorf("failed to unmarshal phones: %w", err)
+	}
+	if err := json.Unmarshal([]byte(d.addresses), &c.Addresses); err != nil {
+		return fmt.Errorf("failed to unmarshal addresses: %w", err)
+	}
+	if err := json.Unmarshal([]byte(d.customFields), &c.CustomFields); err != nil {
+		return fmt.Errorf("failed to unmarshal custom fields: %w", err)
+	}
+	if err := json.Unmarshal([]byte(d.tags), &c.Tags); err != nil {
+		return fmt.Errorf("failed to unmarshal tags: %w", err)
+	}
+	if len(c.Phones) == 0 {
+		c.Phones = nil
+	}
+	if len(c.Addresses) == 0 {
+		c.Addresses = nil
+	}
+	if len(c.CustomFields) == 0 {
+		c.CustomFields = nil
+	}
+	if len(c.Tags) == 0 {
+		c.Tags = nil
+	}
+	return nil
+}
+
+// escapeLike escapes LIKE wildcards so that user input matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,51 @@
+package postgres
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/lib/pq"
+
+	"golang/internal/models"
+)
+
+// mapError translates PostgreSQL driver errors into the shared domain errors
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch {
+		case pqErr.Code == "23505": // unique_violation
+			return fmt.Errorf("%w: a contact with this email already exists", models.ErrConflict)
+		case pqErr.Code.Class() == "08", // connection exception
+			pqErr.Code.Class() == "53", // insufficient resources
+			pqErr.Code.Class() == "57": // operator intervention (e.g. shutdown)
+			return fmt.Errorf("%w: %w", models.ErrUnavailable, err)
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, sql.ErrConnDone) || errors.Is(err, driver.ErrBadConn) {
+		return fmt.Errorf("%w: %w", models.ErrUnavailable, err)
+	}
+
+	return err
+}
+
+// requireAffected reports ErrNotFound when a statement did not touch any row
+func requireAffected(result sql.Result, entity string, id int) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return mapError(err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s %d", models.ErrNotFound, entity, id)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// Loader is the PostgreSQL implementation of LoaderInterface. Rows are
+// inserted with ON CONFLICT (id) DO NOTHING, so that other constraints, such
+// as the uniqueness of emails, still fail, and the ID sequences are moved
+// past the loaded IDs.
+type Loader struct {
+	db *sql.DB
+	tx interfaces.TransactorInterface
+}
+
+// NewLoader creates a PostgreSQL loader
+func NewLoader(db *sql.DB) interfaces.LoaderInterface {
+	return &Loader{db: db, tx: NewTransactor(db)}
+}
+
+func (l *Loader) LoadContact(ctx context.Context, tenant string, contact models.Contact) (bool, error) {
+	details, err := marshalDetails(contact)
+	if err != nil {
+		return false, err
+	}
+	query := `INSERT INTO contacts (id, tenant_id, first_name, last_name, email, company, title, birthday, notes,
+		phones, addresses, custom_fields, tags, version) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		ON CONFLICT (id) DO NOTHING`
+	result, err := conn(ctx, l.db).ExecContext(ctx, query, contact.ID, tenant, contact.FirstName, contact.LastName,
+		contact.Email, contact.Company, contact.Title, contact.Birthday, contact.Notes,
+		details.phones, details.addresses, details.customFields, details.tags, contact.Version)
+	if err != nil {
+		return false, mapError(err)
+	}
+	return l.inserted(ctx, result, "contacts")
+}
+
+func (l *Loader) LoadGroup(ctx context.Context, tenant string, group models.Group, memberIDs []int) (bool, error) {
+	var loaded bool
+	err := l.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		query := `INSERT INTO contact_groups (id, tenant_id, name, description, created_at) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (id) DO NOTHING`
+		result, err := conn(ctx, l.db).ExecContext(ctx, query,
+			group.ID, tenant, group.Name, group.Description, group.CreatedAt.UTC())
+		if err != nil {
+			return groupError(err, group.Name)
+		}
+		if loaded, err = l.inserted(ctx, result, "contact_groups"); err != nil || !loaded {
+			return err
+		}
+
+		for _, contactID := range memberIDs {
+			query := "INSERT INTO group_members (group_id, contact_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+			if _, err := conn(ctx, l.db).ExecContext(ctx, query, group.ID, contactID); err != nil {
+				return mapError(err)
+			}
+		}
+		return nil
+	})
+	return loaded, err
+}
+
+func (l *Loader) LoadOutboxMessage(ctx context.Context, msg models.OutboxMessage) (bool, error) {
+	payload, err := json.Marshal(msg.Email)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+	var deliveredAt sql.NullTime
+	if msg.DeliveredAt != nil {
+		deliveredAt = sql.NullTime{Time: msg.DeliveredAt.UTC(), Valid: true}
+	}
+
+	query := `INSERT INTO outbox (id, tenant_id, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) ON CONFLICT (id) DO NOTHING`
+	result, err := conn(ctx, l.db).ExecContext(ctx, query, msg.ID, msg.Tenant, string(payload), msg.Status, msg.Attempts,
+		msg.NextAttemptAt.UTC(), msg.LastError, msg.CreatedAt.UTC(), deliveredAt)
+	if err != nil {
+		return false, mapError(err)
+	}
+	return l.inserted(ctx, result, "outbox")
+}
+
+func (l *Loader) LoadAuditEntry(ctx context.Context, entry models.AuditEntry) (bool, error) {
+	before, err := marshalSnapshot(entry.Before)
+	if err != nil {
+		return false, err
+	}
+	after, err := marshalSnapshot(entry.After)
+	if err != nil {
+		return false, err
+	}
+
+	query := `INSERT INTO audit_log (id, tenant_id, contact_id, action, actor, created_at, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8) ON CONFLICT (id) DO NOTHING`
+	result, err := conn(ctx, l.db).ExecContext(ctx, query, entry.ID, entry.Tenant, entry.ContactID, entry.Action,
+		entry.Actor, entry.Timestamp.UTC(), before, after)
+	if err != nil {
+		return false, mapError(err)
+	}
+	return l.inserted(ctx, result, "audit_log")
+}
+
+// inserted tells whether an INSERT ... ON CONFLICT DO NOTHING added its row
+// to table, and if so moves the ID sequence of table to the highest ID so
+// that the rows created next do not collide with the loaded ones
+func (l *Loader) inserted(ctx context.Context, result sql.Result, table string) (bool, error) {
+	n, err := result.RowsAffected()
+	if err != nil || n == 0 {
+		return false, mapError(err)
+	}
+	query := fmt.Sprintf("SELECT setval(pg_get_serial_sequence('%[1]s', 'id'), (SELECT MAX(id) FROM %[1]s))", table)
+	_, err = conn(ctx, l.db).ExecContext(ctx, query)
+	return true, mapError(err)
+}
@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"golang/internal/store/interfaces"
+)
+
+// Snapshotter is the PostgreSQL implementation of SnapshotterInterface. The
+// snapshot is a read-only REPEATABLE READ transaction: every query fn makes
+// sees the data as of Snapshot, while other sessions keep writing.
+type Snapshotter struct {
+	db *sql.DB
+}
+
+// NewSnapshotter creates a PostgreSQL snapshotter
+func NewSnapshotter(db *sql.DB) interfaces.SnapshotterInterface {
+	return &Snapshotter{db: db}
+}
+
+func (s *Snapshotter) Snapshot(ctx context.Context, fn func(ctx context.Context, snapshot *interfaces.Store) error) error {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return mapError(err)
+	}
+	defer tx.Rollback()
+
+	// the snapshot is taken by the first query of the transaction, not by
+	// BEGIN, so that writes made before fn starts stay out of it
+	if _, err := tx.ExecContext(ctx, "SELECT 1"); err != nil {
+		return mapError(err)
+	}
+
+	// the repositories of the store run their queries in the transaction
+	// carried by ctx
+	return fn(context.WithValue(ctx, txKey{}, tx), NewStorage(s.db))
+}
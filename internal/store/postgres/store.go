@@ -7,6 +7,13 @@ import (
 
 func NewStorage(db *sql.DB) *interfaces.Store {
 	return &interfaces.Store{
-		Contact: NewContactRepository(db),
+		Tx:       NewTransactor(db),
+		Tenant:   NewTenantRepository(db),
+		Contact:  NewContactRepository(db),
+		Group:    NewGroupRepository(db),
+		Outbox:   NewOutboxRepository(db),
+		Audit:    NewAuditRepository(db),
+		Loader:   NewLoader(db),
+		Snapshot: NewSnapshotter(db),
 	}
 }
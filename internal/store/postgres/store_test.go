@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang/internal/config"
+	"golang/internal/database"
+	"golang/internal/store/interfaces"
+	"golang/internal/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	admin := connectAdmin(t)
+	storetest.Run(t, func(t *testing.T) *interfaces.Store {
+		return newTestStore(t, admin)
+	})
+}
+
+// testConfig points at the local server described by the usual PG*
+// environment variables, defaulting to the docker-compose instance
+func testConfig() config.PostgresConfig {
+	cfg := config.PostgresConfig{
+		Host:          env("PGHOST", "localhost"),
+		User:          env("PGUSER", "postgres"),
+		Password:      env("PGPASSWORD", "postgres"),
+		DBName:        "postgres",
+		MigrationsDir: filepath.Join("..", "..", "..", "db", "migrations"),
+	}
+	cfg.Port, _ = strconv.Atoi(env("PGPORT", "5432"))
+	return cfg
+}
+
+// connectAdmin opens the maintenance database, skipping the test when no
+// server is reachable
+func connectAdmin(t *testing.T) *sql.DB {
+	cfg := testConfig()
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable connect_timeout=2",
+		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName)
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		t.Skipf("PostgreSQL is not available: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// newTestStore migrates a throwaway database that is dropped after the test
+func newTestStore(t *testing.T, admin *sql.DB) *interfaces.Store {
+	name := fmt.Sprintf("contacts_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE DATABASE " + name); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec("DROP DATABASE IF EXISTS " + name); err != nil {
+			t.Errorf("failed to drop %s: %v", name, err)
+		}
+	})
+
+	cfg := testConfig()
+	cfg.DBName = name
+	db := database.NewPostgresDB(cfg)
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return NewStorage(db.GetDB())
+}
+
+func env(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// TenantRepository is the PostgreSQL implementation of TenantRepositoryInterface
+type TenantRepository struct {
+	db *sql.DB
+}
+
+// NewTenantRepository creates a PostgreSQL tenant repository
+func NewTenantRepository(db *sql.DB) interfaces.TenantRepositoryInterface {
+	return &TenantRepository{db: db}
+}
+
+func (r *TenantRepository) List(ctx context.Context) ([]models.Tenant, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT id, name, created_at FROM tenants ORDER BY id")
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var tenants []models.Tenant
+	for rows.Next() {
+		var t models.Tenant
+		if err := rows.Scan(&t.ID, &t.Name, &t.CreatedAt); err != nil {
+			return nil, mapError(err)
+		}
+		tenants = append(tenants, t)
+	}
+	return tenants, mapError(rows.Err())
+}
+
+func (r *TenantRepository) GetByID(ctx context.Context, id string) (*models.Tenant, error) {
+	var t models.Tenant
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT id, name, created_at FROM tenants WHERE id = $1", id).
+		Scan(&t.ID, &t.Name, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: tenant %s", models.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &t, nil
+}
+
+func (r *TenantRepository) Create(ctx context.Context, tenant models.Tenant) error {
+	query := "INSERT INTO tenants (id, name, created_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING"
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, tenant.ID, tenant.Name, tenant.CreatedAt.UTC())
+	if err != nil {
+		return mapError(err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return mapError(err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: tenant %s already exists", models.ErrConflict, tenant.ID)
+	}
+	return nil
+}
+
+func (r *TenantRepository) Delete(ctx context.Context, id string) error {
+	if id == models.DefaultTenant {
+		return fmt.Errorf("%w: the %s tenant cannot be deleted", models.ErrConflict, id)
+	}
+	for _, query := range []string{
+		"DELETE FROM contact_groups WHERE tenant_id = $1",
+		"DELETE FROM contacts WHERE tenant_id = $1",
+		"DELETE FROM outbox WHERE tenant_id = $1",
+	} {
+		if _, err := conn(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+			return mapError(err)
+		}
+	}
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM tenants WHERE id = $1", id)
+	if err != nil {
+		return mapError(err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return mapError(err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: tenant %s", models.ErrNotFound, id)
+	}
+	return nil
+}
@@ -3,7 +3,11 @@ package sqlite
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"unicode"
 
 	"golang/internal/models"
 	"golang/internal/store/interfaces"
@@ -14,61 +18,434 @@ type ContactRepository struct {
 	db *sql.DB
 }
 
+// contactColumns lists the columns scanned into models.Contact by scanContact
+const contactColumns = "id, first_name, last_name, email, company, title, birthday, notes, " +
+	"phones, addresses, custom_fields, tags, version"
+
 // NewContactRepository creates a SQLite contact repository
 func NewContactRepository(db *sql.DB) interfaces.ContactRepositoryInterface {
 	return &ContactRepository{db: db}
 }
 
-func (r *ContactRepository) GetAll(ctx context.Context) ([]models.Contact, error) {
-	query := "SELECT id, first_name, last_name, email FROM contacts"
-	rows, err := r.db.QueryContext(ctx, query)
+func (r *ContactRepository) GetAll(ctx context.Context, tenant string) ([]models.Contact, error) {
+	query := "SELECT " + contactColumns + " FROM contacts WHERE tenant_id = ?"
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, tenant)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return collectContacts(rows, nil)
+}
+
+// List returns one page of contacts using keyset pagination on (sort column, id)
+func (r *ContactRepository) List(ctx context.Context, tenant string, q models.ContactQuery) (*models.ContactPage, error) {
+	if err := q.Normalize(); err != nil {
+		return nil, err
+	}
+	cursor, _ := q.DecodeCursor()
+
+	where := []string{"tenant_id = ?"}
+	args := []any{tenant}
+	for _, f := range []struct{ column, prefix string }{
+		{"first_name", q.FirstNamePrefix},
+		{"last_name", q.LastNamePrefix},
+		{"email", q.EmailPrefix},
+	} {
+		if f.prefix != "" {
+			where = append(where, f.column+" GLOB ?")
+			args = append(args, globPrefixFold(f.prefix))
+		}
+	}
+	for _, tag := range q.Tags {
+		where = append(where, "EXISTS (SELECT 1 FROM json_each(contacts.tags) WHERE value = ?)")
+		args = append(args, tag)
+	}
+
+	op, dir := ">", "ASC"
+	if q.Descending {
+		op, dir = "<", "DESC"
+	}
+	if cursor != nil {
+		if q.SortBy == models.SortByID {
+			where = append(where, "id "+op+" ?")
+			args = append(args, cursor.ID)
+		} else {
+			where = append(where, fmt.Sprintf("(%s, id) %s (?, ?)", q.SortBy, op))
+			args = append(args, cursor.Value, cursor.ID)
+		}
+	}
+
+	query := "SELECT " + contactColumns + " FROM contacts WHERE " + strings.Join(where, " AND ")
+	if q.SortBy == models.SortByID {
+		query += " ORDER BY id " + dir
+	} else {
+		query += fmt.Sprintf(" ORDER BY %s %s, id %s", q.SortBy, dir, dir)
+	}
+	query += " LIMIT ?"
+	args = append(args, q.Limit+1)
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	contacts, err := collectContacts(rows, make([]models.Contact, 0, q.Limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.ContactPage{Contacts: contacts}
+	if len(contacts) > q.Limit {
+		page.Contacts = contacts[:q.Limit]
+		page.NextCursor = q.CursorAfter(page.Contacts[q.Limit-1])
+	}
+	return page, nil
+}
+
+// Search finds the candidates in the contacts_fts full-text index: each term
+// must match a word as a prefix or, within its typo tolerance, one of the
+// indexed words listed by contacts_fts_terms
+func (r *ContactRepository) Search(ctx context.Context, tenant string, search models.ContactSearch) ([]models.Contact, error) {
+	if err := search.Normalize(); err != nil {
+		return nil, err
+	}
+	match, err := r.matchExpression(ctx, search.Terms)
 	if err != nil {
 		return nil, err
 	}
+
+	query := "SELECT " + contactColumns + ` FROM contacts WHERE tenant_id = ?
+		AND id IN (SELECT rowid FROM contacts_fts WHERE contacts_fts MATCH ?)`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, tenant, match)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	candidates, err := collectContacts(rows, nil)
+	if err != nil {
+		return nil, err
+	}
+	return models.RankContacts(candidates, search), nil
+}
+
+// matchExpression builds the full-text query requiring every term, e.g.
+// ("ada"*) AND ("lovelace"* OR "lovelance") when "lovelance" is an indexed word.
+// Terms are quoted so that none is read as an FTS5 operator.
+func (r *ContactRepository) matchExpression(ctx context.Context, terms []string) (string, error) {
+	var vocabulary []string
+	groups := make([]string, len(terms))
+	for i, term := range terms {
+		alternatives := []string{ftsString(term) + "*"}
+		if typos := models.MaxTypos(term); typos > 0 {
+			if vocabulary == nil {
+				var err error
+				if vocabulary, err = r.vocabulary(ctx); err != nil {
+					return "", err
+				}
+			}
+			for _, word := range vocabulary {
+				if !strings.HasPrefix(word, term) && models.EditDistance(term, word, typos) <= typos {
+					alternatives = append(alternatives, ftsString(word))
+				}
+			}
+		}
+		groups[i] = "(" + strings.Join(alternatives, " OR ") + ")"
+	}
+	return strings.Join(groups, " AND "), nil
+}
+
+// ftsString quotes a word as an FTS5 string
+func ftsString(word string) string {
+	return `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+}
+
+// vocabulary lists the words of the full-text index, of every tenant
+func (r *ContactRepository) vocabulary(ctx context.Context) ([]string, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT term FROM contacts_fts_terms")
+	if err != nil {
+		return nil, mapError(err)
+	}
 	defer rows.Close()
 
-	var contacts []models.Contact
+	words := []string{}
 	for rows.Next() {
-		var c models.Contact
-		if err := rows.Scan(&c.ID, &c.FirstName, &c.LastName, &c.Email); err != nil {
-			return nil, err
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			return nil, mapError(err)
 		}
-		contacts = append(contacts, c)
+		words = append(words, word)
 	}
-	return contacts, rows.Err()
+	return words, mapError(rows.Err())
 }
 
-func (r *ContactRepository) GetByID(ctx context.Context, id int) (*models.Contact, error) {
-	query := "SELECT id, first_name, last_name, email FROM contacts WHERE id = ?"
-	var c models.Contact
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&c.ID, &c.FirstName, &c.LastName, &c.Email)
+func (r *ContactRepository) GetByID(ctx context.Context, tenant string, id int) (*models.Contact, error) {
+	query := "SELECT " + contactColumns + " FROM contacts WHERE tenant_id = ? AND id = ?"
+	c, err := scanContact(conn(ctx, r.db).QueryRowContext(ctx, query, tenant, id))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("contact not found")
+		return nil, fmt.Errorf("%w: contact %d", models.ErrNotFound, id)
 	}
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 	return &c, nil
 }
 
-func (r *ContactRepository) Create(ctx context.Context, contact models.Contact) (int, error) {
-	query := "INSERT INTO contacts (first_name, last_name, email) VALUES (?, ?, ?)"
-	result, err := r.db.ExecContext(ctx, query, contact.FirstName, contact.LastName, contact.Email)
+func (r *ContactRepository) GetByEmail(ctx context.Context, tenant string, email string) (*models.Contact, error) {
+	query := "SELECT " + contactColumns + " FROM contacts WHERE tenant_id = ? AND email = ?"
+	c, err := scanContact(conn(ctx, r.db).QueryRowContext(ctx, query, tenant, email))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: contact with email %s", models.ErrNotFound, email)
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &c, nil
+}
+
+func (r *ContactRepository) Create(ctx context.Context, tenant string, contact models.Contact) (int, error) {
+	details, err := marshalDetails(contact)
 	if err != nil {
 		return 0, err
 	}
+	query := `INSERT INTO contacts (tenant_id, first_name, last_name, email, company, title, birthday, notes,
+		phones, addresses, custom_fields, tags) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, tenant, contact.FirstName, contact.LastName, contact.Email,
+		contact.Company, contact.Title, contact.Birthday, contact.Notes,
+		details.phones, details.addresses, details.customFields, details.tags)
+	if err != nil {
+		return 0, mapError(err)
+	}
 	id, err := result.LastInsertId()
-	return int(id), err
+	return int(id), mapError(err)
 }
 
-func (r *ContactRepository) Update(ctx context.Context, contact models.Contact) error {
-	query := "UPDATE contacts SET first_name = ?, last_name = ?, email = ? WHERE id = ?"
-	_, err := r.db.ExecContext(ctx, query, contact.FirstName, contact.LastName, contact.Email, contact.ID)
-	return err
+func (r *ContactRepository) Update(ctx context.Context, tenant string, contact models.Contact) error {
+	details, err := marshalDetails(contact)
+	if err != nil {
+		return err
+	}
+	query := `UPDATE contacts SET first_name = ?, last_name = ?, email = ?, company = ?, title = ?, birthday = ?,
+		notes = ?, phones = ?, addresses = ?, custom_fields = ?, tags = ?, version = version + 1
+		WHERE tenant_id = ? AND id = ? AND (? = 0 OR version = ?)`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		contact.FirstName, contact.LastName, contact.Email, contact.Company, contact.Title, contact.Birthday,
+		contact.Notes, details.phones, details.addresses, details.customFields, details.tags,
+		tenant, contact.ID, contact.Version, contact.Version)
+	if err != nil {
+		return mapError(err)
+	}
+	return r.requireCurrent(ctx, result, tenant, contact.ID)
 }
 
-func (r *ContactRepository) Delete(ctx context.Context, id int) error {
-	query := "DELETE FROM contacts WHERE id = ?"
-	_, err := r.db.ExecContext(ctx, query, id)
+// Patch updates only the columns of the fields set in patch
+func (r *ContactRepository) Patch(ctx context.Context, tenant string, id int, version int, patch models.ContactPatch) error {
+	var set []string
+	var args []any
+	for _, f := range []struct {
+		column string
+		value  *string
+	}{
+		{"first_name", patch.FirstName},
+		{"last_name", patch.LastName},
+		{"email", patch.Email},
+		{"company", patch.Company},
+		{"title", patch.Title},
+		{"birthday", patch.Birthday},
+		{"notes", patch.Notes},
+	} {
+		if f.value != nil {
+			set = append(set, f.column+" = ?")
+			args = append(args, *f.value)
+		}
+	}
+	if patch.Phones != nil {
+		phones, err := marshalList(*patch.Phones)
+		if err != nil {
+			return err
+		}
+		set = append(set, "phones = ?")
+		args = append(args, phones)
+	}
+	if patch.Addresses != nil {
+		addresses, err := marshalList(*patch.Addresses)
+		if err != nil {
+			return err
+		}
+		set = append(set, "addresses = ?")
+		args = append(args, addresses)
+	}
+	if patch.Tags != nil {
+		tags, err := marshalList(*patch.Tags)
+		if err != nil {
+			return err
+		}
+		set = append(set, "tags = ?")
+		args = append(args, tags)
+	}
+	if len(patch.CustomFields) > 0 {
+		// null members of a JSON merge patch remove the field
+		changes, err := json.Marshal(patch.CustomFields)
+		if err != nil {
+			return fmt.Errorf("failed to marshal custom fields: %w", err)
+		}
+		set = append(set, "custom_fields = json_patch(custom_fields, ?)")
+		args = append(args, string(changes))
+	}
+	set = append(set, "version = version + 1")
+	args = append(args, tenant, id, version, version)
+
+	query := "UPDATE contacts SET " + strings.Join(set, ", ") + " WHERE tenant_id = ? AND id = ? AND (? = 0 OR version = ?)"
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return mapError(err)
+	}
+	return r.requireCurrent(ctx, result, tenant, id)
+}
+
+func (r *ContactRepository) Delete(ctx context.Context, tenant string, id int, version int) error {
+	query := "DELETE FROM contacts WHERE tenant_id = ? AND id = ? AND (? = 0 OR version = ?)"
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, tenant, id, version, version)
+	if err != nil {
+		return mapError(err)
+	}
+	return r.requireCurrent(ctx, result, tenant, id)
+}
+
+// requireCurrent tells a missing contact from a stale version when a
+// versioned statement did not touch any row
+func (r *ContactRepository) requireCurrent(ctx context.Context, result sql.Result, tenant string, id int) error {
+	err := requireAffected(result, "contact", id)
+	if !errors.Is(err, models.ErrNotFound) {
+		return err
+	}
+	var exists bool
+	query := "SELECT EXISTS (SELECT 1 FROM contacts WHERE tenant_id = ? AND id = ?)"
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, tenant, id).Scan(&exists); err != nil {
+		return mapError(err)
+	}
+	if exists {
+		return fmt.Errorf("%w: contact %d", models.ErrVersionConflict, id)
+	}
 	return err
 }
+
+// scanContact reads one row selected with contactColumns
+func scanContact(row interface{ Scan(dest ...any) error }) (models.Contact, error) {
+	var c models.Contact
+	var details detailColumns
+	if err := row.Scan(&c.ID, &c.FirstName, &c.LastName, &c.Email, &c.Company, &c.Title, &c.Birthday, &c.Notes,
+		&details.phones, &details.addresses, &details.customFields, &details.tags, &c.Version); err != nil {
+		return c, err
+	}
+	if err := unmarshalDetails(&c, details); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// collectContacts appends the scanned rows to contacts
+func collectContacts(rows *sql.Rows, contacts []models.Contact) ([]models.Contact, error) {
+	defer rows.Close()
+
+	for rows.Next() {
+		c, err := scanContact(rows)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		contacts = append(contacts, c)
+	}
+	return contacts, mapError(rows.Err())
+}
+
+// detailColumns holds the JSON columns of a contact, encoded
+type detailColumns struct {
+	phones, addresses, customFields, tags string
+}
+
+// marshalDetails encodes the JSON columns of a contact
+func marshalDetails(c models.Contact) (detailColumns, error) {
+	var d detailColumns
+	var err error
+	if d.phones, err = marshalList(c.Phones); err != nil {
+		return d, err
+	}
+	if d.addresses, err = marshalList(c.Addresses); err != nil {
+		return d, err
+	}
+	if d.tags, err = marshalList(c.Tags); err != nil {
+		return d, err
+	}
+	d.customFields = "{}"
+	if len(c.CustomFields) > 0 {
+		data, err := json.Marshal(c.CustomFields)
+		if err != nil {
+			return d, fmt.Errorf("failed to marshal custom fields: %w", err)
+		}
+		d.customFields = string(data)
+	}
+	return d, nil
+}
+
+// marshalList encodes a list column, writing an empty list rather than null
+func marshalList[T any](list []T) (string, error) {
+	if len(list) == 0 {
+		return "[]", nil
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal contact details: %w", err)
+	}
+	return string(data), nil
+}
+
+// unmarshalDetails decodes the JSON columns of a contact, leaving empty
+// lists and maps nil as models.Contact.Normalize does
+func unmarshalDetails(c *models.Contact, d detailColumns) error {
+	if err := json.Unmarshal([]byte(d.phones), &c.Phones); err != nil {
+		return fmt.Errorf("failed to unmarshal phones: %w", err)
+	}
+	if err := json.Unmarshal([]byte(d.addresses), &c.Addresses); err != nil {
+		return fmt.Errorf("failed to unmarshal addresses: %w", err)
+	}
+	if err := json.Unmarshal([]byte(d.customFields), &c.CustomFields); err != nil {
+		return fmt.Errorf("failed to unmarshal custom fields: %w", err)
+	}
+	if err := json.Unmarshal([]byte(d.tags), &c.Tags); err != nil {
+		return fmt.Errorf("failed to unmarshal tags: %w", err)
+	}
+	if len(c.Phones) == 0 {
+		c.Phones = nil
+	}
+	if len(c.Addresses) == 0 {
+		c.Addresses = nil
+	}
+	if len(c.CustomFields) == 0 {
+		c.CustomFields = nil
+	}
+	if len(c.Tags) == 0 {
+		c.Tags = nil
+	}
+	return nil
+}
+
+// globPrefixFold returns the GLOB pattern of the values starting with prefix,
+// case folded like strings.EqualFold. LIKE would only fold ASCII letters, so
+// each letter becomes the set of its case variants, e.g. "Ém" becomes
+// "[Éé][Mm]*", and the wildcards of the input are matched literally.
+func globPrefixFold(prefix string) string {
+	var b strings.Builder
+	for _, r := range prefix {
+		switch {
+		case r == '*' || r == '?' || r == '[':
+			b.WriteString("[" + string(r) + "]")
+		case unicode.SimpleFold(r) != r:
+			b.WriteRune('[')
+			b.WriteRune(r)
+			for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+				b.WriteRune(f)
+			}
+			b.WriteRune(']')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteRune('*')
+	return b.String()
+}
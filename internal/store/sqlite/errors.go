@@ -0,0 +1,50 @@
+package sqlite
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-sqlite3"
+
+	"golang/internal/models"
+)
+
+// mapError translates SQLite driver errors into the shared domain errors
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch {
+		case sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique:
+			return fmt.Errorf("%w: a contact with this email already exists", models.ErrConflict)
+		case sqliteErr.Code == sqlite3.ErrBusy,
+			sqliteErr.Code == sqlite3.ErrLocked,
+			sqliteErr.Code == sqlite3.ErrCantOpen,
+			sqliteErr.Code == sqlite3.ErrIoErr:
+			return fmt.Errorf("%w: %w", models.ErrUnavailable, err)
+		}
+	}
+
+	if errors.Is(err, sql.ErrConnDone) || errors.Is(err, driver.ErrBadConn) {
+		return fmt.Errorf("%w: %w", models.ErrUnavailable, err)
+	}
+
+	return err
+}
+
+// requireAffected reports ErrNotFound when a statement did not touch any row
+func requireAffected(result sql.Result, entity string, id int) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return mapError(err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: %s %d", models.ErrNotFound, entity, id)
+	}
+	return nil
+}
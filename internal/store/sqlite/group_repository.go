@@ -0,0 +1,164 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// GroupRepository is the SQLite implementation of GroupRepositoryInterface
+type GroupRepository struct {
+	db *sql.DB
+}
+
+// NewGroupRepository creates a SQLite group repository
+func NewGroupRepository(db *sql.DB) interfaces.GroupRepositoryInterface {
+	return &GroupRepository{db: db}
+}
+
+func (r *GroupRepository) List(ctx context.Context, tenant string) ([]models.Group, error) {
+	query := "SELECT id, name, description, created_at FROM contact_groups WHERE tenant_id = ? ORDER BY name, id"
+	return r.query(ctx, query, tenant)
+}
+
+func (r *GroupRepository) GetByID(ctx context.Context, tenant string, id int) (*models.Group, error) {
+	var g models.Group
+	query := "SELECT id, name, description, created_at FROM contact_groups WHERE tenant_id = ? AND id = ?"
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, tenant, id).Scan(&g.ID, &g.Name, &g.Description, &g.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: group %d", models.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &g, nil
+}
+
+func (r *GroupRepository) Create(ctx context.Context, tenant string, group models.Group) (int, error) {
+	query := "INSERT INTO contact_groups (tenant_id, name, description, created_at) VALUES (?, ?, ?, ?)"
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, tenant, group.Name, group.Description, group.CreatedAt.UTC())
+	if err != nil {
+		return 0, groupError(err, group.Name)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, mapError(err)
+	}
+	return int(id), nil
+}
+
+func (r *GroupRepository) Update(ctx context.Context, tenant string, group models.Group) error {
+	query := "UPDATE contact_groups SET name = ?, description = ? WHERE tenant_id = ? AND id = ?"
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, group.Name, group.Description, tenant, group.ID)
+	if err != nil {
+		return groupError(err, group.Name)
+	}
+	return requireAffected(result, "group", group.ID)
+}
+
+func (r *GroupRepository) Delete(ctx context.Context, tenant string, id int) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM contact_groups WHERE tenant_id = ? AND id = ?", tenant, id)
+	if err != nil {
+		return mapError(err)
+	}
+	return requireAffected(result, "group", id)
+}
+
+func (r *GroupRepository) AddMember(ctx context.Context, tenant string, groupID, contactID int) error {
+	if err := r.requireMembers(ctx, tenant, groupID, contactID); err != nil {
+		return err
+	}
+	query := "INSERT INTO group_members (group_id, contact_id) VALUES (?, ?) ON CONFLICT DO NOTHING"
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, groupID, contactID)
+	return mapError(err)
+}
+
+func (r *GroupRepository) RemoveMember(ctx context.Context, tenant string, groupID, contactID int) error {
+	query := `DELETE FROM group_members WHERE group_id = ? AND contact_id = ?
+		AND group_id IN (SELECT id FROM contact_groups WHERE tenant_id = ?)`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, groupID, contactID, tenant)
+	if err != nil {
+		return mapError(err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return mapError(err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w: membership of contact %d in group %d", models.ErrNotFound, contactID, groupID)
+	}
+	return nil
+}
+
+func (r *GroupRepository) ListMembers(ctx context.Context, tenant string, groupID int) ([]models.Contact, error) {
+	if _, err := r.GetByID(ctx, tenant, groupID); err != nil {
+		return nil, err
+	}
+	query := "SELECT " + contactColumns + ` FROM contacts WHERE tenant_id = ?
+		AND id IN (SELECT contact_id FROM group_members WHERE group_id = ?) ORDER BY id`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, tenant, groupID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return collectContacts(rows, nil)
+}
+
+func (r *GroupRepository) ListByContact(ctx context.Context, tenant string, contactID int) ([]models.Group, error) {
+	if err := r.requireContact(ctx, tenant, contactID); err != nil {
+		return nil, err
+	}
+	query := `SELECT id, name, description, created_at FROM contact_groups WHERE tenant_id = ?
+		AND id IN (SELECT group_id FROM group_members WHERE contact_id = ?) ORDER BY name, id`
+	return r.query(ctx, query, tenant, contactID)
+}
+
+// requireMembers reports ErrNotFound unless both the group and the contact
+// belong to the tenant
+func (r *GroupRepository) requireMembers(ctx context.Context, tenant string, groupID, contactID int) error {
+	if _, err := r.GetByID(ctx, tenant, groupID); err != nil {
+		return err
+	}
+	return r.requireContact(ctx, tenant, contactID)
+}
+
+func (r *GroupRepository) requireContact(ctx context.Context, tenant string, contactID int) error {
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT id FROM contacts WHERE tenant_id = ? AND id = ?", tenant, contactID).
+		Scan(&id)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: contact %d", models.ErrNotFound, contactID)
+	}
+	return mapError(err)
+}
+
+func (r *GroupRepository) query(ctx context.Context, query string, args ...any) ([]models.Group, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var groups []models.Group
+	for rows.Next() {
+		var g models.Group
+		if err := rows.Scan(&g.ID, &g.Name, &g.Description, &g.CreatedAt); err != nil {
+			return nil, mapError(err)
+		}
+		groups = append(groups, g)
+	}
+	return groups, mapError(rows.Err())
+}
+
+// groupError reports a taken group name as a conflict naming the group
+// rather than the contact email that mapError assumes
+func groupError(err error, name string) error {
+	err = mapError(err)
+	if errors.Is(err, models.ErrConflict) {
+		return fmt.Errorf("%w: a group named %q already exists", models.ErrConflict, name)
+	}
+	return err
+}
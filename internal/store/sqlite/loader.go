@@ -0,0 +1,115 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// Loader is the SQLite implementation of LoaderInterface. Rows are inserted
+// with ON CONFLICT (id) DO NOTHING, so that other constraints, such as the
+// uniqueness of emails, still fail.
+type Loader struct {
+	db *sql.DB
+	tx interfaces.TransactorInterface
+}
+
+// NewLoader creates a SQLite loader
+func NewLoader(db *sql.DB) interfaces.LoaderInterface {
+	return &Loader{db: db, tx: NewTransactor(db)}
+}
+
+func (l *Loader) LoadContact(ctx context.Context, tenant string, contact models.Contact) (bool, error) {
+	details, err := marshalDetails(contact)
+	if err != nil {
+		return false, err
+	}
+	query := `INSERT INTO contacts (id, tenant_id, first_name, last_name, email, company, title, birthday, notes,
+		phones, addresses, custom_fields, tags, version) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`
+	result, err := conn(ctx, l.db).ExecContext(ctx, query, contact.ID, tenant, contact.FirstName, contact.LastName,
+		contact.Email, contact.Company, contact.Title, contact.Birthday, contact.Notes,
+		details.phones, details.addresses, details.customFields, details.tags, contact.Version)
+	if err != nil {
+		return false, mapError(err)
+	}
+	return inserted(result)
+}
+
+func (l *Loader) LoadGroup(ctx context.Context, tenant string, group models.Group, memberIDs []int) (bool, error) {
+	var loaded bool
+	err := l.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		query := `INSERT INTO contact_groups (id, tenant_id, name, description, created_at) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT (id) DO NOTHING`
+		result, err := conn(ctx, l.db).ExecContext(ctx, query,
+			group.ID, tenant, group.Name, group.Description, group.CreatedAt.UTC())
+		if err != nil {
+			return groupError(err, group.Name)
+		}
+		if loaded, err = inserted(result); err != nil || !loaded {
+			return err
+		}
+
+		for _, contactID := range memberIDs {
+			query := "INSERT INTO group_members (group_id, contact_id) VALUES (?, ?) ON CONFLICT DO NOTHING"
+			if _, err := conn(ctx, l.db).ExecContext(ctx, query, group.ID, contactID); err != nil {
+				return mapError(err)
+			}
+		}
+		return nil
+	})
+	return loaded, err
+}
+
+func (l *Loader) LoadOutboxMessage(ctx context.Context, msg models.OutboxMessage) (bool, error) {
+	payload, err := json.Marshal(msg.Email)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+	var deliveredAt sql.NullTime
+	if msg.DeliveredAt != nil {
+		deliveredAt = sql.NullTime{Time: msg.DeliveredAt.UTC(), Valid: true}
+	}
+
+	query := `INSERT INTO outbox (id, tenant_id, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (id) DO NOTHING`
+	result, err := conn(ctx, l.db).ExecContext(ctx, query, msg.ID, msg.Tenant, string(payload), msg.Status, msg.Attempts,
+		msg.NextAttemptAt.UTC(), msg.LastError, msg.CreatedAt.UTC(), deliveredAt)
+	if err != nil {
+		return false, mapError(err)
+	}
+	return inserted(result)
+}
+
+func (l *Loader) LoadAuditEntry(ctx context.Context, entry models.AuditEntry) (bool, error) {
+	before, err := marshalSnapshot(entry.Before)
+	if err != nil {
+		return false, err
+	}
+	after, err := marshalSnapshot(entry.After)
+	if err != nil {
+		return false, err
+	}
+
+	query := `INSERT INTO audit_log (id, tenant_id, contact_id, action, actor, created_at, before, after)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (id) DO NOTHING`
+	result, err := conn(ctx, l.db).ExecContext(ctx, query, entry.ID, entry.Tenant, entry.ContactID, entry.Action,
+		entry.Actor, entry.Timestamp.UTC(), before, after)
+	if err != nil {
+		return false, mapError(err)
+	}
+	return inserted(result)
+}
+
+// inserted tells whether an INSERT ... ON CONFLICT DO NOTHING added its row
+func inserted(result sql.Result) (bool, error) {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, mapError(err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,141 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+const outboxColumns = "id, tenant_id, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at"
+
+// OutboxRepository is the SQLite implementation of OutboxRepositoryInterface
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+// NewOutboxRepository creates a SQLite outbox repository
+func NewOutboxRepository(db *sql.DB) interfaces.OutboxRepositoryInterface {
+	return &OutboxRepository{db: db}
+}
+
+func (r *OutboxRepository) Enqueue(ctx context.Context, msg models.OutboxMessage) (int, error) {
+	payload, err := json.Marshal(msg.Email)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal outbox payload: %w", err)
+	}
+
+	query := "INSERT INTO outbox (tenant_id, payload, status, attempts, next_attempt_at, last_error, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		msg.Tenant, string(payload), msg.Status, msg.Attempts, msg.NextAttemptAt.UTC(), msg.LastError, msg.CreatedAt.UTC())
+	if err != nil {
+		return 0, mapError(err)
+	}
+	id, err := result.LastInsertId()
+	return int(id), mapError(err)
+}
+
+func (r *OutboxRepository) GetByID(ctx context.Context, tenant string, id int) (*models.OutboxMessage, error) {
+	query := "SELECT " + outboxColumns + " FROM outbox WHERE tenant_id = ? AND id = ?"
+	msg, err := scanOutbox(conn(ctx, r.db).QueryRowContext(ctx, query, tenant, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("%w: outbox message %d", models.ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &msg, nil
+}
+
+// Claim leases due messages with a single UPDATE ... RETURNING statement
+func (r *OutboxRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]models.OutboxMessage, error) {
+	query := `UPDATE outbox SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM outbox
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at, id
+			LIMIT ?
+		)
+		RETURNING ` + outboxColumns
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, now.Add(lease).UTC(), models.OutboxPending, now.UTC(), limit)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	msgs, err := collectOutbox(rows)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(msgs, func(i, j int) bool { return msgs[i].ID < msgs[j].ID })
+	return msgs, nil
+}
+
+func (r *OutboxRepository) Save(ctx context.Context, msg models.OutboxMessage) error {
+	var deliveredAt sql.NullTime
+	if msg.DeliveredAt != nil {
+		deliveredAt = sql.NullTime{Time: msg.DeliveredAt.UTC(), Valid: true}
+	}
+
+	query := "UPDATE outbox SET status = ?, attempts = ?, next_attempt_at = ?, last_error = ?, delivered_at = ? WHERE id = ?"
+	result, err := conn(ctx, r.db).ExecContext(ctx, query,
+		msg.Status, msg.Attempts, msg.NextAttemptAt.UTC(), msg.LastError, deliveredAt, msg.ID)
+	if err != nil {
+		return mapError(err)
+	}
+	return requireAffected(result, "outbox message", msg.ID)
+}
+
+func (r *OutboxRepository) ListByStatus(ctx context.Context, tenant string, status models.OutboxStatus) ([]models.OutboxMessage, error) {
+	query := "SELECT " + outboxColumns + " FROM outbox WHERE tenant_id = ? AND status = ? ORDER BY id"
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, tenant, status)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return collectOutbox(rows)
+}
+
+func (r *OutboxRepository) PurgeDelivered(ctx context.Context, before time.Time) (int, error) {
+	query := "DELETE FROM outbox WHERE status = ? AND delivered_at < ?"
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, models.OutboxDelivered, before.UTC())
+	if err != nil {
+		return 0, mapError(err)
+	}
+	n, err := result.RowsAffected()
+	return int(n), mapError(err)
+}
+
+// scanOutbox reads one row selected with outboxColumns
+func scanOutbox(row interface{ Scan(dest ...any) error }) (models.OutboxMessage, error) {
+	var msg models.OutboxMessage
+	var payload string
+	var deliveredAt sql.NullTime
+	if err := row.Scan(&msg.ID, &msg.Tenant, &payload, &msg.Status, &msg.Attempts, &msg.NextAttemptAt,
+		&msg.LastError, &msg.CreatedAt, &deliveredAt); err != nil {
+		return msg, err
+	}
+	if err := json.Unmarshal([]byte(payload), &msg.Email); err != nil {
+		return msg, fmt.Errorf("failed to unmarshal outbox payload: %w", err)
+	}
+	if deliveredAt.Valid {
+		msg.DeliveredAt = &deliveredAt.Time
+	}
+	return msg, nil
+}
+
+func collectOutbox(rows *sql.Rows) ([]models.OutboxMessage, error) {
+	defer rows.Close()
+
+	var msgs []models.OutboxMessage
+	for rows.Next() {
+		msg, err := scanOutbox(rows)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, mapError(rows.Err())
+}
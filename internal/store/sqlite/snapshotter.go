@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// Pages copied per step of an online backup, and the pause between steps
+// during which writers get the database back. A write between two steps
+// makes SQLite start the copy over, so the result is always consistent.
+const (
+	snapshotPages = 1024
+	snapshotPause = 5 * time.Millisecond
+)
+
+// Snapshotter is the SQLite implementation of SnapshotterInterface. It copies
+// the database to a temporary file with the online backup API.
+type Snapshotter struct {
+	db *sql.DB
+}
+
+// NewSnapshotter creates a SQLite snapshotter
+func NewSnapshotter(db *sql.DB) interfaces.SnapshotterInterface {
+	return &Snapshotter{db: db}
+}
+
+func (s *Snapshotter) Snapshot(ctx context.Context, fn func(ctx context.Context, snapshot *interfaces.Store) error) error {
+	dir, err := os.MkdirTemp("", "contacts-snapshot-")
+	if err != nil {
+		return fmt.Errorf("%w: failed to create snapshot directory: %w", models.ErrUnavailable, err)
+	}
+	defer os.RemoveAll(dir)
+
+	copyDB, err := sql.Open("sqlite3", filepath.Join(dir, "snapshot.db"))
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot database: %w", err)
+	}
+	defer copyDB.Close()
+
+	if err := backup(ctx, s.db, copyDB); err != nil {
+		return fmt.Errorf("failed to snapshot the database: %w", mapError(err))
+	}
+	return fn(ctx, NewStorage(copyDB))
+}
+
+// backup copies the main database of from to to, a few pages at a time
+func backup(ctx context.Context, from, to *sql.DB) error {
+	src, err := from.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := to.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	return dst.Raw(func(dstConn any) error {
+		return src.Raw(func(srcConn any) error {
+			b, err := dstConn.(*sqlite3.SQLiteConn).Backup("main", srcConn.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return err
+			}
+			for {
+				done, err := b.Step(snapshotPages)
+				if err != nil || done {
+					if finishErr := b.Finish(); err == nil {
+						err = finishErr
+					}
+					return err
+				}
+				select {
+				case <-ctx.Done():
+					b.Finish()
+					return ctx.Err()
+				case <-time.After(snapshotPause):
+				}
+			}
+		})
+	})
+}
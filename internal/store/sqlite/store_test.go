@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"golang/internal/config"
+	"golang/internal/database"
+	"golang/internal/store/interfaces"
+	"golang/internal/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	// fail rather than skip, so that an untagged run cannot pass for a
+	// tested SQLite store
+	if !database.FTS5 {
+		t.Fatal(database.ErrNoFTS5)
+	}
+	storetest.Run(t, newTestStore)
+}
+
+// newTestStore migrates a fresh database file in a temp dir
+func newTestStore(t *testing.T) *interfaces.Store {
+	db := database.NewSQLiteDB(config.SQLiteConfig{
+		DBPath:        filepath.Join(t.TempDir(), "contacts.db"),
+		MigrationsDir: filepath.Join("..", "..", "..", "db", "migrations"),
+	})
+	if err := db.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	if err := db.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return NewStorage(db.GetDB())
+}
@@ -0,0 +1,192 @@
+// Package storecopy copies the data of one store to another, such as from
+// the file store to PostgreSQL: tenants, contacts, groups with their members,
+// the outbox and the audit log, keeping their IDs. Records are read from a
+// Source, a store or a backup archive, and written in batches, each batch in
+// one transaction of the destination.
+//
+// Records whose ID the destination already holds are skipped, so that a copy
+// that was interrupted is resumed by running it again. Verify then tells
+// whether both stores hold the same records. The source should not change
+// while it is copied.
+//
+// A store is read through FromStore and a backup archive through the backup
+// package, both as a Source. Before copying or verifying, the IDs that
+// several tenants of the source use are checked for, and given new ones
+// with Options.Renumber, in renumber.go.
+package storecopy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// BatchSize is the number of records read and written at once
+const BatchSize = models.MaxPageSize
+
+// Kinds of records, in the order they are copied
+const (
+	Tenants  = "tenants"
+	Contacts = "contacts"
+	Groups   = "groups"
+	Outbox   = "outbox"
+	Audit    = "audit"
+)
+
+// Kinds lists the kinds of records in the order they are copied
+var Kinds = []string{Tenants, Contacts, Groups, Outbox, Audit}
+
+// Options tune a copy
+type Options struct {
+	// Renumber gives new IDs to the contacts and groups whose ID another
+	// tenant of the source uses, instead of failing with ErrIDCollision.
+	// Memberships and the audit log follow the new IDs.
+	Renumber bool
+	// Progress, if set, is called after each batch with the totals so far
+	Progress func(Count)
+}
+
+// Count is the number of records of a kind copied, and of those skipped
+// because the destination already held their ID
+type Count struct {
+	Kind    string
+	Copied  int
+	Skipped int
+}
+
+// copier copies the records read by source to the destination
+type copier struct {
+	to       *interfaces.Store
+	progress func(Count)
+	counts   []Count
+}
+
+// Copy copies every record of src to to and returns the counts of each kind
+func Copy(ctx context.Context, src Source, to *interfaces.Store, opts Options) ([]Count, error) {
+	src, err := renumber(ctx, src, opts.Renumber)
+	if err != nil {
+		return nil, err
+	}
+	c := &copier{to: to, progress: opts.Progress}
+
+	tenants, err := src.Tenants(ctx)
+	if err != nil {
+		return c.counts, err
+	}
+	if err := c.copyTenants(ctx, tenants); err != nil {
+		return c.counts, fmt.Errorf("failed to copy tenants: %w", err)
+	}
+	if err := c.copyContacts(ctx, src); err != nil {
+		return c.counts, fmt.Errorf("failed to copy contacts: %w", err)
+	}
+	if err := c.copyGroups(ctx, src); err != nil {
+		return c.counts, fmt.Errorf("failed to copy groups: %w", err)
+	}
+	if err := c.copyOutbox(ctx, src); err != nil {
+		return c.counts, fmt.Errorf("failed to copy the outbox: %w", err)
+	}
+	if err := c.copyAudit(ctx, src); err != nil {
+		return c.counts, fmt.Errorf("failed to copy the audit log: %w", err)
+	}
+	return c.counts, nil
+}
+
+// start begins counting the records of a kind
+func (c *copier) start(kind string) {
+	c.counts = append(c.counts, Count{Kind: kind})
+}
+
+// count adds the outcome of one record to the current kind
+func (c *copier) count(loaded bool) {
+	current := &c.counts[len(c.counts)-1]
+	if loaded {
+		current.Copied++
+	} else {
+		current.Skipped++
+	}
+}
+
+// batch writes records in one transaction, counting them once it commits
+func batch[T any](ctx context.Context, c *copier, records []T, load func(ctx context.Context, record T) (bool, error)) error {
+	loaded := make([]bool, len(records))
+	err := c.to.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		for i, record := range records {
+			var err error
+			if loaded[i], err = load(ctx, record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, l := range loaded {
+		c.count(l)
+	}
+	if c.progress != nil {
+		c.progress(c.counts[len(c.counts)-1])
+	}
+	return nil
+}
+
+// copyTenants creates the tenants missing from the destination. The default
+// tenant always exists. They are looked up rather than created regardless,
+// as a failed statement aborts a PostgreSQL transaction.
+func (c *copier) copyTenants(ctx context.Context, tenants []models.Tenant) error {
+	c.start(Tenants)
+	return batch(ctx, c, tenants, func(ctx context.Context, tenant models.Tenant) (bool, error) {
+		_, err := c.to.Tenant.GetByID(ctx, tenant.ID)
+		if !errors.Is(err, models.ErrNotFound) {
+			return false, err
+		}
+		if err := c.to.Tenant.Create(ctx, tenant); err != nil {
+			return false, fmt.Errorf("tenant %s: %w", tenant.ID, err)
+		}
+		return true, nil
+	})
+}
+
+func (c *copier) copyContacts(ctx context.Context, src Source) error {
+	c.start(Contacts)
+	return src.Contacts(ctx, func(contacts []Contact) error {
+		return batch(ctx, c, contacts, func(ctx context.Context, contact Contact) (bool, error) {
+			loaded, err := c.to.Loader.LoadContact(ctx, contact.Tenant, contact.Contact)
+			if err != nil {
+				return false, fmt.Errorf("contact %d of tenant %s: %w", contact.ID, contact.Tenant, err)
+			}
+			return loaded, nil
+		})
+	})
+}
+
+func (c *copier) copyGroups(ctx context.Context, src Source) error {
+	c.start(Groups)
+	return src.Groups(ctx, func(groups []Group) error {
+		return batch(ctx, c, groups, func(ctx context.Context, g Group) (bool, error) {
+			loaded, err := c.to.Loader.LoadGroup(ctx, g.Tenant, g.Group, g.Members)
+			if err != nil {
+				return false, fmt.Errorf("group %d of tenant %s: %w", g.ID, g.Tenant, err)
+			}
+			return loaded, nil
+		})
+	})
+}
+
+func (c *copier) copyOutbox(ctx context.Context, src Source) error {
+	c.start(Outbox)
+	return src.Outbox(ctx, func(msgs []models.OutboxMessage) error {
+		return batch(ctx, c, msgs, c.to.Loader.LoadOutboxMessage)
+	})
+}
+
+func (c *copier) copyAudit(ctx context.Context, src Source) error {
+	c.start(Audit)
+	return src.Audit(ctx, func(entries []models.AuditEntry) error {
+		return batch(ctx, c, entries, c.to.Loader.LoadAuditEntry)
+	})
+}
@@ -0,0 +1,168 @@
+package storecopy
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang/internal/models"
+	"golang/internal/store/filestore"
+	"golang/internal/store/interfaces"
+)
+
+// newFileStore returns an empty file store in a temp dir
+func newFileStore(t *testing.T) *interfaces.Store {
+	t.Helper()
+	store, err := filestore.NewStorage(filepath.Join(t.TempDir(), "contacts.json"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// newCollidingSource returns a file store whose tenants default and sales
+// both use contact 1 and group 1. The audit log also remembers contact 7 of
+// the default tenant, since deleted.
+func newCollidingSource(t *testing.T) *interfaces.Store {
+	t.Helper()
+	ctx := context.Background()
+	store := newFileStore(t)
+	created := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	if err := store.Tenant.Create(ctx, models.Tenant{ID: "sales", Name: "Sales", CreatedAt: created}); err != nil {
+		t.Fatal(err)
+	}
+	ada := models.Contact{ID: 1, FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Version: 1}
+	grace := models.Contact{ID: 1, FirstName: "Grace", LastName: "Hopper", Email: "grace@example.com", Version: 1}
+	for _, load := range []func() (bool, error){
+		func() (bool, error) { return store.Loader.LoadContact(ctx, models.DefaultTenant, ada) },
+		func() (bool, error) { return store.Loader.LoadContact(ctx, "sales", grace) },
+		func() (bool, error) {
+			return store.Loader.LoadGroup(ctx, models.DefaultTenant, models.Group{ID: 1, Name: "Pioneers", CreatedAt: created}, []int{1})
+		},
+		func() (bool, error) {
+			return store.Loader.LoadGroup(ctx, "sales", models.Group{ID: 1, Name: "Admirals", CreatedAt: created}, []int{1})
+		},
+		func() (bool, error) {
+			return store.Loader.LoadAuditEntry(ctx, models.AuditEntry{ID: 1, Tenant: models.DefaultTenant, ContactID: 7,
+				Action: models.AuditDelete, Actor: "test", Timestamp: created})
+		},
+		func() (bool, error) {
+			return store.Loader.LoadAuditEntry(ctx, models.AuditEntry{ID: 2, Tenant: "sales", ContactID: 1,
+				Action: models.AuditCreate, Actor: "test", Timestamp: created, After: &grace})
+		},
+	} {
+		if _, err := load(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return store
+}
+
+func TestCopyRefusesCollidingIDs(t *testing.T) {
+	ctx := context.Background()
+	src, to := newCollidingSource(t), newFileStore(t)
+
+	_, err := Copy(ctx, FromStore(src), to, Options{})
+	if !errors.Is(err, ErrIDCollision) {
+		t.Fatalf("Copy = %v, want ErrIDCollision", err)
+	}
+	contacts, err := to.Contact.GetAll(ctx, models.DefaultTenant)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(contacts) != 0 {
+		t.Errorf("Copy wrote %d contacts before failing", len(contacts))
+	}
+}
+
+func TestCopyRenumbersAndResumes(t *testing.T) {
+	ctx := context.Background()
+	src, to := newCollidingSource(t), newFileStore(t)
+	opts := Options{Renumber: true}
+
+	counts, err := Copy(ctx, FromStore(src), to, opts)
+	if err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+	want := []Count{{Tenants, 1, 1}, {Contacts, 2, 0}, {Groups, 2, 0}, {Outbox, 0, 0}, {Audit, 2, 0}}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("Copy counts = %v, want %v", counts, want)
+	}
+
+	// Grace takes the ID after the deleted contact 7, and her group the
+	// next free group ID; her membership and history follow
+	grace, err := to.Contact.GetByEmail(ctx, "sales", "grace@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grace.ID != 8 {
+		t.Errorf("Grace was copied as contact %d, want 8", grace.ID)
+	}
+	members, err := to.Group.ListMembers(ctx, "sales", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || members[0].ID != 8 {
+		t.Errorf("members of group 2 = %+v, want Grace as contact 8", members)
+	}
+	entries, err := to.Audit.List(ctx, 0, BatchSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].ContactID != 8 || entries[1].After == nil || entries[1].After.ID != 8 {
+		t.Errorf("audit log = %+v, want the entry of Grace renumbered to 8", entries)
+	}
+
+	// running it again copies nothing more
+	counts, err = Copy(ctx, FromStore(src), to, opts)
+	if err != nil {
+		t.Fatalf("Copy again: %v", err)
+	}
+	want = []Count{{Tenants, 0, 2}, {Contacts, 0, 2}, {Groups, 0, 2}, {Outbox, 0, 0}, {Audit, 0, 2}}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("Copy again counts = %v, want %v", counts, want)
+	}
+
+	checks, err := Verify(ctx, FromStore(src), to, opts)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	for _, c := range checks {
+		if !c.OK() {
+			t.Errorf("Verify %s: source %+v, destination %+v", c.Kind, c.Source, c.Destination)
+		}
+	}
+}
+
+func TestVerifyTellsDifferences(t *testing.T) {
+	ctx := context.Background()
+	src, to := newCollidingSource(t), newFileStore(t)
+	opts := Options{Renumber: true}
+	if _, err := Copy(ctx, FromStore(src), to, opts); err != nil {
+		t.Fatalf("Copy: %v", err)
+	}
+
+	grace, err := to.Contact.GetByEmail(ctx, "sales", "grace@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grace.Title = "Rear Admiral"
+	if err := to.Contact.Update(ctx, "sales", *grace); err != nil {
+		t.Fatal(err)
+	}
+
+	checks, err := Verify(ctx, FromStore(src), to, opts)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	for _, c := range checks {
+		if c.OK() == (c.Kind == Contacts) {
+			t.Errorf("Verify %s OK = %t", c.Kind, c.OK())
+		}
+	}
+}
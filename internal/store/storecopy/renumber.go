@@ -0,0 +1,169 @@
+package storecopy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang/internal/models"
+)
+
+// ErrIDCollision reports contact or group IDs that more than one tenant of
+// the source uses. The file store numbers the records of each tenant on
+// their own, while the SQL stores number them across tenants.
+var ErrIDCollision = errors.New("IDs used by more than one tenant")
+
+// key identifies a contact or a group of a tenant
+type key struct {
+	tenant string
+	id     int
+}
+
+// renumbered is a source whose colliding IDs are given new ones
+type renumbered struct {
+	Source
+	contactIDs map[key]int // new IDs of renumbered contacts
+	groupIDs   map[key]int // new IDs of renumbered groups
+}
+
+// renumber checks the IDs of src for collisions. Unless enabled, it fails
+// with ErrIDCollision when there are any; otherwise it gives each colliding
+// ID but the first (in tenant order) a new one past the highest ID in use.
+// The new IDs only depend on the contents of src, so that an interrupted
+// copy can be run again.
+func renumber(ctx context.Context, src Source, enabled bool) (Source, error) {
+	tenants, err := src.Tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	contactsOf := map[string][]int{}
+	err = src.Contacts(ctx, func(contacts []Contact) error {
+		for _, c := range contacts {
+			contactsOf[c.Tenant] = append(contactsOf[c.Tenant], c.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	groupsOf := map[string][]int{}
+	err = src.Groups(ctx, func(groups []Group) error {
+		for _, g := range groups {
+			groupsOf[g.Tenant] = append(groupsOf[g.Tenant], g.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// new contact IDs are also kept clear of deleted contacts, whose history
+	// stays in the audit log
+	deleted := 0
+	err = src.Audit(ctx, func(entries []models.AuditEntry) error {
+		for _, e := range entries {
+			deleted = max(deleted, e.ContactID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var collisions []string
+	r := &renumbered{Source: src}
+	r.contactIDs, collisions = renumbering(tenants, contactsOf, deleted, "contact", collisions)
+	r.groupIDs, collisions = renumbering(tenants, groupsOf, 0, "group", collisions)
+	switch {
+	case len(collisions) == 0:
+		return src, nil
+	case !enabled:
+		if len(collisions) > 5 {
+			collisions = append(collisions[:5], fmt.Sprintf("and %d more", len(collisions)-5))
+		}
+		return nil, fmt.Errorf("%w: %s", ErrIDCollision, strings.Join(collisions, ", "))
+	}
+	return r, nil
+}
+
+// renumbering maps the colliding IDs of ids, listed by tenant, to new ones
+// past the highest ID and past next, and describes the collisions
+func renumbering(tenants []models.Tenant, ids map[string][]int, next int, entity string, collisions []string) (map[key]int, []string) {
+	for _, list := range ids {
+		slices.Sort(list)
+		next = max(next, slices.Max(append(list, 0)))
+	}
+
+	owners := map[int]string{}
+	renumbered := map[key]int{}
+	for _, tenant := range tenants {
+		for _, id := range ids[tenant.ID] {
+			owner, taken := owners[id]
+			if !taken {
+				owners[id] = tenant.ID
+				continue
+			}
+			next++
+			renumbered[key{tenant.ID, id}] = next
+			collisions = append(collisions, fmt.Sprintf("%s %d of %s and %s", entity, id, owner, tenant.ID))
+		}
+	}
+	return renumbered, collisions
+}
+
+func (r *renumbered) contactID(tenant string, id int) int {
+	if renumbered, ok := r.contactIDs[key{tenant, id}]; ok {
+		return renumbered
+	}
+	return id
+}
+
+func (r *renumbered) Contacts(ctx context.Context, fn func([]Contact) error) error {
+	return r.Source.Contacts(ctx, func(contacts []Contact) error {
+		for i := range contacts {
+			contacts[i].ID = r.contactID(contacts[i].Tenant, contacts[i].ID)
+		}
+		return fn(contacts)
+	})
+}
+
+func (r *renumbered) Groups(ctx context.Context, fn func([]Group) error) error {
+	return r.Source.Groups(ctx, func(groups []Group) error {
+		for i, g := range groups {
+			if id, ok := r.groupIDs[key{g.Tenant, g.ID}]; ok {
+				groups[i].ID = id
+			}
+			members := make([]int, len(g.Members))
+			for j, id := range g.Members {
+				members[j] = r.contactID(g.Tenant, id)
+			}
+			slices.Sort(members)
+			groups[i].Members = members
+		}
+		return fn(groups)
+	})
+}
+
+func (r *renumbered) Audit(ctx context.Context, fn func([]models.AuditEntry) error) error {
+	return r.Source.Audit(ctx, func(entries []models.AuditEntry) error {
+		for i, e := range entries {
+			entries[i].ContactID = r.contactID(e.Tenant, e.ContactID)
+			entries[i].Before = r.snapshot(e.Tenant, e.Before)
+			entries[i].After = r.snapshot(e.Tenant, e.After)
+		}
+		return fn(entries)
+	})
+}
+
+// snapshot renumbers a contact snapshot of the audit log
+func (r *renumbered) snapshot(tenant string, c *models.Contact) *models.Contact {
+	if c == nil {
+		return nil
+	}
+	renumbered := *c
+	renumbered.ID = r.contactID(tenant, c.ID)
+	return &renumbered
+}
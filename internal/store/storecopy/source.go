@@ -0,0 +1,158 @@
+package storecopy
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// Source lists the records to copy, kind by kind, handing them to fn in
+// batches of at most BatchSize. Stores list them in a stable order: tenants
+// by ID, contacts and groups by tenant then ID, the rest by ID.
+type Source interface {
+	Tenants(ctx context.Context) ([]models.Tenant, error)
+	Contacts(ctx context.Context, fn func([]Contact) error) error
+	Groups(ctx context.Context, fn func([]Group) error) error
+	Outbox(ctx context.Context, fn func([]models.OutboxMessage) error) error
+	Audit(ctx context.Context, fn func([]models.AuditEntry) error) error
+}
+
+// Contact is a contact of a tenant
+type Contact struct {
+	Tenant string `json:"tenant"`
+	models.Contact
+}
+
+// Group is a group of a tenant with the IDs of its members, in order
+type Group struct {
+	Tenant string `json:"tenant"`
+	models.Group
+	Members []int `json:"members"`
+}
+
+// storeSource reads the records of a store
+type storeSource struct {
+	store *interfaces.Store
+}
+
+// FromStore returns the records of a store as a Source
+func FromStore(store *interfaces.Store) Source {
+	return &storeSource{store: store}
+}
+
+func (s *storeSource) Tenants(ctx context.Context) ([]models.Tenant, error) {
+	tenants, err := s.store.Tenant.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(tenants, func(a, b models.Tenant) int { return strings.Compare(a.ID, b.ID) })
+	return tenants, nil
+}
+
+func (s *storeSource) Contacts(ctx context.Context, fn func([]Contact) error) error {
+	tenants, err := s.Tenants(ctx)
+	if err != nil {
+		return err
+	}
+	for _, tenant := range tenants {
+		q := models.ContactQuery{Limit: BatchSize, SortBy: models.SortByID}
+		for {
+			page, err := s.store.Contact.List(ctx, tenant.ID, q)
+			if err != nil {
+				return err
+			}
+			if len(page.Contacts) > 0 {
+				contacts := make([]Contact, len(page.Contacts))
+				for i, c := range page.Contacts {
+					contacts[i] = Contact{Tenant: tenant.ID, Contact: c}
+				}
+				if err := fn(contacts); err != nil {
+					return err
+				}
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+	}
+	return nil
+}
+
+func (s *storeSource) Groups(ctx context.Context, fn func([]Group) error) error {
+	tenants, err := s.Tenants(ctx)
+	if err != nil {
+		return err
+	}
+	for _, tenant := range tenants {
+		listed, err := s.store.Group.List(ctx, tenant.ID)
+		if err != nil {
+			return err
+		}
+		groups := make([]Group, 0, len(listed))
+		for _, g := range listed {
+			members, err := s.store.Group.ListMembers(ctx, tenant.ID, g.ID)
+			if err != nil {
+				return err
+			}
+			ids := make([]int, len(members))
+			for i, c := range members {
+				ids[i] = c.ID
+			}
+			groups = append(groups, Group{Tenant: tenant.ID, Group: g, Members: ids})
+		}
+		slices.SortFunc(groups, func(a, b Group) int { return a.ID - b.ID })
+		if err := inBatches(groups, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *storeSource) Outbox(ctx context.Context, fn func([]models.OutboxMessage) error) error {
+	tenants, err := s.Tenants(ctx)
+	if err != nil {
+		return err
+	}
+	var msgs []models.OutboxMessage
+	for _, tenant := range tenants {
+		for _, status := range []models.OutboxStatus{models.OutboxPending, models.OutboxDelivered, models.OutboxDead} {
+			listed, err := s.store.Outbox.ListByStatus(ctx, tenant.ID, status)
+			if err != nil {
+				return err
+			}
+			msgs = append(msgs, listed...)
+		}
+	}
+	slices.SortFunc(msgs, func(a, b models.OutboxMessage) int { return a.ID - b.ID })
+	return inBatches(msgs, fn)
+}
+
+func (s *storeSource) Audit(ctx context.Context, fn func([]models.AuditEntry) error) error {
+	afterID := 0
+	for {
+		entries, err := s.store.Audit.List(ctx, afterID, BatchSize)
+		if err != nil || len(entries) == 0 {
+			return err
+		}
+		afterID = entries[len(entries)-1].ID
+		if err := fn(entries); err != nil {
+			return err
+		}
+	}
+}
+
+// inBatches hands records to fn BatchSize at a time
+func inBatches[T any](records []T, fn func([]T) error) error {
+	for len(records) > 0 {
+		n := min(len(records), BatchSize)
+		if err := fn(records[:n]); err != nil {
+			return err
+		}
+		records = records[n:]
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package storecopy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// Check compares the records of a kind in both stores
+type Check struct {
+	Kind        string
+	Source      Summary
+	Destination Summary
+}
+
+// OK tells whether both stores hold the same records
+func (c Check) OK() bool {
+	return c.Source == c.Destination
+}
+
+// Summary is the number of records of a kind in a store and their checksum:
+// the sum of the SHA-256 of the JSON of each record, which does not depend
+// on the order stores list them in. Timestamps are taken in UTC to the
+// microsecond, the precision of PostgreSQL, and tenants by ID and name only,
+// as each store creates the default one on its own.
+type Summary struct {
+	Count    int    `json:"count"`
+	Checksum string `json:"checksum"`
+}
+
+// Verify compares every kind of records of src, renumbered as Copy would,
+// with those of to
+func Verify(ctx context.Context, src Source, to *interfaces.Store, opts Options) ([]Check, error) {
+	src, err := renumber(ctx, src, opts.Renumber)
+	if err != nil {
+		return nil, err
+	}
+	sums, err := Summarize(ctx, src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the source: %w", err)
+	}
+	destSums, err := Summarize(ctx, FromStore(to))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the destination: %w", err)
+	}
+
+	checks := make([]Check, len(Kinds))
+	for i, kind := range Kinds {
+		checks[i] = Check{Kind: kind, Source: sums[kind], Destination: destSums[kind]}
+	}
+	return checks, nil
+}
+
+// Summarize reads every record of src and returns the summary of each kind
+func Summarize(ctx context.Context, src Source) (map[string]Summary, error) {
+	s := NewSummarizer()
+	tenants, err := src.Tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, tenant := range tenants {
+		if err := s.Add(tenant); err != nil {
+			return nil, err
+		}
+	}
+	if err := src.Contacts(ctx, addAll[Contact](s)); err != nil {
+		return nil, err
+	}
+	if err := src.Groups(ctx, addAll[Group](s)); err != nil {
+		return nil, err
+	}
+	if err := src.Outbox(ctx, addAll[models.OutboxMessage](s)); err != nil {
+		return nil, err
+	}
+	if err := src.Audit(ctx, addAll[models.AuditEntry](s)); err != nil {
+		return nil, err
+	}
+	return s.Summaries(), nil
+}
+
+// addAll returns a batch function adding records to s
+func addAll[T any](s *Summarizer) func([]T) error {
+	return func(records []T) error {
+		for _, record := range records {
+			if err := s.Add(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// Summarizer accumulates the summary of each kind of records, one record at
+// a time
+type Summarizer struct {
+	digests map[string]*digest
+}
+
+// NewSummarizer returns a Summarizer with no records
+func NewSummarizer() *Summarizer {
+	return &Summarizer{digests: map[string]*digest{}}
+}
+
+// Add adds a record: a models.Tenant, Contact, Group, models.OutboxMessage
+// or models.AuditEntry
+func (s *Summarizer) Add(record any) error {
+	var kind string
+	var canonical any
+	switch r := record.(type) {
+	case models.Tenant:
+		kind, canonical = Tenants, []string{r.ID, r.Name}
+	case Contact:
+		kind, canonical = Contacts, r
+	case Group:
+		r.CreatedAt = canonicalTime(r.CreatedAt)
+		kind, canonical = Groups, r
+	case models.OutboxMessage:
+		r.NextAttemptAt = canonicalTime(r.NextAttemptAt)
+		r.CreatedAt = canonicalTime(r.CreatedAt)
+		if r.DeliveredAt != nil {
+			deliveredAt := canonicalTime(*r.DeliveredAt)
+			r.DeliveredAt = &deliveredAt
+		}
+		kind, canonical = Outbox, r
+	case models.AuditEntry:
+		r.Timestamp = canonicalTime(r.Timestamp)
+		kind, canonical = Audit, r
+	default:
+		return fmt.Errorf("cannot summarize a %T", record)
+	}
+
+	d, ok := s.digests[kind]
+	if !ok {
+		d = &digest{}
+		s.digests[kind] = d
+	}
+	return d.add(canonical)
+}
+
+// Summaries returns the summary of every kind, including those without
+// records
+func (s *Summarizer) Summaries() map[string]Summary {
+	sums := make(map[string]Summary, len(Kinds))
+	for _, kind := range Kinds {
+		d, ok := s.digests[kind]
+		if !ok {
+			d = &digest{}
+		}
+		sums[kind] = d.Summary()
+	}
+	return sums
+}
+
+// digest accumulates the summary of a kind
+type digest struct {
+	count int
+	sum   [sha256.Size]byte
+}
+
+// add adds the hash of a record to the sum, as a big-endian number modulo
+// 2^256
+func (d *digest) add(record any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	d.count++
+	h := sha256.Sum256(data)
+	carry := 0
+	for i := len(d.sum) - 1; i >= 0; i-- {
+		n := int(d.sum[i]) + int(h[i]) + carry
+		d.sum[i], carry = byte(n), n>>8
+	}
+	return nil
+}
+
+func (d *digest) Summary() Summary {
+	return Summary{Count: d.count, Checksum: hex.EncodeToString(d.sum[:])}
+}
+
+func canonicalTime(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Microsecond)
+}
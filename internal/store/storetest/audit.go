@@ -0,0 +1,209 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// RunAuditRepositoryTests checks the AuditRepositoryInterface contract
+// against the stores built by newStore
+func RunAuditRepositoryTests(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store *interfaces.Store)
+	}{
+		{"AppendAndListByContact", testAuditAppendAndListByContact},
+		{"TenantScoping", testAuditTenantScoping},
+		{"List", testAuditList},
+		{"OutlivesDeletes", testAuditOutlivesDeletes},
+		{"TransactionRollback", testAuditTransactionRollback},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func testAuditAppendAndListByContact(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	before := models.Contact{ID: 1, FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Version: 1}
+	after := before
+	after.Title, after.Version = "Countess", 2
+
+	want := []models.AuditEntry{
+		{Tenant: tenant, ContactID: 1, Action: models.AuditCreate, Actor: "alice", Timestamp: now, After: &before},
+		{Tenant: tenant, ContactID: 2, Action: models.AuditCreate, Actor: "alice", Timestamp: now},
+		{Tenant: tenant, ContactID: 1, Action: models.AuditUpdate, Actor: "bob", Timestamp: now.Add(time.Minute), Before: &before, After: &after},
+		{Tenant: tenant, ContactID: 1, Action: models.AuditDelete, Actor: "bob", Timestamp: now.Add(2 * time.Minute), Before: &after},
+	}
+	for i := range want {
+		want[i].ID = appendEntry(t, store, want[i])
+		if i > 0 && want[i].ID <= want[i-1].ID {
+			t.Errorf("Append returned id %d after %d, want increasing IDs", want[i].ID, want[i-1].ID)
+		}
+	}
+
+	// oldest first, the entries of other contacts left out
+	got, err := store.Audit.ListByContact(ctx, tenant, 1)
+	if err != nil {
+		t.Fatalf("ListByContact: %v", err)
+	}
+	assertEntries(t, "ListByContact", got, []models.AuditEntry{want[0], want[2], want[3]})
+
+	got, err = store.Audit.ListByContact(ctx, tenant, 3)
+	if err != nil {
+		t.Fatalf("ListByContact of a contact without history: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ListByContact of a contact without history = %+v", got)
+	}
+}
+
+func testAuditTenantScoping(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	// the file store numbers contacts per tenant, so the same ID may belong
+	// to a contact of each
+	ours := models.AuditEntry{Tenant: tenant, ContactID: 1, Action: models.AuditCreate, Actor: "alice", Timestamp: now}
+	theirs := models.AuditEntry{Tenant: models.DefaultTenant, ContactID: 1, Action: models.AuditCreate, Actor: "bob", Timestamp: now}
+	ours.ID = appendEntry(t, store, ours)
+	theirs.ID = appendEntry(t, store, theirs)
+
+	for _, tt := range []struct {
+		tenant string
+		want   models.AuditEntry
+	}{
+		{tenant, ours},
+		{models.DefaultTenant, theirs},
+	} {
+		got, err := store.Audit.ListByContact(ctx, tt.tenant, 1)
+		if err != nil {
+			t.Fatalf("ListByContact of %s: %v", tt.tenant, err)
+		}
+		assertEntries(t, "ListByContact of "+tt.tenant, got, []models.AuditEntry{tt.want})
+	}
+}
+
+func testAuditList(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var ids []int
+	for i, tenantID := range []string{tenant, models.DefaultTenant, tenant} {
+		ids = append(ids, appendEntry(t, store, models.AuditEntry{
+			Tenant: tenantID, ContactID: i + 1, Action: models.AuditCreate, Actor: "alice", Timestamp: now}))
+	}
+
+	// every tenant's entries, in ID order, a page at a time
+	for _, tt := range []struct {
+		afterID, limit int
+		want           []int
+	}{
+		{0, 10, ids},
+		{0, 2, ids[:2]},
+		{ids[1], 2, ids[2:]},
+		{ids[2], 10, nil},
+	} {
+		entries, err := store.Audit.List(ctx, tt.afterID, tt.limit)
+		if err != nil {
+			t.Fatalf("List(%d, %d): %v", tt.afterID, tt.limit, err)
+		}
+		var got []int
+		for _, e := range entries {
+			got = append(got, e.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("List(%d, %d) returned IDs %v, want %v", tt.afterID, tt.limit, got, tt.want)
+		}
+	}
+}
+
+// testAuditOutlivesDeletes checks that the history of a contact stays once
+// the contact, then its tenant, are deleted
+func testAuditOutlivesDeletes(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	if err := store.Tenant.Create(ctx, models.Tenant{ID: tenant, Name: "Acme", CreatedAt: now}); err != nil {
+		t.Fatalf("Create tenant: %v", err)
+	}
+	c := createContacts(t, store, 1)[0]
+	created := models.AuditEntry{Tenant: tenant, ContactID: c.ID, Action: models.AuditCreate, Actor: "alice", Timestamp: now, After: &c}
+	created.ID = appendEntry(t, store, created)
+
+	if err := store.Contact.Delete(ctx, tenant, c.ID, c.Version); err != nil {
+		t.Fatalf("Delete contact: %v", err)
+	}
+	deleted := models.AuditEntry{Tenant: tenant, ContactID: c.ID, Action: models.AuditDelete, Actor: "alice", Timestamp: now, Before: &c}
+	deleted.ID = appendEntry(t, store, deleted)
+	if err := store.Tenant.Delete(ctx, tenant); err != nil {
+		t.Fatalf("Delete tenant: %v", err)
+	}
+
+	got, err := store.Audit.ListByContact(ctx, tenant, c.ID)
+	if err != nil {
+		t.Fatalf("ListByContact: %v", err)
+	}
+	assertEntries(t, "ListByContact after the deletes", got, []models.AuditEntry{created, deleted})
+}
+
+func testAuditTransactionRollback(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+	err := store.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		_, err := store.Audit.Append(ctx, models.AuditEntry{Tenant: tenant, ContactID: 1, Action: models.AuditCreate,
+			Actor: "alice", Timestamp: time.Now().UTC()})
+		if err != nil {
+			return err
+		}
+		return errRollback
+	})
+	assertIs(t, "WithinTransaction", err, errRollback)
+
+	entries, err := store.Audit.List(ctx, 0, 10)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("entries appended in a rolled back transaction kept: %+v", entries)
+	}
+}
+
+// appendEntry appends e and returns its ID
+func appendEntry(t *testing.T, store *interfaces.Store, e models.AuditEntry) int {
+	t.Helper()
+	id, err := store.Audit.Append(context.Background(), e)
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if id <= 0 {
+		t.Fatalf("Append returned id %d, want a positive id", id)
+	}
+	return id
+}
+
+func assertEntries(t *testing.T, what string, got, want []models.AuditEntry) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s returned %d entries, want %d: %+v", what, len(got), len(want), got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		// stores differ in the location they read times back in
+		if !g.Timestamp.Equal(w.Timestamp) {
+			t.Errorf("%s[%d] taken at %s, want %s", what, i, g.Timestamp, w.Timestamp)
+		}
+		g.Timestamp = w.Timestamp
+		if !reflect.DeepEqual(g, w) {
+			t.Errorf("%s[%d] = %+v, want %+v", what, i, g, w)
+		}
+	}
+}
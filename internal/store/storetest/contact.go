@@ -0,0 +1,827 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// RunContactRepositoryTests checks the ContactRepositoryInterface contract
+// against the stores built by newStore
+func RunContactRepositoryTests(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store *interfaces.Store)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"GetAll", testGetAll},
+		{"GetByIDNotFound", testGetByIDNotFound},
+		{"GetByEmail", testGetByEmail},
+		{"Update", testUpdate},
+		{"UpdateNotFound", testUpdateNotFound},
+		{"UpdateStaleVersion", testUpdateStaleVersion},
+		{"Patch", testPatch},
+		{"PatchErrors", testPatchErrors},
+		{"Details", testDetails},
+		{"PatchDetails", testPatchDetails},
+		{"Delete", testDelete},
+		{"DeleteStaleVersion", testDeleteStaleVersion},
+		{"DeletedIDNotReused", testDeletedIDNotReused},
+		{"DuplicateEmail", testDuplicateEmail},
+		{"ListOrdering", testListOrdering},
+		{"ListFilters", testListFilters},
+		{"ListRejectsBadQueries", testListRejectsBadQueries},
+		{"Search", testSearch},
+		{"SearchFollowsChanges", testSearchFollowsChanges},
+		{"TenantIsolation", testTenantIsolation},
+		{"TransactionRollback", testTransactionRollback},
+		{"TransactionReusesDeletedEmail", testTransactionReusesDeletedEmail},
+		{"ConcurrentCreates", testConcurrentCreates},
+		{"ConcurrentUpdates", testConcurrentUpdates},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func testCreateAndGet(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	want := models.Contact{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com"}
+
+	id, err := store.Contact.Create(ctx, tenant, want)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if id <= 0 {
+		t.Fatalf("Create returned id %d, want a positive id", id)
+	}
+
+	got, err := store.Contact.GetByID(ctx, tenant, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	want.ID, want.Version = id, 1
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("GetByID = %+v, want %+v", *got, want)
+	}
+}
+
+func testGetAll(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+
+	contacts, err := store.Contact.GetAll(ctx, tenant)
+	if err != nil {
+		t.Fatalf("GetAll on an empty store: %v", err)
+	}
+	if len(contacts) != 0 {
+		t.Fatalf("GetAll on an empty store returned %d contacts", len(contacts))
+	}
+
+	created := createContacts(t, store, 3)
+	contacts, err = store.Contact.GetAll(ctx, tenant)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	sort.Slice(contacts, func(i, j int) bool { return contacts[i].ID < contacts[j].ID })
+	assertContacts(t, "GetAll", contacts, created)
+}
+
+func testGetByIDNotFound(t *testing.T, store *interfaces.Store) {
+	_, err := store.Contact.GetByID(context.Background(), tenant, 42)
+	assertIs(t, "GetByID of a missing contact", err, models.ErrNotFound)
+}
+
+func testGetByEmail(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	contacts := createContacts(t, store, 2)
+	longer, err := store.Contact.Create(ctx, tenant, models.Contact{FirstName: "Ada", LastName: "Lovelace", Email: "contact1@example.com.au"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	for _, want := range contacts {
+		got, err := store.Contact.GetByEmail(ctx, tenant, want.Email)
+		if err != nil {
+			t.Fatalf("GetByEmail(%s): %v", want.Email, err)
+		}
+		if !reflect.DeepEqual(*got, want) {
+			t.Errorf("GetByEmail(%s) = %+v, want %+v", want.Email, *got, want)
+		}
+	}
+	if got, err := store.Contact.GetByEmail(ctx, tenant, "contact1@example.com.au"); err != nil || got.ID != longer {
+		t.Errorf("GetByEmail of an email extending another = %+v, %v, want contact %d", got, err, longer)
+	}
+
+	// only the whole email, in its tenant, matches
+	for _, email := range []string{"contact1@example.co", "contact1@", "", "Contact1@example.com"} {
+		_, err := store.Contact.GetByEmail(ctx, tenant, email)
+		assertIs(t, fmt.Sprintf("GetByEmail(%q)", email), err, models.ErrNotFound)
+	}
+	_, err = store.Contact.GetByEmail(ctx, models.DefaultTenant, contacts[0].Email)
+	assertIs(t, "GetByEmail from another tenant", err, models.ErrNotFound)
+
+	// it follows changes
+	c := contacts[0]
+	c.Email = "changed@example.com"
+	if err := store.Contact.Update(ctx, tenant, c); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	_, err = store.Contact.GetByEmail(ctx, tenant, contacts[0].Email)
+	assertIs(t, "GetByEmail of a replaced email", err, models.ErrNotFound)
+	if got, err := store.Contact.GetByEmail(ctx, tenant, c.Email); err != nil || got.ID != c.ID {
+		t.Errorf("GetByEmail of the new email = %+v, %v, want contact %d", got, err, c.ID)
+	}
+	if err := store.Contact.Delete(ctx, tenant, c.ID, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	_, err = store.Contact.GetByEmail(ctx, tenant, c.Email)
+	assertIs(t, "GetByEmail of a deleted contact", err, models.ErrNotFound)
+}
+
+func testUpdate(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	c := createContacts(t, store, 1)[0]
+
+	// version 0 updates whatever is stored
+	c.FirstName = "Changed"
+	c.Version = 0
+	if err := store.Contact.Update(ctx, tenant, c); err != nil {
+		t.Fatalf("unconditional Update: %v", err)
+	}
+	c.Version = 2
+	assertStored(t, store, c)
+
+	c.Email = "changed@example.com"
+	if err := store.Contact.Update(ctx, tenant, c); err != nil {
+		t.Fatalf("Update at the current version: %v", err)
+	}
+	c.Version = 3
+	assertStored(t, store, c)
+}
+
+func testUpdateNotFound(t *testing.T, store *interfaces.Store) {
+	c := models.Contact{ID: 42, FirstName: "No", LastName: "One", Email: "nobody@example.com"}
+	err := store.Contact.Update(context.Background(), tenant, c)
+	assertIs(t, "Update of a missing contact", err, models.ErrNotFound)
+
+	c.Version = 1
+	err = store.Contact.Update(context.Background(), tenant, c)
+	assertIs(t, "versioned Update of a missing contact", err, models.ErrNotFound)
+}
+
+func testUpdateStaleVersion(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	c := createContacts(t, store, 1)[0]
+
+	stale := c
+	stale.FirstName = "Stale"
+	stale.Version = 2
+	err := store.Contact.Update(ctx, tenant, stale)
+	assertIs(t, "Update at a stale version", err, models.ErrVersionConflict)
+	assertIs(t, "Update at a stale version", err, models.ErrConflict)
+	assertStored(t, store, c)
+}
+
+func testPatch(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	c := createContacts(t, store, 1)[0]
+
+	name := "Patched"
+	if err := store.Contact.Patch(ctx, tenant, c.ID, 0, models.ContactPatch{LastName: &name}); err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	c.LastName, c.Version = name, 2
+	assertStored(t, store, c)
+
+	email := "patched@example.com"
+	if err := store.Contact.Patch(ctx, tenant, c.ID, 2, models.ContactPatch{FirstName: &name, Email: &email}); err != nil {
+		t.Fatalf("Patch at the current version: %v", err)
+	}
+	c.FirstName, c.Email, c.Version = name, email, 3
+	assertStored(t, store, c)
+}
+
+func testPatchErrors(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	created := createContacts(t, store, 2)
+	name := "Patched"
+
+	err := store.Contact.Patch(ctx, tenant, 42, 0, models.ContactPatch{FirstName: &name})
+	assertIs(t, "Patch of a missing contact", err, models.ErrNotFound)
+
+	err = store.Contact.Patch(ctx, tenant, created[0].ID, 2, models.ContactPatch{FirstName: &name})
+	assertIs(t, "Patch at a stale version", err, models.ErrVersionConflict)
+
+	err = store.Contact.Patch(ctx, tenant, created[0].ID, 1, models.ContactPatch{Email: &created[1].Email})
+	assertIs(t, "Patch to a taken email", err, models.ErrConflict)
+
+	assertStored(t, store, created[0])
+}
+
+// detailedContact has every optional field set
+func detailedContact() models.Contact {
+	return models.Contact{
+		FirstName: "Grace",
+		LastName:  "Hopper",
+		Email:     "grace@example.com",
+		Phones: []models.Phone{
+			{Type: models.PhoneMobile, Number: "+14155550123"},
+			{Type: models.PhoneWork, Number: "+442079460958"},
+		},
+		Addresses: []models.Address{
+			{Type: models.AddressWork, Street: "1 Navy Way", City: "Arlington", Region: "VA", PostalCode: "22202", Country: "US"},
+		},
+		Company:      "US Navy",
+		Title:        "Rear Admiral",
+		Birthday:     "1906-12-09",
+		Notes:        "Found the first bug.\nKept it.",
+		CustomFields: map[string]string{"team": "compilers", "desk": "B-12"},
+		Tags:         []string{"navy", "pioneer"},
+	}
+}
+
+func testDetails(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	c := detailedContact()
+
+	id, err := store.Contact.Create(ctx, tenant, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	c.ID, c.Version = id, 1
+	assertStored(t, store, c)
+
+	page, err := store.Contact.List(ctx, tenant, models.ContactQuery{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	assertContacts(t, "List", page.Contacts, []models.Contact{c})
+
+	// an update without details clears them
+	c.Phones, c.Addresses, c.CustomFields, c.Tags = nil, nil, nil, nil
+	c.Company, c.Title, c.Birthday, c.Notes = "", "", "", ""
+	if err := store.Contact.Update(ctx, tenant, c); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	c.Version = 2
+	assertStored(t, store, c)
+}
+
+func testPatchDetails(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	c := detailedContact()
+	id, err := store.Contact.Create(ctx, tenant, c)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	c.ID = id
+
+	phones := []models.Phone{{Type: models.PhoneHome, Number: "+33142685300"}}
+	company, team := "Remington Rand", "UNIVAC"
+	tags := []string{"univac"}
+	patch := models.ContactPatch{
+		Phones:       &phones,
+		Company:      &company,
+		CustomFields: map[string]*string{"team": &team, "desk": nil},
+		Tags:         &tags,
+	}
+	if err := store.Contact.Patch(ctx, tenant, id, 1, patch); err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	c.Phones, c.Company, c.Tags, c.Version = phones, company, tags, 2
+	c.CustomFields = map[string]string{"team": team}
+	assertStored(t, store, c)
+
+	// removing the last custom field and every address leaves none
+	var addresses []models.Address
+	patch = models.ContactPatch{Addresses: &addresses, CustomFields: map[string]*string{"team": nil}}
+	if err := store.Contact.Patch(ctx, tenant, id, 2, patch); err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	c.Addresses, c.CustomFields, c.Version = nil, nil, 3
+	assertStored(t, store, c)
+}
+
+func testDelete(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	created := createContacts(t, store, 2)
+
+	if err := store.Contact.Delete(ctx, tenant, created[0].ID, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	_, err := store.Contact.GetByID(ctx, tenant, created[0].ID)
+	assertIs(t, "GetByID after Delete", err, models.ErrNotFound)
+
+	err = store.Contact.Delete(ctx, tenant, created[0].ID, 0)
+	assertIs(t, "second Delete", err, models.ErrNotFound)
+
+	if err := store.Contact.Delete(ctx, tenant, created[1].ID, created[1].Version); err != nil {
+		t.Fatalf("Delete at the current version: %v", err)
+	}
+	contacts, err := store.Contact.GetAll(ctx, tenant)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(contacts) != 0 {
+		t.Errorf("GetAll after deleting everything returned %+v", contacts)
+	}
+}
+
+func testDeleteStaleVersion(t *testing.T, store *interfaces.Store) {
+	c := createContacts(t, store, 1)[0]
+
+	err := store.Contact.Delete(context.Background(), tenant, c.ID, c.Version+1)
+	assertIs(t, "Delete at a stale version", err, models.ErrVersionConflict)
+	assertStored(t, store, c)
+}
+
+// testDeletedIDNotReused checks that the ID of a deleted contact, even the
+// newest, is never handed out again: its history stays in the audit log
+func testDeletedIDNotReused(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	created := createContacts(t, store, 2)
+	newest := created[1]
+	if err := store.Contact.Delete(ctx, tenant, newest.ID, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	id, err := store.Contact.Create(ctx, tenant, models.Contact{FirstName: "New", LastName: "Contact", Email: "new@example.com"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if id <= newest.ID {
+		t.Errorf("Create after deleting contact %d returned id %d, want more", newest.ID, id)
+	}
+}
+
+func testDuplicateEmail(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	created := createContacts(t, store, 2)
+
+	_, err := store.Contact.Create(ctx, tenant, models.Contact{FirstName: "Copy", LastName: "Cat", Email: created[0].Email})
+	assertIs(t, "Create with a taken email", err, models.ErrConflict)
+
+	taken := created[1]
+	taken.Email = created[0].Email
+	err = store.Contact.Update(ctx, tenant, taken)
+	assertIs(t, "Update to a taken email", err, models.ErrConflict)
+	assertStored(t, store, created[1])
+
+	// keeping one's own email is not a conflict
+	same := created[0]
+	same.LastName = "Renamed"
+	if err := store.Contact.Update(ctx, tenant, same); err != nil {
+		t.Fatalf("Update keeping the same email: %v", err)
+	}
+}
+
+func testListOrdering(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	// duplicated names make the id tie-breaker matter
+	for _, c := range []models.Contact{
+		{FirstName: "Carol", LastName: "Smith", Email: "carol@example.com"},
+		{FirstName: "alice", LastName: "Jones", Email: "alice@example.com"},
+		{FirstName: "Bob", LastName: "Smith", Email: "bob@example.com"},
+		{FirstName: "Alice", LastName: "Brown", Email: "alice.b@example.com"},
+		{FirstName: "Bob", LastName: "Adams", Email: "bob.a@example.com"},
+	} {
+		if _, err := store.Contact.Create(ctx, tenant, c); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	all, err := store.Contact.GetAll(ctx, tenant)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+
+	for _, field := range []models.SortField{models.SortByID, models.SortByFirstName, models.SortByLastName, models.SortByEmail} {
+		for _, descending := range []bool{false, true} {
+			name := fmt.Sprintf("%s descending=%v", field, descending)
+
+			want := append([]models.Contact(nil), all...)
+			sort.Slice(want, func(i, j int) bool {
+				a, b := want[i], want[j]
+				if descending {
+					a, b = b, a
+				}
+				av, bv := models.SortValue(a, field), models.SortValue(b, field)
+				if av != bv {
+					return av < bv
+				}
+				return a.ID < b.ID
+			})
+
+			q := models.ContactQuery{Limit: 2, SortBy: field, Descending: descending}
+			assertContacts(t, name, listAll(t, store, q), want)
+		}
+	}
+}
+
+func testListFilters(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	for _, c := range []models.Contact{
+		{FirstName: "John", LastName: "Doe", Email: "john@example.com", Tags: []string{"client", "vip"}},
+		{FirstName: "joanna", LastName: "Smith", Email: "joanna@example.com", Tags: []string{"vip"}},
+		{FirstName: "Jim", LastName: "Doe", Email: "jim_d@example.com"},
+		{FirstName: "Ann", LastName: "Dow", Email: "jimxd@example.com", Tags: []string{"client"}},
+		{FirstName: "Émile", LastName: "Özdemir", Email: "emile@example.com"},
+	} {
+		if _, err := store.Contact.Create(ctx, tenant, c); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+
+	tests := []struct {
+		query models.ContactQuery
+		want  []string
+	}{
+		{models.ContactQuery{FirstNamePrefix: "JO"}, []string{"john@example.com", "joanna@example.com"}},
+		{models.ContactQuery{LastNamePrefix: "do"}, []string{"john@example.com", "jim_d@example.com", "jimxd@example.com"}},
+		{models.ContactQuery{LastNamePrefix: "doe", FirstNamePrefix: "j"}, []string{"john@example.com", "jim_d@example.com"}},
+		// wildcards in the prefix match literally
+		{models.ContactQuery{EmailPrefix: "jim_"}, []string{"jim_d@example.com"}},
+		{models.ContactQuery{EmailPrefix: "%"}, nil},
+		{models.ContactQuery{EmailPrefix: "*"}, nil},
+		{models.ContactQuery{EmailPrefix: "?"}, nil},
+		{models.ContactQuery{EmailPrefix: "["}, nil},
+		// case folds beyond ASCII, but diacritics still count
+		{models.ContactQuery{FirstNamePrefix: "émile"}, []string{"emile@example.com"}},
+		{models.ContactQuery{FirstNamePrefix: "ÉMI"}, []string{"emile@example.com"}},
+		{models.ContactQuery{LastNamePrefix: "özd"}, []string{"emile@example.com"}},
+		{models.ContactQuery{FirstNamePrefix: "emile"}, nil},
+		{models.ContactQuery{Tags: []string{"VIP"}}, []string{"john@example.com", "joanna@example.com"}},
+		// every tag must match
+		{models.ContactQuery{Tags: []string{"vip", "client"}}, []string{"john@example.com"}},
+		{models.ContactQuery{Tags: []string{"vip"}, LastNamePrefix: "smith"}, []string{"joanna@example.com"}},
+		{models.ContactQuery{Tags: []string{"vi"}}, nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, c := range listAll(t, store, tt.query) {
+			got = append(got, c.Email)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("List(%+v) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func testListRejectsBadQueries(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	// a cursor only holds a position in the order it was taken from
+	byFirstName := models.ContactQuery{SortBy: models.SortByFirstName}
+	cursor := byFirstName.CursorAfter(models.Contact{ID: 1, FirstName: "Ada"})
+	for _, q := range []models.ContactQuery{
+		{Limit: -1},
+		{SortBy: "password"},
+		{Cursor: "not a cursor"},
+		{Cursor: cursor, SortBy: models.SortByLastName},
+		{Cursor: cursor, SortBy: models.SortByFirstName, Descending: true},
+		{Cursor: cursor},
+	} {
+		_, err := store.Contact.List(ctx, tenant, q)
+		assertIs(t, fmt.Sprintf("List(%+v)", q), err, models.ErrValidation)
+	}
+}
+
+func testSearch(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	for _, c := range []models.Contact{
+		{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com"},
+		{FirstName: "Grace", LastName: "Hopper", Email: "grace@navy.mil"},
+		{FirstName: "Adam", LastName: "Smith", Email: "adam.smith@example.com"},
+		{FirstName: "Alan", LastName: "Turing", Email: "alan@bletchley.org"},
+		{FirstName: "José", LastName: "Núñez", Email: "jose@ejemplo.es"},
+	} {
+		if _, err := store.Contact.Create(ctx, tenant, c); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	// another tenant's contacts never show up
+	other := models.Contact{FirstName: "Ada", LastName: "Other", Email: "ada@other.com"}
+	if _, err := store.Contact.Create(ctx, models.DefaultTenant, other); err != nil {
+		t.Fatalf("Create in another tenant: %v", err)
+	}
+
+	tests := []struct {
+		search models.ContactSearch
+		want   []string
+	}{
+		// an exact word ranks above a prefix
+		{models.ContactSearch{Text: "ada"}, []string{"ada@example.com", "adam.smith@example.com"}},
+		{models.ContactSearch{Text: "  LOVELACE "}, []string{"ada@example.com"}},
+		{models.ContactSearch{Text: "smith adam"}, []string{"adam.smith@example.com"}},
+		{models.ContactSearch{Text: "ad sm"}, []string{"adam.smith@example.com"}},
+		// typos
+		{models.ContactSearch{Text: "lovelase"}, []string{"ada@example.com"}},
+		{models.ContactSearch{Text: "hoper"}, []string{"grace@navy.mil"}},
+		{models.ContactSearch{Text: "turnig"}, []string{"alan@bletchley.org"}},
+		// case and diacritics are ignored on both sides
+		{models.ContactSearch{Text: "jose nunez"}, []string{"jose@ejemplo.es"}},
+		{models.ContactSearch{Text: "NÚÑEZ"}, []string{"jose@ejemplo.es"}},
+		{models.ContactSearch{Text: "nún"}, []string{"jose@ejemplo.es"}},
+		{models.ContactSearch{Text: "nunes"}, []string{"jose@ejemplo.es"}},
+		// a name match ranks above an email match, then IDs break ties
+		{models.ContactSearch{Text: "example"}, []string{"ada@example.com", "adam.smith@example.com"}},
+		{models.ContactSearch{Text: "bletchley.org"}, []string{"alan@bletchley.org"}},
+		{models.ContactSearch{Text: "example", Limit: 1}, []string{"ada@example.com"}},
+		// every term must match, short ones exactly or as a prefix
+		{models.ContactSearch{Text: "ada turing"}, nil},
+		{models.ContactSearch{Text: "adx"}, nil},
+	}
+	for _, tt := range tests {
+		contacts, err := store.Contact.Search(ctx, tenant, tt.search)
+		if err != nil {
+			t.Fatalf("Search(%+v): %v", tt.search, err)
+		}
+		var got []string
+		for _, c := range contacts {
+			got = append(got, c.Email)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("Search(%q) = %v, want %v", tt.search.Text, got, tt.want)
+		}
+	}
+
+	for _, search := range []models.ContactSearch{{Text: " ,. "}, {Text: "ada", Limit: -1}} {
+		_, err := store.Contact.Search(ctx, tenant, search)
+		assertIs(t, fmt.Sprintf("Search(%+v)", search), err, models.ErrValidation)
+	}
+}
+
+func testSearchFollowsChanges(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	c := createContacts(t, store, 1)[0]
+	search := func(text string) []models.Contact {
+		t.Helper()
+		contacts, err := store.Contact.Search(ctx, tenant, models.ContactSearch{Text: text})
+		if err != nil {
+			t.Fatalf("Search(%q): %v", text, err)
+		}
+		return contacts
+	}
+
+	c.LastName = "Brewster"
+	if err := store.Contact.Update(ctx, tenant, c); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	c.Version = 2
+	assertContacts(t, "Search of the new name", search("brewster"), []models.Contact{c})
+	assertContacts(t, "Search of the old name", search("last0"), nil)
+
+	email := "grace@navy.mil"
+	if err := store.Contact.Patch(ctx, tenant, c.ID, 2, models.ContactPatch{Email: &email}); err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	c.Email, c.Version = email, 3
+	assertContacts(t, "Search of the new email", search("navy"), []models.Contact{c})
+
+	if err := store.Contact.Delete(ctx, tenant, c.ID, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	assertContacts(t, "Search after Delete", search("brewster"), nil)
+}
+
+func testTenantIsolation(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	c := createContacts(t, store, 1)[0]
+	other := models.DefaultTenant
+	name := "Intruder"
+
+	_, err := store.Contact.GetByID(ctx, other, c.ID)
+	assertIs(t, "GetByID from another tenant", err, models.ErrNotFound)
+	err = store.Contact.Update(ctx, other, models.Contact{ID: c.ID, FirstName: name, LastName: name, Email: c.Email})
+	assertIs(t, "Update from another tenant", err, models.ErrNotFound)
+	err = store.Contact.Patch(ctx, other, c.ID, c.Version, models.ContactPatch{FirstName: &name})
+	assertIs(t, "Patch from another tenant", err, models.ErrNotFound)
+	err = store.Contact.Delete(ctx, other, c.ID, 0)
+	assertIs(t, "Delete from another tenant", err, models.ErrNotFound)
+
+	page, err := store.Contact.List(ctx, other, models.ContactQuery{})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(page.Contacts) != 0 {
+		t.Errorf("List of another tenant returned %+v", page.Contacts)
+	}
+
+	// emails are only unique within a tenant
+	if _, err := store.Contact.Create(ctx, other, models.Contact{FirstName: name, LastName: name, Email: c.Email}); err != nil {
+		t.Fatalf("Create with an email taken in another tenant: %v", err)
+	}
+	contacts, err := store.Contact.GetAll(ctx, other)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(contacts) != 1 || contacts[0].FirstName != name {
+		t.Errorf("GetAll of another tenant = %+v, want only its own contact", contacts)
+	}
+	assertStored(t, store, c)
+}
+
+func testTransactionRollback(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	errAbort := errors.New("abort")
+
+	var id int
+	err := store.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = store.Contact.Create(ctx, tenant, models.Contact{FirstName: "Temp", LastName: "Orary", Email: "temp@example.com"})
+		if err != nil {
+			return err
+		}
+		// the transaction sees its own writes
+		if _, err := store.Contact.GetByID(ctx, tenant, id); err != nil {
+			return fmt.Errorf("GetByID inside the transaction: %w", err)
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithinTransaction = %v, want the error returned by fn", err)
+	}
+
+	_, err = store.Contact.GetByID(ctx, tenant, id)
+	assertIs(t, "GetByID after rollback", err, models.ErrNotFound)
+}
+
+// testTransactionReusesDeletedEmail checks what merging duplicates relies
+// on: a contact may take the email of one deleted earlier in the transaction
+func testTransactionReusesDeletedEmail(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	created := createContacts(t, store, 2)
+	kept, duplicate := created[0], created[1]
+
+	err := store.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := store.Contact.Delete(ctx, tenant, duplicate.ID, duplicate.Version); err != nil {
+			return err
+		}
+		kept.Email = duplicate.Email
+		return store.Contact.Update(ctx, tenant, kept)
+	})
+	if err != nil {
+		t.Fatalf("WithinTransaction: %v", err)
+	}
+	kept.Version = 2
+	assertStored(t, store, kept)
+	_, err = store.Contact.GetByID(ctx, tenant, duplicate.ID)
+	assertIs(t, "GetByID of the deleted contact", err, models.ErrNotFound)
+}
+
+func testConcurrentCreates(t *testing.T, store *interfaces.Store) {
+	const n = 20
+	ctx := context.Background()
+
+	ids := make([]int, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ids[i], errs[i] = store.Contact.Create(ctx, tenant, models.Contact{
+				FirstName: "Worker",
+				LastName:  fmt.Sprint(i),
+				Email:     fmt.Sprintf("worker%d@example.com", i),
+			})
+		}()
+	}
+	wg.Wait()
+
+	seen := map[int]bool{}
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("Create #%d: %v", i, err)
+		}
+		if seen[ids[i]] {
+			t.Fatalf("id %d was handed out twice", ids[i])
+		}
+		seen[ids[i]] = true
+	}
+
+	contacts, err := store.Contact.GetAll(ctx, tenant)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(contacts) != n {
+		t.Errorf("GetAll returned %d contacts, want %d", len(contacts), n)
+	}
+}
+
+// testConcurrentUpdates races writers that all read version 1: exactly one
+// of them may win, the others must see a version conflict
+func testConcurrentUpdates(t *testing.T, store *interfaces.Store) {
+	const n = 10
+	ctx := context.Background()
+	c := createContacts(t, store, 1)[0]
+
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			update := c
+			update.FirstName = fmt.Sprintf("Writer%d", i)
+			errs[i] = store.Contact.Update(ctx, tenant, update)
+		}()
+	}
+	wg.Wait()
+
+	winner := -1
+	for i, err := range errs {
+		switch {
+		case err == nil && winner >= 0:
+			t.Fatalf("writers %d and %d both updated version 1", winner, i)
+		case err == nil:
+			winner = i
+		case !errors.Is(err, models.ErrVersionConflict):
+			t.Fatalf("writer %d: %v, want a version conflict", i, err)
+		}
+	}
+	if winner < 0 {
+		t.Fatal("no writer succeeded")
+	}
+
+	c.FirstName = fmt.Sprintf("Writer%d", winner)
+	c.Version = 2
+	assertStored(t, store, c)
+}
+
+// createContacts stores n distinct contacts and returns them as stored
+func createContacts(t *testing.T, store *interfaces.Store, n int) []models.Contact {
+	t.Helper()
+	contacts := make([]models.Contact, n)
+	for i := range contacts {
+		c := models.Contact{
+			FirstName: fmt.Sprintf("First%d", i),
+			LastName:  fmt.Sprintf("Last%d", i),
+			Email:     fmt.Sprintf("contact%d@example.com", i),
+		}
+		id, err := store.Contact.Create(context.Background(), tenant, c)
+		if err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		c.ID, c.Version = id, 1
+		contacts[i] = c
+	}
+	return contacts
+}
+
+// listAll follows the cursors of q until the last page
+func listAll(t *testing.T, store *interfaces.Store, q models.ContactQuery) []models.Contact {
+	t.Helper()
+	var all []models.Contact
+	for pages := 0; ; pages++ {
+		if pages > 100 {
+			t.Fatalf("List(%+v) does not terminate", q)
+		}
+		page, err := store.Contact.List(context.Background(), tenant, q)
+		if err != nil {
+			t.Fatalf("List(%+v): %v", q, err)
+		}
+		all = append(all, page.Contacts...)
+		if page.NextCursor == "" {
+			return all
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+func assertStored(t *testing.T, store *interfaces.Store, want models.Contact) {
+	t.Helper()
+	got, err := store.Contact.GetByID(context.Background(), tenant, want.ID)
+	if err != nil {
+		t.Fatalf("GetByID(%d): %v", want.ID, err)
+	}
+	if !reflect.DeepEqual(*got, want) {
+		t.Errorf("stored contact = %+v, want %+v", *got, want)
+	}
+}
+
+func assertContacts(t *testing.T, what string, got, want []models.Contact) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s returned %d contacts, want %d: %+v", what, len(got), len(want), got)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("%s[%d] = %+v, want %+v", what, i, got[i], want[i])
+		}
+	}
+}
+
+func assertIs(t *testing.T, what string, err, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("%s: got error %v, want %v", what, err, target)
+	}
+}
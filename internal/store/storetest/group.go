@@ -0,0 +1,281 @@
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// RunGroupRepositoryTests checks the GroupRepositoryInterface contract
+// against the stores built by newStore
+func RunGroupRepositoryTests(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store *interfaces.Store)
+	}{
+		{"CreateAndList", testGroupCreateAndList},
+		{"DuplicateName", testGroupDuplicateName},
+		{"UpdateAndDelete", testGroupUpdateAndDelete},
+		{"DeletedIDNotReused", testGroupDeletedIDNotReused},
+		{"Members", testGroupMembers},
+		{"ContactDeleteLeavesGroups", testGroupContactDelete},
+		{"TenantIsolation", testGroupTenantIsolation},
+		{"TenantDelete", testGroupTenantDelete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func testGroupCreateAndList(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	want := models.Group{Name: "Suppliers", Description: "Who we buy from", CreatedAt: time.Now().UTC().Truncate(time.Second)}
+
+	id := createGroup(t, store, want)
+	got, err := store.Group.GetByID(ctx, tenant, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	want.ID = id
+	if got.ID != want.ID || got.Name != want.Name || got.Description != want.Description ||
+		!got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("GetByID = %+v, want %+v", *got, want)
+	}
+	_, err = store.Group.GetByID(ctx, tenant, id+1000)
+	assertIs(t, "GetByID of a missing group", err, models.ErrNotFound)
+
+	createGroup(t, store, models.Group{Name: "Clients"})
+	assertGroups(t, "List", listGroups(t, store), "Clients", "Suppliers")
+}
+
+func testGroupDuplicateName(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	createGroup(t, store, models.Group{Name: "Clients"})
+	other := createGroup(t, store, models.Group{Name: "Suppliers"})
+
+	_, err := store.Group.Create(ctx, tenant, models.Group{Name: "Clients", CreatedAt: time.Now()})
+	assertIs(t, "Create of a taken name", err, models.ErrConflict)
+	err = store.Group.Update(ctx, tenant, models.Group{ID: other, Name: "Clients"})
+	assertIs(t, "Update to a taken name", err, models.ErrConflict)
+
+	// names are only unique within a tenant
+	if _, err := store.Group.Create(ctx, models.DefaultTenant, models.Group{Name: "Clients", CreatedAt: time.Now()}); err != nil {
+		t.Errorf("Create with a name taken in another tenant: %v", err)
+	}
+}
+
+func testGroupUpdateAndDelete(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	id := createGroup(t, store, models.Group{Name: "Clients", Description: "old"})
+
+	if err := store.Group.Update(ctx, tenant, models.Group{ID: id, Name: "Customers"}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err := store.Group.GetByID(ctx, tenant, id)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Name != "Customers" || got.Description != "" {
+		t.Errorf("GetByID after Update = %+v, want Customers without a description", *got)
+	}
+	err = store.Group.Update(ctx, tenant, models.Group{ID: id + 1000, Name: "Nobody"})
+	assertIs(t, "Update of a missing group", err, models.ErrNotFound)
+
+	c := createContacts(t, store, 1)[0]
+	if err := store.Group.AddMember(ctx, tenant, id, c.ID); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	if err := store.Group.Delete(ctx, tenant, id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	_, err = store.Group.GetByID(ctx, tenant, id)
+	assertIs(t, "GetByID after Delete", err, models.ErrNotFound)
+	err = store.Group.Delete(ctx, tenant, id)
+	assertIs(t, "second Delete", err, models.ErrNotFound)
+
+	// the members stay, outside of any group
+	assertStored(t, store, c)
+	assertGroups(t, "ListByContact", listByContact(t, store, c.ID))
+}
+
+func testGroupDeletedIDNotReused(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	createGroup(t, store, models.Group{Name: "Clients"})
+	newest := createGroup(t, store, models.Group{Name: "Suppliers"})
+	if err := store.Group.Delete(ctx, tenant, newest); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	if id := createGroup(t, store, models.Group{Name: "Partners"}); id <= newest {
+		t.Errorf("Create after deleting group %d returned id %d, want more", newest, id)
+	}
+}
+
+func testGroupMembers(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	contacts := createContacts(t, store, 3)
+	clients := createGroup(t, store, models.Group{Name: "Clients"})
+	partners := createGroup(t, store, models.Group{Name: "Partners"})
+
+	for _, m := range []struct{ group, contact int }{
+		{clients, contacts[2].ID},
+		{clients, contacts[0].ID},
+		{clients, contacts[0].ID}, // adding twice is fine
+		{partners, contacts[0].ID},
+	} {
+		if err := store.Group.AddMember(ctx, tenant, m.group, m.contact); err != nil {
+			t.Fatalf("AddMember(%d, %d): %v", m.group, m.contact, err)
+		}
+	}
+	err := store.Group.AddMember(ctx, tenant, clients+1000, contacts[1].ID)
+	assertIs(t, "AddMember to a missing group", err, models.ErrNotFound)
+	err = store.Group.AddMember(ctx, tenant, clients, contacts[2].ID+1000)
+	assertIs(t, "AddMember of a missing contact", err, models.ErrNotFound)
+
+	assertContacts(t, "ListMembers", listMembers(t, store, clients), []models.Contact{contacts[0], contacts[2]})
+	assertGroups(t, "ListByContact", listByContact(t, store, contacts[0].ID), "Clients", "Partners")
+	assertGroups(t, "ListByContact of a contact without groups", listByContact(t, store, contacts[1].ID))
+	_, err = store.Group.ListMembers(ctx, tenant, clients+1000)
+	assertIs(t, "ListMembers of a missing group", err, models.ErrNotFound)
+	_, err = store.Group.ListByContact(ctx, tenant, contacts[2].ID+1000)
+	assertIs(t, "ListByContact of a missing contact", err, models.ErrNotFound)
+
+	if err := store.Group.RemoveMember(ctx, tenant, clients, contacts[0].ID); err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+	err = store.Group.RemoveMember(ctx, tenant, clients, contacts[0].ID)
+	assertIs(t, "RemoveMember of a non-member", err, models.ErrNotFound)
+	assertContacts(t, "ListMembers after RemoveMember", listMembers(t, store, clients), []models.Contact{contacts[2]})
+	assertGroups(t, "ListByContact after RemoveMember", listByContact(t, store, contacts[0].ID), "Partners")
+}
+
+func testGroupContactDelete(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	contacts := createContacts(t, store, 2)
+	id := createGroup(t, store, models.Group{Name: "Clients"})
+	for _, c := range contacts {
+		if err := store.Group.AddMember(ctx, tenant, id, c.ID); err != nil {
+			t.Fatalf("AddMember: %v", err)
+		}
+	}
+
+	if err := store.Contact.Delete(ctx, tenant, contacts[0].ID, 0); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	assertContacts(t, "ListMembers after Delete", listMembers(t, store, id), contacts[1:])
+	err := store.Group.RemoveMember(ctx, tenant, id, contacts[0].ID)
+	assertIs(t, "RemoveMember of a deleted contact", err, models.ErrNotFound)
+}
+
+func testGroupTenantIsolation(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	c := createContacts(t, store, 1)[0]
+	id := createGroup(t, store, models.Group{Name: "Clients"})
+	if err := store.Group.AddMember(ctx, tenant, id, c.ID); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	other := models.DefaultTenant
+
+	_, err := store.Group.GetByID(ctx, other, id)
+	assertIs(t, "GetByID from another tenant", err, models.ErrNotFound)
+	err = store.Group.Update(ctx, other, models.Group{ID: id, Name: "Intruders"})
+	assertIs(t, "Update from another tenant", err, models.ErrNotFound)
+	_, err = store.Group.ListMembers(ctx, other, id)
+	assertIs(t, "ListMembers from another tenant", err, models.ErrNotFound)
+	err = store.Group.RemoveMember(ctx, other, id, c.ID)
+	assertIs(t, "RemoveMember from another tenant", err, models.ErrNotFound)
+	err = store.Group.Delete(ctx, other, id)
+	assertIs(t, "Delete from another tenant", err, models.ErrNotFound)
+
+	// a group only takes contacts of its own tenant
+	outsider, err := store.Group.Create(ctx, other, models.Group{Name: "Outsiders", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("Create in another tenant: %v", err)
+	}
+	err = store.Group.AddMember(ctx, other, outsider, c.ID)
+	assertIs(t, "AddMember of a contact of another tenant", err, models.ErrNotFound)
+
+	groups, err := store.Group.List(ctx, other)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	assertGroups(t, "List of another tenant", groups, "Outsiders")
+	assertContacts(t, "ListMembers", listMembers(t, store, id), []models.Contact{c})
+}
+
+func testGroupTenantDelete(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	if err := store.Tenant.Create(ctx, models.Tenant{ID: tenant, Name: "Acme", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	c := createContacts(t, store, 1)[0]
+	id := createGroup(t, store, models.Group{Name: "Clients"})
+	if err := store.Group.AddMember(ctx, tenant, id, c.ID); err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+
+	err := store.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return store.Tenant.Delete(ctx, tenant)
+	})
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	assertGroups(t, "List after the tenant was deleted", listGroups(t, store))
+}
+
+func createGroup(t *testing.T, store *interfaces.Store, g models.Group) int {
+	t.Helper()
+	if g.CreatedAt.IsZero() {
+		g.CreatedAt = time.Now()
+	}
+	id, err := store.Group.Create(context.Background(), tenant, g)
+	if err != nil {
+		t.Fatalf("Create(%s): %v", g.Name, err)
+	}
+	return id
+}
+
+func listGroups(t *testing.T, store *interfaces.Store) []models.Group {
+	t.Helper()
+	groups, err := store.Group.List(context.Background(), tenant)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	return groups
+}
+
+func listMembers(t *testing.T, store *interfaces.Store, id int) []models.Contact {
+	t.Helper()
+	contacts, err := store.Group.ListMembers(context.Background(), tenant, id)
+	if err != nil {
+		t.Fatalf("ListMembers(%d): %v", id, err)
+	}
+	return contacts
+}
+
+func listByContact(t *testing.T, store *interfaces.Store, id int) []models.Group {
+	t.Helper()
+	groups, err := store.Group.ListByContact(context.Background(), tenant, id)
+	if err != nil {
+		t.Fatalf("ListByContact(%d): %v", id, err)
+	}
+	return groups
+}
+
+// assertGroups checks the names of groups, in order
+func assertGroups(t *testing.T, what string, groups []models.Group, names ...string) {
+	t.Helper()
+	var got []string
+	for _, g := range groups {
+		got = append(got, g.Name)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(names) {
+		t.Errorf("%s = %v, want %v", what, got, names)
+	}
+}
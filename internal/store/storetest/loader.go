@@ -0,0 +1,188 @@
+package storetest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// RunLoaderTests checks the LoaderInterface contract, and the listing of the
+// audit log it goes with, against the stores built by newStore
+func RunLoaderTests(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store *interfaces.Store)
+	}{
+		{"LoadContact", testLoadContact},
+		{"LoadGroup", testLoadGroup},
+		{"LoadOutboxMessage", testLoadOutboxMessage},
+		{"LoadAuditEntry", testLoadAuditEntry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func testLoadContact(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	want := detailedContact()
+	want.ID, want.Version = 7, 3
+
+	loaded, err := store.Loader.LoadContact(ctx, tenant, want)
+	if err != nil || !loaded {
+		t.Fatalf("LoadContact = %v, %v, want true", loaded, err)
+	}
+	assertStored(t, store, want)
+
+	changed := want
+	changed.FirstName = "Changed"
+	loaded, err = store.Loader.LoadContact(ctx, tenant, changed)
+	if err != nil || loaded {
+		t.Fatalf("LoadContact of a loaded ID = %v, %v, want false", loaded, err)
+	}
+	assertStored(t, store, want)
+
+	taken := models.Contact{ID: 8, FirstName: "Other", LastName: "Person", Email: want.Email, Version: 1}
+	_, err = store.Loader.LoadContact(ctx, tenant, taken)
+	assertIs(t, "LoadContact of a taken email", err, models.ErrConflict)
+
+	// contacts created afterwards get IDs past the loaded ones
+	id, err := store.Contact.Create(ctx, tenant, models.Contact{FirstName: "New", LastName: "Contact", Email: "new@example.com"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if id <= want.ID {
+		t.Errorf("Create after LoadContact returned id %d, want more than %d", id, want.ID)
+	}
+}
+
+func testLoadGroup(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	contacts := createContacts(t, store, 3)
+	want := models.Group{ID: 4, Name: "Clients", Description: "Paying ones", CreatedAt: time.Now().UTC().Truncate(time.Second)}
+
+	loaded, err := store.Loader.LoadGroup(ctx, tenant, want, []int{contacts[2].ID, contacts[0].ID})
+	if err != nil || !loaded {
+		t.Fatalf("LoadGroup = %v, %v, want true", loaded, err)
+	}
+	got, err := store.Group.GetByID(ctx, tenant, want.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Name != want.Name || got.Description != want.Description || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("GetByID = %+v, want %+v", *got, want)
+	}
+	assertContacts(t, "ListMembers", listMembers(t, store, want.ID), []models.Contact{contacts[0], contacts[2]})
+
+	loaded, err = store.Loader.LoadGroup(ctx, tenant, want, []int{contacts[1].ID})
+	if err != nil || loaded {
+		t.Fatalf("LoadGroup of a loaded ID = %v, %v, want false", loaded, err)
+	}
+	assertContacts(t, "ListMembers after a second load", listMembers(t, store, want.ID), []models.Contact{contacts[0], contacts[2]})
+
+	_, err = store.Loader.LoadGroup(ctx, tenant, models.Group{ID: 5, Name: want.Name}, nil)
+	assertIs(t, "LoadGroup of a taken name", err, models.ErrConflict)
+
+	if id := createGroup(t, store, models.Group{Name: "Partners"}); id <= want.ID {
+		t.Errorf("Create after LoadGroup returned id %d, want more than %d", id, want.ID)
+	}
+}
+
+func testLoadOutboxMessage(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	want := models.NewOutboxMessage(tenant, models.EmailMessage{To: "ada@example.com", Subject: "Hello", Body: "Hi"}, now)
+	want.ID, want.Status, want.Attempts, want.DeliveredAt = 5, models.OutboxDelivered, 2, &now
+
+	loaded, err := store.Loader.LoadOutboxMessage(ctx, want)
+	if err != nil || !loaded {
+		t.Fatalf("LoadOutboxMessage = %v, %v, want true", loaded, err)
+	}
+	loaded, err = store.Loader.LoadOutboxMessage(ctx, want)
+	if err != nil || loaded {
+		t.Fatalf("LoadOutboxMessage of a loaded ID = %v, %v, want false", loaded, err)
+	}
+
+	got, err := store.Outbox.GetByID(ctx, tenant, want.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Tenant != want.Tenant || got.Status != want.Status || got.Attempts != want.Attempts || !reflect.DeepEqual(got.Email, want.Email) ||
+		!got.CreatedAt.Equal(want.CreatedAt) || got.DeliveredAt == nil || !got.DeliveredAt.Equal(now) {
+		t.Errorf("GetByID = %+v, want %+v", *got, want)
+	}
+
+	id, err := store.Outbox.Enqueue(ctx, models.NewOutboxMessage(models.DefaultTenant, want.Email, now))
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if id <= want.ID {
+		t.Errorf("Enqueue after LoadOutboxMessage returned id %d, want more than %d", id, want.ID)
+	}
+}
+
+func testLoadAuditEntry(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	contact := models.Contact{ID: 1, FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Version: 1}
+
+	// loaded out of order, listed in ID order
+	for _, e := range []models.AuditEntry{
+		{ID: 3, Tenant: models.DefaultTenant, ContactID: 1, Action: models.AuditDelete, Actor: "admin", Timestamp: now, Before: &contact},
+		{ID: 2, Tenant: tenant, ContactID: 1, Action: models.AuditCreate, Actor: "admin", Timestamp: now, After: &contact},
+	} {
+		loaded, err := store.Loader.LoadAuditEntry(ctx, e)
+		if err != nil || !loaded {
+			t.Fatalf("LoadAuditEntry(%d) = %v, %v, want true", e.ID, loaded, err)
+		}
+	}
+	loaded, err := store.Loader.LoadAuditEntry(ctx, models.AuditEntry{ID: 2, Tenant: tenant, ContactID: 9, Timestamp: now})
+	if err != nil || loaded {
+		t.Fatalf("LoadAuditEntry of a loaded ID = %v, %v, want false", loaded, err)
+	}
+
+	for _, tt := range []struct {
+		afterID, limit int
+		want           []int
+	}{
+		{0, 10, []int{2, 3}},
+		{0, 1, []int{2}},
+		{2, 10, []int{3}},
+		{3, 10, nil},
+	} {
+		entries, err := store.Audit.List(ctx, tt.afterID, tt.limit)
+		if err != nil {
+			t.Fatalf("List(%d, %d): %v", tt.afterID, tt.limit, err)
+		}
+		var ids []int
+		for _, e := range entries {
+			ids = append(ids, e.ID)
+		}
+		if !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("List(%d, %d) returned IDs %v, want %v", tt.afterID, tt.limit, ids, tt.want)
+		}
+	}
+
+	entries, err := store.Audit.ListByContact(ctx, tenant, 1)
+	if err != nil {
+		t.Fatalf("ListByContact: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Action != models.AuditCreate || !entries[0].Timestamp.Equal(now) ||
+		!reflect.DeepEqual(entries[0].After, &contact) {
+		t.Errorf("ListByContact = %+v, want the loaded creation", entries)
+	}
+
+	id, err := store.Audit.Append(ctx, models.AuditEntry{Tenant: tenant, ContactID: 1, Action: models.AuditUpdate, Timestamp: now})
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	if id <= 3 {
+		t.Errorf("Append after LoadAuditEntry returned id %d, want more than 3", id)
+	}
+}
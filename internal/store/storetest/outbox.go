@@ -0,0 +1,238 @@
+package storetest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// RunOutboxRepositoryTests checks the OutboxRepositoryInterface contract
+// against the stores built by newStore
+func RunOutboxRepositoryTests(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store *interfaces.Store)
+	}{
+		{"Tenants", testOutboxTenants},
+		{"ClaimLease", testOutboxClaimLease},
+		{"FailedAttempts", testOutboxFailedAttempts},
+		{"PurgeDelivered", testOutboxPurgeDelivered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func testOutboxTenants(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	ids := map[string]int{}
+	for _, tn := range []string{models.DefaultTenant, tenant} {
+		id, err := store.Outbox.Enqueue(ctx, models.NewOutboxMessage(tn, models.EmailMessage{To: tn + "@example.com"}, now))
+		if err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+		ids[tn] = id
+	}
+
+	for tn, id := range ids {
+		msgs, err := store.Outbox.ListByStatus(ctx, tn, models.OutboxPending)
+		if err != nil {
+			t.Fatalf("ListByStatus: %v", err)
+		}
+		if len(msgs) != 1 || msgs[0].ID != id || msgs[0].Tenant != tn {
+			t.Errorf("ListByStatus(%s) = %+v, want message %d only", tn, msgs, id)
+		}
+		msg, err := store.Outbox.GetByID(ctx, tn, id)
+		if err != nil || msg.Tenant != tn || msg.Email.To != tn+"@example.com" {
+			t.Errorf("GetByID(%s, %d) = %+v, %v", tn, id, msg, err)
+		}
+	}
+	_, err := store.Outbox.GetByID(ctx, models.DefaultTenant, ids[tenant])
+	assertIs(t, "GetByID of another tenant's message", err, models.ErrNotFound)
+
+	// the dispatcher claims the messages of every tenant
+	claimed, err := store.Outbox.Claim(ctx, now, time.Minute, 10)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if len(claimed) != 2 || claimed[0].Tenant != models.DefaultTenant || claimed[1].Tenant != tenant {
+		t.Errorf("Claim = %+v, want the messages of both tenants", claimed)
+	}
+}
+
+// testOutboxClaimLease checks that a claimed message stays hidden from
+// other claims until its lease expires, and that only due pending messages
+// are claimed
+func testOutboxClaimLease(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	lease := time.Minute
+
+	due := enqueueMessage(t, store, now)
+	later := enqueueMessage(t, store, now.Add(time.Hour))
+	dead := enqueueMessage(t, store, now)
+	dead.Status = models.OutboxDead
+	if err := store.Outbox.Save(ctx, dead); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	for _, tt := range []struct {
+		what string
+		at   time.Time
+		want []int
+	}{
+		{"Claim", now, []int{due.ID}},
+		{"Claim during the lease", now.Add(lease / 2), nil},
+		{"Claim as the lease expires", now.Add(lease), []int{due.ID}},
+		// the second claim renewed the lease
+		{"Claim during the renewed lease", now.Add(lease + lease/2), nil},
+		{"Claim once every message is due", now.Add(time.Hour + lease), []int{due.ID, later.ID}},
+	} {
+		claimed, err := store.Outbox.Claim(ctx, tt.at, lease, 10)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.what, err)
+		}
+		var ids []int
+		for _, msg := range claimed {
+			ids = append(ids, msg.ID)
+		}
+		if !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("%s returned messages %v, want %v", tt.what, ids, tt.want)
+		}
+	}
+
+	claimed, err := store.Outbox.Claim(ctx, now.Add(3*time.Hour), lease, 1)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if len(claimed) != 1 {
+		t.Errorf("Claim with a limit of 1 returned %d messages", len(claimed))
+	}
+}
+
+// testOutboxFailedAttempts checks that the state of a failed attempt is kept
+// and that the message waits for its next attempt
+func testOutboxFailedAttempts(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	msg := enqueueMessage(t, store, now)
+
+	for attempt := 1; attempt <= 2; attempt++ {
+		claimed, err := store.Outbox.Claim(ctx, now, time.Minute, 10)
+		if err != nil || len(claimed) != 1 {
+			t.Fatalf("Claim of attempt %d = %+v, %v, want the message", attempt, claimed, err)
+		}
+		msg = claimed[0]
+		if msg.Attempts != attempt-1 {
+			t.Errorf("claimed message has %d attempts, want %d", msg.Attempts, attempt-1)
+		}
+
+		msg.Attempts++
+		msg.LastError = fmt.Sprintf("attempt %d failed", attempt)
+		msg.NextAttemptAt = now.Add(time.Hour)
+		if err := store.Outbox.Save(ctx, msg); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		got, err := store.Outbox.GetByID(ctx, msg.Tenant, msg.ID)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		if got.Status != models.OutboxPending || got.Attempts != attempt || got.LastError != msg.LastError ||
+			!got.NextAttemptAt.Equal(msg.NextAttemptAt) {
+			t.Errorf("message after attempt %d = %+v, want it pending with the attempt counted", attempt, *got)
+		}
+
+		claimed, err = store.Outbox.Claim(ctx, now.Add(time.Minute), time.Minute, 10)
+		if err != nil || len(claimed) != 0 {
+			t.Errorf("Claim before the next attempt = %+v, %v, want none", claimed, err)
+		}
+		now = msg.NextAttemptAt
+	}
+}
+
+// enqueueMessage queues a message of the default tenant due at the given
+// time and returns it as stored
+func enqueueMessage(t *testing.T, store *interfaces.Store, due time.Time) models.OutboxMessage {
+	t.Helper()
+	msg := models.NewOutboxMessage(models.DefaultTenant, models.EmailMessage{To: "ada@example.com", Subject: "Hello"}, due)
+	id, err := store.Outbox.Enqueue(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	msg.ID = id
+	return msg
+}
+
+func testOutboxPurgeDelivered(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	old, recent := now.Add(-48*time.Hour), now.Add(-time.Hour)
+
+	// delivered long ago, delivered recently, dead long ago, pending
+	var ids []int
+	for _, state := range []struct {
+		status      models.OutboxStatus
+		deliveredAt *time.Time
+	}{
+		{models.OutboxDelivered, &old},
+		{models.OutboxDelivered, &recent},
+		{models.OutboxDead, nil},
+		{models.OutboxPending, nil},
+	} {
+		msg := models.NewOutboxMessage(models.DefaultTenant, models.EmailMessage{To: "ada@example.com", Subject: "Hello"}, old)
+		id, err := store.Outbox.Enqueue(ctx, msg)
+		if err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+		msg.ID, msg.Status, msg.DeliveredAt = id, state.status, state.deliveredAt
+		if err := store.Outbox.Save(ctx, msg); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	purged, err := store.Outbox.PurgeDelivered(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("PurgeDelivered: %v", err)
+	}
+	if purged != 1 {
+		t.Errorf("PurgeDelivered = %d, want 1", purged)
+	}
+	_, err = store.Outbox.GetByID(ctx, models.DefaultTenant, ids[0])
+	assertIs(t, "GetByID of a purged message", err, models.ErrNotFound)
+	for _, id := range ids[1:] {
+		if _, err := store.Outbox.GetByID(ctx, models.DefaultTenant, id); err != nil {
+			t.Errorf("GetByID(%d) after PurgeDelivered: %v", id, err)
+		}
+	}
+
+	// once every message is delivered and purged, IDs still count up
+	for _, id := range ids[1:] {
+		msg, err := store.Outbox.GetByID(ctx, models.DefaultTenant, id)
+		if err != nil {
+			t.Fatalf("GetByID: %v", err)
+		}
+		msg.Status, msg.DeliveredAt = models.OutboxDelivered, &old
+		if err := store.Outbox.Save(ctx, *msg); err != nil {
+			t.Fatalf("Save: %v", err)
+		}
+	}
+	if _, err := store.Outbox.PurgeDelivered(ctx, now); err != nil {
+		t.Fatalf("PurgeDelivered: %v", err)
+	}
+	id, err := store.Outbox.Enqueue(ctx, models.NewOutboxMessage(models.DefaultTenant, models.EmailMessage{To: "ada@example.com"}, now))
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if id <= ids[len(ids)-1] {
+		t.Errorf("Enqueue after PurgeDelivered = ID %d, want above %d", id, ids[len(ids)-1])
+	}
+}
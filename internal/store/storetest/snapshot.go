@@ -0,0 +1,67 @@
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// RunSnapshotTests checks the SnapshotterInterface contract against the
+// stores built by newStore
+func RunSnapshotTests(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store *interfaces.Store)
+	}{
+		{"SnapshotIgnoresLaterWrites", testSnapshotIgnoresLaterWrites},
+		{"SnapshotReturnsError", testSnapshotReturnsError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func testSnapshotIgnoresLaterWrites(t *testing.T, store *interfaces.Store) {
+	want := createContacts(t, store, 3)
+
+	err := store.Snapshot.Snapshot(context.Background(), func(ctx context.Context, snapshot *interfaces.Store) error {
+		// the live store changes while the snapshot is read
+		if _, err := store.Contact.Create(context.Background(), tenant, models.Contact{
+			FirstName: "Later", LastName: "Contact", Email: "later@example.com",
+		}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if err := store.Contact.Delete(context.Background(), tenant, want[0].ID, 0); err != nil {
+			t.Fatalf("Delete: %v", err)
+		}
+
+		page, err := snapshot.Contact.List(ctx, tenant, models.ContactQuery{Limit: models.MaxPageSize, SortBy: models.SortByID})
+		if err != nil {
+			t.Fatalf("List of the snapshot: %v", err)
+		}
+		assertContacts(t, "List of the snapshot", page.Contacts, want)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Snapshot: %v", err)
+	}
+
+	if got := listAll(t, store, models.ContactQuery{SortBy: models.SortByID}); len(got) != 3 || got[0].ID != want[1].ID {
+		t.Errorf("live store after the snapshot = %+v, want the later writes", got)
+	}
+}
+
+func testSnapshotReturnsError(t *testing.T, store *interfaces.Store) {
+	failure := errors.New("backup failed")
+	err := store.Snapshot.Snapshot(context.Background(), func(ctx context.Context, snapshot *interfaces.Store) error {
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Snapshot = %v, want the error of fn", err)
+	}
+}
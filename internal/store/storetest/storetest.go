@@ -0,0 +1,42 @@
+// Package storetest is a conformance suite for the store implementations.
+// Each backend runs it from its own tests, so that sqlite, postgres and
+// filestore keep behaving the same way behind store/interfaces.
+package storetest
+
+import (
+	"testing"
+
+	"golang/internal/store/interfaces"
+)
+
+// tenant is the tenant the tests work in. It is not the default tenant so
+// that backends cannot pass by ignoring it.
+const tenant = "acme"
+
+// Factory returns an empty, ready to use store.
+// It is called once per test and should register its own cleanup with t.
+type Factory func(t *testing.T) *interfaces.Store
+
+// suites lists the contract of every part of a store
+var suites = []struct {
+	name string
+	run  func(t *testing.T, newStore Factory)
+}{
+	{"ContactRepository", RunContactRepositoryTests},
+	{"TenantRepository", RunTenantRepositoryTests},
+	{"GroupRepository", RunGroupRepositoryTests},
+	{"OutboxRepository", RunOutboxRepositoryTests},
+	{"AuditRepository", RunAuditRepositoryTests},
+	{"Loader", RunLoaderTests},
+	{"Snapshot", RunSnapshotTests},
+}
+
+// Run checks every contract against the stores built by newStore. Backends
+// call it from a single test, so that a new suite reaches all of them.
+func Run(t *testing.T, newStore Factory) {
+	for _, s := range suites {
+		t.Run(s.name, func(t *testing.T) {
+			s.run(t, newStore)
+		})
+	}
+}
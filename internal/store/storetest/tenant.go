@@ -0,0 +1,127 @@
+package storetest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"golang/internal/models"
+	"golang/internal/store/interfaces"
+)
+
+// RunTenantRepositoryTests checks the TenantRepositoryInterface contract
+// against the stores built by newStore
+func RunTenantRepositoryTests(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, store *interfaces.Store)
+	}{
+		{"CreateAndList", testTenantCreateAndList},
+		{"CreateDuplicate", testTenantCreateDuplicate},
+		{"Delete", testTenantDelete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+func testTenantCreateAndList(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+
+	if _, err := store.Tenant.GetByID(ctx, models.DefaultTenant); err != nil {
+		t.Fatalf("GetByID of the default tenant: %v", err)
+	}
+	_, err := store.Tenant.GetByID(ctx, tenant)
+	assertIs(t, "GetByID of a missing tenant", err, models.ErrNotFound)
+
+	want := models.Tenant{ID: tenant, Name: "Acme", CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	if err := store.Tenant.Create(ctx, want); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	got, err := store.Tenant.GetByID(ctx, tenant)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.ID != want.ID || got.Name != want.Name || !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Errorf("GetByID = %+v, want %+v", *got, want)
+	}
+
+	tenants, err := store.Tenant.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(tenants) != 2 || tenants[0].ID != tenant || tenants[1].ID != models.DefaultTenant {
+		t.Errorf("List = %+v, want %s and %s in ID order", tenants, tenant, models.DefaultTenant)
+	}
+}
+
+func testTenantCreateDuplicate(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	if err := store.Tenant.Create(ctx, models.Tenant{ID: tenant, Name: "Acme", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	err := store.Tenant.Create(ctx, models.Tenant{ID: tenant, Name: "Again", CreatedAt: time.Now()})
+	assertIs(t, "Create of a taken ID", err, models.ErrConflict)
+	err = store.Tenant.Create(ctx, models.Tenant{ID: models.DefaultTenant, Name: "Again", CreatedAt: time.Now()})
+	assertIs(t, "Create of the default tenant", err, models.ErrConflict)
+}
+
+func testTenantDelete(t *testing.T, store *interfaces.Store) {
+	ctx := context.Background()
+	if err := store.Tenant.Create(ctx, models.Tenant{ID: tenant, Name: "Acme", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	createContacts(t, store, 2)
+	kept, err := store.Contact.Create(ctx, models.DefaultTenant, models.Contact{FirstName: "Kept", LastName: "Kept", Email: "kept@example.com"})
+	if err != nil {
+		t.Fatalf("Create in the default tenant: %v", err)
+	}
+	now := time.Now().UTC()
+	// the deleted tenant's notification is the newest
+	lastID := 0
+	for _, tn := range []string{models.DefaultTenant, tenant} {
+		if lastID, err = store.Outbox.Enqueue(ctx, models.NewOutboxMessage(tn, models.EmailMessage{To: "ada@example.com"}, now)); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	err = store.Tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return store.Tenant.Delete(ctx, tenant)
+	})
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+
+	_, err = store.Tenant.GetByID(ctx, tenant)
+	assertIs(t, "GetByID after Delete", err, models.ErrNotFound)
+	contacts, err := store.Contact.GetAll(ctx, tenant)
+	if err != nil {
+		t.Fatalf("GetAll: %v", err)
+	}
+	if len(contacts) != 0 {
+		t.Errorf("contacts of a deleted tenant survived: %+v", contacts)
+	}
+	if _, err := store.Contact.GetByID(ctx, models.DefaultTenant, kept); err != nil {
+		t.Errorf("contact of another tenant lost: %v", err)
+	}
+	for _, status := range []models.OutboxStatus{models.OutboxPending, models.OutboxDelivered, models.OutboxDead} {
+		if msgs, err := store.Outbox.ListByStatus(ctx, tenant, status); err != nil || len(msgs) != 0 {
+			t.Errorf("%s notifications of a deleted tenant = %+v, %v, want none", status, msgs, err)
+		}
+	}
+	claimed, err := store.Outbox.Claim(ctx, now, time.Minute, 10)
+	if err != nil || len(claimed) != 1 || claimed[0].Tenant != models.DefaultTenant {
+		t.Errorf("Claim after Delete = %+v, %v, want the notification of the default tenant only", claimed, err)
+	}
+	if id, err := store.Outbox.Enqueue(ctx, models.NewOutboxMessage(models.DefaultTenant, models.EmailMessage{To: "ada@example.com"}, now)); err != nil || id <= lastID {
+		t.Errorf("Enqueue after Delete = ID %d, %v, want above %d", id, err, lastID)
+	}
+
+	err = store.Tenant.Delete(ctx, tenant)
+	assertIs(t, "second Delete", err, models.ErrNotFound)
+	err = store.Tenant.Delete(ctx, models.DefaultTenant)
+	assertIs(t, "Delete of the default tenant", err, models.ErrConflict)
+}
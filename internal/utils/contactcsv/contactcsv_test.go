@@ -0,0 +1,269 @@
+package contactcsv
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang/internal/models"
+)
+
+// decodeAll reads every contact of a file, failing the test on any error
+func decodeAll(t *testing.T, d *Decoder) []models.Contact {
+	t.Helper()
+	var contacts []models.Contact
+	for {
+		c, err := d.Decode()
+		if errors.Is(err, io.EOF) {
+			return contacts
+		}
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		contacts = append(contacts, c)
+	}
+}
+
+func TestDecodePresets(t *testing.T) {
+	tests := []struct {
+		name      string
+		preset    string
+		overrides []string
+		file      string
+		want      []models.Contact
+	}{
+		{"DefaultFieldNames", "", nil,
+			"First_Name, Last_Name ,EMAIL,phone,phone.work,tags,custom.team,birthday,nickname\n" +
+				"Ada,Lovelace,ada@example.com,+441234567890,+449876543210,vip;speaker,math,1815-12-10,Countess\n",
+			[]models.Contact{{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com",
+				Phones: []models.Phone{
+					{Type: models.PhoneOther, Number: "+441234567890"},
+					{Type: models.PhoneWork, Number: "+449876543210"},
+				},
+				Tags: []string{"vip", "speaker"}, CustomFields: map[string]string{"team": "math"}, Birthday: "1815-12-10"}}},
+		{"Google", "google", nil,
+			"First Name,Last Name,E-mail 1 - Value,Phone 1 - Label,Phone 1 - Value,Phone 2 - Label,Phone 2 - Value," +
+				"Address 1 - Label,Address 1 - Street,Address 1 - City,Address 1 - Country,Organization Name,Birthday,Labels\n" +
+				"Ada,Lovelace,ada@example.com ::: ada@lovelace.org,Mobile,+441234567890 ::: +441234567891,Work Fax,+449876543210," +
+				"Home,12 St James's Square,London,GB,Analytical Engines,--12-10,* myContacts ::: Friends ::: Math\n",
+			[]models.Contact{{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com",
+				Phones: []models.Phone{
+					{Type: models.PhoneMobile, Number: "+441234567890"},
+					{Type: models.PhoneMobile, Number: "+441234567891"},
+					{Type: models.PhoneFax, Number: "+449876543210"},
+				},
+				Addresses: []models.Address{{Type: models.AddressHome, Street: "12 St James's Square", City: "London", Country: "GB"}},
+				Company:   "Analytical Engines", Tags: []string{"Friends", "Math"}}}},
+		{"GoogleLegacyName", "google", nil,
+			"Name,Given Name,Family Name,E-mail 1 - Value,Group Membership\n" +
+				"Augusta Ada King,,,ada@example.com,* My Contacts\n" +
+				"Plato,,,plato@example.com,\n",
+			[]models.Contact{
+				{FirstName: "Augusta Ada", LastName: "King", Email: "ada@example.com"},
+				{FirstName: "Plato", Email: "plato@example.com"},
+			}},
+		{"Outlook", "outlook", nil,
+			"First Name,Last Name,E-mail Address,E-mail 2 Address,Business Phone,Mobile Phone,Home Fax," +
+				"Business Street,Business City,Business State,Business Postal Code,Categories,Birthday,Job Title\n" +
+				"Grace,Hopper,,grace@navy.mil,+12025550100,+12025550101,,1 Navy Way,Arlington,VA,22202,Navy;Computing,12/9/1906,Rear Admiral\n" +
+				"Alan,Turing,alan@example.com,,,,,,,,,,0/0/00,\n",
+			[]models.Contact{
+				{FirstName: "Grace", LastName: "Hopper", Email: "grace@navy.mil",
+					Phones: []models.Phone{
+						{Type: models.PhoneWork, Number: "+12025550100"},
+						{Type: models.PhoneMobile, Number: "+12025550101"},
+					},
+					Addresses: []models.Address{{Type: models.AddressWork, Street: "1 Navy Way", City: "Arlington", Region: "VA", PostalCode: "22202"}},
+					Tags:      []string{"Navy", "Computing"}, Birthday: "1906-12-09", Title: "Rear Admiral"},
+				{FirstName: "Alan", LastName: "Turing", Email: "alan@example.com"},
+			}},
+		{"OverridesPreset", "Outlook", []string{"Job Title=-", "Nickname=custom.nickname", "Web=Page=notes", "x-phone=phone.cell.type"},
+			"First Name,Last Name,E-mail Address,Job Title,Nickname,Web=Page,Other,x-phone\n" +
+				"Grace,Hopper,grace@navy.mil,Rear Admiral,Amazing Grace,https://navy.mil,ignored,Mobile\n",
+			[]models.Contact{{FirstName: "Grace", LastName: "Hopper", Email: "grace@navy.mil",
+				CustomFields: map[string]string{"nickname": "Amazing Grace"}, Notes: "https://navy.mil"}}},
+		{"OverrideColumnNamingAField", "", []string{"email=notes", "work email=email"},
+			"first_name,last_name,email,Work Email\n" +
+				"Ada,Lovelace,personal note,ada@example.com\n",
+			[]models.Contact{{FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com", Notes: "personal note"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := NewMapping(tt.preset, tt.overrides)
+			if err != nil {
+				t.Fatalf("NewMapping: %v", err)
+			}
+			d, err := NewDecoder(strings.NewReader(tt.file), mapping)
+			if err != nil {
+				t.Fatalf("NewDecoder: %v", err)
+			}
+			if got := decodeAll(t, d); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMappingRejects(t *testing.T) {
+	tests := []struct {
+		preset    string
+		overrides []string
+	}{
+		{"thunderbird", nil},
+		{"", []string{"email"}},
+		{"", []string{"=email"}},
+	}
+	for _, tt := range tests {
+		if _, err := NewMapping(tt.preset, tt.overrides); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("NewMapping(%q, %q) = %v, want ErrValidation", tt.preset, tt.overrides, err)
+		}
+	}
+}
+
+func TestNewDecoderRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		mapping Mapping
+	}{
+		{"Empty", "", Mapping{}},
+		{"UnknownField", "name,mail\n", Mapping{"mail": "e-mail"}},
+		{"BadPhoneSlot", "name,tel\n", Mapping{"tel": "phone.work.number"}},
+		{"NothingMapped", "Nickname,Web Page\nAmazing Grace,https://navy.mil\n", Mapping{}},
+		{"EverythingIgnored", "email\nada@example.com\n", Mapping{"email": "-"}},
+	}
+	for _, tt := range tests {
+		if _, err := NewDecoder(strings.NewReader(tt.file), tt.mapping); !errors.Is(err, models.ErrValidation) {
+			t.Errorf("NewDecoder %s = %v, want ErrValidation", tt.name, err)
+		}
+	}
+}
+
+func TestDecodeBOMAndRaggedRows(t *testing.T) {
+	file := "\ufefffirst_name,last_name,email,Web Page\r\n" +
+		"Ada,Lovelace\r\n" +
+		" , ,\r\n" +
+		"\r\n" +
+		"Grace,Hopper,grace@example.com,https://navy.mil,extra,columns\r\n"
+	d, err := NewDecoder(strings.NewReader(file), Mapping{})
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	if want := []string{"first_name", "last_name", "email", "Web Page"}; !reflect.DeepEqual(d.Header(), want) {
+		t.Errorf("Header = %q, want %q", d.Header(), want)
+	}
+	if want := []string{"Web Page"}; !reflect.DeepEqual(d.Unmapped(), want) {
+		t.Errorf("Unmapped = %q, want %q", d.Unmapped(), want)
+	}
+
+	for _, want := range []struct {
+		contact models.Contact
+		line    int
+		fields  []string
+	}{
+		{models.Contact{FirstName: "Ada", LastName: "Lovelace"}, 2, []string{"Ada", "Lovelace"}},
+		// blank rows are skipped
+		{models.Contact{FirstName: "Grace", LastName: "Hopper", Email: "grace@example.com"}, 5,
+			[]string{"Grace", "Hopper", "grace@example.com", "https://navy.mil", "extra", "columns"}},
+	} {
+		got, err := d.Decode()
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		line, fields := d.Record()
+		if !reflect.DeepEqual(got, want.contact) || line != want.line || !reflect.DeepEqual(fields, want.fields) {
+			t.Errorf("Decode = %+v at line %d %q, want %+v at line %d %q", got, line, fields, want.contact, want.line, want.fields)
+		}
+	}
+	if _, err := d.Decode(); !errors.Is(err, io.EOF) {
+		t.Errorf("Decode after the last row = %v, want io.EOF", err)
+	}
+}
+
+func TestDecodeResumesAfterParseErrors(t *testing.T) {
+	file := "first_name,last_name,email\n" +
+		"Ada,Lovelace,ada@example.com\n" +
+		`"Gra"ce,Hopper,grace@example.com` + "\n" +
+		`Alan,"Tu"ring,alan@example.com` + "\n" +
+		"Edsger,Dijkstra,edsger@example.com\n"
+	d, err := NewDecoder(strings.NewReader(file), Mapping{})
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+
+	for _, want := range []struct {
+		name string // of the contact read, or "" for a parse error
+		line int
+	}{
+		{"Ada", 2}, {"", 3}, {"", 4}, {"Edsger", 5},
+	} {
+		got, err := d.Decode()
+		line, _ := d.Record()
+		switch {
+		case want.name == "" && (!errors.Is(err, models.ErrValidation) || line != want.line):
+			t.Errorf("Decode = %+v, %v at line %d, want an ErrValidation at line %d", got, err, line, want.line)
+		case want.name != "" && (err != nil || got.FirstName != want.name || line != want.line):
+			t.Errorf("Decode = %+v, %v at line %d, want %s at line %d", got, err, line, want.name, want.line)
+		}
+	}
+	if _, err := d.Decode(); !errors.Is(err, io.EOF) {
+		t.Errorf("Decode after the last row = %v, want io.EOF", err)
+	}
+}
+
+func TestDecodeBirthdays(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"1815-12-10", "1815-12-10"},
+		{"12/10/1815", "1815-12-10"},
+		{"18151210", "1815-12-10"},
+		{"--12-10", ""},
+		{"0/0/00", ""},
+		{"10 December 1815", "10 December 1815"}, // left for validation to report
+	}
+	for _, tt := range tests {
+		d, err := NewDecoder(strings.NewReader("first_name,birthday\nAda,"+tt.value+"\n"), Mapping{})
+		if err != nil {
+			t.Fatalf("NewDecoder: %v", err)
+		}
+		if got := decodeAll(t, d); len(got) != 1 || got[0].Birthday != tt.want {
+			t.Errorf("Decode of birthday %q = %+v, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	header := []string{"first_name", "last_name", "email"}
+	failed := []models.ImportFailure{
+		{Record: 3, Error: "validation failed: email is invalid", Row: []string{"Alan", "Turing", "not an email"}},
+		{Record: 5, Error: "validation failed: line 5: bare \" in non-quoted field", Row: []string{"Gra\"ce"}},
+	}
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, header, failed); err != nil {
+		t.Fatalf("WriteReport: %v", err)
+	}
+	want := "record,error,first_name,last_name,email\n" +
+		"3,validation failed: email is invalid,Alan,Turing,not an email\n" +
+		`5,"validation failed: line 5: bare "" in non-quoted field","Gra""ce",,` + "\n"
+	if buf.String() != want {
+		t.Errorf("WriteReport wrote\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	// the report imports again with the same mapping
+	d, err := NewDecoder(&buf, Mapping{})
+	if err != nil {
+		t.Fatalf("NewDecoder of the report: %v", err)
+	}
+	if !reflect.DeepEqual(d.Unmapped(), []string{"record", "error"}) {
+		t.Errorf("Unmapped columns of the report = %q, want record and error", d.Unmapped())
+	}
+	got := decodeAll(t, d)
+	if len(got) != 2 || got[0].Email != "not an email" || got[1].FirstName != `Gra"ce` {
+		t.Errorf("Decode of the report = %+v", got)
+	}
+}
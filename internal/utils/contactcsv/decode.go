@@ -0,0 +1,311 @@
+package contactcsv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"golang/internal/models"
+)
+
+// multiValueSeparator separates the values of a Google Contacts cell
+const multiValueSeparator = " ::: "
+
+// Decoder reads contacts from the rows of a CSV file
+type Decoder struct {
+	r        *csv.Reader
+	header   []string
+	targets  []target // of each column
+	unmapped []string // columns ignored for lack of a mapping
+	line     int      // of the last record read
+	fields   []string // of the last record read
+}
+
+// NewDecoder reads the header of a CSV file and maps its columns. Columns
+// the mapping leaves out keep the field they are named after, if any.
+// It fails with models.ErrValidation when a column is mapped to an unknown
+// field or when no column is mapped at all.
+func NewDecoder(r io.Reader, mapping Mapping) (*Decoder, error) {
+	d := &Decoder{r: csv.NewReader(r)}
+	d.r.FieldsPerRecord = -1 // short and long rows are read as they are
+	d.r.TrimLeadingSpace = true
+
+	header, err := d.r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: the CSV file is empty", models.ErrValidation)
+	}
+	if err != nil {
+		return nil, parseError(err)
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff") // byte order mark
+	d.header = header
+
+	fields := make(map[string]string, len(mapping))
+	for column, field := range mapping {
+		fields[strings.ToLower(strings.TrimSpace(column))] = field
+	}
+	mapped := false
+	for _, column := range header {
+		key := strings.ToLower(strings.TrimSpace(column))
+		field, ok := fields[key]
+		if !ok {
+			field = key
+		}
+		t, valid := parseTarget(field)
+		switch {
+		case !valid && ok:
+			return nil, fmt.Errorf("%w: column %q is mapped to unknown field %q", models.ErrValidation, column, field)
+		case !valid:
+			d.unmapped = append(d.unmapped, column)
+		}
+		mapped = mapped || t.kind != ""
+		d.targets = append(d.targets, t)
+	}
+	if !mapped {
+		return nil, fmt.Errorf("%w: no column of the CSV header is mapped to a contact field", models.ErrValidation)
+	}
+	return d, nil
+}
+
+// Header returns the column names of the file
+func (d *Decoder) Header() []string {
+	return d.header
+}
+
+// Unmapped returns the columns ignored because no field is mapped to them
+func (d *Decoder) Unmapped() []string {
+	return d.unmapped
+}
+
+// Record returns the line of the last record read and its fields
+func (d *Decoder) Record() (int, []string) {
+	return d.line, d.fields
+}
+
+// Decode reads the next row, skipping rows without any value. It returns
+// io.EOF after the last one. A row that is not valid CSV fails with an error
+// matching models.ErrValidation, and the next call resumes after it.
+// The contact is not validated.
+func (d *Decoder) Decode() (models.Contact, error) {
+	for {
+		fields, err := d.r.Read()
+		if errors.Is(err, io.EOF) {
+			return models.Contact{}, io.EOF
+		}
+		d.fields = fields
+		if err != nil {
+			var perr *csv.ParseError
+			if errors.As(err, &perr) {
+				d.line = perr.StartLine
+			}
+			return models.Contact{}, parseError(err)
+		}
+		d.line, _ = d.r.FieldPos(0)
+		if slices.ContainsFunc(fields, func(s string) bool { return strings.TrimSpace(s) != "" }) {
+			return d.contact(fields), nil
+		}
+	}
+}
+
+func parseError(err error) error {
+	var perr *csv.ParseError
+	if errors.As(err, &perr) {
+		return fmt.Errorf("%w: line %d: %v", models.ErrValidation, perr.StartLine, perr.Err)
+	}
+	return err
+}
+
+// slot gathers the columns of one phone or address slot
+type slot struct {
+	name    string
+	typ     string
+	numbers []string
+	address models.Address
+}
+
+// contact fills a contact from the fields of a row
+func (d *Decoder) contact(fields []string) models.Contact {
+	var c models.Contact
+	var name string
+	var phones, addresses []*slot
+	slotOf := func(slots *[]*slot, name string) *slot {
+		for _, s := range *slots {
+			if s.name == name {
+				return s
+			}
+		}
+		s := &slot{name: name}
+		*slots = append(*slots, s)
+		return s
+	}
+
+	for i, t := range d.targets {
+		if i >= len(fields) {
+			break
+		}
+		value := strings.TrimSpace(fields[i])
+		if value == "" {
+			continue
+		}
+		switch t.kind {
+		case "field":
+			setField(&c, t.name, value)
+		case "name":
+			name = value
+		case "tags":
+			c.Tags = append(c.Tags, splitTags(value)...)
+		case "custom":
+			if c.CustomFields == nil {
+				c.CustomFields = map[string]string{}
+			}
+			c.CustomFields[t.name] = value
+		case "phone":
+			s := slotOf(&phones, t.slot)
+			if t.typ {
+				s.typ = value
+			} else {
+				s.numbers = append(s.numbers, strings.Split(value, multiValueSeparator)...)
+			}
+		case "address":
+			setAddressPart(&slotOf(&addresses, t.slot).address, t.name, value)
+		}
+	}
+
+	if c.FirstName == "" && c.LastName == "" && name != "" {
+		words := strings.Fields(name)
+		c.FirstName, c.LastName = strings.Join(words[:len(words)-1], " "), words[len(words)-1]
+		if c.FirstName == "" {
+			c.FirstName, c.LastName = c.LastName, ""
+		}
+	}
+	for _, s := range phones {
+		kind := phoneType(s.name)
+		if s.typ != "" || kind == "" {
+			kind = phoneType(s.typ)
+		}
+		if kind == "" {
+			kind = models.PhoneOther
+		}
+		for _, number := range s.numbers {
+			if number = strings.TrimSpace(number); number != "" {
+				c.Phones = append(c.Phones, models.Phone{Type: kind, Number: number})
+			}
+		}
+	}
+	for _, s := range addresses {
+		a := s.address
+		if a.Type == "" {
+			a.Type = addressType(s.name)
+		}
+		if a != (models.Address{Type: a.Type}) {
+			c.Addresses = append(c.Addresses, a)
+		}
+	}
+	return c
+}
+
+func setField(c *models.Contact, field, value string) {
+	switch field {
+	case "first_name":
+		c.FirstName = value
+	case "last_name":
+		c.LastName = value
+	case "email":
+		// the first address wins, of a column or of a Google cell
+		if c.Email == "" {
+			c.Email, _, _ = strings.Cut(value, multiValueSeparator)
+		}
+	case "company":
+		c.Company = value
+	case "title":
+		c.Title = value
+	case "birthday":
+		c.Birthday = parseBirthday(value)
+	case "notes":
+		c.Notes = value
+	}
+}
+
+func setAddressPart(a *models.Address, part, value string) {
+	// Outlook repeats a part in several columns: the first one set wins
+	field := map[string]*string{
+		"street":      &a.Street,
+		"city":        &a.City,
+		"region":      &a.Region,
+		"postal_code": &a.PostalCode,
+		"country":     &a.Country,
+	}[part]
+	switch {
+	case part == "type":
+		a.Type = addressType(value)
+	case *field == "":
+		*field = value
+	}
+}
+
+// splitTags splits a cell of tags. Google marks its own groups, such as
+// "* myContacts", with a star: they are dropped.
+func splitTags(value string) []string {
+	var tags []string
+	for _, tag := range strings.FieldsFunc(strings.ReplaceAll(value, multiValueSeparator, ";"), func(r rune) bool {
+		return r == ';' || r == ','
+	}) {
+		if tag = strings.TrimSpace(tag); tag != "" && !strings.HasPrefix(tag, "*") {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// phoneType reads a phone type such as "Work Fax" or "Mobile", or returns
+// "" when it names none
+func phoneType(s string) models.PhoneType {
+	s = strings.ToLower(s)
+	switch {
+	case strings.Contains(s, "fax"):
+		return models.PhoneFax
+	case strings.Contains(s, "mobile"), strings.Contains(s, "cell"):
+		return models.PhoneMobile
+	case strings.Contains(s, "work"), strings.Contains(s, "business"):
+		return models.PhoneWork
+	case strings.Contains(s, "home"):
+		return models.PhoneHome
+	case strings.Contains(s, "other"), strings.Contains(s, "main"):
+		return models.PhoneOther
+	}
+	return ""
+}
+
+func addressType(s string) models.AddressType {
+	s = strings.ToLower(s)
+	switch {
+	case strings.Contains(s, "home"):
+		return models.AddressHome
+	case strings.Contains(s, "work"), strings.Contains(s, "business"):
+		return models.AddressWork
+	}
+	return models.AddressOther
+}
+
+// birthdayLayouts are the dates spreadsheets write birthdays as: ISO, and
+// the month/day/year of Outlook
+var birthdayLayouts = []string{time.DateOnly, "1/2/2006", "20060102"}
+
+// parseBirthday reads a birthday in one of birthdayLayouts. Dates without a
+// year (--06-15) and Outlook's 0/0/00 for none are dropped; other values are
+// kept as they are, for validation to report them.
+func parseBirthday(value string) string {
+	if strings.HasPrefix(value, "--") || value == "0/0/00" {
+		return ""
+	}
+	for _, layout := range birthdayLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Format(time.DateOnly)
+		}
+	}
+	return value
+}
@@ -0,0 +1,171 @@
+// Package contactcsv reads contacts from spreadsheets saved as CSV, whose
+// first line names the columns. A Mapping tells which contact field each
+// column fills; presets cover the exports of Google Contacts and Outlook.
+//
+// The fields a column can be mapped to are:
+//
+//	first_name, last_name, email, company, title, birthday, notes
+//	name                      a full name: its last word is the last name
+//	tags                      labels separated by ";", "," or " ::: "
+//	phone, phone.<slot>       numbers; a slot named mobile, home, work, fax or
+//	                          other types them, any other slot takes its type
+//	                          from a phone.<slot>.type column
+//	address.<slot>.<part>     part is street, city, region, postal_code,
+//	                          country or type; slots type addresses as phones do
+//	custom.<key>              the custom field key
+//	- (or nothing)            the column is ignored
+//
+// A column the mapping leaves out is mapped to the field it is named after,
+// if any, so that a file whose header holds field names needs no mapping.
+package contactcsv
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	"golang/internal/models"
+)
+
+// Mapping maps column names, compared ignoring case and surrounding spaces,
+// to the fields they fill
+type Mapping map[string]string
+
+// Presets are the mappings of the CSV files other address books export
+var Presets = map[string]Mapping{
+	"default": {},
+	"google":  googleMapping(),
+	"outlook": outlookMapping(),
+}
+
+// googleMapping covers both Google Contacts exports: the older "Google CSV"
+// and the current one
+func googleMapping() Mapping {
+	m := Mapping{
+		"Given Name":             "first_name",
+		"First Name":             "first_name",
+		"Family Name":            "last_name",
+		"Last Name":              "last_name",
+		"Name":                   "name",
+		"E-mail 1 - Value":       "email",
+		"E-mail 2 - Value":       "email",
+		"E-mail 3 - Value":       "email",
+		"Organization 1 - Name":  "company",
+		"Organization Name":      "company",
+		"Organization 1 - Title": "title",
+		"Organization Title":     "title",
+		"Birthday":               "birthday",
+		"Notes":                  "notes",
+		"Group Membership":       "tags",
+		"Labels":                 "tags",
+	}
+	for i := 1; i <= 4; i++ {
+		m[fmt.Sprintf("Phone %d - Value", i)] = fmt.Sprintf("phone.%d", i)
+		m[fmt.Sprintf("Phone %d - Type", i)] = fmt.Sprintf("phone.%d.type", i)
+		m[fmt.Sprintf("Phone %d - Label", i)] = fmt.Sprintf("phone.%d.type", i)
+	}
+	for i := 1; i <= 3; i++ {
+		for column, part := range map[string]string{
+			"Type": "type", "Label": "type", "Street": "street", "City": "city", "Region": "region",
+			"Postal Code": "postal_code", "Country": "country",
+		} {
+			m[fmt.Sprintf("Address %d - %s", i, column)] = fmt.Sprintf("address.%d.%s", i, part)
+		}
+	}
+	return m
+}
+
+func outlookMapping() Mapping {
+	m := Mapping{
+		"First Name":       "first_name",
+		"Last Name":        "last_name",
+		"E-mail Address":   "email",
+		"E-mail 2 Address": "email",
+		"E-mail 3 Address": "email",
+		"Company":          "company",
+		"Job Title":        "title",
+		"Birthday":         "birthday",
+		"Notes":            "notes",
+		"Categories":       "tags",
+		"Mobile Phone":     "phone.mobile",
+		"Home Phone":       "phone.home",
+		"Home Phone 2":     "phone.home",
+		"Business Phone":   "phone.work",
+		"Business Phone 2": "phone.work",
+		"Business Fax":     "phone.fax",
+		"Home Fax":         "phone.fax",
+		"Primary Phone":    "phone.other",
+		"Other Phone":      "phone.other",
+	}
+	for prefix, slot := range map[string]string{"Home": "home", "Business": "work", "Other": "other"} {
+		for column, part := range map[string]string{
+			"Street": "street", "City": "city", "State": "region", "Postal Code": "postal_code",
+			"Country/Region": "country",
+		} {
+			m[prefix+" "+column] = "address." + slot + "." + part
+		}
+	}
+	return m
+}
+
+// NewMapping returns the mapping of a preset, "default" if empty, with
+// column=field overrides as given on the command line or in a query. Each
+// is split at its last "=", since fields hold none.
+func NewMapping(preset string, overrides []string) (Mapping, error) {
+	if preset == "" {
+		preset = "default"
+	}
+	base, ok := Presets[strings.ToLower(preset)]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown CSV preset %q (default, google or outlook)", models.ErrValidation, preset)
+	}
+	m := maps.Clone(base)
+	for _, spec := range overrides {
+		i := strings.LastIndexByte(spec, '=')
+		if i <= 0 {
+			return nil, fmt.Errorf("%w: column mapping %q must be written column=field", models.ErrValidation, spec)
+		}
+		m[spec[:i]] = spec[i+1:]
+	}
+	return m, nil
+}
+
+// target is a parsed field name
+type target struct {
+	kind string // field, name, tags, phone, address, custom or "" (ignored)
+	name string // the field, custom field key or address part
+	slot string // phone or address slot
+	typ  bool   // phone.<slot>.type
+}
+
+// parseTarget reads a field a column is mapped to
+func parseTarget(field string) (target, bool) {
+	field = strings.TrimSpace(field)
+	switch field {
+	case "", "-":
+		return target{}, true
+	case "first_name", "last_name", "email", "company", "title", "birthday", "notes":
+		return target{kind: "field", name: field}, true
+	case "name", "tags":
+		return target{kind: field}, true
+	case "phone":
+		return target{kind: "phone", slot: string(models.PhoneOther)}, true
+	}
+
+	kind, rest, _ := strings.Cut(field, ".")
+	parts := strings.Split(rest, ".")
+	switch {
+	case kind == "custom" && rest != "":
+		return target{kind: "custom", name: rest}, true
+	case kind == "phone" && len(parts) == 1 && parts[0] != "":
+		return target{kind: "phone", slot: parts[0]}, true
+	case kind == "phone" && len(parts) == 2 && parts[0] != "" && parts[1] == "type":
+		return target{kind: "phone", slot: parts[0], typ: true}, true
+	case kind == "address" && len(parts) == 2 && parts[0] != "":
+		switch parts[1] {
+		case "street", "city", "region", "postal_code", "country", "type":
+			return target{kind: "address", slot: parts[0], name: parts[1]}, true
+		}
+	}
+	return target{}, false
+}
@@ -0,0 +1,29 @@
+package contactcsv
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"golang/internal/models"
+)
+
+// WriteReport writes the records an import failed on as CSV: the record (the
+// line of a CSV file) and the error, followed by the columns of the file as
+// read. Once the errors are fixed, the report can be imported again with the
+// same mapping, as the two extra columns map to no field.
+func WriteReport(w io.Writer, header []string, failed []models.ImportFailure) error {
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{"record", "error"}, header...))
+	for _, f := range failed {
+		row := make([]string, 2, 2+len(header))
+		row[0], row[1] = strconv.Itoa(f.Record), f.Error
+		row = append(row, f.Row...)
+		for len(row) < 2+len(header) {
+			row = append(row, "")
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
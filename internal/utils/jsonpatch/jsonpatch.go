@@ -0,0 +1,292 @@
+// Package jsonpatch applies JSON Patch documents (RFC 6902) to decoded JSON
+// values, the maps, slices and scalars json.Unmarshal produces into an any.
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrTestFailed is returned when a "test" operation does not match
+var ErrTestFailed = errors.New("test failed")
+
+// Operation is one operation of a JSON Patch document
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch is a JSON Patch document: operations applied in order
+type Patch []Operation
+
+// OpError reports the operation of a patch that failed
+type OpError struct {
+	Index int
+	Err   error
+}
+
+func (e *OpError) Error() string {
+	return fmt.Sprintf("operation %d: %v", e.Index, e.Err)
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+// Decode parses a JSON Patch document
+func Decode(data []byte) (Patch, error) {
+	var p Patch
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, errors.New("a JSON Patch must be an array of operations")
+	}
+	return p, nil
+}
+
+// Apply runs the operations of p in order against doc and returns the
+// patched document. The whole patch fails, with an *OpError, if one
+// operation does; doc itself is never changed.
+func (p Patch) Apply(doc any) (any, error) {
+	doc, err := deepCopy(doc)
+	if err != nil {
+		return nil, err
+	}
+	// the document is held under the empty key of a root object, so that
+	// operations on the whole document work like those on a member
+	root := map[string]any{"": doc}
+	for i, op := range p {
+		if err := apply(root, op); err != nil {
+			return nil, &OpError{Index: i, Err: err}
+		}
+	}
+	return root[""], nil
+}
+
+// ParsePointer splits a JSON pointer (RFC 6901) into its reference tokens.
+// The empty pointer designates the whole document and has none.
+func ParsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("path %q is not a JSON pointer", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		// ~1 is unescaped first, so that ~01 stands for ~1
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func apply(root map[string]any, op Operation) error {
+	path, err := rootPath(op.Path)
+	if err != nil {
+		return err
+	}
+
+	switch op.Op {
+	case "test":
+		value, err := pointerValue(root, path)
+		if err != nil {
+			return err
+		}
+		var want any
+		if err := json.Unmarshal(op.Value, &want); err != nil {
+			return errors.New("invalid value")
+		}
+		if !reflect.DeepEqual(value, want) {
+			return fmt.Errorf("%w: %s is not %s", ErrTestFailed, op.Path, op.Value)
+		}
+		return nil
+	case "add", "replace":
+		var value any
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return errors.New("invalid value")
+		}
+		return setPointer(root, path, value, op.Op == "replace")
+	case "remove":
+		_, err := removePointer(root, path)
+		return err
+	case "copy", "move":
+		from, err := rootPath(op.From)
+		if err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+		value, err := pointerValue(root, from)
+		if err != nil {
+			return fmt.Errorf("from: %w", err)
+		}
+		if op.Op == "move" && len(path) > len(from) && slices.Equal(path[:len(from)], from) {
+			return errors.New("a value cannot be moved into itself")
+		}
+		if op.Op == "move" {
+			if _, err := removePointer(root, from); err != nil {
+				return err
+			}
+		} else if value, err = deepCopy(value); err != nil {
+			return err
+		}
+		return setPointer(root, path, value, false)
+	}
+	return fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// rootPath parses a pointer into a path from the root object of Apply
+func rootPath(pointer string) ([]string, error) {
+	tokens, err := ParsePointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{""}, tokens...), nil
+}
+
+// pointerValue returns the value a path designates
+func pointerValue(doc any, path []string) (any, error) {
+	node := doc
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			child, ok := n[token]
+			if !ok {
+				return nil, notExist(path)
+			}
+			node = child
+		case []any:
+			i, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, notExist(path)
+		}
+	}
+	return node, nil
+}
+
+// setPointer adds value at path, or replaces the existing value there.
+// An array index inserts before the element, and "-" appends.
+func setPointer(root map[string]any, path []string, value any, replace bool) error {
+	parent, err := pointerValue(root, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		if _, ok := p[last]; replace && !ok {
+			return notExist(path)
+		}
+		p[last] = value
+		return nil
+	case []any:
+		if replace {
+			i, err := arrayIndex(last, len(p)-1)
+			if err != nil {
+				return err
+			}
+			p[i] = value
+			return nil
+		}
+		var updated []any
+		if last == "-" {
+			updated = append(p, value)
+		} else {
+			i, err := arrayIndex(last, len(p))
+			if err != nil {
+				return err
+			}
+			updated = slices.Insert(p, i, value)
+		}
+		return replaceArray(root, path[:len(path)-1], updated)
+	}
+	return notExist(path)
+}
+
+// removePointer removes the value at path and returns it
+func removePointer(root map[string]any, path []string) (any, error) {
+	if len(path) == 1 {
+		return nil, errors.New("the whole document cannot be removed")
+	}
+	parent, err := pointerValue(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		value, ok := p[last]
+		if !ok {
+			return nil, notExist(path)
+		}
+		delete(p, last)
+		return value, nil
+	case []any:
+		i, err := arrayIndex(last, len(p)-1)
+		if err != nil {
+			return nil, err
+		}
+		value := p[i]
+		return value, replaceArray(root, path[:len(path)-1], slices.Delete(p, i, i+1))
+	}
+	return nil, notExist(path)
+}
+
+// replaceArray stores an array whose length changed back into its parent
+func replaceArray(root map[string]any, path []string, array []any) error {
+	parent, err := pointerValue(root, path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		p[last] = array
+	case []any:
+		i, _ := arrayIndex(last, len(p)-1)
+		p[i] = array
+	}
+	return nil
+}
+
+// arrayIndex parses an array index of at most max
+func arrayIndex(token string, max int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("array index %q is out of range", token)
+	}
+	return i, nil
+}
+
+// notExist reports a path, taken from the root object, that does not exist
+func notExist(path []string) error {
+	return fmt.Errorf("path %q does not exist", pointer(path[1:]))
+}
+
+// pointer escapes reference tokens back into a JSON pointer
+func pointer(tokens []string) string {
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/")
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return b.String()
+}
+
+// deepCopy copies a decoded JSON value, so that copies can be changed
+// independently
+func deepCopy(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var copied any
+	return copied, json.Unmarshal(data, &copied)
+}
@@ -0,0 +1,204 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+		want  string // the patched document, or "" when the patch fails
+	}{
+		// RFC 6902, Appendix A
+		{"A.1 AddObjectMember", `{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			`{"baz": "qux", "foo": "bar"}`},
+		{"A.2 AddArrayElement", `{"foo": ["bar", "baz"]}`,
+			`[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			`{"foo": ["bar", "qux", "baz"]}`},
+		{"A.3 RemoveObjectMember", `{"baz": "qux", "foo": "bar"}`,
+			`[{"op": "remove", "path": "/baz"}]`,
+			`{"foo": "bar"}`},
+		{"A.4 RemoveArrayElement", `{"foo": ["bar", "qux", "baz"]}`,
+			`[{"op": "remove", "path": "/foo/1"}]`,
+			`{"foo": ["bar", "baz"]}`},
+		{"A.5 ReplaceValue", `{"baz": "qux", "foo": "bar"}`,
+			`[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			`{"baz": "boo", "foo": "bar"}`},
+		{"A.6 MoveValue", `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			`{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`},
+		{"A.7 MoveArrayElement", `{"foo": ["all", "grass", "cows", "eat"]}`,
+			`[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			`{"foo": ["all", "cows", "eat", "grass"]}`},
+		{"A.8 TestValueSuccess", `{"baz": "qux", "foo": ["a", 2, "c"]}`,
+			`[{"op": "test", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/foo/1", "value": 2}]`,
+			`{"baz": "qux", "foo": ["a", 2, "c"]}`},
+		{"A.9 TestValueError", `{"baz": "qux"}`,
+			`[{"op": "test", "path": "/baz", "value": "bar"}]`,
+			""},
+		{"A.10 AddNestedMemberObject", `{"foo": "bar"}`,
+			`[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+			`{"foo": "bar", "child": {"grandchild": {}}}`},
+		{"A.11 IgnoreUnrecognizedElements", `{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz", "value": "qux", "xyz": 123}]`,
+			`{"foo": "bar", "baz": "qux"}`},
+		{"A.12 AddToNonexistentTarget", `{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			""},
+		{"A.14 EscapeOrdering", `{"/": 9, "~1": 10}`,
+			`[{"op": "test", "path": "/~01", "value": 10}]`,
+			`{"/": 9, "~1": 10}`},
+		{"A.15 CompareStringsAndNumbers", `{"/": 9, "~1": 10}`,
+			`[{"op": "test", "path": "/~01", "value": "10"}]`,
+			""},
+		{"A.16 AddArrayValue", `{"foo": ["bar"]}`,
+			`[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			`{"foo": ["bar", ["abc", "def"]]}`},
+
+		// pointers
+		{"EscapedSlash", `{"a/b": 1}`,
+			`[{"op": "replace", "path": "/a~1b", "value": 2}]`,
+			`{"a/b": 2}`},
+		{"EscapedTilde", `{"m~n": 1}`,
+			`[{"op": "remove", "path": "/m~0n"}]`,
+			`{}`},
+		{"AppendWithDash", `{"tags": []}`,
+			`[{"op": "add", "path": "/tags/-", "value": "a"}, {"op": "add", "path": "/tags/-", "value": "b"}]`,
+			`{"tags": ["a", "b"]}`},
+		{"DashOnlyAdds", `{"tags": ["a"]}`,
+			`[{"op": "remove", "path": "/tags/-"}]`,
+			""},
+		{"IndexPastEnd", `{"tags": ["a"]}`,
+			`[{"op": "add", "path": "/tags/2", "value": "b"}]`,
+			""},
+		{"IndexWithLeadingZero", `{"tags": ["a", "b"]}`,
+			`[{"op": "remove", "path": "/tags/01"}]`,
+			""},
+		{"ReplaceMissingMember", `{"foo": "bar"}`,
+			`[{"op": "replace", "path": "/baz", "value": "qux"}]`,
+			""},
+		{"ReplaceWholeDocument", `{"foo": "bar"}`,
+			`[{"op": "replace", "path": "", "value": {"baz": "qux"}}]`,
+			`{"baz": "qux"}`},
+		{"NotAPointer", `{"foo": "bar"}`,
+			`[{"op": "remove", "path": "foo"}]`,
+			""},
+
+		// copy and move
+		{"CopyIsIndependent", `{"a": {"b": 1}}`,
+			`[{"op": "copy", "from": "/a", "path": "/c"}, {"op": "replace", "path": "/c/b", "value": 2}]`,
+			`{"a": {"b": 1}, "c": {"b": 2}}`},
+		{"CopyArrayElement", `{"foo": ["a", "b"]}`,
+			`[{"op": "copy", "from": "/foo/0", "path": "/foo/-"}]`,
+			`{"foo": ["a", "b", "a"]}`},
+		{"MoveIntoItself", `{"a": {"b": 1}}`,
+			`[{"op": "move", "from": "/a", "path": "/a/c"}]`,
+			""},
+		{"MoveFromMissing", `{"a": 1}`,
+			`[{"op": "move", "from": "/b", "path": "/c"}]`,
+			""},
+
+		// the patch is applied as a whole or not at all
+		{"LaterOperationFails", `{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz", "value": "qux"}, {"op": "test", "path": "/baz", "value": "other"}]`,
+			""},
+		{"UnknownOperation", `{"foo": "bar"}`,
+			`[{"op": "increment", "path": "/foo"}]`,
+			""},
+		{"MissingValue", `{"foo": "bar"}`,
+			`[{"op": "add", "path": "/baz"}]`,
+			""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patch, err := Decode([]byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			doc := decodeJSON(t, tt.doc)
+			got, err := patch.Apply(doc)
+
+			if !reflect.DeepEqual(doc, decodeJSON(t, tt.doc)) {
+				t.Errorf("Apply changed its input to %v", doc)
+			}
+			if tt.want == "" {
+				var opErr *OpError
+				if !errors.As(err, &opErr) {
+					t.Fatalf("Apply = %v, %v, want an *OpError", got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if want := decodeJSON(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("Apply = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestApplyReportsFailedTests(t *testing.T) {
+	patch, err := Decode([]byte(`[{"op": "add", "path": "/a", "value": 1}, {"op": "test", "path": "/a", "value": 2}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = patch.Apply(map[string]any{})
+
+	var opErr *OpError
+	if !errors.As(err, &opErr) || opErr.Index != 1 || !errors.Is(err, ErrTestFailed) {
+		t.Errorf("Apply = %v, want ErrTestFailed at operation 1", err)
+	}
+
+	_, err = Patch{{Op: "remove", Path: "/a"}}.Apply(map[string]any{})
+	if errors.Is(err, ErrTestFailed) {
+		t.Errorf("Apply of a missing path = %v, want an error other than ErrTestFailed", err)
+	}
+}
+
+func TestDecodeRejectsNonArrays(t *testing.T) {
+	for _, body := range []string{`{"op": "add"}`, `"add"`, `[{"op": 1}]`, `not json`} {
+		if _, err := Decode([]byte(body)); err == nil {
+			t.Errorf("Decode(%s) succeeded", body)
+		}
+	}
+}
+
+func TestParsePointer(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    []string
+	}{
+		{"", nil},
+		{"/", []string{""}},
+		{"/foo/0", []string{"foo", "0"}},
+		{"/a~1b", []string{"a/b"}},
+		{"/m~0n", []string{"m~n"}},
+		{"/~01", []string{"~1"}},
+		{"/~10", []string{"/0"}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePointer(tt.pointer)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePointer(%q) = %q, %v, want %q", tt.pointer, got, err, tt.want)
+		}
+	}
+	if _, err := ParsePointer("foo"); err == nil {
+		t.Errorf("ParsePointer(%q) succeeded", "foo")
+	}
+}
+
+func decodeJSON(t *testing.T, s string) any {
+	t.Helper()
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("bad test JSON %s: %v", s, err)
+	}
+	return v
+}
@@ -1,24 +1,147 @@
 package messaging
 
 import (
-	"golang/internal/models"
+	"context"
+	"crypto/tls"
+	"fmt"
 	"log"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"golang/internal/config"
+	"golang/internal/models"
 )
 
+// EmailClient is the SMTP implementation of Sender.
+// It opens one connection per message, which keeps it safe for concurrent use.
 type EmailClient struct {
-	token string
+	cfg config.EmailConfig
 }
 
-func NewEmailClient(token string) *EmailClient {
-	return &EmailClient{token: token}
+func NewEmailClient(cfg config.EmailConfig) *EmailClient {
+	return &EmailClient{cfg: cfg}
 }
 
+// Connect verifies that the SMTP server is reachable and accepts our credentials
 func (c *EmailClient) Connect() error {
-	log.Printf("Email Client Connected!")
-	return nil
+	if c.cfg.Host == "" {
+		log.Printf("Email Client Connected! (no SMTP host configured, emails are only logged)")
+		return nil
+	}
+
+	client, err := c.dial(context.Background())
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	log.Printf("Email Client Connected! (%s:%d)", c.cfg.Host, c.cfg.Port)
+	return client.Quit()
+}
+
+func (c *EmailClient) SendEmail(ctx context.Context, emailMessage models.EmailMessage) error {
+	if c.cfg.Host == "" {
+		log.Printf("Sending email to %s: %s", emailMessage.To, emailMessage.Subject)
+		return nil
+	}
+
+	from, err := mail.ParseAddress(c.cfg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	to, err := mail.ParseAddress(emailMessage.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	data, err := buildMessage(from, to, emailMessage, time.Now())
+	if err != nil {
+		return err
+	}
+
+	client, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp MAIL FROM: %w", err)
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return fmt.Errorf("smtp RCPT TO: %w", err)
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp DATA: %w", err)
+	}
+
+	log.Printf("Sent email to %s: %s", emailMessage.To, emailMessage.Subject)
+	return client.Quit()
 }
 
-func (c *EmailClient) SendEmail(emailMessage models.EmailMessage) error {
-	log.Printf("Sending email to %s: %s", emailMessage.To, emailMessage.Subject)
-	return nil
+// dial connects, negotiates TLS and authenticates according to the config
+func (c *EmailClient) dial(ctx context.Context) (*smtp.Client, error) {
+	timeout := time.Duration(c.cfg.Timeout)
+	addr := net.JoinHostPort(c.cfg.Host, strconv.Itoa(c.cfg.Port))
+
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server %s: %w", addr, err)
+	}
+
+	// the whole exchange must finish within the timeout (or the ctx deadline)
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	tlsConfig := &tls.Config{ServerName: c.cfg.Host}
+	if c.cfg.Security == "tls" {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, c.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+
+	if c.cfg.Security == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("SMTP server %s does not support STARTTLS", addr)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp STARTTLS: %w", err)
+		}
+	}
+
+	if c.cfg.Auth != "none" && c.cfg.Username != "" {
+		var auth smtp.Auth
+		if c.cfg.Auth == "login" {
+			auth = LoginAuth(c.cfg.Username, c.cfg.Password, c.cfg.Host)
+		} else {
+			auth = smtp.PlainAuth("", c.cfg.Username, c.cfg.Password, c.cfg.Host)
+		}
+		if err := client.Auth(auth); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("smtp AUTH: %w", err)
+		}
+	}
+
+	return client, nil
 }
@@ -0,0 +1,113 @@
+package messaging
+
+import (
+	"context"
+	"mime"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang/internal/config"
+	"golang/internal/models"
+	"golang/internal/utils/messaging/smtpsink"
+)
+
+func TestSendEmailToSink(t *testing.T) {
+	tests := []struct {
+		name         string
+		auth         string
+		username     string
+		rcpt         string
+		msg          models.EmailMessage
+		wantText     string
+		wantHTML     string
+		wantMultiple bool
+	}{
+		{
+			name:     "PlainAuthMultipart",
+			auth:     "plain",
+			username: "mailer",
+			rcpt:     "ada@example.com",
+			msg: models.EmailMessage{
+				To: "Ada Lovelace <ada@example.com>", Subject: "Contact créé",
+				Body: "Hello Ada,\nyour contact was created.", HTMLBody: "<p>Hello <b>Ada</b>, voilà.</p>",
+			},
+			wantText:     "Hello Ada,\nyour contact was created.",
+			wantHTML:     "<p>Hello <b>Ada</b>, voilà.</p>",
+			wantMultiple: true,
+		},
+		{
+			name:     "LoginAuthMultipart",
+			auth:     "login",
+			username: "relay",
+			rcpt:     "grace@example.com",
+			msg: models.EmailMessage{
+				To: "grace@example.com", Subject: "Hello",
+				Body: "Plain text", HTMLBody: "<p>HTML</p>",
+			},
+			wantText:     "Plain text",
+			wantHTML:     "<p>HTML</p>",
+			wantMultiple: true,
+		},
+		{
+			name: "NoAuthTextOnly",
+			auth: "none",
+			rcpt: "alan@example.com",
+			msg: models.EmailMessage{
+				To: "alan@example.com", Subject: "Hello",
+				Body: strings.Repeat("A long line that quoted-printable must soft-break. ", 4) + "Done.",
+			},
+			// DATA ends a message that does not end with a line break with one
+			wantText: strings.Repeat("A long line that quoted-printable must soft-break. ", 4) + "Done.\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink, err := smtpsink.Start("127.0.0.1:0", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer sink.Close()
+
+			host, port, _ := net.SplitHostPort(sink.Addr())
+			cfg := config.EmailConfig{
+				Host: host, Security: "none", Auth: tt.auth,
+				Username: tt.username, Password: "secret",
+				From: "Contact Manager <no-reply@example.com>", Timeout: config.Duration(5 * time.Second),
+			}
+			cfg.Port, _ = strconv.Atoi(port)
+			if err := NewEmailClient(cfg).SendEmail(context.Background(), tt.msg); err != nil {
+				t.Fatalf("SendEmail: %v", err)
+			}
+
+			msgs, err := sink.WaitForMessages(1, 5*time.Second)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := msgs[0]
+			if got.Username != tt.username {
+				t.Errorf("authenticated as %q, want %q", got.Username, tt.username)
+			}
+			if got.From != "no-reply@example.com" {
+				t.Errorf("MAIL FROM %q, want no-reply@example.com", got.From)
+			}
+			if len(got.To) != 1 || got.To[0] != tt.rcpt {
+				t.Errorf("RCPT TO %v, want %s", got.To, tt.rcpt)
+			}
+			if subject, err := new(mime.WordDecoder).DecodeHeader(got.Header.Get("Subject")); err != nil || subject != tt.msg.Subject {
+				t.Errorf("Subject = %q, %v, want %q", subject, err, tt.msg.Subject)
+			}
+			if multipart := strings.HasPrefix(got.Header.Get("Content-Type"), "multipart/alternative"); multipart != tt.wantMultiple {
+				t.Errorf("Content-Type = %s, want multipart %t", got.Header.Get("Content-Type"), tt.wantMultiple)
+			}
+			if got.Text != tt.wantText {
+				t.Errorf("text body = %q, want %q", got.Text, tt.wantText)
+			}
+			if got.HTML != tt.wantHTML {
+				t.Errorf("HTML body = %q, want %q", got.HTML, tt.wantHTML)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package messaging
+
+import (
+	"context"
+
+	"golang/internal/models"
+)
+
+// Sender delivers email messages.
+// Services depend on this abstraction rather than on a concrete transport.
+type Sender interface {
+	SendEmail(ctx context.Context, emailMessage models.EmailMessage) error
+}
@@ -0,0 +1,325 @@
+// Package smtpsink is an in-process SMTP server that accepts every message and
+// keeps it in memory. It is meant for integration tests and local development:
+// point EmailConfig at it with security "none" and inspect what was sent.
+package smtpsink
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message is one email captured by the sink
+type Message struct {
+	From     string
+	To       []string
+	Username string // set when the client authenticated
+	Data     []byte // raw message as received after DATA
+	Header   mail.Header
+	Text     string // decoded text/plain body
+	HTML     string // decoded text/html body
+}
+
+// Server is a running sink
+type Server struct {
+	listener  net.Listener
+	onMessage func(Message)
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	messages []Message
+	closed   bool
+	conns    map[net.Conn]struct{} // open sessions, closed by Close
+
+	wg sync.WaitGroup
+}
+
+// Start listens on addr (e.g. "127.0.0.1:0") and serves until Close.
+// onMessage, if not nil, is called for every message received.
+func Start(addr string, onMessage func(Message)) (*Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start SMTP sink: %w", err)
+	}
+
+	s := &Server{listener: listener, onMessage: onMessage, conns: map[net.Conn]struct{}{}}
+	s.cond = sync.NewCond(&s.mu)
+
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the address the sink listens on
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Messages returns a copy of everything received so far
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// WaitForMessages blocks until at least n messages have arrived
+func (s *Server) WaitForMessages(n int, timeout time.Duration) ([]Message, error) {
+	timer := time.AfterFunc(timeout, func() {
+		s.mu.Lock()
+		s.cond.Broadcast()
+		s.mu.Unlock()
+	})
+	defer timer.Stop()
+
+	deadline := time.Now().Add(timeout)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.messages) < n {
+		if s.closed || !time.Now().Before(deadline) {
+			return append([]Message(nil), s.messages...),
+				fmt.Errorf("received %d of %d messages before timeout", len(s.messages), n)
+		}
+		s.cond.Wait()
+	}
+	return append([]Message(nil), s.messages...), nil
+}
+
+// Reset forgets every received message
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = nil
+}
+
+// Close stops listening, closes the open sessions and waits for them to end
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.cond.Broadcast()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	err := s.listener.Close()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+func (s *Server) store(msg Message) {
+	s.mu.Lock()
+	s.messages = append(s.messages, msg)
+	s.cond.Broadcast()
+	s.mu.Unlock()
+
+	if s.onMessage != nil {
+		s.onMessage(msg)
+	}
+}
+
+// handle runs one SMTP session
+func (s *Server) handle(netConn net.Conn) {
+	defer netConn.Close()
+	netConn.SetDeadline(time.Now().Add(5 * time.Minute))
+
+	conn := textproto.NewConn(netConn)
+	reply := func(code int, text string) { conn.PrintfLine("%d %s", code, text) }
+
+	reply(220, "smtpsink ready")
+	var msg Message
+	for {
+		line, err := conn.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			conn.PrintfLine("250-smtpsink")
+			conn.PrintfLine("250-8BITMIME")
+			conn.PrintfLine("250 AUTH PLAIN LOGIN")
+		case "HELO":
+			reply(250, "smtpsink")
+		case "AUTH":
+			username, ok := s.authenticate(conn, arg)
+			if !ok {
+				reply(501, "malformed authentication")
+				continue
+			}
+			msg.Username = username
+			reply(235, "authenticated")
+		case "MAIL":
+			msg = Message{Username: msg.Username, From: trimPath(arg, "FROM:")}
+			reply(250, "ok")
+		case "RCPT":
+			msg.To = append(msg.To, trimPath(arg, "TO:"))
+			reply(250, "ok")
+		case "DATA":
+			if len(msg.To) == 0 {
+				reply(503, "need RCPT before DATA")
+				continue
+			}
+			reply(354, "end data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(conn.DotReader())
+			if err != nil {
+				return
+			}
+			msg.Data = data
+			decode(&msg)
+			s.store(msg)
+			msg = Message{Username: msg.Username}
+			reply(250, "queued")
+		case "RSET":
+			msg = Message{Username: msg.Username}
+			reply(250, "ok")
+		case "NOOP":
+			reply(250, "ok")
+		case "QUIT":
+			reply(221, "bye")
+			return
+		default:
+			reply(502, "command not implemented")
+		}
+	}
+}
+
+// authenticate accepts any credentials for PLAIN and LOGIN and returns the username
+func (s *Server) authenticate(conn *textproto.Conn, arg string) (string, bool) {
+	mechanism, initial, _ := strings.Cut(arg, " ")
+	challenge := func(prompt string) (string, bool) {
+		conn.PrintfLine("334 %s", base64.StdEncoding.EncodeToString([]byte(prompt)))
+		line, err := conn.ReadLine()
+		if err != nil {
+			return "", false
+		}
+		decoded, err := base64.StdEncoding.DecodeString(line)
+		return string(decoded), err == nil
+	}
+
+	switch strings.ToUpper(mechanism) {
+	case "PLAIN":
+		var creds string
+		if initial != "" {
+			decoded, err := base64.StdEncoding.DecodeString(initial)
+			if err != nil {
+				return "", false
+			}
+			creds = string(decoded)
+		} else {
+			var ok bool
+			if creds, ok = challenge(""); !ok {
+				return "", false
+			}
+		}
+		fields := strings.Split(creds, "\x00")
+		if len(fields) != 3 {
+			return "", false
+		}
+		return fields[1], true
+	case "LOGIN":
+		username, ok := challenge("Username:")
+		if !ok {
+			return "", false
+		}
+		_, ok = challenge("Password:")
+		return username, ok
+	default:
+		return "", false
+	}
+}
+
+// trimPath extracts the address from "FROM:<a@b> SIZE=..." style arguments
+func trimPath(arg, prefix string) string {
+	arg = strings.TrimSpace(arg)
+	if len(arg) >= len(prefix) && strings.EqualFold(arg[:len(prefix)], prefix) {
+		arg = strings.TrimSpace(arg[len(prefix):])
+	}
+	if end := strings.Index(arg, ">"); strings.HasPrefix(arg, "<") && end > 0 {
+		arg = arg[1:end]
+	}
+	return arg
+}
+
+// decode fills Header, Text and HTML from the raw data, best effort
+func decode(msg *Message) {
+	parsed, err := mail.ReadMessage(bytes.NewReader(msg.Data))
+	if err != nil {
+		return
+	}
+	msg.Header = parsed.Header
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		// multipart.Reader undoes quoted-printable transfer encoding itself
+		parts := multipart.NewReader(parsed.Body, params["boundary"])
+		for {
+			part, err := parts.NextPart()
+			if err != nil {
+				return
+			}
+			body, _ := io.ReadAll(part)
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			switch partType {
+			case "text/plain":
+				msg.Text = string(body)
+			case "text/html":
+				msg.HTML = string(body)
+			}
+		}
+	}
+
+	var body io.Reader = parsed.Body
+	if strings.EqualFold(parsed.Header.Get("Content-Transfer-Encoding"), "quoted-printable") {
+		body = quotedprintable.NewReader(body)
+	}
+	data, _ := io.ReadAll(body)
+	if mediaType == "text/html" {
+		msg.HTML = string(data)
+	} else {
+		msg.Text = string(data)
+	}
+}
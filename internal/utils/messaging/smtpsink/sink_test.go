@@ -0,0 +1,38 @@
+package smtpsink
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestCloseEndsOpenSessions(t *testing.T) {
+	sink, err := Start("127.0.0.1:0", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a client that connects and then goes quiet
+	conn, err := net.Dial("tcp", sink.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+		t.Fatalf("reading the greeting: %v", err)
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- sink.Close() }()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close still waiting for an idle session")
+	}
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Error("the session is still open after Close")
+	}
+}
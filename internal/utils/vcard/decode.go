@@ -0,0 +1,398 @@
+package vcard
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang/internal/models"
+)
+
+// Decoder reads contacts from a stream of vCards
+type Decoder struct {
+	r    *bufio.Reader
+	line int // number of the last physical line read
+	card int // number of cards started
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// property is one content line: [group.]NAME;PARAM=value,...:value
+type property struct {
+	name   string              // upper case, without group
+	params map[string][]string // upper-case names, unquoted values
+	value  string              // still escaped
+}
+
+// Decode reads the next card. It returns io.EOF after the last one. A card
+// it cannot read fails with an error matching models.ErrValidation, and the
+// next call resumes with the following card.
+func (d *Decoder) Decode() (models.Contact, error) {
+	// skip to BEGIN:VCARD
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return models.Contact{}, err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if p, err := parseLine(line); err == nil && p.name == "BEGIN" && strings.EqualFold(p.value, "VCARD") {
+			break
+		}
+		return models.Contact{}, d.errorf("expected BEGIN:VCARD")
+	}
+	d.card++
+
+	var c card
+	var cardErr error
+	depth := 0 // of cards nested in properties such as AGENT
+	for {
+		line, err := d.readLine()
+		if errors.Is(err, io.EOF) {
+			return models.Contact{}, d.errorf("card %d is not closed by END:VCARD", d.card)
+		}
+		if err != nil {
+			return models.Contact{}, err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		p, err := parseLine(line)
+		switch {
+		case err != nil:
+			if cardErr == nil {
+				cardErr = d.errorf("%v", err)
+			}
+			continue
+		case p.name == "BEGIN" && strings.EqualFold(p.value, "VCARD"):
+			depth++
+			continue
+		case p.name == "END" && strings.EqualFold(p.value, "VCARD"):
+			if depth == 0 {
+				if cardErr != nil {
+					return models.Contact{}, cardErr
+				}
+				return c.result(), nil
+			}
+			depth--
+			continue
+		}
+		if depth == 0 && cardErr == nil {
+			if err := c.add(p); err != nil {
+				cardErr = d.errorf("%v", err)
+			}
+		}
+	}
+}
+
+// errorf reports a problem with the card being read, at the current line
+func (d *Decoder) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: line %d: %s", models.ErrValidation, d.line, fmt.Sprintf(format, args...))
+}
+
+// readLine returns the next logical line, unfolding the physical lines that
+// continue it: those starting with a space or a tab
+func (d *Decoder) readLine() (string, error) {
+	line, err := d.readPhysical()
+	if err != nil {
+		return "", err
+	}
+	if d.line == 1 {
+		line = strings.TrimPrefix(line, "\ufeff") // byte order mark
+	}
+	for {
+		next, err := d.r.Peek(1)
+		if err != nil || (next[0] != ' ' && next[0] != '\t') {
+			return line, nil
+		}
+		more, err := d.readPhysical()
+		if err != nil {
+			return line, nil
+		}
+		line += more[1:]
+	}
+}
+
+func (d *Decoder) readPhysical() (string, error) {
+	line, err := d.r.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", err
+	}
+	d.line++
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+// parseLine splits a content line into its name, parameters and value
+func parseLine(line string) (property, error) {
+	// the value starts at the first colon outside a quoted parameter value
+	colon, quoted := -1, false
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return property{}, fmt.Errorf("%q is not a property", truncate(line))
+	}
+
+	head := splitParams(line[:colon])
+	name := strings.ToUpper(head[0])
+	if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+		name = name[dot+1:]
+	}
+	if name == "" {
+		return property{}, fmt.Errorf("%q has no property name", truncate(line))
+	}
+
+	p := property{name: name, params: map[string][]string{}, value: line[colon+1:]}
+	for _, param := range head[1:] {
+		key, values, ok := strings.Cut(param, "=")
+		if !ok {
+			// vCard 2.1 wrote types bare, as in TEL;CELL:...
+			key, values = "TYPE", param
+		}
+		key = strings.ToUpper(key)
+		p.params[key] = append(p.params[key], splitParamValues(values)...)
+	}
+	return p, nil
+}
+
+// splitParams cuts at semicolons outside quotes
+func splitParams(s string) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case ';':
+			if !quoted {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// splitParamValues cuts at commas outside quotes and removes the quotes
+func splitParamValues(s string) []string {
+	var values []string
+	var b strings.Builder
+	quoted := false
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == ',' && !quoted:
+			values = append(values, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return append(values, b.String())
+}
+
+func truncate(s string) string {
+	if len(s) > 40 {
+		return s[:40] + "..."
+	}
+	return s
+}
+
+// hasType tells whether a TYPE parameter lists t, ignoring case
+func (p property) hasType(t string) bool {
+	for _, v := range p.params["TYPE"] {
+		if strings.EqualFold(v, t) {
+			return true
+		}
+	}
+	return false
+}
+
+// preferred tells whether the property is marked as the preferred one,
+// with TYPE=pref in 3.0 or PREF in 4.0
+func (p property) preferred() bool {
+	return p.hasType("pref") || len(p.params["PREF"]) > 0
+}
+
+// card accumulates the properties of the card being read
+type card struct {
+	contact   models.Contact
+	fn        string // formatted name
+	emailPref bool   // whether contact.Email was marked preferred
+}
+
+func (c *card) add(p property) error {
+	contact := &c.contact
+	switch p.name {
+	case "VERSION":
+		if _, ok := ParseVersion(p.value); !ok {
+			return fmt.Errorf("vCard version %q is not supported (3.0 or 4.0)", truncate(p.value))
+		}
+	case "N":
+		parts := split(p.value, ';')
+		name := func(i int) string {
+			if i >= len(parts) {
+				return ""
+			}
+			return strings.Join(splitText(parts[i], ','), " ")
+		}
+		contact.LastName, contact.FirstName = name(0), name(1)
+	case "FN":
+		c.fn = unescape(p.value)
+	case "EMAIL":
+		email := unescape(p.value)
+		if email != "" && (contact.Email == "" || (p.preferred() && !c.emailPref)) {
+			contact.Email = email
+			c.emailPref = p.preferred()
+		}
+	case "TEL":
+		if number := telNumber(p); number != "" {
+			contact.Phones = append(contact.Phones, models.Phone{Type: phoneType(p), Number: number})
+		}
+	case "ADR":
+		if address, ok := parseAddress(p); ok {
+			contact.Addresses = append(contact.Addresses, address)
+		}
+	case "ORG":
+		contact.Company = splitText(p.value, ';')[0]
+	case "TITLE":
+		contact.Title = unescape(p.value)
+	case "BDAY":
+		contact.Birthday = parseBirthday(p)
+	case "NOTE":
+		if contact.Notes != "" {
+			contact.Notes += "\n\n"
+		}
+		contact.Notes += unescape(p.value)
+	case "CATEGORIES":
+		contact.Tags = append(contact.Tags, splitText(p.value, ',')...)
+	case customFieldProperty:
+		if keys := p.params["KEY"]; len(keys) > 0 && keys[0] != "" {
+			if contact.CustomFields == nil {
+				contact.CustomFields = map[string]string{}
+			}
+			contact.CustomFields[keys[0]] = unescape(p.value)
+		}
+	}
+	return nil
+}
+
+// result returns the contact read, naming it after FN when N is missing:
+// its last word is taken as the last name
+func (c *card) result() models.Contact {
+	contact := c.contact
+	if contact.FirstName == "" && contact.LastName == "" {
+		words := strings.Fields(c.fn)
+		if n := len(words); n > 1 {
+			contact.FirstName, contact.LastName = strings.Join(words[:n-1], " "), words[n-1]
+		} else {
+			contact.FirstName = c.fn
+		}
+	}
+	return contact
+}
+
+func phoneType(p property) models.PhoneType {
+	switch {
+	case p.hasType("fax"):
+		return models.PhoneFax
+	case p.hasType("cell"):
+		return models.PhoneMobile
+	case p.hasType("work"):
+		return models.PhoneWork
+	case p.hasType("home"):
+		return models.PhoneHome
+	default:
+		return models.PhoneOther
+	}
+}
+
+// telNumber reads a number written as text or as a tel: URI (4.0), whose
+// parameters such as ;ext= are dropped
+func telNumber(p property) string {
+	value := unescape(p.value)
+	if rest, ok := strings.CutPrefix(strings.ToLower(value), "tel:"); ok {
+		number, _, _ := strings.Cut(rest, ";")
+		return number
+	}
+	return value
+}
+
+// parseAddress reads the components post office box; extended address;
+// street; locality; region; postal code; country. The first three make the
+// street.
+func parseAddress(p property) (models.Address, bool) {
+	parts := split(p.value, ';')
+	component := func(i int) string {
+		if i >= len(parts) {
+			return ""
+		}
+		return strings.Join(splitText(parts[i], ','), ", ")
+	}
+
+	var street []string
+	for _, i := range []int{2, 1, 0} {
+		if s := component(i); s != "" {
+			street = append(street, s)
+		}
+	}
+	address := models.Address{
+		Type:       models.AddressOther,
+		Street:     strings.Join(street, ", "),
+		City:       component(3),
+		Region:     component(4),
+		PostalCode: component(5),
+		Country:    component(6),
+	}
+	if len(address.Country) == 2 {
+		address.Country = strings.ToUpper(address.Country)
+	}
+	switch {
+	case p.hasType("home"):
+		address.Type = models.AddressHome
+	case p.hasType("work"):
+		address.Type = models.AddressWork
+	}
+	empty := models.Address{Type: address.Type}
+	return address, address != empty
+}
+
+// parseBirthday reads the dates BDAY is written as: 1906-12-09, 19061209,
+// or either followed by a time. Dates without a year (--1209) and free text
+// cannot be stored and are dropped; other values are kept as they are, for
+// validation to report them.
+func parseBirthday(p property) string {
+	value := unescape(p.value)
+	if strings.HasPrefix(value, "--") || strings.EqualFold(firstParam(p, "VALUE"), "text") {
+		return ""
+	}
+	date, _, _ := strings.Cut(value, "T")
+	for _, layout := range []string{time.DateOnly, "20060102"} {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t.Format(time.DateOnly)
+		}
+	}
+	return value
+}
+
+func firstParam(p property, key string) string {
+	if values := p.params[key]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
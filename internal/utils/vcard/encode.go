@@ -0,0 +1,146 @@
+package vcard
+
+import (
+	"bufio"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"golang/internal/models"
+)
+
+// maxLineLength is the length in octets beyond which lines are folded
+const maxLineLength = 75
+
+// productID names the software that wrote a card
+const productID = "-//Contact Manager//vCard Export//EN"
+
+// Encoder writes contacts as vCards of one version
+type Encoder struct {
+	w       *bufio.Writer
+	version Version
+}
+
+func NewEncoder(w io.Writer, version Version) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w), version: version}
+}
+
+// Encode writes one card, with CRLF line endings and lines folded at 75 octets
+func (e *Encoder) Encode(c models.Contact) error {
+	e.line("BEGIN", nil, "VCARD")
+	e.line("VERSION", nil, string(e.version))
+	e.line("PRODID", nil, productID)
+	e.line("N", nil, escape(c.LastName)+";"+escape(c.FirstName)+";;;")
+	e.line("FN", nil, escape(c.FullName()))
+
+	if c.Email != "" {
+		if e.version == V3 {
+			e.line("EMAIL", []string{"TYPE=INTERNET"}, escape(c.Email))
+		} else {
+			e.line("EMAIL", nil, escape(c.Email))
+		}
+	}
+	for _, p := range c.Phones {
+		if e.version == V3 {
+			e.line("TEL", []string{"TYPE=" + e.typeName(phoneTypeNames[p.Type])}, escape(p.Number))
+		} else {
+			e.line("TEL", []string{"VALUE=uri", "TYPE=" + e.typeName(phoneTypeNames[p.Type])}, "tel:"+p.Number)
+		}
+	}
+	for _, a := range c.Addresses {
+		var params []string
+		if a.Type == models.AddressHome || a.Type == models.AddressWork {
+			params = []string{"TYPE=" + e.typeName(string(a.Type))}
+		}
+		components := []string{"", "", a.Street, a.City, a.Region, a.PostalCode, a.Country}
+		for i, component := range components {
+			components[i] = escape(component)
+		}
+		e.line("ADR", params, strings.Join(components, ";"))
+	}
+
+	if c.Company != "" {
+		e.line("ORG", nil, escape(c.Company))
+	}
+	if c.Title != "" {
+		e.line("TITLE", nil, escape(c.Title))
+	}
+	if c.Birthday != "" {
+		if e.version == V3 {
+			e.line("BDAY", nil, c.Birthday)
+		} else {
+			e.line("BDAY", nil, strings.ReplaceAll(c.Birthday, "-", ""))
+		}
+	}
+	if c.Notes != "" {
+		e.line("NOTE", nil, escape(c.Notes))
+	}
+	if len(c.Tags) > 0 {
+		tags := make([]string, len(c.Tags))
+		for i, tag := range c.Tags {
+			tags[i] = escape(tag)
+		}
+		e.line("CATEGORIES", nil, strings.Join(tags, ","))
+	}
+	for _, key := range slices.Sorted(maps.Keys(c.CustomFields)) {
+		e.line(customFieldProperty, []string{"KEY=" + paramValue(key)}, escape(c.CustomFields[key]))
+	}
+	e.line("END", nil, "VCARD")
+	return e.w.Flush()
+}
+
+// phoneTypeNames are the TEL types of each phone type
+var phoneTypeNames = map[models.PhoneType]string{
+	models.PhoneMobile: "cell",
+	models.PhoneHome:   "home",
+	models.PhoneWork:   "work",
+	models.PhoneFax:    "fax",
+	models.PhoneOther:  "voice",
+}
+
+// typeName writes types in upper case for 3.0, as most 3.0 readers expect,
+// and in lower case for 4.0
+func (e *Encoder) typeName(name string) string {
+	if name == "" {
+		name = "voice"
+	}
+	if e.version == V3 {
+		return strings.ToUpper(name)
+	}
+	return name
+}
+
+// paramValue quotes a parameter value holding a separator
+func paramValue(v string) string {
+	if strings.ContainsAny(v, ":;,") {
+		return `"` + strings.ReplaceAll(v, `"`, "") + `"`
+	}
+	return v
+}
+
+// line writes a content line, folded so that no line exceeds maxLineLength
+// octets: continuation lines start with a space and never split a UTF-8
+// sequence. Write errors are reported by the final Flush.
+func (e *Encoder) line(name string, params []string, value string) {
+	line := name
+	for _, p := range params {
+		line += ";" + p
+	}
+	line += ":" + value
+
+	limit := maxLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		e.w.WriteString(line[:cut])
+		e.w.WriteString("\r\n ")
+		line = line[cut:]
+		limit = maxLineLength - 1 // after the leading space
+	}
+	e.w.WriteString(line)
+	e.w.WriteString("\r\n")
+}
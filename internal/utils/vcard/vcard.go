@@ -0,0 +1,106 @@
+// Package vcard reads and writes contacts as vCard 3.0 (RFC 2426) and 4.0
+// (RFC 6350), the format phones and mail clients exchange address books in.
+//
+// A contact maps to a card as follows:
+//
+//	first_name, last_name  N (family;given), and FN
+//	email                  EMAIL, the preferred one on import
+//	phones                 TEL, typed cell, home, work, fax or voice
+//	addresses              ADR, typed home or work
+//	company, title         ORG (first component), TITLE
+//	birthday               BDAY
+//	notes                  NOTE
+//	tags                   CATEGORIES
+//	custom_fields          X-CUSTOM-FIELD;KEY=<key>
+//
+// Other properties are ignored on import.
+package vcard
+
+import (
+	"strings"
+)
+
+// Version is a vCard version this package reads and writes
+type Version string
+
+const (
+	V3 Version = "3.0"
+	V4 Version = "4.0"
+)
+
+// MediaType is the media type of vCard files (RFC 6350). Older clients also
+// send text/x-vcard and text/directory.
+const MediaType = "text/vcard"
+
+// MediaTypes lists every media type vCard files are sent as
+var MediaTypes = []string{MediaType, "text/x-vcard", "text/directory"}
+
+// ParseVersion accepts "3.0" and "4.0", and "3" and "4" for short
+func ParseVersion(s string) (Version, bool) {
+	switch s {
+	case "3", "3.0":
+		return V3, true
+	case "4", "4.0":
+		return V4, true
+	}
+	return "", false
+}
+
+// customFieldProperty holds one custom field, named by its KEY parameter
+const customFieldProperty = "X-CUSTOM-FIELD"
+
+// escaper protects the characters with a meaning in property values
+var escaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, ",", `\,`, ";", `\;`)
+
+func escape(s string) string {
+	return escaper.Replace(s)
+}
+
+// unescape reverses escape; a backslash before any other character stands
+// for that character
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n', 'N':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+// split cuts a raw value at every sep not escaped by a backslash, leaving
+// the parts escaped
+func split(value string, sep byte) []string {
+	var parts []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case sep:
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// splitText splits a raw value at sep and unescapes the parts
+func splitText(value string, sep byte) []string {
+	parts := split(value, sep)
+	for i, p := range parts {
+		parts[i] = unescape(p)
+	}
+	return parts
+}
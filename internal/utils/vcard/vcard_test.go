@@ -0,0 +1,260 @@
+package vcard
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"golang/internal/models"
+)
+
+// roundTripContact sets every field the cards hold, with the characters
+// values must be escaped for
+func roundTripContact() models.Contact {
+	return models.Contact{
+		FirstName: "Zoë, Jr",
+		LastName:  `O'Neil; Smith\Jones`,
+		Email:     "zoe@example.com",
+		Phones: []models.Phone{
+			{Type: models.PhoneMobile, Number: "+15550100"},
+			{Type: models.PhoneHome, Number: "+15550101"},
+			{Type: models.PhoneWork, Number: "+15550102"},
+			{Type: models.PhoneFax, Number: "+15550103"},
+			{Type: models.PhoneOther, Number: "+15550104"},
+		},
+		Addresses: []models.Address{
+			{Type: models.AddressHome, Street: "1 Main St, Apt 2", City: "Spring;field", Region: "IL", PostalCode: "62701", Country: "US"},
+			{Type: models.AddressWork, Street: `Back\slash Lane`, City: "Boston"},
+			{Type: models.AddressOther, City: "Paris", Country: "FR"},
+		},
+		Company:  "Acme; Inc",
+		Title:    "CTO, R&D",
+		Birthday: "1906-12-09",
+		Notes:    "Première ligne, avec des accents: éèàùç; et un \\ de trop\n第二行は日本語のテキストで、折り返しが多バイト文字の途中に来ないことを確かめます 🎉🎉🎉",
+		Tags:     []string{"a,b", `c\d`, "vip"},
+		CustomFields: map[string]string{
+			"team":       "ops",
+			"desk;floor": "3, left",
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, version := range []Version{V3, V4} {
+		t.Run(string(version), func(t *testing.T) {
+			want := roundTripContact()
+			var buf bytes.Buffer
+			if err := NewEncoder(&buf, version).Encode(want); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+
+			d := NewDecoder(&buf)
+			got, err := d.Decode()
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Decode = %+v\nwant %+v", got, want)
+			}
+			if _, err := d.Decode(); !errors.Is(err, io.EOF) {
+				t.Errorf("Decode after the last card = %v, want io.EOF", err)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	c := models.Contact{
+		FirstName: "Ada", LastName: "Lovelace", Email: "ada@example.com",
+		Phones:   []models.Phone{{Type: models.PhoneMobile, Number: "+441234567890"}},
+		Birthday: "1815-12-10",
+	}
+	tests := []struct {
+		version Version
+		want    []string // lines the card must hold
+	}{
+		{V3, []string{"VERSION:3.0", "N:Lovelace;Ada;;;", "FN:Ada Lovelace", "EMAIL;TYPE=INTERNET:ada@example.com",
+			"TEL;TYPE=CELL:+441234567890", "BDAY:1815-12-10"}},
+		{V4, []string{"VERSION:4.0", "N:Lovelace;Ada;;;", "FN:Ada Lovelace", "EMAIL:ada@example.com",
+			"TEL;VALUE=uri;TYPE=cell:tel:+441234567890", "BDAY:18151210"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := NewEncoder(&buf, tt.version).Encode(c); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+		lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+		if lines[0] != "BEGIN:VCARD" || lines[len(lines)-1] != "END:VCARD" {
+			t.Errorf("%s card is not enclosed in BEGIN and END:\n%s", tt.version, buf.String())
+		}
+		for _, line := range tt.want {
+			if !strings.Contains(buf.String(), "\r\n"+line+"\r\n") {
+				t.Errorf("%s card lacks %q:\n%s", tt.version, line, buf.String())
+			}
+		}
+	}
+}
+
+func TestEncodeFoldsAt75Octets(t *testing.T) {
+	for _, notes := range []string{
+		strings.Repeat("a", 200),
+		strings.Repeat("é", 100),      // 2 octets
+		strings.Repeat("語", 100),      // 3 octets
+		strings.Repeat("🎉", 100),      // 4 octets
+		"x" + strings.Repeat("🎉", 50), // runes straddling every fold
+	} {
+		var buf bytes.Buffer
+		c := models.Contact{FirstName: "Ada", LastName: "Lovelace", Notes: notes}
+		if err := NewEncoder(&buf, V4).Encode(c); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+
+		var unfolded string
+		for i, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+			if len(line) > maxLineLength {
+				t.Errorf("line %d is %d octets long: %q", i+1, len(line), line)
+			}
+			if !utf8.ValidString(line) {
+				t.Errorf("line %d splits a UTF-8 sequence: %q", i+1, line)
+			}
+			if rest, ok := strings.CutPrefix(line, " "); ok {
+				unfolded += rest
+			} else {
+				unfolded += "\n" + line
+			}
+		}
+		if !strings.Contains(unfolded, "\nNOTE:"+notes+"\n") {
+			t.Errorf("unfolded card lacks the notes %q:%s", notes, unfolded)
+		}
+
+		got, err := NewDecoder(&buf).Decode()
+		if err != nil || got.Notes != notes {
+			t.Errorf("Decode notes = %q, %v, want %q", got.Notes, err, notes)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	card := func(lines ...string) string {
+		return "BEGIN:VCARD\r\nVERSION:3.0\r\n" + strings.Join(lines, "\r\n") + "\r\nEND:VCARD\r\n"
+	}
+	tests := []struct {
+		name string
+		card string
+		want models.Contact
+	}{
+		{"EscapedName", card(`N:O\,Neil\\Smith;Ann\;Marie;;;`),
+			models.Contact{LastName: `O,Neil\Smith`, FirstName: "Ann;Marie"}},
+		{"SeveralGivenNames", card(`N:Lovelace;Augusta,Ada;;;`),
+			models.Contact{LastName: "Lovelace", FirstName: "Augusta Ada"}},
+		{"NameFromFN", card("FN:Augusta Ada King"),
+			models.Contact{FirstName: "Augusta Ada", LastName: "King"}},
+		{"EscapedAddress", card(`ADR;TYPE=home,pref:PO Box 1;Suite 2;1 Main St\, Apt 3;Spring\;field;IL;62701;us`),
+			models.Contact{Addresses: []models.Address{{Type: models.AddressHome,
+				Street: "1 Main St, Apt 3, Suite 2, PO Box 1", City: "Spring;field", Region: "IL", PostalCode: "62701", Country: "US"}}}},
+		{"SeveralStreetLines", card(`ADR;TYPE=WORK:;;1 Main St,Floor 3;Boston;;;`),
+			models.Contact{Addresses: []models.Address{{Type: models.AddressWork, Street: "1 Main St, Floor 3", City: "Boston"}}}},
+		{"EmptyAddress", card(`ADR:;;;;;;`),
+			models.Contact{}},
+		{"EscapedCategories", card(`CATEGORIES:a\,b,c\\d,\;e`),
+			models.Contact{Tags: []string{"a,b", `c\d`, ";e"}}},
+		{"TelURI", card("TEL;VALUE=uri;TYPE=cell:tel:+1-555-0100;ext=12"),
+			models.Contact{Phones: []models.Phone{{Type: models.PhoneMobile, Number: "+1-555-0100"}}}},
+		{"TelText", card(`TEL;TYPE=WORK,VOICE:+1 555 0100`),
+			models.Contact{Phones: []models.Phone{{Type: models.PhoneWork, Number: "+1 555 0100"}}}},
+		{"TelFaxOverWork", card(`TEL;TYPE=work;TYPE=fax:+15550100`),
+			models.Contact{Phones: []models.Phone{{Type: models.PhoneFax, Number: "+15550100"}}}},
+		{"TelBareType", card("TEL;CELL:+15550100"),
+			models.Contact{Phones: []models.Phone{{Type: models.PhoneMobile, Number: "+15550100"}}}},
+		{"PreferredEmail", card("EMAIL:ada@example.com", "EMAIL;TYPE=INTERNET,pref:ada@lovelace.org", "EMAIL;PREF=1:other@example.com"),
+			models.Contact{Email: "ada@lovelace.org"}},
+		{"FirstEmail", card("EMAIL:ada@example.com", "EMAIL:ada@lovelace.org"),
+			models.Contact{Email: "ada@example.com"}},
+		{"OrgFirstUnit", card(`ORG:Acme\; Inc;Research;Lab`),
+			models.Contact{Company: "Acme; Inc"}},
+		{"NotesJoined", card(`NOTE:one\nline`, "NOTE:two"),
+			models.Contact{Notes: "one\nline\n\ntwo"}},
+		{"CustomFields", card(`X-CUSTOM-FIELD;KEY="desk;floor":3\, left`, "X-CUSTOM-FIELD;KEY=team:ops", "X-CUSTOM-FIELD:no key"),
+			models.Contact{CustomFields: map[string]string{"desk;floor": "3, left", "team": "ops"}}},
+		{"Grouped", card("item1.EMAIL:ada@example.com", "item1.X-ABLabel:home"),
+			models.Contact{Email: "ada@example.com"}},
+		{"FoldedLines", card("NOTE:a long", "  note,\tfolded", "\tby space and tab"),
+			models.Contact{Notes: "a long note,\tfoldedby space and tab"}},
+		{"LowerCase", card("note:lower"),
+			models.Contact{Notes: "lower"}},
+		{"NestedCardIgnored", card("AGENT:", "BEGIN:VCARD", "FN:Agent Smith", "END:VCARD", "TITLE:Boss"),
+			models.Contact{Title: "Boss"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewDecoder(strings.NewReader(tt.card)).Decode()
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeBirthday(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"BDAY:1906-12-09", "1906-12-09"},
+		{"BDAY:19061209", "1906-12-09"},
+		{"BDAY:19061209T120000Z", "1906-12-09"},
+		{"BDAY:1906-12-09T12:00:00-05:00", "1906-12-09"},
+		{"BDAY;VALUE=date:1906-12-09", "1906-12-09"},
+		{"BDAY:--1209", ""},  // no year
+		{"BDAY:--12-09", ""}, // no year
+		{"BDAY;VALUE=text:circa 1900", ""},
+		{"BDAY:1906-13-40", "1906-13-40"}, // left for validation to report
+		{"BDAY:December", "December"},
+	}
+	for _, tt := range tests {
+		got, err := NewDecoder(strings.NewReader("BEGIN:VCARD\nVERSION:4.0\n" + tt.line + "\nEND:VCARD\n")).Decode()
+		if err != nil || got.Birthday != tt.want {
+			t.Errorf("Decode of %s = birthday %q, %v, want %q", tt.line, got.Birthday, err, tt.want)
+		}
+	}
+}
+
+func TestDecodeResumesAfterABadCard(t *testing.T) {
+	input := "\ufeffBEGIN:VCARD\r\nVERSION:3.0\r\nFN:Ada Lovelace\r\nEND:VCARD\r\n" +
+		"\r\n" +
+		"BEGIN:VCARD\r\nVERSION:2.1\r\nFN:Old Card\r\nEND:VCARD\r\n" +
+		"BEGIN:VCARD\r\nVERSION:3.0\r\nnot a property\r\nEND:VCARD\r\n" +
+		"BEGIN:VCARD\nVERSION:4.0\nFN:Grace Hopper\nEND:VCARD\n" +
+		"BEGIN:VCARD\r\nVERSION:4.0\r\nFN:Unfinished\r\n"
+
+	d := NewDecoder(strings.NewReader(input))
+	for i, want := range []string{"Ada Lovelace", "line 7", "line 12", "Grace Hopper", "not closed"} {
+		got, err := d.Decode()
+		if strings.HasPrefix(want, "line") || want == "not closed" {
+			if !errors.Is(err, models.ErrValidation) || !strings.Contains(err.Error(), want) {
+				t.Errorf("card %d: Decode = %+v, %v, want an ErrValidation at %s", i+1, got, err, want)
+			}
+			continue
+		}
+		if err != nil || got.FullName() != want {
+			t.Errorf("card %d: Decode = %+v, %v, want %s", i+1, got, err, want)
+		}
+	}
+	if _, err := d.Decode(); !errors.Is(err, io.EOF) {
+		t.Errorf("Decode after the last card = %v, want io.EOF", err)
+	}
+}
+
+func TestDecodeRejectsTextOutsideCards(t *testing.T) {
+	_, err := NewDecoder(strings.NewReader("hello\r\n")).Decode()
+	if !errors.Is(err, models.ErrValidation) {
+		t.Errorf("Decode = %v, want ErrValidation", err)
+	}
+}